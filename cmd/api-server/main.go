@@ -1,45 +1,244 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/apikey"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/fee"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/idempotency"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/limit"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/schedule"
 	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/webhook"
 	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/config"
 	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/db"
 	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/httpserver"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/idgen"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/jwtauth"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/logging"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/metrics"
 	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/tigerbeetledb"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/tracing"
+
+	"github.com/jmoiron/sqlx"
 )
 
 func main() {
 	cfg := config.LoadConfig()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger, err := logging.New(os.Stdout, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := tracing.New(ctx, cfg.TraceExporter, os.Stdout)
+	if err != nil {
+		log.Fatalf("config: TRACE_EXPORTER: %v", err)
+	}
+
 	dbConn := db.MustNewPostgreSQL(cfg.PostgresUser, cfg.PostgresPassword, cfg.PostgresHost, cfg.PostgresDBName)
-	defer dbConn.Close()
+	metrics.RegisterDB(dbConn.DB, "postgres")
 
 	tigerbeetleDB := &tigerbeetledb.TigerBeetleDB{}
 	if cfg.IsTigerBeetleOn {
 		tigerbeetleDB = tigerbeetledb.MustNewTigerbeetle(cfg.TigerbeetleAddress)
 	}
-	defer tigerbeetleDB.Close()
 
-	accountRepo := db.NewAccountDB(dbConn)
-	accountSvc := account.NewAccountService(accountRepo, tigerbeetleDB, cfg.IsTigerBeetleOn)
+	uow := db.NewUnitOfWorkDB(dbConn)
+
+	journalRepo := db.NewJournalDB(dbConn)
+
+	outboxRepo := db.NewOutboxDB(dbConn)
+	outboxSvc := outbox.NewOutboxService(outboxRepo, uow, tigerbeetleDB, cfg.OutboxBatchSize, cfg.OutboxMaxAttempts)
+	var workers sync.WaitGroup
+	if cfg.IsTigerBeetleOn {
+		runEvery(ctx, &workers, cfg.OutboxPollInterval, func(ctx context.Context) {
+			_, _ = outboxSvc.Drain(ctx)
+		})
+	}
+	expvar.Publish("outbox", expvar.Func(func() any {
+		stats, _ := outboxSvc.Stats(context.Background())
+		return stats
+	}))
+	metrics.RegisterOutbox(outboxSvc.Stats)
+
+	webhookRepo := db.NewWebhookDB(dbConn)
+	webhookSvc := webhook.NewWebhookService(webhookRepo, uow, &http.Client{Timeout: cfg.WebhookTimeout}, cfg.WebhookBatchSize, cfg.WebhookMaxAttempts)
+	runEvery(ctx, &workers, cfg.WebhookPollInterval, func(ctx context.Context) {
+		_, _ = webhookSvc.Drain(ctx)
+	})
 
-	transactionRepo := db.NewTransactionDB(dbConn)
-	transactionSvc := transaction.NewTransactionService(transactionRepo, accountRepo, tigerbeetleDB, cfg.IsTigerBeetleOn)
+	accountDB := db.NewAccountDB(dbConn)
+	accountRepo := tracing.NewAccountRepo(metrics.NewAccountRepo(accountDB))
+	accountSvc := account.NewAccountService(accountRepo, journalRepo, uow, mustNewIdGenerator(cfg, accountDB), cfg.IsClientAccountIdOn, webhookSvc, outboxRepo, cfg.IsTigerBeetleOn)
+
+	limitRepo := db.NewLimitDB(dbConn)
+	limitSvc := limit.NewLimitService(limitRepo, accountRepo)
+
+	feeRepo := db.NewFeeDB(dbConn)
+	feeSvc := fee.NewFeeService(feeRepo)
+
+	transactionRepo := tracing.NewTransactionRepo(metrics.NewTransactionRepo(db.NewTransactionDB(dbConn)))
+	holdRepo := db.NewHoldDB(dbConn)
+	transactionSvc := metrics.Transactions{TransactionService: transaction.NewTransactionService(transactionRepo, holdRepo, accountRepo, journalRepo, uow, limitSvc, feeSvc, webhookSvc, outboxRepo, cfg.IsTigerBeetleOn)}
+	runEvery(ctx, &workers, time.Minute, func(ctx context.Context) {
+		_, _ = transactionSvc.ExpireHolds(ctx)
+	})
+
+	scheduleRepo := db.NewScheduleDB(dbConn)
+	scheduleSvc := schedule.NewScheduleService(scheduleRepo, accountRepo, uow, transactionSvc)
+	runEvery(ctx, &workers, cfg.SchedulerPollInterval, func(ctx context.Context) {
+		_, _ = scheduleSvc.RunDue(ctx)
+	})
+
+	idempotencyRepo := db.NewIdempotencyDB(dbConn)
+	idempotencySvc := idempotency.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyKeyTTL)
+	runEvery(ctx, &workers, time.Hour, func(ctx context.Context) {
+		_ = idempotencySvc.Purge(ctx)
+	})
 
 	handler := &httpserver.ServiceHandler{
 		Account:     accountSvc,
 		Transaction: transactionSvc,
+		Limit:       limitSvc,
+		Fee:         feeSvc,
+		Schedule:    scheduleSvc,
+		Webhook:     webhookSvc,
+		Idempotency: idempotencySvc,
+		Readiness:   readinessChecks(ctx, cfg, dbConn, tigerbeetleDB),
+	}
+	if cfg.IsAuthOn {
+		handler.Auth = apikey.NewApiKeyService(db.NewApiKeyDB(dbConn))
+		handler.Bearer = mustNewVerifier(ctx, cfg)
 	}
 
 	server := httpserver.NewMux(fmt.Sprintf(":%s", cfg.Port), handler)
 
-	log.Printf("listen on :%s\n", cfg.Port)
-	if err := server.ListenAndServe(); err != http.ErrServerClosed {
-		log.Fatalf("HTTP server ListenAndServe: %v", err)
+	go func() {
+		slog.Info("listen", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalf("HTTP server ListenAndServe: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	slog.Info("shutting down", "timeout", cfg.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("shut down HTTP server", "error", err)
+	}
+	if !wait(shutdownCtx, &workers) {
+		slog.Error("shut down workers", "error", shutdownCtx.Err())
+	}
+	if err := dbConn.Close(); err != nil {
+		slog.Error("close postgres", "error", err)
+	}
+	tigerbeetleDB.Close()
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("shut down tracing", "error", err)
+	}
+	slog.Info("shut down")
+}
+
+// runEvery calls run every interval until ctx is done. A call in progress when ctx is done runs
+// to completion, with a context that is not canceled, and wg waits for it.
+func runEvery(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, run func(ctx context.Context)) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run(context.WithoutCancel(ctx))
+			}
+		}
+	}()
+}
+
+// wait waits for wg until ctx is done, reporting whether wg finished.
+func wait(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// readinessChecks returns the checks of /readyz: the server is not shutting down, which is
+// when ctx is done, Postgres answers and, when it is turned on, TigerBeetle answers.
+func readinessChecks(ctx context.Context, cfg *config.Config, dbConn *sqlx.DB, tigerbeetleDB *tigerbeetledb.TigerBeetleDB) []httpserver.ReadinessCheck {
+	checks := []httpserver.ReadinessCheck{
+		{Name: "shutdown", Check: func(context.Context) error { return ctx.Err() }},
+		{Name: "postgres", Check: dbConn.PingContext},
 	}
+	if cfg.IsTigerBeetleOn {
+		checks = append(checks, httpserver.ReadinessCheck{Name: "tigerbeetle", Check: tigerbeetleDB.Ping})
+	}
+	return checks
+}
+
+// mustNewVerifier returns the verifier of bearer tokens signed by the keys of cfg.JwtJwks, or nil
+// when it is not set, terminating the application when the keys can not be read.
+func mustNewVerifier(ctx context.Context, cfg *config.Config) httpserver.AuthHandler {
+	if cfg.JwtJwks == "" {
+		return nil
+	}
+
+	keys, err := jwtauth.NewKeySet(ctx, cfg.JwtJwks, &http.Client{Timeout: 10 * time.Second})
+	if err != nil {
+		log.Fatalf("config: JWT_JWKS: %v", err)
+	}
+	return jwtauth.NewVerifier(keys, cfg.JwtIssuer, cfg.JwtAudience)
+}
+
+// mustNewIdGenerator returns the account id generator selected by cfg.AccountIdStrategy,
+// terminating the application when it is unknown.
+func mustNewIdGenerator(cfg *config.Config, accountRepo *db.AccountDB) account.IdGenerator {
+	switch cfg.AccountIdStrategy {
+	case "sequence":
+		return accountRepo
+	case "snowflake":
+		generator, err := idgen.NewSnowflake(cfg.AccountIdNode)
+		if err != nil {
+			log.Fatalf("config: ACCOUNT_ID_NODE: %v", err)
+		}
+		return generator
+	case "tigerbeetle":
+		return idgen.TigerBeetle{}
+	}
+
+	log.Fatalf("config: unknown ACCOUNT_ID_STRATEGY %q, expected sequence, snowflake or tigerbeetle", cfg.AccountIdStrategy)
+	return nil
 }
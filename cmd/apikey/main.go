@@ -0,0 +1,110 @@
+// Command apikey administers the API keys clients send in the X-API-Key header. Keys are
+// written to stdout as JSON; the key itself is only shown by create and rotate.
+//
+//	go run cmd/apikey/main.go create -name billing -scopes accounts:read,transactions:write -accounts 1,2
+//	go run cmd/apikey/main.go rotate -id 1
+//	go run cmd/apikey/main.go revoke -id 1
+//	go run cmd/apikey/main.go list
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/apikey"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/config"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/db"
+)
+
+const usage = "usage: apikey create|rotate|revoke|list [flags]"
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+	command, args := os.Args[1], os.Args[2:]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	name := flags.String("name", "", "create: name of the client the key is for")
+	scopes := flags.String("scopes", "", "create: comma separated scopes, any of "+strings.Join(auth.Scopes, ", "))
+	accounts := flags.String("accounts", "", "create: comma separated ids of the only accounts the key may debit and change")
+	id := flags.Int("id", 0, "rotate, revoke: id of the key")
+	_ = flags.Parse(args)
+
+	cfg := config.LoadConfig()
+	dbConn := db.MustNewPostgreSQL(cfg.PostgresUser, cfg.PostgresPassword, cfg.PostgresHost, cfg.PostgresDBName)
+	defer dbConn.Close()
+
+	apiKeySvc := apikey.NewApiKeyService(db.NewApiKeyDB(dbConn))
+	ctx := context.Background()
+
+	var out any
+	var err error
+	switch command {
+	case "create":
+		accountIds, parseErr := parseIds(*accounts)
+		if parseErr != nil {
+			log.Fatalf("apikey: -accounts: %v", parseErr)
+		}
+		out, err = apiKeySvc.Create(ctx, apikey.ApiKeyCreate{
+			Name:       *name,
+			Scopes:     splitList(*scopes),
+			AccountIds: accountIds,
+		})
+	case "rotate":
+		out, err = apiKeySvc.Rotate(ctx, *id)
+	case "revoke":
+		err = apiKeySvc.Revoke(ctx, *id)
+		out = map[string]any{"api_key_id": *id, "revoked": err == nil}
+	case "list":
+		out, err = apiKeySvc.List(ctx)
+	default:
+		log.Fatalf("apikey: unknown command %q, %s", command, usage)
+	}
+	if err != nil {
+		log.Fatalf("apikey: %s: %v", command, err)
+	}
+
+	if err := writeJSON(os.Stdout, out); err != nil {
+		log.Fatalf("apikey: writing output: %v", err)
+	}
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// splitList splits a comma separated list, skipping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseIds parses a comma separated list of ids. An empty list is nil, which allows every
+// account.
+func parseIds(s string) ([]int, error) {
+	var ids []int
+	for _, item := range splitList(s) {
+		id, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid account id %q", item)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
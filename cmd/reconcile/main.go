@@ -0,0 +1,99 @@
+// Command reconcile compares the account balances in Postgres with TigerBeetle and writes a
+// discrepancy report to stdout. It exits with status 2 when discrepancies were found.
+//
+//	go run cmd/reconcile/main.go -format csv -repair
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/reconciliation"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/config"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/db"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/tigerbeetledb"
+)
+
+func main() {
+	if found := run(); found {
+		os.Exit(2)
+	}
+}
+
+// run reconciles and writes the report. It reports whether discrepancies were found.
+func run() bool {
+	format := flag.String("format", "json", "report format: json or csv")
+	repair := flag.Bool("repair", false, "queue corrections for missing accounts and balance differences")
+	flag.Parse()
+
+	if *format != "json" && *format != "csv" {
+		log.Fatalf("reconcile: unknown format %q", *format)
+	}
+
+	cfg := config.LoadConfig()
+	if !cfg.IsTigerBeetleOn {
+		log.Fatal("reconcile: FEATURE_FLAG_TIGERBEETLE is not ON")
+	}
+
+	dbConn := db.MustNewPostgreSQL(cfg.PostgresUser, cfg.PostgresPassword, cfg.PostgresHost, cfg.PostgresDBName)
+	defer dbConn.Close()
+
+	tigerbeetleDB := tigerbeetledb.MustNewTigerbeetle(cfg.TigerbeetleAddress)
+	defer tigerbeetleDB.Close()
+
+	reconciliationSvc := reconciliation.NewReconciliationService(db.NewReconciliationDB(dbConn), db.NewOutboxDB(dbConn), tigerbeetleDB)
+	report, err := reconciliationSvc.Reconcile(context.Background(), *repair)
+	if err != nil {
+		log.Fatalf("reconcile: %v", err)
+	}
+
+	if *format == "csv" {
+		err = writeCSV(os.Stdout, report)
+	} else {
+		err = writeJSON(os.Stdout, report)
+	}
+	if err != nil {
+		log.Fatalf("reconcile: writing report: %v", err)
+	}
+
+	log.Printf("reconciled %d accounts: %d discrepancies, %d outbox messages pending\n", report.Accounts, len(report.Discrepancies), report.PendingMessages)
+	return len(report.Discrepancies) > 0
+}
+
+func writeJSON(w io.Writer, report reconciliation.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// writeCSV writes one line per discrepancy under a header line.
+func writeCSV(w io.Writer, report reconciliation.Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"account_id", "currency", "kind", "balance", "ledger_balance", "transfer_count", "ledger_transfer_count", "repaired"}); err != nil {
+		return err
+	}
+	for _, d := range report.Discrepancies {
+		record := []string{
+			strconv.Itoa(d.AccountId),
+			d.Currency,
+			d.Kind,
+			d.Balance.String(),
+			d.LedgerBalance.String(),
+			strconv.Itoa(d.TransferCount),
+			strconv.Itoa(d.LedgerTransferCount),
+			strconv.FormatBool(d.Repaired),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("account %d: %w", d.AccountId, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
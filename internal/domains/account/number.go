@@ -0,0 +1,61 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var ErrAccountNumberInvalid = errors.New("account number invalid")
+
+// AccountNumber returns the human-facing number of an account: its id in decimal followed by a
+// Luhn check digit, so a mistyped digit or two swapped neighbouring digits are caught before
+// they reach another account. Account 1 has number "18".
+func AccountNumber(accountId int) string {
+	digits := strconv.Itoa(accountId)
+	return digits + strconv.Itoa(luhnCheckDigit(digits))
+}
+
+// ParseAccountNumber validates the check digit of an account number and returns the account id
+// it stands for.
+func ParseAccountNumber(number string) (int, error) {
+	if len(number) < 2 || !isDigits(number) || number[0] == '0' {
+		return 0, fmt.Errorf("%w: %q", ErrAccountNumberInvalid, number)
+	}
+
+	digits, check := number[:len(number)-1], int(number[len(number)-1]-'0')
+	if luhnCheckDigit(digits) != check {
+		return 0, fmt.Errorf("%w: %q fails its check digit", ErrAccountNumberInvalid, number)
+	}
+
+	accountId, err := strconv.Atoi(digits)
+	if err != nil || accountId <= 0 {
+		return 0, fmt.Errorf("%w: %q", ErrAccountNumberInvalid, number)
+	}
+	return accountId, nil
+}
+
+// luhnCheckDigit returns the digit that makes digits followed by it pass the Luhn check.
+func luhnCheckDigit(digits string) int {
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if (len(digits)-i)%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
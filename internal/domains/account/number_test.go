@@ -0,0 +1,53 @@
+package account
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountNumber(t *testing.T) {
+	tests := []struct {
+		accountId int
+		want      string
+	}{
+		{accountId: 1, want: "18"},
+		{accountId: 42, want: "422"},
+		{accountId: 7992739871, want: "79927398713"},
+		{accountId: 1_234_567_890_123_456_789, want: "12345678901234567894"},
+	}
+	for _, tt := range tests {
+		got := AccountNumber(tt.accountId)
+		if got != tt.want {
+			t.Errorf("AccountNumber(%d) = %s, want %s", tt.accountId, got, tt.want)
+		}
+
+		accountId, err := ParseAccountNumber(got)
+		if err != nil || accountId != tt.accountId {
+			t.Errorf("ParseAccountNumber(%s) = %d, %v, want %d", got, accountId, err, tt.accountId)
+		}
+	}
+}
+
+func TestParseAccountNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		number string
+	}{
+		{name: "empty", number: ""},
+		{name: "check digit only", number: "8"},
+		{name: "wrong check digit", number: "19"},
+		{name: "mistyped digit", number: "4522"},
+		{name: "swapped digits", number: "242"},
+		{name: "leading zero", number: "018"},
+		{name: "not digits", number: "1a8"},
+		{name: "signed", number: "-18"},
+		{name: "overflow", number: "999999999999999999999999"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseAccountNumber(tt.number); !errors.Is(err, ErrAccountNumberInvalid) {
+				t.Errorf("ParseAccountNumber(%q) error = %v, want %v", tt.number, err, ErrAccountNumberInvalid)
+			}
+		})
+	}
+}
@@ -1,38 +1,111 @@
 package account
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // AccountRepo defines the interface for account data persistence.
 // Implementations of this interface handle the actual data storage and retrieval.
 type AccountRepo interface {
-	Create(ctx context.Context, data AccountCreateParams) error
+	Create(ctx context.Context, data AccountCreateParams) (bool, error)
 	ById(ctx context.Context, accountId int) (AccountRow, error)
+	ByIdsForUpdate(ctx context.Context, accountIds []int) ([]AccountRow, error)
 	UpdateBalance(ctx context.Context, params AccountUpdateBalanceParams) error
+	UpdateHeldBalance(ctx context.Context, params AccountUpdateHeldBalanceParams) error
+	UpdateStatus(ctx context.Context, params AccountUpdateStatusParams) error
+	UpdateOverdraftLimit(ctx context.Context, params AccountUpdateOverdraftLimitParams) error
+	OverdraftLimitChanges(ctx context.Context, accountId int) ([]OverdraftLimitChangeRow, error)
 }
 
-// AccountCreateParams holds the parameters required to create a new account.
+// IdGenerator allocates the ids of new accounts. Ids must be positive and fit in 63 bits; they
+// are expected to be unique, but a collision is detected and retried by AccountService.
+type IdGenerator interface {
+	NextId(ctx context.Context) (int, error)
+}
+
+// EventRecorder records the events of account changes for webhook subscribers. Record must join
+// the database transaction carried by ctx so an event is committed if and only if its change is.
+type EventRecorder interface {
+	Record(ctx context.Context, eventType string, data any) error
+}
+
+// UnitOfWork groups repository calls so they commit or roll back together.
+// Repository calls made with the context passed to fn take part in the same
+// database transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// AccountCreateParams holds the parameters required to create a new account. OverdraftLimit is
+// in the scale of the balance.
 type AccountCreateParams struct {
-	AccountId    int
-	Balance      int
-	ScaleBalance int
+	AccountId      int
+	Balance        int64
+	ScaleBalance   int
+	Currency       string
+	OverdraftLimit int64
+	Tier           string
 }
 
 // AccountRow represents a row in the accounts table, containing the account ID,
-// the current balance, and the scaled balance for precision handling.
+// the current balance, the scaled balance for precision handling and the currency.
+// HeldBalance is the part of Balance reserved by pending holds and OverdraftLimit is how far
+// below zero Balance may go, both in the same scale. OverdraftEnabled is set once the account
+// has had an overdraft limit and never cleared, since TigerBeetle fixes the balance constraint
+// of an account when it is created. Tier selects the tier-scoped transfer limits of the account.
 type AccountRow struct {
-	AccountId    int `db:"account_id"`
-	Balance      int `db:"balance"`
-	ScaleBalance int `db:"scale_balance"`
+	AccountId        int    `db:"account_id"`
+	Balance          int64  `db:"balance"`
+	ScaleBalance     int    `db:"scale_balance"`
+	Currency         string `db:"currency"`
+	HeldBalance      int64  `db:"held_balance"`
+	Status           string `db:"status"`
+	OverdraftLimit   int64  `db:"overdraft_limit"`
+	OverdraftEnabled bool   `db:"overdraft_enabled"`
+	Tier             string `db:"tier"`
 }
 
 // AccountUpdateBalanceParams contains the parameters required to update the balance of an account.
 // It includes the unique identifier of the account and the new balance value to be set.
 type AccountUpdateBalanceParams struct {
 	AccountId int
-	Balance   int
+	Balance   int64
+}
+
+// AccountUpdateHeldBalanceParams contains the parameters required to update the amount of an
+// account reserved by pending holds.
+type AccountUpdateHeldBalanceParams struct {
+	AccountId   int
+	HeldBalance int64
+}
+
+// AccountUpdateStatusParams contains the parameters required to move an account to a new status.
+// The change is recorded in the status history of the account together with Reason.
+type AccountUpdateStatusParams struct {
+	AccountId  int
+	FromStatus string
+	ToStatus   string
+	Reason     string
+}
+
+// AccountUpdateOverdraftLimitParams contains the parameters required to change the overdraft
+// limit of an account. The change is recorded in the overdraft limit history of the account
+// together with Reason.
+type AccountUpdateOverdraftLimitParams struct {
+	AccountId int
+	FromLimit int64
+	ToLimit   int64
+	Reason    string
 }
 
-type AccountTBRepo interface {
-	CreateAccount(accountId int) error
-	CreateTransaction(debitAccountId int, creditAccountId int, amount int) error
+// OverdraftLimitChangeRow represents a row in the account_overdraft_limit_changes table. The
+// limits are in the scale of the account balance.
+type OverdraftLimitChangeRow struct {
+	ChangeId  int       `db:"change_id"`
+	AccountId int       `db:"account_id"`
+	FromLimit int64     `db:"from_limit"`
+	ToLimit   int64     `db:"to_limit"`
+	Reason    string    `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
 }
@@ -5,93 +5,568 @@ package account
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
+	"strings"
+	"time"
 
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
 	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/webhook"
 )
 
 // AccountService encapsulates account-related operations and business logic.
 type AccountService struct {
-	repo AccountRepo
+	repo        AccountRepo
+	journalRepo journal.JournalRepo
+	uow         UnitOfWork
+	idGenerator IdGenerator
+	events      EventRecorder
 
+	isClientIdOn    bool
 	isTigerBeetleOn bool
-	tigerbeetleRepo AccountTBRepo
+	outboxRepo      outbox.OutboxRepo
 }
 
-// AccountCreate represents the parameters required to create a new account.
+// AccountCreate represents the parameters required to create a new account. AccountId is
+// allocated by the server and may only be set by clients when client-supplied ids are enabled,
+// e.g. to migrate existing accounts.
 type AccountCreate struct {
-	AccountId      int    `json:"account_id"`      // Unique identifier for the account.
-	InitialBalance string `json:"initial_balance"` // Initial balance as a string (e.g., "100.00").
+	AccountId      int          `json:"account_id,omitempty"`      // Unique identifier for the account.
+	Currency       string       `json:"currency"`                  // ISO 4217 currency code (e.g., "USD").
+	InitialBalance money.Amount `json:"initial_balance"`           // Initial balance as a decimal string (e.g., "100.00").
+	OverdraftLimit money.Amount `json:"overdraft_limit,omitempty"` // How far below zero the balance may go, zero for none.
+	Tier           string       `json:"tier,omitempty"`            // Tier the transfer limits of the account are looked up by, DefaultTier when empty.
 }
 
 // Account represents an account with its ID and balance.
 type Account struct {
-	AccountId      int    `json:"account_id"`      // Unique identifier for the account.
-	InitialBalance string `json:"initial_balance"` // Balance as a string (e.g., "100.00").
+	AccountId        int          `json:"account_id"`        // Unique identifier for the account.
+	AccountNumber    string       `json:"account_number"`    // Account id followed by a check digit, see AccountNumber.
+	Currency         string       `json:"currency"`          // ISO 4217 currency code (e.g., "USD").
+	InitialBalance   money.Amount `json:"initial_balance"`   // Balance as a decimal string (e.g., "100.00").
+	HeldBalance      money.Amount `json:"held_balance"`      // Part of the balance reserved by pending holds.
+	OverdraftLimit   money.Amount `json:"overdraft_limit"`   // How far below zero the balance may go.
+	AvailableBalance money.Amount `json:"available_balance"` // Balance that can be spent, i.e. balance minus held balance plus overdraft limit.
+	Status           string       `json:"status"`            // One of the Status constants.
+	Tier             string       `json:"tier"`              // Tier the transfer limits of the account are looked up by.
+}
+
+// AccountStatusChange represents a request to move an account to Status. Reason is recorded in
+// the status history of the account and is required.
+type AccountStatusChange struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// AccountOverdraftLimit represents a request to set the overdraft limit of an account. Reason is
+// recorded in the overdraft limit history of the account and is required.
+type AccountOverdraftLimit struct {
+	OverdraftLimit money.Amount `json:"overdraft_limit"`
+	Reason         string       `json:"reason"`
+}
+
+// OverdraftLimitChange is an entry of the overdraft limit history of an account.
+type OverdraftLimitChange struct {
+	ChangeId  int          `json:"change_id"`
+	FromLimit money.Amount `json:"from_limit"`
+	ToLimit   money.Amount `json:"to_limit"`
+	Reason    string       `json:"reason"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// BalanceVerification compares the stored balance of an account with the balance derived
+// from the postings written to it.
+type BalanceVerification struct {
+	AccountId      int          `json:"account_id"`
+	Balance        money.Amount `json:"balance"`         // Balance stored on the account.
+	JournalBalance money.Amount `json:"journal_balance"` // Credits minus debits posted to the account.
+	Consistent     bool         `json:"consistent"`
 }
 
 var (
 	ErrAccountCreateFailed           = errors.New("account creation fail")
 	ErrAccountByIdFailed             = errors.New("account by id fail")
 	ErrAccountInitialBalanceNegative = errors.New("account initial balance negative")
+	ErrAccountCurrencyInvalid        = errors.New("account currency invalid")
+	ErrAccountVerifyBalanceFailed    = errors.New("account verify balance fail")
+	ErrAccountNotFound               = errors.New("account not found")
+	ErrAccountStatusInvalid          = errors.New("account status invalid")
+	ErrAccountStatusReasonRequired   = errors.New("account status change reason required")
+	ErrAccountClosed                 = errors.New("account closed")
+	ErrAccountStatusChangeFailed     = errors.New("account status change fail")
+	ErrAccountIdNotAllowed           = errors.New("account id supplied by client not allowed")
+	ErrAccountIdConflict             = errors.New("account id already exists")
+	ErrAccountTierInvalid            = errors.New("account tier invalid")
+	ErrAccountForbidden              = errors.New("account forbidden")
+
+	ErrAccountOverdraftLimitNegative       = errors.New("account overdraft limit negative")
+	ErrAccountOverdraftLimitReasonRequired = errors.New("account overdraft limit change reason required")
+	ErrAccountOverdraftNotEnabled          = errors.New("account overdraft not enabled")
+	ErrAccountOverdraftLimitChangeFailed   = errors.New("account overdraft limit change fail")
+	ErrAccountOverdraftLimitHistoryFailed  = errors.New("account overdraft limit history fail")
 )
 
-// NewAccountService creates a new AccountService with the given repository.
-func NewAccountService(repo AccountRepo, tigerbeetleRepo AccountTBRepo, isTigerBeetleOn bool) *AccountService {
-	return &AccountService{repo, isTigerBeetleOn, tigerbeetleRepo}
+// maxIdAttempts is how many generated ids Create tries before giving up on collisions.
+const maxIdAttempts = 3
+
+// Account statuses. Frozen accounts can neither send nor receive, debit-blocked accounts can
+// only receive and credit-blocked accounts can only send. Closed is final; an account is closed
+// through TransactionService.CloseAccount, which sweeps whatever balance is left.
+const (
+	StatusActive        = "active"
+	StatusFrozen        = "frozen"
+	StatusDebitBlocked  = "debit_blocked"
+	StatusCreditBlocked = "credit_blocked"
+	StatusClosed        = "closed"
+)
+
+// DefaultTier is the tier of accounts opened without one.
+const DefaultTier = "standard"
+
+// maxTierLength is the longest tier name accounts and transfer limits accept.
+const maxTierLength = 32
+
+// IsValidTier reports whether tier is a usable tier name: 1 to 32 lowercase letters, digits,
+// dashes or underscores.
+func IsValidTier(tier string) bool {
+	if tier == "" || len(tier) > maxTierLength {
+		return false
+	}
+	for i := 0; i < len(tier); i++ {
+		c := tier[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewAccountService creates a new AccountService with the given repository. New accounts get
+// their ids from idGenerator unless isClientIdOn is set and the client supplies one. When
+// isTigerBeetleOn is set, new accounts are queued in outboxRepo for synchronization to TigerBeetle.
+// New accounts are recorded as webhook events in events unless it is nil.
+func NewAccountService(repo AccountRepo, journalRepo journal.JournalRepo, uow UnitOfWork, idGenerator IdGenerator, isClientIdOn bool, events EventRecorder, outboxRepo outbox.OutboxRepo, isTigerBeetleOn bool) *AccountService {
+	return &AccountService{repo, journalRepo, uow, idGenerator, events, isClientIdOn, isTigerBeetleOn, outboxRepo}
 }
 
-// Create creates a new account with the specified initial balance.
-func (svc *AccountService) Create(ctx context.Context, data AccountCreate) error {
-	initialBalance, err := money.StringToInt(data.InitialBalance, money.Scale)
+// Create creates a new account in the given currency with the specified initial balance.
+// The balance is stored with the scale of the currency and funded from the treasury
+// account through a journal entry written in the same database transaction, together
+// with the outbox message creating the account in TigerBeetle. A generated id that is
+// already taken is replaced by a new one; a client-supplied id that is taken fails with
+// ErrAccountIdConflict.
+func (svc *AccountService) Create(ctx context.Context, data AccountCreate) (Account, error) {
+	if data.AccountId != 0 && !svc.isClientIdOn {
+		slog.WarnContext(ctx, ErrAccountIdNotAllowed.Error(), "account_id", data.AccountId)
+		return Account{}, ErrAccountIdNotAllowed
+	}
+
+	if data.AccountId < 0 {
+		slog.ErrorContext(ctx, ErrAccountCreateFailed.Error(), "account_id", data.AccountId)
+		return Account{}, ErrAccountCreateFailed
+	}
+
+	currency, err := money.LookupCurrency(data.Currency)
+	if err != nil {
+		slog.WarnContext(ctx, ErrAccountCurrencyInvalid.Error(), "error", err)
+		return Account{}, ErrAccountCurrencyInvalid
+	}
+
+	initialBalance, err := data.InitialBalance.Rescale(currency.Scale)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountCreateFailed.Error(), "error", err)
+		return Account{}, err
+	}
+
+	if initialBalance.Sign() < 0 {
+		slog.WarnContext(ctx, ErrAccountInitialBalanceNegative.Error(), "initial_balance", initialBalance)
+		return Account{}, ErrAccountInitialBalanceNegative
+	}
+
+	overdraftLimit, err := data.OverdraftLimit.Rescale(currency.Scale)
 	if err != nil {
-		log.Printf("%s: %s\n", ErrAccountCreateFailed, err)
-		return money.ErrMoneyParseFail
+		slog.ErrorContext(ctx, ErrAccountCreateFailed.Error(), "error", err)
+		return Account{}, err
 	}
 
-	if initialBalance < 0 {
-		log.Printf("%s\n", ErrAccountInitialBalanceNegative)
-		return ErrAccountInitialBalanceNegative
+	if overdraftLimit.Sign() < 0 {
+		slog.WarnContext(ctx, ErrAccountOverdraftLimitNegative.Error(), "overdraft_limit", overdraftLimit)
+		return Account{}, ErrAccountOverdraftLimitNegative
+	}
+
+	if data.Tier == "" {
+		data.Tier = DefaultTier
+	}
+	if !IsValidTier(data.Tier) {
+		slog.WarnContext(ctx, ErrAccountTierInvalid.Error(), "tier", data.Tier)
+		return Account{}, ErrAccountTierInvalid
 	}
 
 	params := AccountCreateParams{
-		AccountId:    data.AccountId,
-		Balance:      initialBalance,
-		ScaleBalance: money.Scale,
+		AccountId:      data.AccountId,
+		Balance:        initialBalance.Value(),
+		ScaleBalance:   initialBalance.Scale(),
+		Currency:       currency.Code,
+		OverdraftLimit: overdraftLimit.Value(),
+		Tier:           data.Tier,
 	}
 
-	if err := svc.repo.Create(ctx, params); err != nil {
-		log.Printf("%s: %s\n", ErrAccountCreateFailed, err)
-		return ErrAccountCreateFailed
+	for attempt := 1; ; attempt++ {
+		if data.AccountId == 0 {
+			if params.AccountId, err = svc.idGenerator.NextId(ctx); err != nil {
+				slog.ErrorContext(ctx, ErrAccountCreateFailed.Error(), "error", err)
+				return Account{}, ErrAccountCreateFailed
+			}
+		}
+
+		account, created, err := svc.create(ctx, params, initialBalance)
+		if err != nil {
+			slog.ErrorContext(ctx, ErrAccountCreateFailed.Error(), "error", err)
+			return Account{}, ErrAccountCreateFailed
+		}
+		if created {
+			return account, nil
+		}
+
+		if data.AccountId != 0 {
+			slog.WarnContext(ctx, ErrAccountIdConflict.Error(), "account_id", data.AccountId)
+			return Account{}, ErrAccountIdConflict
+		}
+		if attempt == maxIdAttempts {
+			slog.ErrorContext(ctx, ErrAccountCreateFailed.Error(), "reason", "generated ids already taken", "attempts", attempt)
+			return Account{}, ErrAccountCreateFailed
+		}
+	}
+}
+
+// create inserts the account with its funding entry, outbox message and webhook event in one
+// database transaction and returns the new account. It reports false without error when the
+// account id is already taken.
+func (svc *AccountService) create(ctx context.Context, params AccountCreateParams, initialBalance money.Amount) (Account, bool, error) {
+	account, err := toAccount(AccountRow{
+		AccountId:        params.AccountId,
+		Balance:          params.Balance,
+		ScaleBalance:     params.ScaleBalance,
+		Currency:         params.Currency,
+		Status:           StatusActive,
+		OverdraftLimit:   params.OverdraftLimit,
+		OverdraftEnabled: params.OverdraftLimit > 0,
+		Tier:             params.Tier,
+	})
+	if err != nil {
+		return Account{}, false, err
 	}
 
-	if svc.isTigerBeetleOn {
-		if err := svc.tigerbeetleRepo.CreateAccount(data.AccountId); err != nil {
-			log.Printf("%s: %s\n", ErrAccountCreateFailed, err)
-			return ErrAccountCreateFailed
+	var created bool
+	err = svc.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = svc.repo.Create(ctx, params); err != nil || !created {
+			return err
 		}
 
-		if err := svc.tigerbeetleRepo.CreateTransaction(data.AccountId, 1, initialBalance); err != nil {
-			log.Printf("%s: %s\n", ErrAccountCreateFailed, err)
-			return ErrAccountCreateFailed
+		if svc.events != nil {
+			if err := svc.events.Record(ctx, webhook.EventAccountCreated, account); err != nil {
+				return err
+			}
 		}
+
+		if svc.isTigerBeetleOn {
+			payload := outbox.AccountPayload{
+				AccountId:      params.AccountId,
+				Currency:       params.Currency,
+				InitialBalance: initialBalance.Value(),
+				Overdraft:      params.OverdraftLimit > 0,
+			}
+			if err := outbox.Enqueue(ctx, svc.outboxRepo, outbox.KindAccountCreate, payload); err != nil {
+				return err
+			}
+		}
+
+		if initialBalance.IsZero() {
+			return nil
+		}
+
+		entry := journal.Entry{
+			Kind: journal.KindFunding,
+			Postings: []journal.Posting{
+				{SystemAccount: journal.TreasuryAccount(params.Currency), Direction: journal.Debit, Amount: initialBalance, Currency: params.Currency},
+				{AccountId: params.AccountId, Direction: journal.Credit, Amount: initialBalance, Currency: params.Currency},
+			},
+		}
+		if err := entry.Validate(); err != nil {
+			return err
+		}
+		_, err = svc.journalRepo.Create(ctx, entry.Params())
+		return err
+	})
+	if err != nil {
+		return Account{}, false, err
 	}
 
-	return nil
+	return account, created, nil
 }
 
 // ById retrieves an account by its ID.
 func (svc *AccountService) ById(ctx context.Context, accountId int) (Account, error) {
 	row, err := svc.repo.ById(ctx, accountId)
 	if err != nil {
-		log.Printf("%s: %s\n", ErrAccountByIdFailed, err)
+		slog.ErrorContext(ctx, ErrAccountByIdFailed.Error(), "error", err)
 		return Account{}, ErrAccountByIdFailed
 	}
 
-	initialBalance := money.IntToString(row.Balance, row.ScaleBalance)
-	data := Account{
+	data, err := toAccount(row)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountByIdFailed.Error(), "error", err)
+		return Account{}, ErrAccountByIdFailed
+	}
+
+	return data, nil
+}
+
+// ByNumber retrieves an account by its account number after validating its check digit.
+func (svc *AccountService) ByNumber(ctx context.Context, accountNumber string) (Account, error) {
+	accountId, err := ParseAccountNumber(accountNumber)
+	if err != nil {
+		slog.WarnContext(ctx, ErrAccountNumberInvalid.Error(), "account_number", accountNumber, "error", err)
+		return Account{}, ErrAccountNumberInvalid
+	}
+
+	return svc.ById(ctx, accountId)
+}
+
+// ChangeStatus freezes, unfreezes or blocks one direction of an account and records the change
+// with its reason. Closed accounts can not change status, and closing goes through
+// TransactionService.CloseAccount instead.
+func (svc *AccountService) ChangeStatus(ctx context.Context, accountId int, data AccountStatusChange) (Account, error) {
+	if !auth.CanActOn(ctx, accountId) {
+		slog.WarnContext(ctx, ErrAccountForbidden.Error(), "account_id", accountId)
+		return Account{}, ErrAccountForbidden
+	}
+
+	switch data.Status {
+	case StatusActive, StatusFrozen, StatusDebitBlocked, StatusCreditBlocked:
+	default:
+		slog.WarnContext(ctx, ErrAccountStatusInvalid.Error(), "account_id", accountId, "status", data.Status)
+		return Account{}, ErrAccountStatusInvalid
+	}
+
+	if strings.TrimSpace(data.Reason) == "" {
+		slog.WarnContext(ctx, ErrAccountStatusReasonRequired.Error(), "account_id", accountId)
+		return Account{}, ErrAccountStatusReasonRequired
+	}
+
+	var row AccountRow
+	var statusErr error
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		rows, err := svc.repo.ByIdsForUpdate(ctx, []int{accountId})
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			slog.WarnContext(ctx, ErrAccountNotFound.Error(), "account_id", accountId)
+			statusErr = ErrAccountNotFound
+			return statusErr
+		}
+
+		row = rows[0]
+		if row.Status == StatusClosed {
+			slog.WarnContext(ctx, ErrAccountClosed.Error(), "account_id", accountId)
+			statusErr = ErrAccountClosed
+			return statusErr
+		}
+
+		params := AccountUpdateStatusParams{
+			AccountId:  accountId,
+			FromStatus: row.Status,
+			ToStatus:   data.Status,
+			Reason:     data.Reason,
+		}
+		row.Status = data.Status
+		return svc.repo.UpdateStatus(ctx, params)
+	})
+	if statusErr != nil {
+		return Account{}, statusErr
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountStatusChangeFailed.Error(), "error", err)
+		return Account{}, ErrAccountStatusChangeFailed
+	}
+
+	account, err := toAccount(row)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountStatusChangeFailed.Error(), "error", err)
+		return Account{}, ErrAccountStatusChangeFailed
+	}
+
+	return account, nil
+}
+
+// ChangeOverdraftLimit sets how far below zero the balance of an account may go and records the
+// change with its reason. Lowering the limit below what the account already owes is allowed; the
+// account can then only receive until it is back within its limit. When TigerBeetle is enabled
+// only accounts opened with an overdraft limit can be given one, because TigerBeetle created the
+// other accounts with a balance that can not go below zero.
+func (svc *AccountService) ChangeOverdraftLimit(ctx context.Context, accountId int, data AccountOverdraftLimit) (Account, error) {
+	if !auth.CanActOn(ctx, accountId) {
+		slog.WarnContext(ctx, ErrAccountForbidden.Error(), "account_id", accountId)
+		return Account{}, ErrAccountForbidden
+	}
+
+	if data.OverdraftLimit.Sign() < 0 {
+		slog.WarnContext(ctx, ErrAccountOverdraftLimitNegative.Error(), "account_id", accountId, "overdraft_limit", data.OverdraftLimit)
+		return Account{}, ErrAccountOverdraftLimitNegative
+	}
+
+	if strings.TrimSpace(data.Reason) == "" {
+		slog.WarnContext(ctx, ErrAccountOverdraftLimitReasonRequired.Error(), "account_id", accountId)
+		return Account{}, ErrAccountOverdraftLimitReasonRequired
+	}
+
+	var row AccountRow
+	var limitErr error
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		rows, err := svc.repo.ByIdsForUpdate(ctx, []int{accountId})
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			slog.WarnContext(ctx, ErrAccountNotFound.Error(), "account_id", accountId)
+			limitErr = ErrAccountNotFound
+			return limitErr
+		}
+
+		row = rows[0]
+		if row.Status == StatusClosed {
+			slog.WarnContext(ctx, ErrAccountClosed.Error(), "account_id", accountId)
+			limitErr = ErrAccountClosed
+			return limitErr
+		}
+
+		limit, err := data.OverdraftLimit.Rescale(row.ScaleBalance)
+		if err != nil {
+			slog.ErrorContext(ctx, ErrAccountOverdraftLimitChangeFailed.Error(), "error", err)
+			limitErr = err
+			return limitErr
+		}
+
+		if svc.isTigerBeetleOn && !row.OverdraftEnabled && limit.Sign() > 0 {
+			slog.WarnContext(ctx, ErrAccountOverdraftNotEnabled.Error(), "account_id", accountId)
+			limitErr = ErrAccountOverdraftNotEnabled
+			return limitErr
+		}
+
+		params := AccountUpdateOverdraftLimitParams{
+			AccountId: accountId,
+			FromLimit: row.OverdraftLimit,
+			ToLimit:   limit.Value(),
+			Reason:    data.Reason,
+		}
+		row.OverdraftLimit = limit.Value()
+		row.OverdraftEnabled = row.OverdraftEnabled || limit.Sign() > 0
+		return svc.repo.UpdateOverdraftLimit(ctx, params)
+	})
+	if limitErr != nil {
+		return Account{}, limitErr
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountOverdraftLimitChangeFailed.Error(), "error", err)
+		return Account{}, ErrAccountOverdraftLimitChangeFailed
+	}
+
+	account, err := toAccount(row)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountOverdraftLimitChangeFailed.Error(), "error", err)
+		return Account{}, ErrAccountOverdraftLimitChangeFailed
+	}
+
+	return account, nil
+}
+
+// OverdraftLimitHistory lists the overdraft limit changes of an account, newest first.
+func (svc *AccountService) OverdraftLimitHistory(ctx context.Context, accountId int) ([]OverdraftLimitChange, error) {
+	row, err := svc.repo.ById(ctx, accountId)
+	if err != nil {
+		slog.WarnContext(ctx, ErrAccountNotFound.Error(), "error", err)
+		return nil, ErrAccountNotFound
+	}
+
+	changes, err := svc.repo.OverdraftLimitChanges(ctx, accountId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountOverdraftLimitHistoryFailed.Error(), "error", err)
+		return nil, ErrAccountOverdraftLimitHistoryFailed
+	}
+
+	data := make([]OverdraftLimitChange, len(changes))
+	for i, change := range changes {
+		data[i] = OverdraftLimitChange{
+			ChangeId:  change.ChangeId,
+			FromLimit: money.New(change.FromLimit, row.ScaleBalance),
+			ToLimit:   money.New(change.ToLimit, row.ScaleBalance),
+			Reason:    change.Reason,
+			CreatedAt: change.CreatedAt,
+		}
+	}
+
+	return data, nil
+}
+
+func toAccount(row AccountRow) (Account, error) {
+	balance := money.New(row.Balance, row.ScaleBalance)
+	heldBalance := money.New(row.HeldBalance, row.ScaleBalance)
+	overdraftLimit := money.New(row.OverdraftLimit, row.ScaleBalance)
+	availableBalance, err := balance.Sub(heldBalance)
+	if err != nil {
+		return Account{}, err
+	}
+	if availableBalance, err = availableBalance.Add(overdraftLimit); err != nil {
+		return Account{}, err
+	}
+
+	return Account{
+		AccountId:        row.AccountId,
+		AccountNumber:    AccountNumber(row.AccountId),
+		Currency:         row.Currency,
+		InitialBalance:   balance,
+		HeldBalance:      heldBalance,
+		OverdraftLimit:   overdraftLimit,
+		AvailableBalance: availableBalance,
+		Status:           row.Status,
+		Tier:             row.Tier,
+	}, nil
+}
+
+// VerifyBalance recomputes the balance of an account from its journal postings and
+// reports whether it matches the stored balance.
+func (svc *AccountService) VerifyBalance(ctx context.Context, accountId int) (BalanceVerification, error) {
+	row, err := svc.repo.ById(ctx, accountId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountByIdFailed.Error(), "error", err)
+		return BalanceVerification{}, ErrAccountByIdFailed
+	}
+
+	sums, err := svc.journalRepo.AccountBalance(ctx, accountId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountVerifyBalanceFailed.Error(), "error", err)
+		return BalanceVerification{}, ErrAccountVerifyBalanceFailed
+	}
+
+	journalBalance, currency, err := journal.Balance(sums)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrAccountVerifyBalanceFailed.Error(), "error", err)
+		return BalanceVerification{}, ErrAccountVerifyBalanceFailed
+	}
+
+	balance := money.New(row.Balance, row.ScaleBalance)
+	data := BalanceVerification{
 		AccountId:      row.AccountId,
-		InitialBalance: initialBalance,
+		Balance:        balance,
+		JournalBalance: journalBalance,
+		Consistent:     balance.Cmp(journalBalance) == 0 && (currency == "" || currency == row.Currency),
+	}
+	if !data.Consistent {
+		slog.WarnContext(ctx, "account balance does not match journal balance", "account_id", row.AccountId, "balance", balance, "journal_balance", journalBalance, "currency", currency)
 	}
 
 	return data, nil
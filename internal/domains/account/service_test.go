@@ -2,69 +2,214 @@ package account
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/webhook"
 )
 
 func TestAccountService_Create(t *testing.T) {
+	fundingJournalRepo := &fakeJournalRepo{
+		CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+			if params.Kind != journal.KindFunding || len(params.Postings) != 2 {
+				return 0, fmt.Errorf("unexpected entry %+v", params)
+			}
+			treasury, credit := params.Postings[0], params.Postings[1]
+			if *treasury.SystemAccount != "treasury:USD" || treasury.Direction != "debit" || *credit.AccountId != 1 || credit.Amount != 10_023 {
+				return 0, fmt.Errorf("unexpected postings %+v", params.Postings)
+			}
+			return 1, nil
+		},
+	}
+
 	type fields struct {
-		repo AccountRepo
+		repo        AccountRepo
+		journalRepo journal.JournalRepo
+		uow         UnitOfWork
+		idGenerator IdGenerator
+		events      EventRecorder
 
+		isClientIdOn    bool
 		isTigerBeetleOn bool
-		tigerbeetleRepo AccountTBRepo
+		outboxRepo      outbox.OutboxRepo
 	}
 	type args struct {
 		ctx  context.Context
 		data AccountCreate
 	}
+	idRepo := func(taken ...int) *fakeAccountRepo {
+		return &fakeAccountRepo{
+			CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) {
+				return !slices.Contains(taken, data.AccountId), nil
+			},
+		}
+	}
+
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
+		name          string
+		fields        fields
+		args          args
+		wantAccountId int
+		wantErr       bool
 	}{
 		{
-			name: "error - invalid intial balance alpha",
-			fields: fields{repo: &fakeAccountRepo{
-				CreateFunc: func(ctx context.Context, data AccountCreateParams) error { return nil },
+			name:   "error - client account id not allowed",
+			fields: fields{repo: idRepo(), uow: &fakeUnitOfWork{}},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{AccountId: 7, Currency: "USD"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "success - client account id",
+			fields: fields{repo: idRepo(), uow: &fakeUnitOfWork{}, isClientIdOn: true},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{AccountId: 7, Currency: "USD"},
+			},
+			wantAccountId: 7,
+		},
+		{
+			name:   "error - client account id conflict",
+			fields: fields{repo: idRepo(7), uow: &fakeUnitOfWork{}, isClientIdOn: true},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{AccountId: 7, Currency: "USD"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "success - generated account id taken is replaced",
+			fields: fields{repo: idRepo(1, 2), uow: &fakeUnitOfWork{}, idGenerator: sequence(1, 2, 3)},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD"},
+			},
+			wantAccountId: 3,
+		},
+		{
+			name:   "error - generated account ids all taken",
+			fields: fields{repo: idRepo(1, 2, 3), uow: &fakeUnitOfWork{}, idGenerator: sequence(1, 2, 3, 4)},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - id generator fail",
+			fields: fields{repo: idRepo(), uow: &fakeUnitOfWork{}, idGenerator: &fakeIdGenerator{
+				NextIdFunc: func(ctx context.Context) (int, error) { return 0, fmt.Errorf("test-error") },
 			}},
 			args: args{
 				ctx:  t.Context(),
-				data: AccountCreate{AccountId: 1, InitialBalance: "aaa"},
+				data: AccountCreate{Currency: "USD"},
 			},
 			wantErr: true,
 		},
 		{
-			name: "error - invalid intial balance negative",
+			name: "error - invalid intial balance too precise",
 			fields: fields{repo: &fakeAccountRepo{
-				CreateFunc: func(ctx context.Context, data AccountCreateParams) error { return nil },
+				CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) { return true, nil },
 			}},
 			args: args{
 				ctx:  t.Context(),
-				data: AccountCreate{AccountId: 1, InitialBalance: "-1"},
+				data: AccountCreate{Currency: "USD", InitialBalance: money.New(1, 3)},
 			},
 			wantErr: true,
 		},
 		{
-			name: "error - db fail",
+			name: "error - unknown currency",
 			fields: fields{repo: &fakeAccountRepo{
-				CreateFunc: func(ctx context.Context, data AccountCreateParams) error { return fmt.Errorf("test-error") },
+				CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) { return true, nil },
 			}},
 			args: args{
 				ctx:  t.Context(),
-				data: AccountCreate{AccountId: 1, InitialBalance: "100.23344"},
+				data: AccountCreate{Currency: "XYZ", InitialBalance: money.New(1, 0)},
 			},
 			wantErr: true,
 		},
 		{
-			name: "success",
+			name: "error - invalid intial balance negative",
 			fields: fields{repo: &fakeAccountRepo{
-				CreateFunc: func(ctx context.Context, data AccountCreateParams) error { return nil },
+				CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) { return true, nil },
 			}},
 			args: args{
 				ctx:  t.Context(),
-				data: AccountCreate{AccountId: 1, InitialBalance: "100.23344"},
+				data: AccountCreate{Currency: "USD", InitialBalance: money.New(-1, 0)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - db fail",
+			fields: fields{
+				repo: &fakeAccountRepo{
+					CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) {
+						return false, fmt.Errorf("test-error")
+					},
+				},
+				uow: &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD", InitialBalance: money.New(10_023, 2)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - journal fail",
+			fields: fields{
+				repo: &fakeAccountRepo{
+					CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) { return true, nil },
+				},
+				journalRepo: &fakeJournalRepo{
+					CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+						return 0, fmt.Errorf("test-error")
+					},
+				},
+				uow: &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD", InitialBalance: money.New(10_023, 2)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "success - zero initial balance writes no journal entry",
+			fields: fields{
+				repo: &fakeAccountRepo{
+					CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) { return true, nil },
+				},
+				uow: &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "success",
+			fields: fields{
+				repo: &fakeAccountRepo{
+					CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) { return true, nil },
+				},
+				journalRepo: fundingJournalRepo,
+				uow:         &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD", InitialBalance: money.New(10_023, 2)},
 			},
 			wantErr: false,
 		},
@@ -72,26 +217,164 @@ func TestAccountService_Create(t *testing.T) {
 			name: "success - tigerbeetle",
 			fields: fields{
 				repo: &fakeAccountRepo{
-					CreateFunc: func(ctx context.Context, data AccountCreateParams) error { return nil },
+					CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) { return true, nil },
+				},
+				journalRepo:     fundingJournalRepo,
+				uow:             &fakeUnitOfWork{},
+				isTigerBeetleOn: true,
+				outboxRepo: &fakeOutboxRepo{
+					CreateFunc: func(ctx context.Context, params outbox.MessageCreateParams) error {
+						if params.Kind != outbox.KindAccountCreate || string(params.Payload) != `{"account_id":1,"currency":"USD","initial_balance":10023}` {
+							return fmt.Errorf("unexpected message %s %s", params.Kind, params.Payload)
+						}
+						return nil
+					},
+				},
+			},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD", InitialBalance: money.New(10_023, 2)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "success - overdraft tigerbeetle",
+			fields: fields{
+				repo: &fakeAccountRepo{
+					CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) {
+						if data.OverdraftLimit != 50_000 {
+							return false, fmt.Errorf("unexpected overdraft limit %d", data.OverdraftLimit)
+						}
+						return true, nil
+					},
 				},
+				uow:             &fakeUnitOfWork{},
 				isTigerBeetleOn: true,
-				tigerbeetleRepo: &fakeAccountTBRepo{
-					CreateAccountFunc:     func(accountId int) error { return nil },
-					CreateTransactionFunc: func(debitAccountId, creditAccountId, amount int) error { return nil },
+				outboxRepo: &fakeOutboxRepo{
+					CreateFunc: func(ctx context.Context, params outbox.MessageCreateParams) error {
+						var payload outbox.AccountPayload
+						if err := json.Unmarshal(params.Payload, &payload); err != nil || !payload.Overdraft {
+							return fmt.Errorf("unexpected payload %s", params.Payload)
+						}
+						return nil
+					},
 				},
 			},
 			args: args{
 				ctx:  t.Context(),
-				data: AccountCreate{AccountId: 1, InitialBalance: "100.23344"},
+				data: AccountCreate{Currency: "USD", OverdraftLimit: money.New(500, 0)},
 			},
 			wantErr: false,
 		},
+		{
+			name: "success - tier",
+			fields: fields{
+				repo: &fakeAccountRepo{
+					CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) {
+						if data.Tier != "premium" {
+							return false, fmt.Errorf("unexpected tier %q", data.Tier)
+						}
+						return true, nil
+					},
+				},
+				uow: &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD", Tier: "premium"},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "error - tier invalid",
+			fields: fields{repo: idRepo(), uow: &fakeUnitOfWork{}},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD", Tier: "Premium Plus"},
+			},
+			wantErr: true,
+		},
+		{
+			name:   "error - overdraft limit negative",
+			fields: fields{repo: idRepo(), uow: &fakeUnitOfWork{}},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD", OverdraftLimit: money.New(-1, 0)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "success - account.created recorded",
+			fields: fields{
+				repo: idRepo(),
+				uow:  &fakeUnitOfWork{},
+				events: &fakeEventRecorder{
+					RecordFunc: func(ctx context.Context, eventType string, data any) error {
+						if account, ok := data.(Account); eventType != webhook.EventAccountCreated || !ok || account.AccountId != 1 {
+							return fmt.Errorf("unexpected event %s %+v", eventType, data)
+						}
+						return nil
+					},
+				},
+			},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD"},
+			},
+		},
+		{
+			name: "error - event record fail",
+			fields: fields{
+				repo: idRepo(),
+				uow:  &fakeUnitOfWork{},
+				events: &fakeEventRecorder{
+					RecordFunc: func(ctx context.Context, eventType string, data any) error {
+						return webhook.ErrWebhookEventRecordFailed
+					},
+				},
+			},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - tigerbeetle outbox fail",
+			fields: fields{
+				repo: &fakeAccountRepo{
+					CreateFunc: func(ctx context.Context, data AccountCreateParams) (bool, error) { return true, nil },
+				},
+				journalRepo:     fundingJournalRepo,
+				uow:             &fakeUnitOfWork{},
+				isTigerBeetleOn: true,
+				outboxRepo: &fakeOutboxRepo{
+					CreateFunc: func(ctx context.Context, params outbox.MessageCreateParams) error {
+						return errors.New("insert fail")
+					},
+				},
+			},
+			args: args{
+				ctx:  t.Context(),
+				data: AccountCreate{Currency: "USD", InitialBalance: money.New(10_023, 2)},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := NewAccountService(tt.fields.repo, tt.fields.tigerbeetleRepo, tt.fields.isTigerBeetleOn)
-			if err := svc.Create(tt.args.ctx, tt.args.data); (err != nil) != tt.wantErr {
+			idGenerator := tt.fields.idGenerator
+			if idGenerator == nil {
+				idGenerator = sequence(1)
+			}
+			svc := NewAccountService(tt.fields.repo, tt.fields.journalRepo, tt.fields.uow, idGenerator, tt.fields.isClientIdOn, tt.fields.events, tt.fields.outboxRepo, tt.fields.isTigerBeetleOn)
+			got, err := svc.Create(tt.args.ctx, tt.args.data)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("AccountService.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if wantAccountId := max(tt.wantAccountId, 1); err == nil && (got.AccountId != wantAccountId || got.AccountNumber != AccountNumber(wantAccountId)) {
+				t.Errorf("AccountService.Create() = %+v, want account %d", got, wantAccountId)
 			}
 		})
 	}
@@ -99,10 +382,14 @@ func TestAccountService_Create(t *testing.T) {
 
 func TestAccountService_ById(t *testing.T) {
 	type fields struct {
-		repo AccountRepo
+		repo        AccountRepo
+		journalRepo journal.JournalRepo
+		uow         UnitOfWork
+		idGenerator IdGenerator
 
+		isClientIdOn    bool
 		isTigerBeetleOn bool
-		tigerbeetleRepo AccountTBRepo
+		outboxRepo      outbox.OutboxRepo
 	}
 	type args struct {
 		ctx       context.Context
@@ -137,6 +424,8 @@ func TestAccountService_ById(t *testing.T) {
 						AccountId:    1,
 						Balance:      100_000,
 						ScaleBalance: 5,
+						Currency:     "USD",
+						HeldBalance:  40_000,
 					}, nil
 				},
 			}},
@@ -145,15 +434,20 @@ func TestAccountService_ById(t *testing.T) {
 				accountId: 1,
 			},
 			want: Account{
-				AccountId:      1,
-				InitialBalance: "1.00000",
+				AccountId:        1,
+				AccountNumber:    "18",
+				Currency:         "USD",
+				InitialBalance:   money.New(100_000, 5),
+				HeldBalance:      money.New(40_000, 5),
+				OverdraftLimit:   money.New(0, 5),
+				AvailableBalance: money.New(60_000, 5),
 			},
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := NewAccountService(tt.fields.repo, tt.fields.tigerbeetleRepo, tt.fields.isTigerBeetleOn)
+			svc := NewAccountService(tt.fields.repo, tt.fields.journalRepo, tt.fields.uow, tt.fields.idGenerator, tt.fields.isClientIdOn, nil, tt.fields.outboxRepo, tt.fields.isTigerBeetleOn)
 			got, err := svc.ById(tt.args.ctx, tt.args.accountId)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("AccountService.ById() error = %v, wantErr %v", err, tt.wantErr)
@@ -166,13 +460,336 @@ func TestAccountService_ById(t *testing.T) {
 	}
 }
 
+func TestAccountService_VerifyBalance(t *testing.T) {
+	accountRepo := &fakeAccountRepo{
+		ByIdFunc: func(ctx context.Context, accountId int) (AccountRow, error) {
+			return AccountRow{AccountId: 1, Balance: 15_000, ScaleBalance: 5, Currency: "USD"}, nil
+		},
+	}
+	journalRepo := func(rows ...journal.AccountBalanceRow) *fakeJournalRepo {
+		return &fakeJournalRepo{
+			AccountBalanceFunc: func(ctx context.Context, accountId int) ([]journal.AccountBalanceRow, error) {
+				return rows, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		journalRepo journal.JournalRepo
+		want        BalanceVerification
+		wantErr     bool
+	}{
+		{
+			name: "error - journal fail",
+			journalRepo: &fakeJournalRepo{
+				AccountBalanceFunc: func(ctx context.Context, accountId int) ([]journal.AccountBalanceRow, error) {
+					return nil, fmt.Errorf("test-error")
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "success - consistent across scales",
+			journalRepo: journalRepo(
+				journal.AccountBalanceRow{Currency: "USD", AmountScale: 2, Balance: 10},
+				journal.AccountBalanceRow{Currency: "USD", AmountScale: 5, Balance: 5_000},
+			),
+			want: BalanceVerification{
+				AccountId:      1,
+				Balance:        money.New(15_000, 5),
+				JournalBalance: money.New(15_000, 5),
+				Consistent:     true,
+			},
+		},
+		{
+			name:        "success - inconsistent",
+			journalRepo: journalRepo(journal.AccountBalanceRow{Currency: "USD", AmountScale: 2, Balance: 10}),
+			want: BalanceVerification{
+				AccountId:      1,
+				Balance:        money.New(15_000, 5),
+				JournalBalance: money.New(10, 2),
+				Consistent:     false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewAccountService(accountRepo, tt.journalRepo, &fakeUnitOfWork{}, nil, false, nil, nil, false)
+			got, err := svc.VerifyBalance(t.Context(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AccountService.VerifyBalance() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AccountService.VerifyBalance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccountService_ChangeStatus(t *testing.T) {
+	accountRepo := func(status string, gotStatus *AccountUpdateStatusParams) *fakeAccountRepo {
+		return &fakeAccountRepo{
+			ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]AccountRow, error) {
+				if accountIds[0] != 1 {
+					return nil, nil
+				}
+				return []AccountRow{{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Status: status}}, nil
+			},
+			UpdateStatusFunc: func(ctx context.Context, params AccountUpdateStatusParams) error {
+				*gotStatus = params
+				return nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		status    string
+		accountId int
+		data      AccountStatusChange
+		want      Account
+		wantErr   error
+	}{
+		{
+			name:      "success - freeze",
+			status:    StatusActive,
+			accountId: 1,
+			data:      AccountStatusChange{Status: StatusFrozen, Reason: "suspected fraud"},
+			want: Account{
+				AccountId:        1,
+				AccountNumber:    "18",
+				Currency:         "USD",
+				InitialBalance:   money.New(1_000, 2),
+				HeldBalance:      money.New(0, 2),
+				OverdraftLimit:   money.New(0, 2),
+				AvailableBalance: money.New(1_000, 2),
+				Status:           StatusFrozen,
+			},
+		},
+		{
+			name:      "success - unfreeze",
+			status:    StatusFrozen,
+			accountId: 1,
+			data:      AccountStatusChange{Status: StatusActive, Reason: "cleared"},
+			want: Account{
+				AccountId:        1,
+				AccountNumber:    "18",
+				Currency:         "USD",
+				InitialBalance:   money.New(1_000, 2),
+				HeldBalance:      money.New(0, 2),
+				OverdraftLimit:   money.New(0, 2),
+				AvailableBalance: money.New(1_000, 2),
+				Status:           StatusActive,
+			},
+		},
+		{
+			name:      "error - closing goes through CloseAccount",
+			status:    StatusActive,
+			accountId: 1,
+			data:      AccountStatusChange{Status: StatusClosed, Reason: "customer request"},
+			wantErr:   ErrAccountStatusInvalid,
+		},
+		{
+			name:      "error - unknown status",
+			status:    StatusActive,
+			accountId: 1,
+			data:      AccountStatusChange{Status: "dormant", Reason: "inactive"},
+			wantErr:   ErrAccountStatusInvalid,
+		},
+		{
+			name:      "error - reason required",
+			status:    StatusActive,
+			accountId: 1,
+			data:      AccountStatusChange{Status: StatusFrozen},
+			wantErr:   ErrAccountStatusReasonRequired,
+		},
+		{
+			name:      "error - closed",
+			status:    StatusClosed,
+			accountId: 1,
+			data:      AccountStatusChange{Status: StatusActive, Reason: "reopen"},
+			wantErr:   ErrAccountClosed,
+		},
+		{
+			name:      "error - not found",
+			status:    StatusActive,
+			accountId: 2,
+			data:      AccountStatusChange{Status: StatusFrozen, Reason: "suspected fraud"},
+			wantErr:   ErrAccountNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotStatus AccountUpdateStatusParams
+			svc := NewAccountService(accountRepo(tt.status, &gotStatus), nil, &fakeUnitOfWork{}, nil, false, nil, nil, false)
+			got, err := svc.ChangeStatus(t.Context(), tt.accountId, tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("AccountService.ChangeStatus() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AccountService.ChangeStatus() = %v, want %v", got, tt.want)
+			}
+			want := AccountUpdateStatusParams{AccountId: 1, FromStatus: tt.status, ToStatus: tt.data.Status, Reason: tt.data.Reason}
+			if gotStatus != want {
+				t.Errorf("status change = %+v, want %+v", gotStatus, want)
+			}
+		})
+	}
+}
+
+func TestAccountService_ChangeOverdraftLimit(t *testing.T) {
+	accountRepo := func(row AccountRow, gotLimit *AccountUpdateOverdraftLimitParams) *fakeAccountRepo {
+		return &fakeAccountRepo{
+			ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]AccountRow, error) {
+				if accountIds[0] != row.AccountId {
+					return nil, nil
+				}
+				return []AccountRow{row}, nil
+			},
+			UpdateOverdraftLimitFunc: func(ctx context.Context, params AccountUpdateOverdraftLimitParams) error {
+				*gotLimit = params
+				return nil
+			},
+		}
+	}
+
+	overdrawn := AccountRow{AccountId: 1, Balance: -30_000, ScaleBalance: 2, Currency: "USD", Status: StatusActive, OverdraftLimit: 50_000, OverdraftEnabled: true}
+	tests := []struct {
+		name            string
+		row             AccountRow
+		isTigerBeetleOn bool
+		data            AccountOverdraftLimit
+		wantLimit       int64
+		wantAvailable   money.Amount
+		wantErr         error
+	}{
+		{
+			name:          "success - grant",
+			row:           AccountRow{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Status: StatusActive},
+			data:          AccountOverdraftLimit{OverdraftLimit: money.New(100, 0), Reason: "approved credit line"},
+			wantLimit:     10_000,
+			wantAvailable: money.New(11_000, 2),
+		},
+		{
+			name:          "success - lower below what is owed",
+			row:           overdrawn,
+			data:          AccountOverdraftLimit{OverdraftLimit: money.New(100, 0), Reason: "risk review"},
+			wantLimit:     10_000,
+			wantAvailable: money.New(-20_000, 2),
+		},
+		{
+			name:            "success - tigerbeetle overdraft account",
+			row:             overdrawn,
+			isTigerBeetleOn: true,
+			data:            AccountOverdraftLimit{OverdraftLimit: money.New(1_000, 0), Reason: "limit increase"},
+			wantLimit:       100_000,
+			wantAvailable:   money.New(70_000, 2),
+		},
+		{
+			name:            "success - tigerbeetle remove limit",
+			row:             AccountRow{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Status: StatusActive},
+			isTigerBeetleOn: true,
+			data:            AccountOverdraftLimit{Reason: "no credit line"},
+			wantLimit:       0,
+			wantAvailable:   money.New(1_000, 2),
+		},
+		{
+			name:            "error - tigerbeetle account opened without overdraft",
+			row:             AccountRow{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Status: StatusActive},
+			isTigerBeetleOn: true,
+			data:            AccountOverdraftLimit{OverdraftLimit: money.New(100, 0), Reason: "approved credit line"},
+			wantErr:         ErrAccountOverdraftNotEnabled,
+		},
+		{
+			name:    "error - negative",
+			row:     overdrawn,
+			data:    AccountOverdraftLimit{OverdraftLimit: money.New(-1, 0), Reason: "approved credit line"},
+			wantErr: ErrAccountOverdraftLimitNegative,
+		},
+		{
+			name:    "error - reason required",
+			row:     overdrawn,
+			data:    AccountOverdraftLimit{OverdraftLimit: money.New(100, 0)},
+			wantErr: ErrAccountOverdraftLimitReasonRequired,
+		},
+		{
+			name:    "error - too precise",
+			row:     overdrawn,
+			data:    AccountOverdraftLimit{OverdraftLimit: money.New(1, 3), Reason: "approved credit line"},
+			wantErr: money.ErrMoneyTooPrecise,
+		},
+		{
+			name:    "error - closed",
+			row:     AccountRow{AccountId: 1, ScaleBalance: 2, Currency: "USD", Status: StatusClosed},
+			data:    AccountOverdraftLimit{OverdraftLimit: money.New(100, 0), Reason: "approved credit line"},
+			wantErr: ErrAccountClosed,
+		},
+		{
+			name:    "error - not found",
+			row:     AccountRow{AccountId: 2},
+			data:    AccountOverdraftLimit{OverdraftLimit: money.New(100, 0), Reason: "approved credit line"},
+			wantErr: ErrAccountNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotLimit AccountUpdateOverdraftLimitParams
+			svc := NewAccountService(accountRepo(tt.row, &gotLimit), nil, &fakeUnitOfWork{}, nil, false, nil, nil, tt.isTigerBeetleOn)
+			got, err := svc.ChangeOverdraftLimit(t.Context(), 1, tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("AccountService.ChangeOverdraftLimit() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.OverdraftLimit.Cmp(money.New(tt.wantLimit, 2)) != 0 || got.AvailableBalance.Cmp(tt.wantAvailable) != 0 {
+				t.Errorf("AccountService.ChangeOverdraftLimit() = %v, want limit %d available %s", got, tt.wantLimit, tt.wantAvailable)
+			}
+			want := AccountUpdateOverdraftLimitParams{AccountId: 1, FromLimit: tt.row.OverdraftLimit, ToLimit: tt.wantLimit, Reason: tt.data.Reason}
+			if gotLimit != want {
+				t.Errorf("overdraft limit change = %+v, want %+v", gotLimit, want)
+			}
+		})
+	}
+}
+
+type fakeIdGenerator struct {
+	NextIdFunc func(ctx context.Context) (int, error)
+}
+
+func (f *fakeIdGenerator) NextId(ctx context.Context) (int, error) {
+	return f.NextIdFunc(ctx)
+}
+
+// sequence returns an IdGenerator handing out ids in order.
+func sequence(ids ...int) *fakeIdGenerator {
+	return &fakeIdGenerator{
+		NextIdFunc: func(ctx context.Context) (int, error) {
+			id := ids[0]
+			ids = ids[1:]
+			return id, nil
+		},
+	}
+}
+
 type fakeAccountRepo struct {
-	CreateFunc        func(ctx context.Context, data AccountCreateParams) error
-	ByIdFunc          func(ctx context.Context, accountId int) (AccountRow, error)
-	UpdateBalanceFunc func(ctx context.Context, params AccountUpdateBalanceParams) error
+	CreateFunc            func(ctx context.Context, data AccountCreateParams) (bool, error)
+	ByIdFunc              func(ctx context.Context, accountId int) (AccountRow, error)
+	ByIdsForUpdateFunc    func(ctx context.Context, accountIds []int) ([]AccountRow, error)
+	UpdateBalanceFunc     func(ctx context.Context, params AccountUpdateBalanceParams) error
+	UpdateHeldBalanceFunc func(ctx context.Context, params AccountUpdateHeldBalanceParams) error
+	UpdateStatusFunc      func(ctx context.Context, params AccountUpdateStatusParams) error
+
+	UpdateOverdraftLimitFunc  func(ctx context.Context, params AccountUpdateOverdraftLimitParams) error
+	OverdraftLimitChangesFunc func(ctx context.Context, accountId int) ([]OverdraftLimitChangeRow, error)
 }
 
-func (f *fakeAccountRepo) Create(ctx context.Context, data AccountCreateParams) error {
+func (f *fakeAccountRepo) Create(ctx context.Context, data AccountCreateParams) (bool, error) {
 	return f.CreateFunc(ctx, data)
 }
 
@@ -180,19 +797,81 @@ func (f *fakeAccountRepo) ById(ctx context.Context, accountId int) (AccountRow,
 	return f.ByIdFunc(ctx, accountId)
 }
 
+func (f *fakeAccountRepo) ByIdsForUpdate(ctx context.Context, accountIds []int) ([]AccountRow, error) {
+	return f.ByIdsForUpdateFunc(ctx, accountIds)
+}
+
 func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, params AccountUpdateBalanceParams) error {
 	return f.UpdateBalanceFunc(ctx, params)
 }
 
-type fakeAccountTBRepo struct {
-	CreateAccountFunc     func(accountId int) error
-	CreateTransactionFunc func(debitAccountId int, creditAccountId int, amount int) error
+func (f *fakeAccountRepo) UpdateHeldBalance(ctx context.Context, params AccountUpdateHeldBalanceParams) error {
+	return f.UpdateHeldBalanceFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateStatus(ctx context.Context, params AccountUpdateStatusParams) error {
+	return f.UpdateStatusFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateOverdraftLimit(ctx context.Context, params AccountUpdateOverdraftLimitParams) error {
+	return f.UpdateOverdraftLimitFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) OverdraftLimitChanges(ctx context.Context, accountId int) ([]OverdraftLimitChangeRow, error) {
+	return f.OverdraftLimitChangesFunc(ctx, accountId)
+}
+
+type fakeOutboxRepo struct {
+	CreateFunc           func(ctx context.Context, params outbox.MessageCreateParams) error
+	PendingForUpdateFunc func(ctx context.Context, now time.Time, limit int) ([]outbox.MessageRow, error)
+	MarkSentFunc         func(ctx context.Context, messageIds []int) error
+	MarkFailedFunc       func(ctx context.Context, params outbox.MessageFailParams) error
+	StatsFunc            func(ctx context.Context) (outbox.StatsRow, error)
+}
+
+func (f *fakeOutboxRepo) Create(ctx context.Context, params outbox.MessageCreateParams) error {
+	return f.CreateFunc(ctx, params)
+}
+
+func (f *fakeOutboxRepo) PendingForUpdate(ctx context.Context, now time.Time, limit int) ([]outbox.MessageRow, error) {
+	return f.PendingForUpdateFunc(ctx, now, limit)
+}
+
+func (f *fakeOutboxRepo) MarkSent(ctx context.Context, messageIds []int) error {
+	return f.MarkSentFunc(ctx, messageIds)
+}
+
+func (f *fakeOutboxRepo) MarkFailed(ctx context.Context, params outbox.MessageFailParams) error {
+	return f.MarkFailedFunc(ctx, params)
+}
+
+func (f *fakeOutboxRepo) Stats(ctx context.Context) (outbox.StatsRow, error) {
+	return f.StatsFunc(ctx)
+}
+
+type fakeEventRecorder struct {
+	RecordFunc func(ctx context.Context, eventType string, data any) error
+}
+
+func (f *fakeEventRecorder) Record(ctx context.Context, eventType string, data any) error {
+	return f.RecordFunc(ctx, eventType, data)
+}
+
+type fakeUnitOfWork struct{}
+
+func (f *fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeJournalRepo struct {
+	CreateFunc         func(ctx context.Context, params journal.EntryCreateParams) (int, error)
+	AccountBalanceFunc func(ctx context.Context, accountId int) ([]journal.AccountBalanceRow, error)
 }
 
-func (f *fakeAccountTBRepo) CreateAccount(accountId int) error {
-	return f.CreateAccountFunc(accountId)
+func (f *fakeJournalRepo) Create(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+	return f.CreateFunc(ctx, params)
 }
 
-func (f *fakeAccountTBRepo) CreateTransaction(debitAccountId int, creditAccountId int, amount int) error {
-	return f.CreateTransactionFunc(debitAccountId, creditAccountId, amount)
+func (f *fakeJournalRepo) AccountBalance(ctx context.Context, accountId int) ([]journal.AccountBalanceRow, error) {
+	return f.AccountBalanceFunc(ctx, accountId)
 }
@@ -0,0 +1,48 @@
+package apikey
+
+import (
+	"context"
+	"time"
+)
+
+// ApiKeyRepo defines the interface for API key persistence. Keys are stored as their SHA-256
+// hash only.
+type ApiKeyRepo interface {
+	Create(ctx context.Context, params ApiKeyCreateParams) (ApiKeyRow, error)
+	ById(ctx context.Context, apiKeyId int) (ApiKeyRow, error)
+	ByHash(ctx context.Context, keyHash string) (ApiKeyRow, bool, error)
+	List(ctx context.Context) ([]ApiKeyRow, error)
+	UpdateKey(ctx context.Context, params ApiKeyUpdateKeyParams) (bool, error)
+	Revoke(ctx context.Context, apiKeyId int) (bool, error)
+}
+
+// ApiKeyCreateParams holds the parameters required to store an API key. AccountIds is nil for a
+// key that may act on every account.
+type ApiKeyCreateParams struct {
+	Name       string
+	KeyPrefix  string
+	KeyHash    string
+	Scopes     []string
+	AccountIds []int
+}
+
+// ApiKeyUpdateKeyParams holds the parameters required to replace the key of an API key that is
+// not revoked.
+type ApiKeyUpdateKeyParams struct {
+	ApiKeyId  int
+	KeyPrefix string
+	KeyHash   string
+}
+
+// ApiKeyRow represents a row in the api_keys table.
+type ApiKeyRow struct {
+	ApiKeyId   int        `db:"api_key_id"`
+	Name       string     `db:"name"`
+	KeyPrefix  string     `db:"key_prefix"`
+	KeyHash    string     `db:"key_hash"`
+	Scopes     []string   `db:"scopes"`
+	AccountIds []int      `db:"account_ids"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
+}
@@ -0,0 +1,249 @@
+// Package apikey provides business logic for API keys: the static credentials clients send in
+// the X-API-Key header, each granted a set of scopes and optionally restricted to the accounts
+// its client owns. Keys are shown once, when they are created or rotated, and stored hashed.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+)
+
+// keyPrefix starts every API key, so leaked keys are easy to recognize.
+const keyPrefix = "tsk_"
+
+// shownPrefixLength is the number of leading characters of a key stored in clear to tell keys
+// apart, keyPrefix included.
+const shownPrefixLength = 12
+
+var (
+	ErrApiKeyCreateFailed       = errors.New("api key creation fail")
+	ErrApiKeyListFailed         = errors.New("api key list fail")
+	ErrApiKeyRotateFailed       = errors.New("api key rotation fail")
+	ErrApiKeyRevokeFailed       = errors.New("api key revocation fail")
+	ErrApiKeyAuthenticateFailed = errors.New("api key authentication fail")
+	ErrApiKeyNotFound           = errors.New("api key not found")
+	ErrApiKeyNameRequired       = errors.New("api key name required")
+	ErrApiKeyScopeInvalid       = errors.New("api key scope invalid")
+	ErrApiKeyAccountIdInvalid   = errors.New("api key account id invalid")
+	ErrApiKeyInvalid            = errors.New("api key invalid")
+)
+
+// ApiKeyCreate represents a request to create an API key granted Scopes. AccountIds restricts
+// the key to debiting and changing the listed accounts; nil allows every account.
+type ApiKeyCreate struct {
+	Name       string
+	Scopes     []string
+	AccountIds []int
+}
+
+// ApiKey represents an API key. Key is only set when the key is created or rotated; afterwards
+// Prefix, its first characters, tells keys apart.
+type ApiKey struct {
+	ApiKeyId   int        `json:"api_key_id"`
+	Name       string     `json:"name"`
+	Key        string     `json:"key,omitempty"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AccountIds []int      `json:"account_ids,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ApiKeyService manages API keys and authenticates the callers presenting them.
+type ApiKeyService struct {
+	repo ApiKeyRepo
+}
+
+// NewApiKeyService creates a new ApiKeyService.
+func NewApiKeyService(repo ApiKeyRepo) *ApiKeyService {
+	return &ApiKeyService{repo}
+}
+
+// Create validates and stores a new API key and returns it with its key, which is not stored and
+// can not be retrieved again.
+func (svc *ApiKeyService) Create(ctx context.Context, data ApiKeyCreate) (ApiKey, error) {
+	if strings.TrimSpace(data.Name) == "" {
+		slog.WarnContext(ctx, ErrApiKeyNameRequired.Error())
+		return ApiKey{}, ErrApiKeyNameRequired
+	}
+
+	if len(data.Scopes) == 0 {
+		slog.WarnContext(ctx, ErrApiKeyScopeInvalid.Error(), "reason", "no scopes")
+		return ApiKey{}, ErrApiKeyScopeInvalid
+	}
+	for _, scope := range data.Scopes {
+		if !slices.Contains(auth.Scopes, scope) {
+			slog.WarnContext(ctx, ErrApiKeyScopeInvalid.Error(), "scope", scope)
+			return ApiKey{}, ErrApiKeyScopeInvalid
+		}
+	}
+	scopes := slices.Clone(data.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	var accountIds []int
+	if data.AccountIds != nil {
+		for _, accountId := range data.AccountIds {
+			if accountId <= 0 {
+				slog.WarnContext(ctx, ErrApiKeyAccountIdInvalid.Error(), "account_id", accountId)
+				return ApiKey{}, ErrApiKeyAccountIdInvalid
+			}
+		}
+		accountIds = slices.Clone(data.AccountIds)
+		slices.Sort(accountIds)
+		accountIds = slices.Compact(accountIds)
+	}
+
+	key, err := newKey()
+	if err != nil {
+		slog.ErrorContext(ctx, ErrApiKeyCreateFailed.Error(), "error", err)
+		return ApiKey{}, ErrApiKeyCreateFailed
+	}
+
+	row, err := svc.repo.Create(ctx, ApiKeyCreateParams{
+		Name:       data.Name,
+		KeyPrefix:  key[:shownPrefixLength],
+		KeyHash:    hashKey(key),
+		Scopes:     scopes,
+		AccountIds: accountIds,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrApiKeyCreateFailed.Error(), "error", err)
+		return ApiKey{}, ErrApiKeyCreateFailed
+	}
+
+	apiKey := toApiKey(row)
+	apiKey.Key = key
+	return apiKey, nil
+}
+
+// List returns every API key, revoked ones included, oldest first.
+func (svc *ApiKeyService) List(ctx context.Context) ([]ApiKey, error) {
+	rows, err := svc.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrApiKeyListFailed.Error(), "error", err)
+		return nil, ErrApiKeyListFailed
+	}
+
+	data := make([]ApiKey, len(rows))
+	for i, row := range rows {
+		data[i] = toApiKey(row)
+	}
+
+	return data, nil
+}
+
+// Rotate replaces the key of an API key that is not revoked with a new one, keeping its scopes
+// and accounts. The old key stops working at once.
+func (svc *ApiKeyService) Rotate(ctx context.Context, apiKeyId int) (ApiKey, error) {
+	key, err := newKey()
+	if err != nil {
+		slog.ErrorContext(ctx, ErrApiKeyRotateFailed.Error(), "error", err)
+		return ApiKey{}, ErrApiKeyRotateFailed
+	}
+
+	updated, err := svc.repo.UpdateKey(ctx, ApiKeyUpdateKeyParams{ApiKeyId: apiKeyId, KeyPrefix: key[:shownPrefixLength], KeyHash: hashKey(key)})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrApiKeyRotateFailed.Error(), "error", err)
+		return ApiKey{}, ErrApiKeyRotateFailed
+	}
+	if !updated {
+		slog.WarnContext(ctx, ErrApiKeyNotFound.Error(), "api_key_id", apiKeyId)
+		return ApiKey{}, ErrApiKeyNotFound
+	}
+
+	row, err := svc.repo.ById(ctx, apiKeyId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrApiKeyRotateFailed.Error(), "error", err)
+		return ApiKey{}, ErrApiKeyRotateFailed
+	}
+
+	apiKey := toApiKey(row)
+	apiKey.Key = key
+	return apiKey, nil
+}
+
+// Revoke disables an API key for good.
+func (svc *ApiKeyService) Revoke(ctx context.Context, apiKeyId int) error {
+	revoked, err := svc.repo.Revoke(ctx, apiKeyId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrApiKeyRevokeFailed.Error(), "error", err)
+		return ErrApiKeyRevokeFailed
+	}
+	if !revoked {
+		slog.WarnContext(ctx, ErrApiKeyNotFound.Error(), "api_key_id", apiKeyId)
+		return ErrApiKeyNotFound
+	}
+
+	return nil
+}
+
+// Authenticate returns the principal of the API key key. Unknown and revoked keys fail with
+// ErrApiKeyInvalid.
+func (svc *ApiKeyService) Authenticate(ctx context.Context, key string) (auth.Principal, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		slog.WarnContext(ctx, ErrApiKeyInvalid.Error(), "reason", "malformed")
+		return auth.Principal{}, ErrApiKeyInvalid
+	}
+
+	row, found, err := svc.repo.ByHash(ctx, hashKey(key))
+	if err != nil {
+		slog.ErrorContext(ctx, ErrApiKeyAuthenticateFailed.Error(), "error", err)
+		return auth.Principal{}, ErrApiKeyAuthenticateFailed
+	}
+	if !found {
+		slog.WarnContext(ctx, ErrApiKeyInvalid.Error(), "reason", "unknown")
+		return auth.Principal{}, ErrApiKeyInvalid
+	}
+	if row.RevokedAt != nil {
+		slog.WarnContext(ctx, ErrApiKeyInvalid.Error(), "reason", "revoked", "api_key_id", row.ApiKeyId)
+		return auth.Principal{}, ErrApiKeyInvalid
+	}
+
+	return auth.Principal{
+		Subject:    fmt.Sprintf("api_key:%d", row.ApiKeyId),
+		Scopes:     row.Scopes,
+		AccountIds: row.AccountIds,
+	}, nil
+}
+
+// newKey returns a random API key.
+func newKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashKey returns the hex SHA-256 hash of key, under which it is stored. Keys are random and
+// long, so a fast hash does not make them guessable.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func toApiKey(row ApiKeyRow) ApiKey {
+	return ApiKey{
+		ApiKeyId:   row.ApiKeyId,
+		Name:       row.Name,
+		Prefix:     row.KeyPrefix,
+		Scopes:     row.Scopes,
+		AccountIds: row.AccountIds,
+		RevokedAt:  row.RevokedAt,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+}
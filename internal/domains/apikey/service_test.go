@@ -0,0 +1,255 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+)
+
+func TestApiKeyService_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           ApiKeyCreate
+		wantScopes     []string
+		wantAccountIds []int
+		wantErr        error
+	}{
+		{
+			name:       "success - scopes sorted and deduplicated",
+			data:       ApiKeyCreate{Name: "billing", Scopes: []string{auth.ScopeTransactionsWrite, auth.ScopeAccountsRead, auth.ScopeTransactionsWrite}},
+			wantScopes: []string{auth.ScopeAccountsRead, auth.ScopeTransactionsWrite},
+		},
+		{
+			name:           "success - restricted to accounts",
+			data:           ApiKeyCreate{Name: "merchant 7", Scopes: []string{auth.ScopeTransactionsWrite}, AccountIds: []int{9, 7, 9}},
+			wantScopes:     []string{auth.ScopeTransactionsWrite},
+			wantAccountIds: []int{7, 9},
+		},
+		{
+			name:    "error - name required",
+			data:    ApiKeyCreate{Name: " ", Scopes: []string{auth.ScopeAccountsRead}},
+			wantErr: ErrApiKeyNameRequired,
+		},
+		{
+			name:    "error - no scopes",
+			data:    ApiKeyCreate{Name: "billing"},
+			wantErr: ErrApiKeyScopeInvalid,
+		},
+		{
+			name:    "error - unknown scope",
+			data:    ApiKeyCreate{Name: "billing", Scopes: []string{"accounts:delete"}},
+			wantErr: ErrApiKeyScopeInvalid,
+		},
+		{
+			name:    "error - account id not positive",
+			data:    ApiKeyCreate{Name: "billing", Scopes: []string{auth.ScopeAccountsRead}, AccountIds: []int{0}},
+			wantErr: ErrApiKeyAccountIdInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryRepo()
+
+			svc := NewApiKeyService(repo)
+			got, err := svc.Create(t.Context(), tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("ApiKeyService.Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(got.Scopes, tt.wantScopes) {
+				t.Errorf("ApiKeyService.Create() scopes = %v, want %v", got.Scopes, tt.wantScopes)
+			}
+			if !reflect.DeepEqual(got.AccountIds, tt.wantAccountIds) {
+				t.Errorf("ApiKeyService.Create() account ids = %v, want %v", got.AccountIds, tt.wantAccountIds)
+			}
+			if !strings.HasPrefix(got.Key, keyPrefix) || got.Prefix != got.Key[:shownPrefixLength] {
+				t.Errorf("ApiKeyService.Create() key = %q, prefix %q", got.Key, got.Prefix)
+			}
+			if stored := repo.rows[got.ApiKeyId]; stored.KeyHash == got.Key || stored.KeyHash != hashKey(got.Key) {
+				t.Errorf("stored key hash = %q, want the hash of the key", stored.KeyHash)
+			}
+		})
+	}
+}
+
+func TestApiKeyService_Authenticate(t *testing.T) {
+	repo := newMemoryRepo()
+	svc := NewApiKeyService(repo)
+
+	restricted, err := svc.Create(t.Context(), ApiKeyCreate{Name: "merchant 7", Scopes: []string{auth.ScopeTransactionsWrite}, AccountIds: []int{7}})
+	if err != nil {
+		t.Fatalf("ApiKeyService.Create() error = %v", err)
+	}
+	revoked, err := svc.Create(t.Context(), ApiKeyCreate{Name: "old", Scopes: []string{auth.ScopeAccountsRead}})
+	if err != nil {
+		t.Fatalf("ApiKeyService.Create() error = %v", err)
+	}
+	if err := svc.Revoke(t.Context(), revoked.ApiKeyId); err != nil {
+		t.Fatalf("ApiKeyService.Revoke() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		repoErr error
+		want    auth.Principal
+		wantErr error
+	}{
+		{
+			name: "success",
+			key:  restricted.Key,
+			want: auth.Principal{Subject: "api_key:1", Scopes: []string{auth.ScopeTransactionsWrite}, AccountIds: []int{7}},
+		},
+		{name: "error - revoked", key: revoked.Key, wantErr: ErrApiKeyInvalid},
+		{name: "error - unknown", key: keyPrefix + "unknown", wantErr: ErrApiKeyInvalid},
+		{name: "error - malformed", key: "secret", wantErr: ErrApiKeyInvalid},
+		{name: "error - repo failed", key: restricted.Key, repoErr: errors.New("connection refused"), wantErr: ErrApiKeyAuthenticateFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.err = tt.repoErr
+			defer func() { repo.err = nil }()
+
+			got, err := svc.Authenticate(t.Context(), tt.key)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("ApiKeyService.Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApiKeyService.Authenticate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApiKeyService_Rotate(t *testing.T) {
+	repo := newMemoryRepo()
+	svc := NewApiKeyService(repo)
+
+	created, err := svc.Create(t.Context(), ApiKeyCreate{Name: "billing", Scopes: []string{auth.ScopeAccountsRead}})
+	if err != nil {
+		t.Fatalf("ApiKeyService.Create() error = %v", err)
+	}
+
+	rotated, err := svc.Rotate(t.Context(), created.ApiKeyId)
+	if err != nil {
+		t.Fatalf("ApiKeyService.Rotate() error = %v", err)
+	}
+	if rotated.Key == "" || rotated.Key == created.Key {
+		t.Fatalf("ApiKeyService.Rotate() key = %q, want a new key", rotated.Key)
+	}
+
+	if _, err := svc.Authenticate(t.Context(), created.Key); !errors.Is(err, ErrApiKeyInvalid) {
+		t.Errorf("ApiKeyService.Authenticate() with the old key error = %v, want %v", err, ErrApiKeyInvalid)
+	}
+	if _, err := svc.Authenticate(t.Context(), rotated.Key); err != nil {
+		t.Errorf("ApiKeyService.Authenticate() with the new key error = %v", err)
+	}
+
+	if err := svc.Revoke(t.Context(), created.ApiKeyId); err != nil {
+		t.Fatalf("ApiKeyService.Revoke() error = %v", err)
+	}
+	if _, err := svc.Rotate(t.Context(), created.ApiKeyId); !errors.Is(err, ErrApiKeyNotFound) {
+		t.Errorf("ApiKeyService.Rotate() of a revoked key error = %v, want %v", err, ErrApiKeyNotFound)
+	}
+	if err := svc.Revoke(t.Context(), 99); !errors.Is(err, ErrApiKeyNotFound) {
+		t.Errorf("ApiKeyService.Revoke() of an unknown key error = %v, want %v", err, ErrApiKeyNotFound)
+	}
+}
+
+// memoryRepo is an in-memory ApiKeyRepo. err, when set, fails every call.
+type memoryRepo struct {
+	rows map[int]ApiKeyRow
+	err  error
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{rows: map[int]ApiKeyRow{}}
+}
+
+func (r *memoryRepo) Create(ctx context.Context, params ApiKeyCreateParams) (ApiKeyRow, error) {
+	if r.err != nil {
+		return ApiKeyRow{}, r.err
+	}
+	now := time.Now()
+	row := ApiKeyRow{
+		ApiKeyId:   len(r.rows) + 1,
+		Name:       params.Name,
+		KeyPrefix:  params.KeyPrefix,
+		KeyHash:    params.KeyHash,
+		Scopes:     params.Scopes,
+		AccountIds: params.AccountIds,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	r.rows[row.ApiKeyId] = row
+	return row, nil
+}
+
+func (r *memoryRepo) ById(ctx context.Context, apiKeyId int) (ApiKeyRow, error) {
+	if r.err != nil {
+		return ApiKeyRow{}, r.err
+	}
+	row, ok := r.rows[apiKeyId]
+	if !ok {
+		return ApiKeyRow{}, errors.New("api key not found")
+	}
+	return row, nil
+}
+
+func (r *memoryRepo) ByHash(ctx context.Context, keyHash string) (ApiKeyRow, bool, error) {
+	if r.err != nil {
+		return ApiKeyRow{}, false, r.err
+	}
+	for _, row := range r.rows {
+		if row.KeyHash == keyHash {
+			return row, true, nil
+		}
+	}
+	return ApiKeyRow{}, false, nil
+}
+
+func (r *memoryRepo) List(ctx context.Context) ([]ApiKeyRow, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	rows := make([]ApiKeyRow, 0, len(r.rows))
+	for id := 1; id <= len(r.rows); id++ {
+		rows = append(rows, r.rows[id])
+	}
+	return rows, nil
+}
+
+func (r *memoryRepo) UpdateKey(ctx context.Context, params ApiKeyUpdateKeyParams) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	row, ok := r.rows[params.ApiKeyId]
+	if !ok || row.RevokedAt != nil {
+		return false, nil
+	}
+	row.KeyPrefix, row.KeyHash = params.KeyPrefix, params.KeyHash
+	r.rows[params.ApiKeyId] = row
+	return true, nil
+}
+
+func (r *memoryRepo) Revoke(ctx context.Context, apiKeyId int) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	row, ok := r.rows[apiKeyId]
+	if !ok || row.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	row.RevokedAt = &now
+	r.rows[apiKeyId] = row
+	return true, nil
+}
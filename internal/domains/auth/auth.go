@@ -0,0 +1,73 @@
+// Package auth describes the caller of a request: who it is, the scopes it was granted and the
+// accounts it may act on. The HTTP server authenticates the caller and carries it in the request
+// context, where the services check it.
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Scopes a caller can be granted. Account reads cover the accounts themselves and account writes
+// opening them; transaction reads and writes cover transfers, holds and schedules. Admin covers
+// the controls on accounts, their status, closing and overdraft limits, and the settings of the
+// system: transfer limits, fee schedules and webhooks.
+const (
+	ScopeAccountsRead      = "accounts:read"
+	ScopeAccountsWrite     = "accounts:write"
+	ScopeTransactionsRead  = "transactions:read"
+	ScopeTransactionsWrite = "transactions:write"
+	ScopeAdmin             = "admin"
+)
+
+// Scopes lists every scope.
+var Scopes = []string{ScopeAccountsRead, ScopeAccountsWrite, ScopeTransactionsRead, ScopeTransactionsWrite, ScopeAdmin}
+
+// Principal is an authenticated caller. Subject identifies it, such as api_key:12 or the sub of a
+// JWT. AccountIds restricts the accounts it may debit or change to the listed ones; nil allows
+// every account.
+type Principal struct {
+	Subject    string
+	Scopes     []string
+	AccountIds []int
+}
+
+// HasScope reports whether p was granted scope.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// CanActOn reports whether p may debit or change accountId.
+func (p Principal) CanActOn(accountId int) bool {
+	return p.AccountIds == nil || slices.Contains(p.AccountIds, accountId)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal carried by ctx, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// CanActOn reports whether the caller of ctx may debit or change accountId. Contexts without a
+// principal, such as those of the background workers or of a server without authentication,
+// may act on every account.
+func CanActOn(ctx context.Context, accountId int) bool {
+	p, ok := PrincipalFrom(ctx)
+	return !ok || p.CanActOn(accountId)
+}
+
+// SubjectFrom returns the subject of the caller of ctx, or nil when ctx carries no principal.
+func SubjectFrom(ctx context.Context) *string {
+	p, ok := PrincipalFrom(ctx)
+	if !ok {
+		return nil
+	}
+	return &p.Subject
+}
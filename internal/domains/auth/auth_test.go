@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCanActOn(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		accountId int
+		want      bool
+	}{
+		{name: "success - no principal", ctx: context.Background(), accountId: 1, want: true},
+		{name: "success - unrestricted principal", ctx: WithPrincipal(context.Background(), Principal{Subject: "api_key:1"}), accountId: 1, want: true},
+		{name: "success - owned account", ctx: WithPrincipal(context.Background(), Principal{Subject: "api_key:1", AccountIds: []int{1, 2}}), accountId: 2, want: true},
+		{name: "error - account not owned", ctx: WithPrincipal(context.Background(), Principal{Subject: "api_key:1", AccountIds: []int{1, 2}}), accountId: 3},
+		{name: "error - restricted to no account", ctx: WithPrincipal(context.Background(), Principal{Subject: "api_key:1", AccountIds: []int{}}), accountId: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanActOn(tt.ctx, tt.accountId); got != tt.want {
+				t.Errorf("CanActOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
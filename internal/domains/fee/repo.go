@@ -0,0 +1,34 @@
+package fee
+
+import (
+	"context"
+	"time"
+)
+
+// FeeRepo defines the interface for fee schedule persistence. A schedule is identified by the
+// account tier and the currency it applies to.
+type FeeRepo interface {
+	Upsert(ctx context.Context, params ScheduleUpsertParams) (ScheduleRow, error)
+	ByTier(ctx context.Context, tier, currency string) ([]ScheduleRow, error)
+	List(ctx context.Context) ([]ScheduleRow, error)
+	Delete(ctx context.Context, tier, currency string) (bool, error)
+}
+
+// ScheduleUpsertParams holds the parameters required to create or replace a fee schedule.
+// Rules is the JSON encoding of the Rules of the schedule.
+type ScheduleUpsertParams struct {
+	Tier     string
+	Currency string
+	Kind     string
+	Rules    []byte
+}
+
+// ScheduleRow represents a row in the fee_schedules table.
+type ScheduleRow struct {
+	Tier      string    `db:"tier"`
+	Currency  string    `db:"currency"`
+	Kind      string    `db:"kind"`
+	Rules     []byte    `db:"rules"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
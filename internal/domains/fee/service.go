@@ -0,0 +1,348 @@
+// Package fee provides business logic for transfer fees: the fee schedules configured per
+// account tier and currency, and the fee they charge on a transfer.
+package fee
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+)
+
+// FeeService manages fee schedules and computes the fee charged on transfers.
+type FeeService struct {
+	repo FeeRepo
+}
+
+// Fee schedule kinds. Flat charges the same fee on every transfer. Percentage charges a rate of
+// the amount, raised to Min and capped at Max when they are set. Tiered picks the first band
+// whose UpTo the amount does not exceed and charges its flat fee plus its rate of the amount.
+const (
+	KindFlat       = "flat"
+	KindPercentage = "percentage"
+	KindTiered     = "tiered"
+)
+
+// bpsDenominator is the number of basis points in a whole; rates are in basis points.
+const bpsDenominator = 10_000
+
+var (
+	ErrFeeScheduleUpsertFailed = errors.New("fee schedule upsert fail")
+	ErrFeeScheduleListFailed   = errors.New("fee schedule list fail")
+	ErrFeeScheduleDeleteFailed = errors.New("fee schedule delete fail")
+	ErrFeeScheduleNotFound     = errors.New("fee schedule not found")
+	ErrFeeScheduleInvalid      = errors.New("fee schedule invalid")
+	ErrFeeKindInvalid          = errors.New("fee kind invalid")
+	ErrFeeTierInvalid          = errors.New("fee tier invalid")
+	ErrFeeCurrencyInvalid      = errors.New("fee currency invalid")
+	ErrFeeQuoteFailed          = errors.New("fee quote fail")
+)
+
+// Rules are the parameters of a fee schedule. Which of them are used depends on its kind; rates
+// are in basis points, 150 being 1.5%. Percentages and tiered rates are rounded half up to the
+// scale of the currency.
+type Rules struct {
+	Flat    *money.Amount `json:"flat,omitempty"`     // Flat fee of a flat schedule.
+	RateBps int           `json:"rate_bps,omitempty"` // Rate of a percentage schedule.
+	Min     *money.Amount `json:"min,omitempty"`      // Lowest fee of a percentage schedule.
+	Max     *money.Amount `json:"max,omitempty"`      // Highest fee of a percentage schedule.
+	Bands   []Band        `json:"bands,omitempty"`    // Bands of a tiered schedule, by ascending UpTo.
+}
+
+// Band is one band of a tiered fee schedule. UpTo is the largest amount the band applies to and
+// is left out on the last band, which covers every larger amount.
+type Band struct {
+	UpTo    *money.Amount `json:"up_to,omitempty"`
+	Flat    *money.Amount `json:"flat,omitempty"`
+	RateBps int           `json:"rate_bps,omitempty"`
+}
+
+// ScheduleSet represents a request to create or replace the fee schedule of a tier and currency.
+type ScheduleSet struct {
+	Kind string `json:"kind"`
+	Rules
+}
+
+// Schedule is the fee schedule charged on transfers out of the accounts of Tier in Currency.
+type Schedule struct {
+	Tier     string `json:"tier"`
+	Currency string `json:"currency"`
+	Kind     string `json:"kind"`
+	Rules
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Quote is a transfer of Amount, in Currency, about to be sent by an account of Tier.
+type Quote struct {
+	Tier     string
+	Currency string
+	Amount   money.Amount
+}
+
+// NewFeeService creates a new FeeService with the given repository.
+func NewFeeService(repo FeeRepo) *FeeService {
+	return &FeeService{repo}
+}
+
+// Set validates and stores the fee schedule of tier and currency, replacing the one it had.
+// Amounts are stored in the scale of the currency.
+func (svc *FeeService) Set(ctx context.Context, tier, currency string, data ScheduleSet) (Schedule, error) {
+	if !account.IsValidTier(tier) {
+		slog.WarnContext(ctx, ErrFeeTierInvalid.Error(), "tier", tier)
+		return Schedule{}, ErrFeeTierInvalid
+	}
+
+	c, err := money.LookupCurrency(currency)
+	if err != nil {
+		slog.WarnContext(ctx, ErrFeeCurrencyInvalid.Error(), "error", err)
+		return Schedule{}, ErrFeeCurrencyInvalid
+	}
+
+	rules, err := normalize(data.Kind, data.Rules, c.Scale)
+	if errors.Is(err, ErrFeeKindInvalid) {
+		slog.WarnContext(ctx, ErrFeeKindInvalid.Error(), "error", err)
+		return Schedule{}, ErrFeeKindInvalid
+	}
+	if err != nil {
+		slog.WarnContext(ctx, ErrFeeScheduleInvalid.Error(), "error", err)
+		return Schedule{}, ErrFeeScheduleInvalid
+	}
+
+	b, err := json.Marshal(rules)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrFeeScheduleUpsertFailed.Error(), "error", err)
+		return Schedule{}, ErrFeeScheduleUpsertFailed
+	}
+
+	row, err := svc.repo.Upsert(ctx, ScheduleUpsertParams{Tier: tier, Currency: c.Code, Kind: data.Kind, Rules: b})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrFeeScheduleUpsertFailed.Error(), "error", err)
+		return Schedule{}, ErrFeeScheduleUpsertFailed
+	}
+
+	schedule, err := toSchedule(row)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrFeeScheduleUpsertFailed.Error(), "error", err)
+		return Schedule{}, ErrFeeScheduleUpsertFailed
+	}
+
+	return schedule, nil
+}
+
+// List returns every fee schedule ordered by tier and currency.
+func (svc *FeeService) List(ctx context.Context) ([]Schedule, error) {
+	rows, err := svc.repo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrFeeScheduleListFailed.Error(), "error", err)
+		return nil, ErrFeeScheduleListFailed
+	}
+
+	data := make([]Schedule, len(rows))
+	for i, row := range rows {
+		if data[i], err = toSchedule(row); err != nil {
+			slog.ErrorContext(ctx, ErrFeeScheduleListFailed.Error(), "error", err)
+			return nil, ErrFeeScheduleListFailed
+		}
+	}
+
+	return data, nil
+}
+
+// Delete removes the fee schedule of tier and currency; their transfers are free from then on.
+func (svc *FeeService) Delete(ctx context.Context, tier, currency string) error {
+	deleted, err := svc.repo.Delete(ctx, tier, currency)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrFeeScheduleDeleteFailed.Error(), "error", err)
+		return ErrFeeScheduleDeleteFailed
+	}
+	if !deleted {
+		slog.WarnContext(ctx, ErrFeeScheduleNotFound.Error(), "tier", tier, "currency", currency)
+		return ErrFeeScheduleNotFound
+	}
+
+	return nil
+}
+
+// Fee returns the fee charged on top of the transfer of quote, in the scale of its amount.
+// Transfers of tiers and currencies without a schedule, and transfers of zero, are free.
+func (svc *FeeService) Fee(ctx context.Context, quote Quote) (money.Amount, error) {
+	free := money.New(0, quote.Amount.Scale())
+	if quote.Amount.IsZero() {
+		return free, nil
+	}
+
+	rows, err := svc.repo.ByTier(ctx, quote.Tier, quote.Currency)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrFeeQuoteFailed.Error(), "error", err)
+		return money.Amount{}, ErrFeeQuoteFailed
+	}
+	if len(rows) == 0 {
+		return free, nil
+	}
+
+	schedule, err := toSchedule(rows[0])
+	if err != nil {
+		slog.ErrorContext(ctx, ErrFeeQuoteFailed.Error(), "error", err)
+		return money.Amount{}, ErrFeeQuoteFailed
+	}
+
+	fee, err := schedule.fee(quote.Amount)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrFeeQuoteFailed.Error(), "error", err)
+		return money.Amount{}, ErrFeeQuoteFailed
+	}
+
+	return fee.Rescale(quote.Amount.Scale())
+}
+
+// fee returns the fee schedule s charges on amount.
+func (s Schedule) fee(amount money.Amount) (money.Amount, error) {
+	switch s.Kind {
+	case KindFlat:
+		return *s.Flat, nil
+
+	case KindPercentage:
+		fee, err := applyRate(amount, s.RateBps)
+		if err != nil {
+			return money.Amount{}, err
+		}
+		if s.Min != nil && fee.Cmp(*s.Min) < 0 {
+			fee = *s.Min
+		}
+		if s.Max != nil && fee.Cmp(*s.Max) > 0 {
+			fee = *s.Max
+		}
+		return fee, nil
+
+	case KindTiered:
+		for _, band := range s.Bands {
+			if band.UpTo != nil && amount.Cmp(*band.UpTo) > 0 {
+				continue
+			}
+			fee, err := applyRate(amount, band.RateBps)
+			if err != nil {
+				return money.Amount{}, err
+			}
+			if band.Flat != nil {
+				return fee.Add(*band.Flat)
+			}
+			return fee, nil
+		}
+	}
+
+	return money.Amount{}, fmt.Errorf("fee schedule %s %s of kind %q covers no amount %s", s.Tier, s.Currency, s.Kind, amount)
+}
+
+// normalize validates rules for a schedule of kind and returns them with every amount in scale.
+func normalize(kind string, rules Rules, scale int) (Rules, error) {
+	var err error
+	switch kind {
+	case KindFlat:
+		if rules.RateBps != 0 || rules.Min != nil || rules.Max != nil || len(rules.Bands) > 0 {
+			return Rules{}, fmt.Errorf("%w: flat schedules only set flat", ErrFeeScheduleInvalid)
+		}
+		if rules.Flat, err = rescaleFee(rules.Flat, true, scale); err != nil {
+			return Rules{}, err
+		}
+
+	case KindPercentage:
+		if rules.Flat != nil || len(rules.Bands) > 0 {
+			return Rules{}, fmt.Errorf("%w: percentage schedules set rate_bps, min and max only", ErrFeeScheduleInvalid)
+		}
+		if rules.RateBps <= 0 || rules.RateBps > bpsDenominator {
+			return Rules{}, fmt.Errorf("%w: rate_bps %d is not between 1 and %d", ErrFeeScheduleInvalid, rules.RateBps, bpsDenominator)
+		}
+		if rules.Min, err = rescaleFee(rules.Min, false, scale); err != nil {
+			return Rules{}, err
+		}
+		if rules.Max, err = rescaleFee(rules.Max, false, scale); err != nil {
+			return Rules{}, err
+		}
+		if rules.Min != nil && rules.Max != nil && rules.Min.Cmp(*rules.Max) > 0 {
+			return Rules{}, fmt.Errorf("%w: min %s is above max %s", ErrFeeScheduleInvalid, rules.Min, rules.Max)
+		}
+
+	case KindTiered:
+		if rules.Flat != nil || rules.RateBps != 0 || rules.Min != nil || rules.Max != nil || len(rules.Bands) == 0 {
+			return Rules{}, fmt.Errorf("%w: tiered schedules only set bands", ErrFeeScheduleInvalid)
+		}
+		bands := make([]Band, len(rules.Bands))
+		for i, band := range rules.Bands {
+			last := i == len(rules.Bands)-1
+			if (band.UpTo == nil) != last {
+				return Rules{}, fmt.Errorf("%w: every band but the last sets up_to", ErrFeeScheduleInvalid)
+			}
+			if band.RateBps < 0 || band.RateBps > bpsDenominator {
+				return Rules{}, fmt.Errorf("%w: rate_bps %d is not between 0 and %d", ErrFeeScheduleInvalid, band.RateBps, bpsDenominator)
+			}
+			if band.UpTo, err = rescaleFee(band.UpTo, false, scale); err != nil {
+				return Rules{}, err
+			}
+			if band.Flat, err = rescaleFee(band.Flat, false, scale); err != nil {
+				return Rules{}, err
+			}
+			if i > 0 && band.UpTo != nil && band.UpTo.Cmp(*bands[i-1].UpTo) <= 0 {
+				return Rules{}, fmt.Errorf("%w: up_to %s does not follow %s", ErrFeeScheduleInvalid, band.UpTo, bands[i-1].UpTo)
+			}
+			bands[i] = band
+		}
+		rules.Bands = bands
+
+	default:
+		return Rules{}, fmt.Errorf("%w: %q", ErrFeeKindInvalid, kind)
+	}
+
+	return rules, nil
+}
+
+// rescaleFee returns amount in scale, rejecting negative amounts and, when required, a missing one.
+func rescaleFee(amount *money.Amount, required bool, scale int) (*money.Amount, error) {
+	if amount == nil {
+		if required {
+			return nil, fmt.Errorf("%w: amount missing", ErrFeeScheduleInvalid)
+		}
+		return nil, nil
+	}
+
+	if amount.Sign() < 0 {
+		return nil, fmt.Errorf("%w: amount %s is negative", ErrFeeScheduleInvalid, amount)
+	}
+
+	rescaled, err := amount.Rescale(scale)
+	if err != nil {
+		return nil, err
+	}
+	return &rescaled, nil
+}
+
+// applyRate returns rateBps basis points of amount, rounded half up in the scale of amount.
+func applyRate(amount money.Amount, rateBps int) (money.Amount, error) {
+	v := new(big.Int).Mul(big.NewInt(amount.Value()), big.NewInt(int64(rateBps)))
+	v.Add(v, big.NewInt(bpsDenominator/2))
+	v.Quo(v, big.NewInt(bpsDenominator))
+	if !v.IsInt64() {
+		return money.Amount{}, money.ErrMoneyOverflow
+	}
+	return money.New(v.Int64(), amount.Scale()), nil
+}
+
+func toSchedule(row ScheduleRow) (Schedule, error) {
+	schedule := Schedule{
+		Tier:      row.Tier,
+		Currency:  row.Currency,
+		Kind:      row.Kind,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if err := json.Unmarshal(row.Rules, &schedule.Rules); err != nil {
+		return Schedule{}, err
+	}
+	return schedule, nil
+}
@@ -0,0 +1,299 @@
+package fee
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+)
+
+func TestFeeService_Set(t *testing.T) {
+	amount := func(val string) *money.Amount {
+		a, err := money.Parse(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &a
+	}
+
+	tests := []struct {
+		name      string
+		tier      string
+		currency  string
+		data      ScheduleSet
+		wantRules string
+		wantErr   error
+	}{
+		{
+			name:      "success - flat rescaled to currency",
+			tier:      "standard",
+			currency:  "USD",
+			data:      ScheduleSet{Kind: KindFlat, Rules: Rules{Flat: amount("0.5")}},
+			wantRules: `{"flat":"0.50"}`,
+		},
+		{
+			name:      "success - percentage with min and max",
+			tier:      "standard",
+			currency:  "USD",
+			data:      ScheduleSet{Kind: KindPercentage, Rules: Rules{RateBps: 150, Min: amount("1"), Max: amount("25")}},
+			wantRules: `{"rate_bps":150,"min":"1.00","max":"25.00"}`,
+		},
+		{
+			name:      "success - tiered",
+			tier:      "premium",
+			currency:  "JPY",
+			data:      ScheduleSet{Kind: KindTiered, Rules: Rules{Bands: []Band{{UpTo: amount("10000"), Flat: amount("100")}, {RateBps: 50}}}},
+			wantRules: `{"bands":[{"up_to":"10000","flat":"100"},{"rate_bps":50}]}`,
+		},
+		{
+			name:     "error - kind invalid",
+			tier:     "standard",
+			currency: "USD",
+			data:     ScheduleSet{Kind: "free"},
+			wantErr:  ErrFeeKindInvalid,
+		},
+		{
+			name:     "error - tier invalid",
+			tier:     "Premium Plus",
+			currency: "USD",
+			data:     ScheduleSet{Kind: KindFlat, Rules: Rules{Flat: amount("1")}},
+			wantErr:  ErrFeeTierInvalid,
+		},
+		{
+			name:     "error - currency invalid",
+			tier:     "standard",
+			currency: "XXX",
+			data:     ScheduleSet{Kind: KindFlat, Rules: Rules{Flat: amount("1")}},
+			wantErr:  ErrFeeCurrencyInvalid,
+		},
+		{
+			name:     "error - flat missing",
+			tier:     "standard",
+			currency: "USD",
+			data:     ScheduleSet{Kind: KindFlat},
+			wantErr:  ErrFeeScheduleInvalid,
+		},
+		{
+			name:     "error - flat too precise",
+			tier:     "standard",
+			currency: "JPY",
+			data:     ScheduleSet{Kind: KindFlat, Rules: Rules{Flat: amount("0.5")}},
+			wantErr:  ErrFeeScheduleInvalid,
+		},
+		{
+			name:     "error - flat with rate",
+			tier:     "standard",
+			currency: "USD",
+			data:     ScheduleSet{Kind: KindFlat, Rules: Rules{Flat: amount("1"), RateBps: 10}},
+			wantErr:  ErrFeeScheduleInvalid,
+		},
+		{
+			name:     "error - percentage rate above whole",
+			tier:     "standard",
+			currency: "USD",
+			data:     ScheduleSet{Kind: KindPercentage, Rules: Rules{RateBps: 10_001}},
+			wantErr:  ErrFeeScheduleInvalid,
+		},
+		{
+			name:     "error - percentage min above max",
+			tier:     "standard",
+			currency: "USD",
+			data:     ScheduleSet{Kind: KindPercentage, Rules: Rules{RateBps: 100, Min: amount("5"), Max: amount("1")}},
+			wantErr:  ErrFeeScheduleInvalid,
+		},
+		{
+			name:     "error - percentage min negative",
+			tier:     "standard",
+			currency: "USD",
+			data:     ScheduleSet{Kind: KindPercentage, Rules: Rules{RateBps: 100, Min: amount("-1")}},
+			wantErr:  ErrFeeScheduleInvalid,
+		},
+		{
+			name:     "error - tiered without bands",
+			tier:     "standard",
+			currency: "USD",
+			data:     ScheduleSet{Kind: KindTiered},
+			wantErr:  ErrFeeScheduleInvalid,
+		},
+		{
+			name:     "error - tiered last band bounded",
+			tier:     "standard",
+			currency: "USD",
+			data:     ScheduleSet{Kind: KindTiered, Rules: Rules{Bands: []Band{{UpTo: amount("100"), RateBps: 10}}}},
+			wantErr:  ErrFeeScheduleInvalid,
+		},
+		{
+			name:     "error - tiered bands not ascending",
+			tier:     "standard",
+			currency: "USD",
+			data:     ScheduleSet{Kind: KindTiered, Rules: Rules{Bands: []Band{{UpTo: amount("100")}, {UpTo: amount("100")}, {RateBps: 10}}}},
+			wantErr:  ErrFeeScheduleInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRules string
+			repo := &fakeFeeRepo{
+				UpsertFunc: func(ctx context.Context, params ScheduleUpsertParams) (ScheduleRow, error) {
+					gotRules = string(params.Rules)
+					return ScheduleRow{Tier: params.Tier, Currency: params.Currency, Kind: params.Kind, Rules: params.Rules}, nil
+				},
+			}
+
+			svc := NewFeeService(repo)
+			got, err := svc.Set(t.Context(), tt.tier, tt.currency, tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("FeeService.Set() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if gotRules != tt.wantRules {
+				t.Errorf("FeeService.Set() rules = %s, want %s", gotRules, tt.wantRules)
+			}
+			if got.Tier != tt.tier || got.Currency != tt.currency || got.Kind != tt.data.Kind {
+				t.Errorf("FeeService.Set() = %+v, want %s %s %s", got, tt.tier, tt.currency, tt.data.Kind)
+			}
+		})
+	}
+}
+
+func TestFeeService_Delete(t *testing.T) {
+	repo := &fakeFeeRepo{
+		DeleteFunc: func(ctx context.Context, tier, currency string) (bool, error) { return tier == "standard", nil },
+	}
+
+	svc := NewFeeService(repo)
+	if err := svc.Delete(t.Context(), "standard", "USD"); err != nil {
+		t.Errorf("FeeService.Delete() error = %v", err)
+	}
+	if err := svc.Delete(t.Context(), "premium", "USD"); !errors.Is(err, ErrFeeScheduleNotFound) {
+		t.Errorf("FeeService.Delete() error = %v, want %v", err, ErrFeeScheduleNotFound)
+	}
+}
+
+func TestFeeService_Fee(t *testing.T) {
+	schedule := func(kind, rules string) []ScheduleRow {
+		return []ScheduleRow{{Tier: "standard", Currency: "USD", Kind: kind, Rules: []byte(rules)}}
+	}
+	tiered := schedule(KindTiered, `{"bands":[{"up_to":"100.00","flat":"1.00"},{"up_to":"1000.00","flat":"2.00","rate_bps":10},{"rate_bps":20}]}`)
+
+	tests := []struct {
+		name      string
+		schedules []ScheduleRow
+		amount    money.Amount
+		want      money.Amount
+		wantErr   error
+	}{
+		{
+			name:   "success - no schedule",
+			amount: money.New(10_000, 2),
+			want:   money.New(0, 2),
+		},
+		{
+			name:      "success - zero amount",
+			schedules: schedule(KindFlat, `{"flat":"1.00"}`),
+			amount:    money.New(0, 2),
+			want:      money.New(0, 2),
+		},
+		{
+			name:      "success - flat",
+			schedules: schedule(KindFlat, `{"flat":"0.50"}`),
+			amount:    money.New(10_000, 2),
+			want:      money.New(50, 2),
+		},
+		{
+			name:      "success - percentage rounds half up",
+			schedules: schedule(KindPercentage, `{"rate_bps":150}`),
+			amount:    money.New(1_034, 2),
+			want:      money.New(16, 2),
+		},
+		{
+			name:      "success - percentage raised to min",
+			schedules: schedule(KindPercentage, `{"rate_bps":150,"min":"1.00","max":"25.00"}`),
+			amount:    money.New(1_000, 2),
+			want:      money.New(100, 2),
+		},
+		{
+			name:      "success - percentage capped at max",
+			schedules: schedule(KindPercentage, `{"rate_bps":150,"min":"1.00","max":"25.00"}`),
+			amount:    money.New(1_000_000, 2),
+			want:      money.New(2_500, 2),
+		},
+		{
+			name:      "success - tiered first band includes bound",
+			schedules: tiered,
+			amount:    money.New(10_000, 2),
+			want:      money.New(100, 2),
+		},
+		{
+			name:      "success - tiered middle band",
+			schedules: tiered,
+			amount:    money.New(50_000, 2),
+			want:      money.New(250, 2),
+		},
+		{
+			name:      "success - tiered last band",
+			schedules: tiered,
+			amount:    money.New(500_000, 2),
+			want:      money.New(1_000, 2),
+		},
+		{
+			name:      "success - in the scale of the amount",
+			schedules: schedule(KindFlat, `{"flat":"0.50"}`),
+			amount:    money.New(10_000, 4),
+			want:      money.New(5_000, 4),
+		},
+		{
+			name:      "error - stored rules unreadable",
+			schedules: schedule(KindFlat, `{`),
+			amount:    money.New(10_000, 2),
+			wantErr:   ErrFeeQuoteFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeFeeRepo{
+				ByTierFunc: func(ctx context.Context, tier, currency string) ([]ScheduleRow, error) {
+					if tier != "standard" || currency != "USD" {
+						t.Errorf("FeeRepo.ByTier() got %s %s, want standard USD", tier, currency)
+					}
+					return tt.schedules, nil
+				},
+			}
+
+			svc := NewFeeService(repo)
+			got, err := svc.Fee(t.Context(), Quote{Tier: "standard", Currency: "USD", Amount: tt.amount})
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("FeeService.Fee() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("FeeService.Fee() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeFeeRepo struct {
+	UpsertFunc func(ctx context.Context, params ScheduleUpsertParams) (ScheduleRow, error)
+	ByTierFunc func(ctx context.Context, tier, currency string) ([]ScheduleRow, error)
+	ListFunc   func(ctx context.Context) ([]ScheduleRow, error)
+	DeleteFunc func(ctx context.Context, tier, currency string) (bool, error)
+}
+
+func (f *fakeFeeRepo) Upsert(ctx context.Context, params ScheduleUpsertParams) (ScheduleRow, error) {
+	return f.UpsertFunc(ctx, params)
+}
+
+func (f *fakeFeeRepo) ByTier(ctx context.Context, tier, currency string) ([]ScheduleRow, error) {
+	return f.ByTierFunc(ctx, tier, currency)
+}
+
+func (f *fakeFeeRepo) List(ctx context.Context) ([]ScheduleRow, error) {
+	return f.ListFunc(ctx)
+}
+
+func (f *fakeFeeRepo) Delete(ctx context.Context, tier, currency string) (bool, error) {
+	return f.DeleteFunc(ctx, tier, currency)
+}
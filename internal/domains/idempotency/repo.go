@@ -0,0 +1,42 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRepo defines the interface for idempotency key persistence.
+type IdempotencyRepo interface {
+	Create(ctx context.Context, params KeyCreateParams) (bool, error)
+	ByKey(ctx context.Context, key, scope string) (KeyRow, error)
+	Complete(ctx context.Context, params KeyCompleteParams) error
+	Delete(ctx context.Context, key, scope string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+// KeyCreateParams holds the parameters required to reserve an idempotency key.
+type KeyCreateParams struct {
+	Key         string
+	Scope       string
+	Fingerprint string
+	ExpiresAt   time.Time
+}
+
+// KeyRow represents a row in the idempotency_keys table. StatusCode and ResponseBody
+// are only set once the request holding the key has completed.
+type KeyRow struct {
+	Key          string    `db:"idempotency_key"`
+	Scope        string    `db:"scope"`
+	Fingerprint  string    `db:"fingerprint"`
+	StatusCode   *int      `db:"status_code"`
+	ResponseBody []byte    `db:"response_body"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// KeyCompleteParams holds the response stored against a completed idempotency key.
+type KeyCompleteParams struct {
+	Key          string
+	Scope        string
+	StatusCode   int
+	ResponseBody []byte
+}
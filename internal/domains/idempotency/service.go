@@ -0,0 +1,122 @@
+// Package idempotency provides business logic for Idempotency-Key handling, so that
+// retried requests replay the original result instead of being executed twice.
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// IdempotencyService reserves idempotency keys and stores the responses produced for them.
+type IdempotencyService struct {
+	repo IdempotencyRepo
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// Response is the stored result of a request executed under an idempotency key.
+type Response struct {
+	StatusCode int
+	Body       []byte
+}
+
+var (
+	ErrIdempotencyFailed        = errors.New("idempotency fail")
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key reused with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("idempotency key request in progress")
+)
+
+// NewIdempotencyService creates a new IdempotencyService whose keys expire after ttl.
+func NewIdempotencyService(repo IdempotencyRepo, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo, ttl, time.Now}
+}
+
+// Begin reserves key within scope for a request identified by fingerprint.
+// It returns a nil Response when the caller should execute the request, or the stored
+// Response when the key already completed for the same fingerprint. Expired keys are
+// treated as unused.
+func (svc *IdempotencyService) Begin(ctx context.Context, key, scope, fingerprint string) (*Response, error) {
+	for range 2 {
+		created, err := svc.repo.Create(ctx, KeyCreateParams{
+			Key:         key,
+			Scope:       scope,
+			Fingerprint: fingerprint,
+			ExpiresAt:   svc.now().Add(svc.ttl),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, ErrIdempotencyFailed.Error(), "error", err)
+			return nil, ErrIdempotencyFailed
+		}
+		if created {
+			return nil, nil
+		}
+
+		row, err := svc.repo.ByKey(ctx, key, scope)
+		if err != nil {
+			slog.ErrorContext(ctx, ErrIdempotencyFailed.Error(), "error", err)
+			return nil, ErrIdempotencyFailed
+		}
+
+		if !row.ExpiresAt.After(svc.now()) {
+			if err := svc.repo.Delete(ctx, key, scope); err != nil {
+				slog.ErrorContext(ctx, ErrIdempotencyFailed.Error(), "error", err)
+				return nil, ErrIdempotencyFailed
+			}
+			continue
+		}
+
+		if row.Fingerprint != fingerprint {
+			slog.WarnContext(ctx, ErrIdempotencyKeyMismatch.Error(), "key", key)
+			return nil, ErrIdempotencyKeyMismatch
+		}
+
+		if row.StatusCode == nil {
+			slog.WarnContext(ctx, ErrIdempotencyKeyInProgress.Error(), "key", key)
+			return nil, ErrIdempotencyKeyInProgress
+		}
+
+		return &Response{StatusCode: *row.StatusCode, Body: row.ResponseBody}, nil
+	}
+
+	slog.ErrorContext(ctx, ErrIdempotencyFailed.Error(), "reason", "key expired concurrently", "key", key)
+	return nil, ErrIdempotencyKeyInProgress
+}
+
+// Complete stores the response produced for key so later requests replay it.
+func (svc *IdempotencyService) Complete(ctx context.Context, key, scope string, resp Response) error {
+	err := svc.repo.Complete(ctx, KeyCompleteParams{
+		Key:          key,
+		Scope:        scope,
+		StatusCode:   resp.StatusCode,
+		ResponseBody: resp.Body,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrIdempotencyFailed.Error(), "error", err)
+		return ErrIdempotencyFailed
+	}
+	return nil
+}
+
+// Release frees key without storing a response, allowing the request to be retried.
+func (svc *IdempotencyService) Release(ctx context.Context, key, scope string) error {
+	if err := svc.repo.Delete(ctx, key, scope); err != nil {
+		slog.ErrorContext(ctx, ErrIdempotencyFailed.Error(), "error", err)
+		return ErrIdempotencyFailed
+	}
+	return nil
+}
+
+// Purge deletes every expired key.
+func (svc *IdempotencyService) Purge(ctx context.Context) error {
+	n, err := svc.repo.DeleteExpired(ctx, svc.now())
+	if err != nil {
+		slog.ErrorContext(ctx, ErrIdempotencyFailed.Error(), "error", err)
+		return ErrIdempotencyFailed
+	}
+	if n > 0 {
+		slog.InfoContext(ctx, "idempotency: purged expired keys", "purged", n)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyService_Begin(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	statusOK := 200
+
+	type fields struct {
+		repo IdempotencyRepo
+	}
+	type args struct {
+		ctx         context.Context
+		key         string
+		scope       string
+		fingerprint string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *Response
+		wantErr error
+	}{
+		{
+			name: "error - db fail",
+			fields: fields{repo: &fakeIdempotencyRepo{
+				CreateFunc: func(ctx context.Context, params KeyCreateParams) (bool, error) {
+					return false, fmt.Errorf("test-error")
+				},
+			}},
+			args:    args{ctx: t.Context(), key: "k", scope: "POST /transactions", fingerprint: "f"},
+			wantErr: ErrIdempotencyFailed,
+		},
+		{
+			name: "error - fingerprint mismatch",
+			fields: fields{repo: &fakeIdempotencyRepo{
+				CreateFunc: func(ctx context.Context, params KeyCreateParams) (bool, error) { return false, nil },
+				ByKeyFunc: func(ctx context.Context, key, scope string) (KeyRow, error) {
+					return KeyRow{Key: key, Scope: scope, Fingerprint: "other", ExpiresAt: now.Add(time.Hour)}, nil
+				},
+			}},
+			args:    args{ctx: t.Context(), key: "k", scope: "POST /transactions", fingerprint: "f"},
+			wantErr: ErrIdempotencyKeyMismatch,
+		},
+		{
+			name: "error - in progress",
+			fields: fields{repo: &fakeIdempotencyRepo{
+				CreateFunc: func(ctx context.Context, params KeyCreateParams) (bool, error) { return false, nil },
+				ByKeyFunc: func(ctx context.Context, key, scope string) (KeyRow, error) {
+					return KeyRow{Key: key, Scope: scope, Fingerprint: "f", ExpiresAt: now.Add(time.Hour)}, nil
+				},
+			}},
+			args:    args{ctx: t.Context(), key: "k", scope: "POST /transactions", fingerprint: "f"},
+			wantErr: ErrIdempotencyKeyInProgress,
+		},
+		{
+			name: "success - new key",
+			fields: fields{repo: &fakeIdempotencyRepo{
+				CreateFunc: func(ctx context.Context, params KeyCreateParams) (bool, error) {
+					if !params.ExpiresAt.Equal(now.Add(time.Hour)) {
+						return false, fmt.Errorf("unexpected expiry %s", params.ExpiresAt)
+					}
+					return true, nil
+				},
+			}},
+			args: args{ctx: t.Context(), key: "k", scope: "POST /transactions", fingerprint: "f"},
+			want: nil,
+		},
+		{
+			name: "success - replay",
+			fields: fields{repo: &fakeIdempotencyRepo{
+				CreateFunc: func(ctx context.Context, params KeyCreateParams) (bool, error) { return false, nil },
+				ByKeyFunc: func(ctx context.Context, key, scope string) (KeyRow, error) {
+					return KeyRow{
+						Key:          key,
+						Scope:        scope,
+						Fingerprint:  "f",
+						StatusCode:   &statusOK,
+						ResponseBody: []byte(`{"message":"transaction created"}`),
+						ExpiresAt:    now.Add(time.Hour),
+					}, nil
+				},
+			}},
+			args: args{ctx: t.Context(), key: "k", scope: "POST /transactions", fingerprint: "f"},
+			want: &Response{StatusCode: 200, Body: []byte(`{"message":"transaction created"}`)},
+		},
+		{
+			name: "success - expired key is reused",
+			fields: fields{repo: func() IdempotencyRepo {
+				deleted := false
+				return &fakeIdempotencyRepo{
+					CreateFunc: func(ctx context.Context, params KeyCreateParams) (bool, error) { return deleted, nil },
+					ByKeyFunc: func(ctx context.Context, key, scope string) (KeyRow, error) {
+						return KeyRow{Key: key, Scope: scope, Fingerprint: "other", ExpiresAt: now}, nil
+					},
+					DeleteFunc: func(ctx context.Context, key, scope string) error {
+						deleted = true
+						return nil
+					},
+				}
+			}()},
+			args: args{ctx: t.Context(), key: "k", scope: "POST /transactions", fingerprint: "f"},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewIdempotencyService(tt.fields.repo, time.Hour)
+			svc.now = func() time.Time { return now }
+			got, err := svc.Begin(tt.args.ctx, tt.args.key, tt.args.scope, tt.args.fingerprint)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("IdempotencyService.Begin() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IdempotencyService.Begin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdempotencyService_Complete(t *testing.T) {
+	var stored KeyCompleteParams
+	repo := &fakeIdempotencyRepo{
+		CompleteFunc: func(ctx context.Context, params KeyCompleteParams) error {
+			stored = params
+			return nil
+		},
+	}
+
+	svc := NewIdempotencyService(repo, time.Hour)
+	err := svc.Complete(t.Context(), "k", "POST /accounts", Response{StatusCode: 200, Body: []byte("{}")})
+	if err != nil {
+		t.Fatalf("IdempotencyService.Complete() error = %v", err)
+	}
+
+	want := KeyCompleteParams{Key: "k", Scope: "POST /accounts", StatusCode: 200, ResponseBody: []byte("{}")}
+	if !reflect.DeepEqual(stored, want) {
+		t.Errorf("IdempotencyService.Complete() stored = %v, want %v", stored, want)
+	}
+}
+
+type fakeIdempotencyRepo struct {
+	CreateFunc        func(ctx context.Context, params KeyCreateParams) (bool, error)
+	ByKeyFunc         func(ctx context.Context, key, scope string) (KeyRow, error)
+	CompleteFunc      func(ctx context.Context, params KeyCompleteParams) error
+	DeleteFunc        func(ctx context.Context, key, scope string) error
+	DeleteExpiredFunc func(ctx context.Context, now time.Time) (int, error)
+}
+
+func (f *fakeIdempotencyRepo) Create(ctx context.Context, params KeyCreateParams) (bool, error) {
+	return f.CreateFunc(ctx, params)
+}
+
+func (f *fakeIdempotencyRepo) ByKey(ctx context.Context, key, scope string) (KeyRow, error) {
+	return f.ByKeyFunc(ctx, key, scope)
+}
+
+func (f *fakeIdempotencyRepo) Complete(ctx context.Context, params KeyCompleteParams) error {
+	return f.CompleteFunc(ctx, params)
+}
+
+func (f *fakeIdempotencyRepo) Delete(ctx context.Context, key, scope string) error {
+	return f.DeleteFunc(ctx, key, scope)
+}
+
+func (f *fakeIdempotencyRepo) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	return f.DeleteExpiredFunc(ctx, now)
+}
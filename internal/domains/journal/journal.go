@@ -0,0 +1,161 @@
+// Package journal provides the double-entry model used to record every balance change.
+// A journal entry groups postings that debit and credit accounts; the postings of an
+// entry must sum to zero in every currency. Customer account balances are the net of
+// the credits and debits posted to them.
+package journal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+)
+
+// Direction is the side of the ledger a posting is written to.
+type Direction string
+
+const (
+	Debit  Direction = "debit"  // Decreases the balance of a customer account.
+	Credit Direction = "credit" // Increases the balance of a customer account.
+)
+
+// Entry kinds.
+const (
+	KindOpening  = "opening"
+	KindFunding  = "funding"
+	KindTransfer = "transfer"
+	KindReversal = "reversal"
+	KindSweep    = "sweep"
+)
+
+var (
+	ErrJournalEntryInvalid    = errors.New("journal entry invalid")
+	ErrJournalEntryUnbalanced = errors.New("journal entry unbalanced")
+)
+
+// TreasuryAccount is the system account that issues initial balances in currency.
+func TreasuryAccount(currency string) string {
+	return "treasury:" + currency
+}
+
+// ExchangeAccount is the system account bridging cross-currency transfers in currency.
+func ExchangeAccount(currency string) string {
+	return "exchange:" + currency
+}
+
+// FeesAccount is the revenue account collecting the transfer fees charged in currency.
+func FeesAccount(currency string) string {
+	return "fees:" + currency
+}
+
+// Posting debits or credits a single account. AccountId identifies a customer account and
+// SystemAccount a system account such as TreasuryAccount("USD"); exactly one must be set.
+type Posting struct {
+	AccountId     int
+	SystemAccount string
+	Direction     Direction
+	Amount        money.Amount
+	Currency      string
+}
+
+// Delta returns the signed effect of p on the balance of its account.
+func (p Posting) Delta() (money.Amount, error) {
+	if p.Direction == Debit {
+		return p.Amount.Neg()
+	}
+	return p.Amount, nil
+}
+
+// Entry is a balanced set of postings. TransactionId links the entry to the transaction
+// it records and is zero for entries that are not transfers.
+type Entry struct {
+	Kind          string
+	TransactionId int
+	Postings      []Posting
+}
+
+// Validate checks that e has at least two postings, that every posting targets exactly one
+// account with a positive amount, and that debits equal credits in every currency.
+func (e Entry) Validate() error {
+	if e.Kind == "" || len(e.Postings) < 2 {
+		return fmt.Errorf("%w: kind %q with %d postings", ErrJournalEntryInvalid, e.Kind, len(e.Postings))
+	}
+
+	sums := map[string]money.Amount{}
+	for i, p := range e.Postings {
+		if (p.AccountId == 0) == (p.SystemAccount == "") {
+			return fmt.Errorf("%w: posting %d must target exactly one account", ErrJournalEntryInvalid, i)
+		}
+		if p.Direction != Debit && p.Direction != Credit {
+			return fmt.Errorf("%w: posting %d has direction %q", ErrJournalEntryInvalid, i, p.Direction)
+		}
+		if p.Amount.Sign() <= 0 || p.Currency == "" {
+			return fmt.Errorf("%w: posting %d has amount %s %s", ErrJournalEntryInvalid, i, p.Amount, p.Currency)
+		}
+
+		delta, err := p.Delta()
+		if err != nil {
+			return fmt.Errorf("%w: posting %d: %s", ErrJournalEntryInvalid, i, err)
+		}
+		sum, err := sums[p.Currency].Add(delta)
+		if err != nil {
+			return fmt.Errorf("%w: posting %d: %s", ErrJournalEntryInvalid, i, err)
+		}
+		sums[p.Currency] = sum
+	}
+
+	for currency, sum := range sums {
+		if !sum.IsZero() {
+			return fmt.Errorf("%w: %s postings sum to %s", ErrJournalEntryUnbalanced, currency, sum)
+		}
+	}
+
+	return nil
+}
+
+// Params converts e into the parameters stored by JournalRepo.Create.
+func (e Entry) Params() EntryCreateParams {
+	params := EntryCreateParams{
+		Kind:     e.Kind,
+		Postings: make([]PostingCreateParams, len(e.Postings)),
+	}
+	if e.TransactionId != 0 {
+		params.TransactionId = &e.TransactionId
+	}
+
+	for i, p := range e.Postings {
+		params.Postings[i] = PostingCreateParams{
+			Direction:   string(p.Direction),
+			Amount:      p.Amount.Value(),
+			AmountScale: p.Amount.Scale(),
+			Currency:    p.Currency,
+		}
+		if p.AccountId != 0 {
+			params.Postings[i].AccountId = &p.AccountId
+		} else {
+			params.Postings[i].SystemAccount = &p.SystemAccount
+		}
+	}
+
+	return params
+}
+
+// Balance sums the rows returned by JournalRepo.AccountBalance into a single amount.
+// It fails when the account has postings in more than one currency.
+func Balance(rows []AccountBalanceRow) (money.Amount, string, error) {
+	var total money.Amount
+	var currency string
+	for _, row := range rows {
+		if currency != "" && row.Currency != currency {
+			return money.Amount{}, "", fmt.Errorf("%w: postings in %s and %s", ErrJournalEntryInvalid, currency, row.Currency)
+		}
+		currency = row.Currency
+
+		sum, err := total.Add(money.New(row.Balance, row.AmountScale))
+		if err != nil {
+			return money.Amount{}, "", err
+		}
+		total = sum
+	}
+	return total, currency, nil
+}
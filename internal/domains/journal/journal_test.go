@@ -0,0 +1,94 @@
+package journal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+)
+
+func TestEntry_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   Entry
+		wantErr error
+	}{
+		{
+			name:    "error - single posting",
+			entry:   Entry{Kind: KindTransfer, Postings: []Posting{{AccountId: 1, Direction: Debit, Amount: money.New(1, 2), Currency: "USD"}}},
+			wantErr: ErrJournalEntryInvalid,
+		},
+		{
+			name: "error - posting without account",
+			entry: Entry{Kind: KindTransfer, Postings: []Posting{
+				{Direction: Debit, Amount: money.New(1, 2), Currency: "USD"},
+				{AccountId: 2, Direction: Credit, Amount: money.New(1, 2), Currency: "USD"},
+			}},
+			wantErr: ErrJournalEntryInvalid,
+		},
+		{
+			name: "error - zero amount",
+			entry: Entry{Kind: KindTransfer, Postings: []Posting{
+				{AccountId: 1, Direction: Debit, Amount: money.New(0, 2), Currency: "USD"},
+				{AccountId: 2, Direction: Credit, Amount: money.New(0, 2), Currency: "USD"},
+			}},
+			wantErr: ErrJournalEntryInvalid,
+		},
+		{
+			name: "error - unbalanced",
+			entry: Entry{Kind: KindTransfer, Postings: []Posting{
+				{AccountId: 1, Direction: Debit, Amount: money.New(100, 2), Currency: "USD"},
+				{AccountId: 2, Direction: Credit, Amount: money.New(99, 2), Currency: "USD"},
+			}},
+			wantErr: ErrJournalEntryUnbalanced,
+		},
+		{
+			name: "error - balanced overall but not per currency",
+			entry: Entry{Kind: KindTransfer, Postings: []Posting{
+				{AccountId: 1, Direction: Debit, Amount: money.New(100, 2), Currency: "USD"},
+				{AccountId: 2, Direction: Credit, Amount: money.New(100, 2), Currency: "EUR"},
+			}},
+			wantErr: ErrJournalEntryUnbalanced,
+		},
+		{
+			name: "success - mixed scale",
+			entry: Entry{Kind: KindFunding, Postings: []Posting{
+				{SystemAccount: TreasuryAccount("USD"), Direction: Debit, Amount: money.New(150, 2), Currency: "USD"},
+				{AccountId: 1, Direction: Credit, Amount: money.New(150_000, 5), Currency: "USD"},
+			}},
+		},
+		{
+			name: "success - cross currency through exchange accounts",
+			entry: Entry{Kind: KindTransfer, Postings: []Posting{
+				{AccountId: 1, Direction: Debit, Amount: money.New(100, 2), Currency: "USD"},
+				{SystemAccount: ExchangeAccount("USD"), Direction: Credit, Amount: money.New(100, 2), Currency: "USD"},
+				{SystemAccount: ExchangeAccount("JPY"), Direction: Debit, Amount: money.New(150, 0), Currency: "JPY"},
+				{AccountId: 2, Direction: Credit, Amount: money.New(150, 0), Currency: "JPY"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.entry.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Entry.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBalance(t *testing.T) {
+	got, currency, err := Balance([]AccountBalanceRow{
+		{Currency: "USD", AmountScale: 2, Balance: 150},
+		{Currency: "USD", AmountScale: 5, Balance: -25_000},
+	})
+	if err != nil {
+		t.Fatalf("Balance() error = %v", err)
+	}
+	if got.Cmp(money.New(125, 2)) != 0 || currency != "USD" {
+		t.Errorf("Balance() = %v %v, want 1.25 USD", got, currency)
+	}
+
+	if _, _, err := Balance([]AccountBalanceRow{{Currency: "USD"}, {Currency: "EUR"}}); !errors.Is(err, ErrJournalEntryInvalid) {
+		t.Errorf("Balance() error = %v, want %v", err, ErrJournalEntryInvalid)
+	}
+}
@@ -0,0 +1,36 @@
+package journal
+
+import "context"
+
+// JournalRepo defines the interface for journal persistence.
+// Implementations store an entry header together with all of its postings.
+type JournalRepo interface {
+	Create(ctx context.Context, params EntryCreateParams) (int, error)
+	AccountBalance(ctx context.Context, accountId int) ([]AccountBalanceRow, error)
+}
+
+// EntryCreateParams holds the parameters required to create a journal entry and its postings.
+type EntryCreateParams struct {
+	Kind          string
+	TransactionId *int
+	Postings      []PostingCreateParams
+}
+
+// PostingCreateParams holds one posting of a journal entry. Exactly one of AccountId
+// and SystemAccount is set.
+type PostingCreateParams struct {
+	AccountId     *int
+	SystemAccount *string
+	Direction     string
+	Amount        int64
+	AmountScale   int
+	Currency      string
+}
+
+// AccountBalanceRow is the net of credits minus debits posted to an account for one
+// currency and scale.
+type AccountBalanceRow struct {
+	Currency    string `db:"currency"`
+	AmountScale int    `db:"scale_amount"`
+	Balance     int64  `db:"balance"`
+}
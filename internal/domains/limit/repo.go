@@ -0,0 +1,89 @@
+package limit
+
+import (
+	"context"
+	"time"
+)
+
+// LimitRepo defines the interface for transfer limit persistence and for reading the outgoing
+// transfers the rolling windows of the limits are measured over.
+type LimitRepo interface {
+	Create(ctx context.Context, params LimitCreateParams) (LimitRow, bool, error)
+	ById(ctx context.Context, limitId int) (LimitRow, error)
+	List(ctx context.Context, params LimitListParams) ([]LimitRow, error)
+	Update(ctx context.Context, params LimitUpdateParams) (LimitRow, error)
+	Delete(ctx context.Context, limitId int) (bool, error)
+	Applicable(ctx context.Context, params LimitApplicableParams) ([]LimitRow, error)
+	Outgoing(ctx context.Context, params OutgoingParams) ([]OutgoingRow, error)
+}
+
+// LimitCreateParams holds the parameters required to create a transfer limit. AccountId is only
+// set for ScopeAccount and Tier only for ScopeTier. Amount limits set Currency, Amount and
+// AmountScale; count limits set Count.
+type LimitCreateParams struct {
+	Kind        string
+	Scope       string
+	AccountId   *int
+	Tier        *string
+	Currency    *string
+	Amount      *int64
+	AmountScale *int
+	Count       *int
+}
+
+// LimitRow represents a row in the transfer_limits table. Amount is in minor units together
+// with the scale needed to format it; Count is set instead for count limits.
+type LimitRow struct {
+	LimitId     int       `db:"limit_id"`
+	Kind        string    `db:"kind"`
+	Scope       string    `db:"scope"`
+	AccountId   *int      `db:"account_id"`
+	Tier        *string   `db:"tier"`
+	Currency    *string   `db:"currency"`
+	Amount      *int64    `db:"amount"`
+	AmountScale *int      `db:"scale_amount"`
+	Count       *int      `db:"count"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// LimitListParams holds the filters used to list transfer limits. Zero values match every limit.
+type LimitListParams struct {
+	Scope     string
+	AccountId int
+	Tier      string
+}
+
+// LimitUpdateParams holds the new value of a transfer limit. Amount is in the scale the limit
+// was created with.
+type LimitUpdateParams struct {
+	LimitId int
+	Amount  *int64
+	Count   *int
+}
+
+// LimitApplicableParams selects the limits that may apply to a transfer out of AccountId: those
+// of the account itself, of its Tier and the global ones, in Currency or without a currency.
+type LimitApplicableParams struct {
+	AccountId int
+	Tier      string
+	Currency  string
+}
+
+// OutgoingParams selects the transfers sent by AccountId and the holds pending on it after Since.
+type OutgoingParams struct {
+	AccountId int
+	Since     time.Time
+}
+
+// OutgoingRow is a transfer sent by an account, read from the transactions table, or a hold
+// still pending on it, read from the holds table, which counts as a transfer of its held amount.
+// Only one of TransactionId and HoldId is set.
+type OutgoingRow struct {
+	TransactionId int       `db:"transaction_id"`
+	HoldId        int       `db:"hold_id"`
+	Amount        int64     `db:"amount"`
+	AmountScale   int       `db:"scale_amount"`
+	Currency      string    `db:"currency"`
+	CreatedAt     time.Time `db:"created_at"`
+}
@@ -0,0 +1,460 @@
+// Package limit provides business logic for transfer limits: how much and how often an account
+// may send, configured for a single account, for every account of a tier or globally.
+package limit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+)
+
+// LimitService manages transfer limits and checks transfers against them.
+type LimitService struct {
+	repo        LimitRepo
+	accountRepo account.AccountRepo
+}
+
+// Limit kinds. SingleAmount caps the amount of one transfer. DailyAmount and MonthlyAmount cap
+// the total sent over a rolling window of 24 hours and 30 days, and DailyCount caps the number
+// of transfers sent over a rolling window of 24 hours.
+const (
+	KindSingleAmount  = "single_amount"
+	KindDailyAmount   = "daily_amount"
+	KindMonthlyAmount = "monthly_amount"
+	KindDailyCount    = "daily_count"
+)
+
+// Limit scopes. When limits of the same kind apply to a transfer, the most specific one wins: a
+// limit of the account overrides a limit of its tier, which overrides a global limit.
+const (
+	ScopeAccount = "account"
+	ScopeTier    = "tier"
+	ScopeGlobal  = "global"
+)
+
+// windows is the length of the rolling window of every kind measured over past transfers, and
+// kinds the order a transfer is checked in.
+var (
+	windows = map[string]time.Duration{
+		KindDailyAmount:   24 * time.Hour,
+		KindMonthlyAmount: 30 * 24 * time.Hour,
+		KindDailyCount:    24 * time.Hour,
+	}
+	kinds = []string{KindSingleAmount, KindDailyAmount, KindMonthlyAmount, KindDailyCount}
+)
+
+var (
+	ErrLimitCreateFailed      = errors.New("limit creation fail")
+	ErrLimitUpdateFailed      = errors.New("limit update fail")
+	ErrLimitDeleteFailed      = errors.New("limit delete fail")
+	ErrLimitListFailed        = errors.New("limit list fail")
+	ErrLimitCheckFailed       = errors.New("limit check fail")
+	ErrLimitNotFound          = errors.New("limit not found")
+	ErrLimitKindInvalid       = errors.New("limit kind invalid")
+	ErrLimitScopeInvalid      = errors.New("limit scope invalid")
+	ErrLimitCurrencyInvalid   = errors.New("limit currency invalid")
+	ErrLimitValueInvalid      = errors.New("limit value invalid")
+	ErrLimitAccountNotFound   = errors.New("limit account not found")
+	ErrLimitAlreadyConfigured = errors.New("limit already configured")
+)
+
+// LimitCreate represents a request to create a transfer limit. Account limits set AccountId and
+// tier limits set Tier. Amount limits set Amount, in Currency; Currency may be left out for an
+// account limit, which then uses the currency of the account. DailyCount limits set Count.
+type LimitCreate struct {
+	Kind      string        `json:"kind"`
+	Scope     string        `json:"scope"`
+	AccountId int           `json:"account_id,omitempty"`
+	Tier      string        `json:"tier,omitempty"`
+	Currency  string        `json:"currency,omitempty"`
+	Amount    *money.Amount `json:"amount,omitempty"`
+	Count     int           `json:"count,omitempty"`
+}
+
+// LimitUpdate represents a request to change the value of a transfer limit. Amount limits set
+// Amount and count limits set Count; the kind and scope of a limit can not change.
+type LimitUpdate struct {
+	Amount *money.Amount `json:"amount,omitempty"`
+	Count  int           `json:"count,omitempty"`
+}
+
+// LimitFilter selects the transfer limits to list. Zero values match every limit.
+type LimitFilter struct {
+	Scope     string
+	AccountId int
+	Tier      string
+}
+
+// Limit represents a configured transfer limit.
+type Limit struct {
+	LimitId   int           `json:"limit_id"`
+	Kind      string        `json:"kind"`
+	Scope     string        `json:"scope"`
+	AccountId *int          `json:"account_id,omitempty"`
+	Tier      string        `json:"tier,omitempty"`
+	Currency  string        `json:"currency,omitempty"`
+	Amount    *money.Amount `json:"amount,omitempty"`
+	Count     *int          `json:"count,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// Transfer is a transfer about to be sent by AccountId, of Tier, at At. Amount is in Currency,
+// the currency of the account.
+type Transfer struct {
+	AccountId int
+	Tier      string
+	Currency  string
+	Amount    money.Amount
+	At        time.Time
+}
+
+// Violation names the limit a transfer would exceed. ResetsAt is when enough past transfers
+// leave the rolling window of the limit for the same transfer to fit; it is nil when waiting
+// does not help because the transfer exceeds the limit on its own.
+type Violation struct {
+	LimitId  int           `json:"limit_id"`
+	Kind     string        `json:"kind"`
+	Scope    string        `json:"scope"`
+	Amount   *money.Amount `json:"amount,omitempty"`
+	Count    *int          `json:"count,omitempty"`
+	ResetsAt *time.Time    `json:"resets_at,omitempty"`
+}
+
+// NewLimitService creates a new LimitService with the given repositories.
+func NewLimitService(repo LimitRepo, accountRepo account.AccountRepo) *LimitService {
+	return &LimitService{repo, accountRepo}
+}
+
+// Create validates and stores a transfer limit. Only one limit of a kind can be configured for
+// the same account, tier or globally in the same currency.
+func (svc *LimitService) Create(ctx context.Context, data LimitCreate) (Limit, error) {
+	if !slices.Contains(kinds, data.Kind) {
+		slog.WarnContext(ctx, ErrLimitKindInvalid.Error(), "kind", data.Kind)
+		return Limit{}, ErrLimitKindInvalid
+	}
+
+	params := LimitCreateParams{Kind: data.Kind, Scope: data.Scope}
+	switch data.Scope {
+	case ScopeAccount:
+		if data.AccountId <= 0 || data.Tier != "" {
+			slog.WarnContext(ctx, ErrLimitScopeInvalid.Error(), "reason", "account limits set account_id only")
+			return Limit{}, ErrLimitScopeInvalid
+		}
+		row, err := svc.accountRepo.ById(ctx, data.AccountId)
+		if err != nil {
+			slog.WarnContext(ctx, ErrLimitAccountNotFound.Error(), "error", err)
+			return Limit{}, ErrLimitAccountNotFound
+		}
+		if data.Kind != KindDailyCount && data.Currency == "" {
+			data.Currency = row.Currency
+		}
+		if data.Currency != "" && data.Currency != row.Currency {
+			slog.WarnContext(ctx, ErrLimitCurrencyInvalid.Error(), "account_id", row.AccountId, "currency", row.Currency)
+			return Limit{}, ErrLimitCurrencyInvalid
+		}
+		params.AccountId = &data.AccountId
+	case ScopeTier:
+		if data.AccountId != 0 || !account.IsValidTier(data.Tier) {
+			slog.WarnContext(ctx, ErrLimitScopeInvalid.Error(), "reason", "tier limits set a valid tier only")
+			return Limit{}, ErrLimitScopeInvalid
+		}
+		params.Tier = &data.Tier
+	case ScopeGlobal:
+		if data.AccountId != 0 || data.Tier != "" {
+			slog.WarnContext(ctx, ErrLimitScopeInvalid.Error(), "reason", "global limits set neither account_id nor tier")
+			return Limit{}, ErrLimitScopeInvalid
+		}
+	default:
+		slog.WarnContext(ctx, ErrLimitScopeInvalid.Error(), "scope", data.Scope)
+		return Limit{}, ErrLimitScopeInvalid
+	}
+
+	if data.Kind == KindDailyCount {
+		if data.Currency != "" {
+			slog.WarnContext(ctx, ErrLimitCurrencyInvalid.Error(), "reason", "count limits apply to every currency")
+			return Limit{}, ErrLimitCurrencyInvalid
+		}
+		if data.Amount != nil || data.Count <= 0 {
+			slog.WarnContext(ctx, ErrLimitValueInvalid.Error(), "reason", "count limits set a positive count")
+			return Limit{}, ErrLimitValueInvalid
+		}
+		params.Count = &data.Count
+	} else {
+		currency, err := money.LookupCurrency(data.Currency)
+		if err != nil {
+			slog.WarnContext(ctx, ErrLimitCurrencyInvalid.Error(), "error", err)
+			return Limit{}, ErrLimitCurrencyInvalid
+		}
+		amount, err := amountValue(ctx, data.Amount, data.Count, currency.Scale)
+		if err != nil {
+			return Limit{}, err
+		}
+		value, scale := amount.Value(), amount.Scale()
+		params.Currency, params.Amount, params.AmountScale = &currency.Code, &value, &scale
+	}
+
+	row, created, err := svc.repo.Create(ctx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrLimitCreateFailed.Error(), "error", err)
+		return Limit{}, ErrLimitCreateFailed
+	}
+	if !created {
+		slog.WarnContext(ctx, ErrLimitAlreadyConfigured.Error(), "kind", data.Kind, "scope", data.Scope)
+		return Limit{}, ErrLimitAlreadyConfigured
+	}
+
+	return toLimit(row), nil
+}
+
+// ById retrieves a transfer limit by its ID.
+func (svc *LimitService) ById(ctx context.Context, limitId int) (Limit, error) {
+	row, err := svc.repo.ById(ctx, limitId)
+	if err != nil {
+		slog.WarnContext(ctx, ErrLimitNotFound.Error(), "error", err)
+		return Limit{}, ErrLimitNotFound
+	}
+
+	return toLimit(row), nil
+}
+
+// List returns the transfer limits matching filter, oldest first.
+func (svc *LimitService) List(ctx context.Context, filter LimitFilter) ([]Limit, error) {
+	if filter.Scope != "" && filter.Scope != ScopeAccount && filter.Scope != ScopeTier && filter.Scope != ScopeGlobal {
+		slog.WarnContext(ctx, ErrLimitScopeInvalid.Error(), "scope", filter.Scope)
+		return nil, ErrLimitScopeInvalid
+	}
+
+	rows, err := svc.repo.List(ctx, LimitListParams(filter))
+	if err != nil {
+		slog.ErrorContext(ctx, ErrLimitListFailed.Error(), "error", err)
+		return nil, ErrLimitListFailed
+	}
+
+	data := make([]Limit, len(rows))
+	for i, row := range rows {
+		data[i] = toLimit(row)
+	}
+
+	return data, nil
+}
+
+// Update changes the value of a transfer limit. Transfers already sent are measured against the
+// new value from the next check on.
+func (svc *LimitService) Update(ctx context.Context, limitId int, data LimitUpdate) (Limit, error) {
+	row, err := svc.repo.ById(ctx, limitId)
+	if err != nil {
+		slog.WarnContext(ctx, ErrLimitNotFound.Error(), "error", err)
+		return Limit{}, ErrLimitNotFound
+	}
+
+	params := LimitUpdateParams{LimitId: limitId}
+	if row.Kind == KindDailyCount {
+		if data.Amount != nil || data.Count <= 0 {
+			slog.WarnContext(ctx, ErrLimitValueInvalid.Error(), "reason", "count limits set a positive count")
+			return Limit{}, ErrLimitValueInvalid
+		}
+		params.Count = &data.Count
+	} else {
+		amount, err := amountValue(ctx, data.Amount, data.Count, *row.AmountScale)
+		if err != nil {
+			return Limit{}, err
+		}
+		value := amount.Value()
+		params.Amount = &value
+	}
+
+	row, err = svc.repo.Update(ctx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrLimitUpdateFailed.Error(), "error", err)
+		return Limit{}, ErrLimitUpdateFailed
+	}
+
+	return toLimit(row), nil
+}
+
+// Delete removes a transfer limit.
+func (svc *LimitService) Delete(ctx context.Context, limitId int) error {
+	deleted, err := svc.repo.Delete(ctx, limitId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrLimitDeleteFailed.Error(), "error", err)
+		return ErrLimitDeleteFailed
+	}
+	if !deleted {
+		slog.WarnContext(ctx, ErrLimitNotFound.Error(), "limit_id", limitId)
+		return ErrLimitNotFound
+	}
+
+	return nil
+}
+
+// Check returns the first limit transfer would exceed, or nil when it stays within all of them.
+// The rolling windows are measured over the transfers the account sent before transfer.At, so
+// the caller must hold a lock on the account for two transfers not to be checked against the
+// same usage.
+func (svc *LimitService) Check(ctx context.Context, transfer Transfer) (*Violation, error) {
+	rows, err := svc.repo.Applicable(ctx, LimitApplicableParams{
+		AccountId: transfer.AccountId,
+		Tier:      transfer.Tier,
+		Currency:  transfer.Currency,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrLimitCheckFailed.Error(), "error", err)
+		return nil, ErrLimitCheckFailed
+	}
+
+	limits := effectiveLimits(rows)
+	if len(limits) == 0 {
+		return nil, nil
+	}
+
+	var window time.Duration
+	for kind := range limits {
+		window = max(window, windows[kind])
+	}
+
+	var usage []OutgoingRow
+	if window > 0 {
+		usage, err = svc.repo.Outgoing(ctx, OutgoingParams{AccountId: transfer.AccountId, Since: transfer.At.Add(-window)})
+		if err != nil {
+			slog.ErrorContext(ctx, ErrLimitCheckFailed.Error(), "error", err)
+			return nil, ErrLimitCheckFailed
+		}
+	}
+
+	for _, kind := range kinds {
+		row, ok := limits[kind]
+		if !ok {
+			continue
+		}
+
+		violation, err := check(row, usage, transfer)
+		if err != nil {
+			slog.ErrorContext(ctx, ErrLimitCheckFailed.Error(), "error", err)
+			return nil, ErrLimitCheckFailed
+		}
+		if violation != nil {
+			return violation, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// effectiveLimits returns the limit of every kind that applies to a transfer, keeping the most
+// specific of rows when several limits of a kind apply.
+func effectiveLimits(rows []LimitRow) map[string]LimitRow {
+	specificity := map[string]int{ScopeGlobal: 1, ScopeTier: 2, ScopeAccount: 3}
+
+	limits := map[string]LimitRow{}
+	for _, row := range rows {
+		if current, ok := limits[row.Kind]; !ok || specificity[row.Scope] > specificity[current.Scope] {
+			limits[row.Kind] = row
+		}
+	}
+	return limits
+}
+
+// check returns the violation of row by transfer, given the outgoing transfers of the account
+// ordered oldest first.
+func check(row LimitRow, usage []OutgoingRow, transfer Transfer) (*Violation, error) {
+	violation := toViolation(row)
+	window := windows[row.Kind]
+	inWindow := slices.DeleteFunc(slices.Clone(usage), func(u OutgoingRow) bool {
+		return !u.CreatedAt.After(transfer.At.Add(-window)) || u.CreatedAt.After(transfer.At)
+	})
+
+	if row.Kind == KindDailyCount {
+		excess := len(inWindow) + 1 - *row.Count
+		if excess <= 0 {
+			return nil, nil
+		}
+		if excess <= len(inWindow) {
+			resetsAt := inWindow[excess-1].CreatedAt.Add(window)
+			violation.ResetsAt = &resetsAt
+		}
+		return &violation, nil
+	}
+
+	limit := money.New(*row.Amount, *row.AmountScale)
+	total := transfer.Amount
+	for _, u := range inWindow {
+		var err error
+		if total, err = total.Add(money.New(u.Amount, u.AmountScale)); err != nil {
+			return nil, err
+		}
+	}
+	if total.Cmp(limit) <= 0 {
+		return nil, nil
+	}
+	if transfer.Amount.Cmp(limit) > 0 {
+		return &violation, nil
+	}
+
+	for _, u := range inWindow {
+		var err error
+		if total, err = total.Sub(money.New(u.Amount, u.AmountScale)); err != nil {
+			return nil, err
+		}
+		if total.Cmp(limit) <= 0 {
+			resetsAt := u.CreatedAt.Add(window)
+			violation.ResetsAt = &resetsAt
+			break
+		}
+	}
+	return &violation, nil
+}
+
+// amountValue validates the amount of an amount limit and returns it in scale.
+func amountValue(ctx context.Context, amount *money.Amount, count int, scale int) (money.Amount, error) {
+	if amount == nil || count != 0 || amount.Sign() <= 0 {
+		slog.WarnContext(ctx, ErrLimitValueInvalid.Error(), "reason", "amount limits set a positive amount")
+		return money.Amount{}, ErrLimitValueInvalid
+	}
+
+	value, err := amount.Rescale(scale)
+	if err != nil {
+		slog.WarnContext(ctx, ErrLimitValueInvalid.Error(), "error", err)
+		return money.Amount{}, err
+	}
+	return value, nil
+}
+
+func toLimit(row LimitRow) Limit {
+	data := Limit{
+		LimitId:   row.LimitId,
+		Kind:      row.Kind,
+		Scope:     row.Scope,
+		AccountId: row.AccountId,
+		Count:     row.Count,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+	}
+	if row.Tier != nil {
+		data.Tier = *row.Tier
+	}
+	if row.Currency != nil {
+		data.Currency = *row.Currency
+	}
+	if row.Amount != nil {
+		amount := money.New(*row.Amount, *row.AmountScale)
+		data.Amount = &amount
+	}
+	return data
+}
+
+func toViolation(row LimitRow) Violation {
+	data := toLimit(row)
+	return Violation{
+		LimitId: data.LimitId,
+		Kind:    data.Kind,
+		Scope:   data.Scope,
+		Amount:  data.Amount,
+		Count:   data.Count,
+	}
+}
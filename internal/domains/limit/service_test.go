@@ -0,0 +1,435 @@
+package limit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+)
+
+func TestLimitService_Create(t *testing.T) {
+	amount := money.New(1_000, 0)
+	negative := money.New(-1, 0)
+
+	accountRepo := &fakeAccountRepo{
+		ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
+			if accountId != 1 {
+				return account.AccountRow{}, fmt.Errorf("account not found [account_id: %d]", accountId)
+			}
+			return account.AccountRow{AccountId: 1, ScaleBalance: 2, Currency: "USD"}, nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		data       LimitCreate
+		wantParams string
+		wantErr    error
+	}{
+		{
+			name:       "success - account amount in account currency",
+			data:       LimitCreate{Kind: KindDailyAmount, Scope: ScopeAccount, AccountId: 1, Amount: &amount},
+			wantParams: "daily_amount account 1 USD 100000/2",
+		},
+		{
+			name:       "success - tier count",
+			data:       LimitCreate{Kind: KindDailyCount, Scope: ScopeTier, Tier: "premium", Count: 10},
+			wantParams: "daily_count tier premium 10",
+		},
+		{
+			name:       "success - global amount",
+			data:       LimitCreate{Kind: KindSingleAmount, Scope: ScopeGlobal, Currency: "JPY", Amount: &amount},
+			wantParams: "single_amount global JPY 1000/0",
+		},
+		{
+			name:    "error - kind invalid",
+			data:    LimitCreate{Kind: "weekly_amount", Scope: ScopeGlobal, Currency: "USD", Amount: &amount},
+			wantErr: ErrLimitKindInvalid,
+		},
+		{
+			name:    "error - scope invalid",
+			data:    LimitCreate{Kind: KindDailyAmount, Scope: "everyone", Currency: "USD", Amount: &amount},
+			wantErr: ErrLimitScopeInvalid,
+		},
+		{
+			name:    "error - account scope without account",
+			data:    LimitCreate{Kind: KindDailyAmount, Scope: ScopeAccount, Amount: &amount},
+			wantErr: ErrLimitScopeInvalid,
+		},
+		{
+			name:    "error - global scope with tier",
+			data:    LimitCreate{Kind: KindDailyAmount, Scope: ScopeGlobal, Tier: "premium", Currency: "USD", Amount: &amount},
+			wantErr: ErrLimitScopeInvalid,
+		},
+		{
+			name:    "error - tier invalid",
+			data:    LimitCreate{Kind: KindDailyCount, Scope: ScopeTier, Tier: "Premium Plus", Count: 10},
+			wantErr: ErrLimitScopeInvalid,
+		},
+		{
+			name:    "error - account not found",
+			data:    LimitCreate{Kind: KindDailyAmount, Scope: ScopeAccount, AccountId: 2, Amount: &amount},
+			wantErr: ErrLimitAccountNotFound,
+		},
+		{
+			name:    "error - account currency differs",
+			data:    LimitCreate{Kind: KindDailyAmount, Scope: ScopeAccount, AccountId: 1, Currency: "EUR", Amount: &amount},
+			wantErr: ErrLimitCurrencyInvalid,
+		},
+		{
+			name:    "error - global amount without currency",
+			data:    LimitCreate{Kind: KindDailyAmount, Scope: ScopeGlobal, Amount: &amount},
+			wantErr: ErrLimitCurrencyInvalid,
+		},
+		{
+			name:    "error - count with currency",
+			data:    LimitCreate{Kind: KindDailyCount, Scope: ScopeGlobal, Currency: "USD", Count: 10},
+			wantErr: ErrLimitCurrencyInvalid,
+		},
+		{
+			name:    "error - count not positive",
+			data:    LimitCreate{Kind: KindDailyCount, Scope: ScopeGlobal},
+			wantErr: ErrLimitValueInvalid,
+		},
+		{
+			name:    "error - amount missing",
+			data:    LimitCreate{Kind: KindMonthlyAmount, Scope: ScopeGlobal, Currency: "USD", Count: 10},
+			wantErr: ErrLimitValueInvalid,
+		},
+		{
+			name:    "error - amount negative",
+			data:    LimitCreate{Kind: KindMonthlyAmount, Scope: ScopeGlobal, Currency: "USD", Amount: &negative},
+			wantErr: ErrLimitValueInvalid,
+		},
+		{
+			name:    "error - already configured",
+			data:    LimitCreate{Kind: KindDailyCount, Scope: ScopeGlobal, Count: 1},
+			wantErr: ErrLimitAlreadyConfigured,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotParams string
+			repo := &fakeLimitRepo{
+				CreateFunc: func(ctx context.Context, params LimitCreateParams) (LimitRow, bool, error) {
+					gotParams = params.Kind + " " + params.Scope
+					if params.AccountId != nil {
+						gotParams += fmt.Sprintf(" %d", *params.AccountId)
+					}
+					if params.Tier != nil {
+						gotParams += " " + *params.Tier
+					}
+					if params.Currency != nil {
+						gotParams += fmt.Sprintf(" %s %d/%d", *params.Currency, *params.Amount, *params.AmountScale)
+					}
+					if params.Count != nil {
+						gotParams += fmt.Sprintf(" %d", *params.Count)
+					}
+					if params.Kind == KindDailyCount && params.Scope == ScopeGlobal {
+						return LimitRow{}, false, nil
+					}
+					return LimitRow{LimitId: 1, Kind: params.Kind, Scope: params.Scope}, true, nil
+				},
+			}
+
+			svc := NewLimitService(repo, accountRepo)
+			_, err := svc.Create(t.Context(), tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("LimitService.Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && gotParams != tt.wantParams {
+				t.Errorf("LimitService.Create() params = %q, want %q", gotParams, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestLimitService_Update(t *testing.T) {
+	scale := 2
+	amount := money.New(5_000, 0)
+	limits := map[int]LimitRow{
+		1: {LimitId: 1, Kind: KindDailyAmount, Scope: ScopeGlobal, Amount: new(int64), AmountScale: &scale},
+		2: {LimitId: 2, Kind: KindDailyCount, Scope: ScopeGlobal, Count: new(int)},
+	}
+	repo := &fakeLimitRepo{
+		ByIdFunc: func(ctx context.Context, limitId int) (LimitRow, error) {
+			row, ok := limits[limitId]
+			if !ok {
+				return LimitRow{}, fmt.Errorf("limit not found [limit_id: %d]", limitId)
+			}
+			return row, nil
+		},
+		UpdateFunc: func(ctx context.Context, params LimitUpdateParams) (LimitRow, error) {
+			row := limits[params.LimitId]
+			row.Amount, row.Count = params.Amount, params.Count
+			return row, nil
+		},
+	}
+
+	tests := []struct {
+		name    string
+		limitId int
+		data    LimitUpdate
+		want    string
+		wantErr error
+	}{
+		{name: "success - amount rescaled", limitId: 1, data: LimitUpdate{Amount: &amount}, want: "5000.00"},
+		{name: "success - count", limitId: 2, data: LimitUpdate{Count: 20}, want: "20"},
+		{name: "error - count for amount limit", limitId: 1, data: LimitUpdate{Count: 20}, wantErr: ErrLimitValueInvalid},
+		{name: "error - amount for count limit", limitId: 2, data: LimitUpdate{Amount: &amount, Count: 20}, wantErr: ErrLimitValueInvalid},
+		{name: "error - not found", limitId: 3, data: LimitUpdate{Count: 20}, wantErr: ErrLimitNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewLimitService(repo, nil)
+			got, err := svc.Update(t.Context(), tt.limitId, tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("LimitService.Update() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var value string
+			if got.Amount != nil {
+				value = got.Amount.String()
+			} else {
+				value = fmt.Sprint(*got.Count)
+			}
+			if value != tt.want {
+				t.Errorf("LimitService.Update() value = %s, want %s", value, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitService_Delete(t *testing.T) {
+	repo := &fakeLimitRepo{
+		DeleteFunc: func(ctx context.Context, limitId int) (bool, error) { return limitId == 1, nil },
+	}
+
+	svc := NewLimitService(repo, nil)
+	if err := svc.Delete(t.Context(), 1); err != nil {
+		t.Errorf("LimitService.Delete() error = %v", err)
+	}
+	if err := svc.Delete(t.Context(), 2); !errors.Is(err, ErrLimitNotFound) {
+		t.Errorf("LimitService.Delete() error = %v, want %v", err, ErrLimitNotFound)
+	}
+}
+
+func TestLimitService_Check(t *testing.T) {
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	amountLimit := func(limitId int, kind, scope string, amount int64) LimitRow {
+		scale := 2
+		return LimitRow{LimitId: limitId, Kind: kind, Scope: scope, Amount: &amount, AmountScale: &scale}
+	}
+	countLimit := func(limitId int, scope string, count int) LimitRow {
+		return LimitRow{LimitId: limitId, Kind: KindDailyCount, Scope: scope, Count: &count}
+	}
+	sent := func(ago time.Duration, amount int64) OutgoingRow {
+		return OutgoingRow{Amount: amount, AmountScale: 2, Currency: "USD", CreatedAt: now.Add(-ago)}
+	}
+	at := func(ago time.Duration, window time.Duration) *time.Time {
+		t := now.Add(-ago).Add(window)
+		return &t
+	}
+
+	tests := []struct {
+		name       string
+		limits     []LimitRow
+		usage      []OutgoingRow
+		amount     int64
+		wantLimit  int
+		wantResets *time.Time
+	}{
+		{
+			name:   "success - no limits",
+			amount: 1_000_000,
+		},
+		{
+			name:   "success - within every limit",
+			limits: []LimitRow{amountLimit(1, KindSingleAmount, ScopeGlobal, 10_000), amountLimit(2, KindDailyAmount, ScopeGlobal, 20_000), countLimit(3, ScopeGlobal, 3)},
+			usage:  []OutgoingRow{sent(2*time.Hour, 5_000), sent(time.Hour, 5_000)},
+			amount: 10_000,
+		},
+		{
+			name:      "error - single amount never resets",
+			limits:    []LimitRow{amountLimit(1, KindSingleAmount, ScopeGlobal, 10_000)},
+			amount:    10_001,
+			wantLimit: 1,
+		},
+		{
+			name:       "error - daily amount resets when enough transfers age out",
+			limits:     []LimitRow{amountLimit(2, KindDailyAmount, ScopeGlobal, 20_000)},
+			usage:      []OutgoingRow{sent(25*time.Hour, 20_000), sent(3*time.Hour, 6_000), sent(2*time.Hour, 6_000), sent(time.Hour, 6_000)},
+			amount:     4_000,
+			wantLimit:  2,
+			wantResets: at(3*time.Hour, 24*time.Hour),
+		},
+		{
+			name:       "error - daily amount counts pending holds",
+			limits:     []LimitRow{amountLimit(2, KindDailyAmount, ScopeGlobal, 10_000)},
+			usage:      []OutgoingRow{{HoldId: 1, Amount: 6_000, AmountScale: 2, Currency: "USD", CreatedAt: now.Add(-time.Hour)}},
+			amount:     6_000,
+			wantLimit:  2,
+			wantResets: at(time.Hour, 24*time.Hour),
+		},
+		{
+			name:       "error - monthly amount",
+			limits:     []LimitRow{amountLimit(4, KindMonthlyAmount, ScopeGlobal, 100_000)},
+			usage:      []OutgoingRow{sent(20*24*time.Hour, 60_000), sent(25*time.Hour, 30_000)},
+			amount:     20_000,
+			wantLimit:  4,
+			wantResets: at(20*24*time.Hour, 30*24*time.Hour),
+		},
+		{
+			name:       "error - daily count",
+			limits:     []LimitRow{countLimit(3, ScopeGlobal, 2)},
+			usage:      []OutgoingRow{sent(30*time.Hour, 1), sent(5*time.Hour, 1), sent(4*time.Hour, 1)},
+			amount:     1,
+			wantLimit:  3,
+			wantResets: at(5*time.Hour, 24*time.Hour),
+		},
+		{
+			name:   "success - account limit overrides tier and global",
+			limits: []LimitRow{amountLimit(1, KindSingleAmount, ScopeGlobal, 1_000), amountLimit(5, KindSingleAmount, ScopeAccount, 50_000), amountLimit(6, KindSingleAmount, ScopeTier, 2_000)},
+			amount: 40_000,
+		},
+		{
+			name:      "error - tier limit overrides global",
+			limits:    []LimitRow{amountLimit(1, KindSingleAmount, ScopeGlobal, 50_000), amountLimit(6, KindSingleAmount, ScopeTier, 2_000)},
+			amount:    40_000,
+			wantLimit: 6,
+		},
+		{
+			name:      "error - single amount checked before windows",
+			limits:    []LimitRow{countLimit(3, ScopeGlobal, 1), amountLimit(1, KindSingleAmount, ScopeGlobal, 1_000)},
+			usage:     []OutgoingRow{sent(time.Hour, 1)},
+			amount:    2_000,
+			wantLimit: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeLimitRepo{
+				ApplicableFunc: func(ctx context.Context, params LimitApplicableParams) ([]LimitRow, error) {
+					if params != (LimitApplicableParams{AccountId: 1, Tier: "premium", Currency: "USD"}) {
+						return nil, fmt.Errorf("unexpected params %+v", params)
+					}
+					return tt.limits, nil
+				},
+				OutgoingFunc: func(ctx context.Context, params OutgoingParams) ([]OutgoingRow, error) {
+					var rows []OutgoingRow
+					for _, row := range tt.usage {
+						if row.CreatedAt.After(params.Since) {
+							rows = append(rows, row)
+						}
+					}
+					return rows, nil
+				},
+			}
+
+			svc := NewLimitService(repo, nil)
+			got, err := svc.Check(t.Context(), Transfer{AccountId: 1, Tier: "premium", Currency: "USD", Amount: money.New(tt.amount, 2), At: now})
+			if err != nil {
+				t.Fatalf("LimitService.Check() error = %v", err)
+			}
+			if tt.wantLimit == 0 {
+				if got != nil {
+					t.Errorf("LimitService.Check() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.LimitId != tt.wantLimit {
+				t.Fatalf("LimitService.Check() = %+v, want limit %d", got, tt.wantLimit)
+			}
+			if (got.ResetsAt == nil) != (tt.wantResets == nil) || (got.ResetsAt != nil && !got.ResetsAt.Equal(*tt.wantResets)) {
+				t.Errorf("LimitService.Check() resets at %v, want %v", got.ResetsAt, tt.wantResets)
+			}
+		})
+	}
+}
+
+type fakeLimitRepo struct {
+	CreateFunc     func(ctx context.Context, params LimitCreateParams) (LimitRow, bool, error)
+	ByIdFunc       func(ctx context.Context, limitId int) (LimitRow, error)
+	ListFunc       func(ctx context.Context, params LimitListParams) ([]LimitRow, error)
+	UpdateFunc     func(ctx context.Context, params LimitUpdateParams) (LimitRow, error)
+	DeleteFunc     func(ctx context.Context, limitId int) (bool, error)
+	ApplicableFunc func(ctx context.Context, params LimitApplicableParams) ([]LimitRow, error)
+	OutgoingFunc   func(ctx context.Context, params OutgoingParams) ([]OutgoingRow, error)
+}
+
+func (f *fakeLimitRepo) Create(ctx context.Context, params LimitCreateParams) (LimitRow, bool, error) {
+	return f.CreateFunc(ctx, params)
+}
+
+func (f *fakeLimitRepo) ById(ctx context.Context, limitId int) (LimitRow, error) {
+	return f.ByIdFunc(ctx, limitId)
+}
+
+func (f *fakeLimitRepo) List(ctx context.Context, params LimitListParams) ([]LimitRow, error) {
+	return f.ListFunc(ctx, params)
+}
+
+func (f *fakeLimitRepo) Update(ctx context.Context, params LimitUpdateParams) (LimitRow, error) {
+	return f.UpdateFunc(ctx, params)
+}
+
+func (f *fakeLimitRepo) Delete(ctx context.Context, limitId int) (bool, error) {
+	return f.DeleteFunc(ctx, limitId)
+}
+
+func (f *fakeLimitRepo) Applicable(ctx context.Context, params LimitApplicableParams) ([]LimitRow, error) {
+	return f.ApplicableFunc(ctx, params)
+}
+
+func (f *fakeLimitRepo) Outgoing(ctx context.Context, params OutgoingParams) ([]OutgoingRow, error) {
+	return f.OutgoingFunc(ctx, params)
+}
+
+type fakeAccountRepo struct {
+	CreateFunc                func(ctx context.Context, data account.AccountCreateParams) (bool, error)
+	ByIdFunc                  func(ctx context.Context, accountId int) (account.AccountRow, error)
+	ByIdsForUpdateFunc        func(ctx context.Context, accountIds []int) ([]account.AccountRow, error)
+	UpdateBalanceFunc         func(ctx context.Context, params account.AccountUpdateBalanceParams) error
+	UpdateHeldBalanceFunc     func(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error
+	UpdateStatusFunc          func(ctx context.Context, params account.AccountUpdateStatusParams) error
+	UpdateOverdraftLimitFunc  func(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error
+	OverdraftLimitChangesFunc func(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error)
+}
+
+func (f *fakeAccountRepo) Create(ctx context.Context, data account.AccountCreateParams) (bool, error) {
+	return f.CreateFunc(ctx, data)
+}
+
+func (f *fakeAccountRepo) ById(ctx context.Context, accountId int) (account.AccountRow, error) {
+	return f.ByIdFunc(ctx, accountId)
+}
+
+func (f *fakeAccountRepo) ByIdsForUpdate(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+	return f.ByIdsForUpdateFunc(ctx, accountIds)
+}
+
+func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+	return f.UpdateBalanceFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateHeldBalance(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+	return f.UpdateHeldBalanceFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateStatus(ctx context.Context, params account.AccountUpdateStatusParams) error {
+	return f.UpdateStatusFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateOverdraftLimit(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error {
+	return f.UpdateOverdraftLimitFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) OverdraftLimitChanges(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error) {
+	return f.OverdraftLimitChangesFunc(ctx, accountId)
+}
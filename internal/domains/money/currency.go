@@ -0,0 +1,37 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMoneyUnknownCurrency = errors.New("money unknown currency")
+
+// Currency describes a currency accounts can hold and the number of fractional digits
+// its amounts are stored with.
+type Currency struct {
+	Code  string // ISO 4217 code, or the ticker for crypto assets (e.g. "BTC").
+	Scale int    // Number of fractional digits, e.g. 2 for USD and 0 for JPY.
+}
+
+// currencies lists every currency supported by the system keyed by code.
+var currencies = map[string]Currency{
+	"USD": {Code: "USD", Scale: 2},
+	"EUR": {Code: "EUR", Scale: 2},
+	"GBP": {Code: "GBP", Scale: 2},
+	"SGD": {Code: "SGD", Scale: 2},
+	"IDR": {Code: "IDR", Scale: 2},
+	"JPY": {Code: "JPY", Scale: 0},
+	"KRW": {Code: "KRW", Scale: 0},
+	"BTC": {Code: "BTC", Scale: 8},
+	"ETH": {Code: "ETH", Scale: 8},
+}
+
+// LookupCurrency returns the Currency registered under code. Codes are upper case, e.g. "USD".
+func LookupCurrency(code string) (Currency, error) {
+	c, ok := currencies[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("%w: %q", ErrMoneyUnknownCurrency, code)
+	}
+	return c, nil
+}
@@ -1,36 +1,270 @@
+// Package money provides an exact decimal Amount type for monetary values.
+// Amounts are parsed and formatted without going through floating point, carry
+// their own scale and report overflow instead of silently wrapping.
 package money
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"math/big"
 	"strconv"
+	"strings"
 )
 
-const Scale int = 5
+// MaxScale is the largest number of fractional digits an Amount can carry.
+const MaxScale int = 18
 
-var ErrMoneyParseFail = errors.New("money parse fail")
+var (
+	ErrMoneyParseFail  = errors.New("money parse fail")
+	ErrMoneyTooPrecise = errors.New("money has more fractional digits than allowed")
+	ErrMoneyOverflow   = errors.New("money overflow")
+)
+
+// Amount is an exact decimal value stored as an integer number of minor units together
+// with its scale. For example, New(12345, 2) is 123.45. The zero value is 0 with scale 0.
+type Amount struct {
+	value int64
+	scale int
+}
+
+// New returns the Amount value / 10^scale.
+func New(value int64, scale int) Amount {
+	return Amount{value: value, scale: scale}
+}
+
+// Parse converts a decimal string such as "-12.340" into an Amount. The result keeps
+// exactly the fractional digits written in val, so Parse("12.340") has scale 3.
+// Signs, digits and a single decimal point are accepted; exponents and spaces are not.
+func Parse(val string) (Amount, error) {
+	s := val
+	neg := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" || (hasPoint && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Amount{}, fmt.Errorf("%w: invalid decimal %q", ErrMoneyParseFail, val)
+	}
+	if len(fracPart) > MaxScale {
+		return Amount{}, fmt.Errorf("%w: %q has more than %d fractional digits", ErrMoneyTooPrecise, val, MaxScale)
+	}
 
-// StringToInt converts a string representation of a decimal number to an integer,
-// scaling it by the specified number of decimal places (scale).
-// For example, StringToInt("12.34", 2) returns 1234.
-// Returns an error if the input string cannot be parsed as a float.
-func StringToInt(val string, scale int) (int, error) {
-	valFloat, err := strconv.ParseFloat(val, 64)
+	digits, err := strconv.ParseUint(intPart+fracPart, 10, 64)
 	if err != nil {
-		return 0, fmt.Errorf("invalid float: %w", err)
+		return Amount{}, fmt.Errorf("%w: %q does not fit in 64 bits", ErrMoneyOverflow, val)
+	}
+
+	var value int64
+	switch {
+	case !neg && digits <= math.MaxInt64:
+		value = int64(digits)
+	case neg && digits <= math.MaxInt64+1:
+		value = int64(-digits)
+	default:
+		return Amount{}, fmt.Errorf("%w: %q does not fit in 64 bits", ErrMoneyOverflow, val)
+	}
+
+	return Amount{value: value, scale: len(fracPart)}, nil
+}
+
+// ParseWithScale parses val and rescales it to scale. It fails with ErrMoneyTooPrecise
+// when val has more significant fractional digits than scale allows,
+// e.g. ParseWithScale("0.125", 2).
+func ParseWithScale(val string, scale int) (Amount, error) {
+	a, err := Parse(val)
+	if err != nil {
+		return Amount{}, err
+	}
+	return a.Rescale(scale)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Value returns the amount in minor units, i.e. the amount multiplied by 10^Scale().
+func (a Amount) Value() int64 {
+	return a.value
+}
+
+// Scale returns the number of fractional digits of the amount.
+func (a Amount) Scale() int {
+	return a.scale
+}
+
+// Rescale returns the same amount expressed with the given scale. Increasing the scale
+// is always exact unless it overflows; decreasing it fails with ErrMoneyTooPrecise when
+// the dropped digits are not all zero.
+func (a Amount) Rescale(scale int) (Amount, error) {
+	if scale < 0 || scale > MaxScale {
+		return Amount{}, fmt.Errorf("%w: scale %d out of range", ErrMoneyParseFail, scale)
+	}
+
+	switch {
+	case scale == a.scale:
+		return a, nil
+	case scale > a.scale:
+		value, ok := mulPow10(a.value, scale-a.scale)
+		if !ok {
+			return Amount{}, fmt.Errorf("%w: %s at scale %d", ErrMoneyOverflow, a, scale)
+		}
+		return Amount{value: value, scale: scale}, nil
+	default:
+		div := pow10(a.scale - scale)
+		if a.value%div != 0 {
+			return Amount{}, fmt.Errorf("%w: %s has more than %d fractional digits", ErrMoneyTooPrecise, a, scale)
+		}
+		return Amount{value: a.value / div, scale: scale}, nil
+	}
+}
+
+// Add returns a + b at the larger of the two scales.
+func (a Amount) Add(b Amount) (Amount, error) {
+	x, y, err := align(a, b)
+	if err != nil {
+		return Amount{}, err
+	}
+	if (y.value > 0 && x.value > math.MaxInt64-y.value) || (y.value < 0 && x.value < math.MinInt64-y.value) {
+		return Amount{}, fmt.Errorf("%w: %s + %s", ErrMoneyOverflow, a, b)
+	}
+	return Amount{value: x.value + y.value, scale: x.scale}, nil
+}
+
+// Sub returns a - b at the larger of the two scales.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	x, y, err := align(a, b)
+	if err != nil {
+		return Amount{}, err
+	}
+	if (y.value < 0 && x.value > math.MaxInt64+y.value) || (y.value > 0 && x.value < math.MinInt64+y.value) {
+		return Amount{}, fmt.Errorf("%w: %s - %s", ErrMoneyOverflow, a, b)
 	}
-	scaleMod := math.Pow10(scale)
-	result := math.Trunc(valFloat * scaleMod)
-	return int(result), nil
+	return Amount{value: x.value - y.value, scale: x.scale}, nil
 }
 
-// IntToString converts an integer value representing a scaled monetary amount into its string
-// representation with up to scale decimal places. The 'val' parameter is the integer value,
-// and 'scale' specifies the number of decimal places the integer should be divided by (as a power of 10).
-// For example, IntToString(12345, 2) returns "123.45000".
-func IntToString(val int, scale int) string {
-	scaleMod := math.Pow10(scale)
-	result := float64(val) / scaleMod
-	return fmt.Sprintf("%.5f", result)
+// Neg returns -a.
+func (a Amount) Neg() (Amount, error) {
+	if a.value == math.MinInt64 {
+		return Amount{}, fmt.Errorf("%w: -(%s)", ErrMoneyOverflow, a)
+	}
+	return Amount{value: -a.value, scale: a.scale}, nil
+}
+
+// Cmp compares a and b and returns -1, 0 or +1. Amounts with different scales are
+// compared by value, so Cmp of 1.5 and 1.50 is 0.
+func (a Amount) Cmp(b Amount) int {
+	scale := max(a.scale, b.scale)
+	x := new(big.Int).Mul(big.NewInt(a.value), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-a.scale)), nil))
+	y := new(big.Int).Mul(big.NewInt(b.value), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale-b.scale)), nil))
+	return x.Cmp(y)
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of a.
+func (a Amount) Sign() int {
+	switch {
+	case a.value < 0:
+		return -1
+	case a.value > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsZero reports whether a is zero.
+func (a Amount) IsZero() bool {
+	return a.value == 0
+}
+
+// String formats a with exactly Scale() fractional digits, e.g. "123.45000".
+func (a Amount) String() string {
+	mag := uint64(a.value)
+	if a.value < 0 {
+		mag = -mag
+	}
+
+	digits := strconv.FormatUint(mag, 10)
+	if len(digits) <= a.scale {
+		digits = strings.Repeat("0", a.scale-len(digits)+1) + digits
+	}
+
+	var sb strings.Builder
+	if a.value < 0 {
+		sb.WriteByte('-')
+	}
+	sb.WriteString(digits[:len(digits)-a.scale])
+	if a.scale > 0 {
+		sb.WriteByte('.')
+		sb.WriteString(digits[len(digits)-a.scale:])
+	}
+	return sb.String()
+}
+
+// MarshalJSON encodes a as a JSON string so no precision is lost by JSON number parsers.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON decodes a JSON string or number into a using Parse. A JSON null leaves a unchanged.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("%w: %s", ErrMoneyParseFail, err)
+		}
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// align rescales a and b to the larger of their two scales.
+func align(a, b Amount) (Amount, Amount, error) {
+	scale := max(a.scale, b.scale)
+	x, err := a.Rescale(scale)
+	if err != nil {
+		return Amount{}, Amount{}, err
+	}
+	y, err := b.Rescale(scale)
+	if err != nil {
+		return Amount{}, Amount{}, err
+	}
+	return x, y, nil
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for range n {
+		p *= 10
+	}
+	return p
+}
+
+// mulPow10 returns v * 10^n and whether the result fits in an int64.
+func mulPow10(v int64, n int) (int64, bool) {
+	for range n {
+		if v > math.MaxInt64/10 || v < math.MinInt64/10 {
+			return 0, false
+		}
+		v *= 10
+	}
+	return v, true
 }
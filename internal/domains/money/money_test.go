@@ -1,10 +1,13 @@
 package money
 
 import (
+	"encoding/json"
+	"errors"
+	"math"
 	"testing"
 )
 
-func TestStringToInt(t *testing.T) {
+func TestParseWithScale(t *testing.T) {
 	type args struct {
 		val   string
 		scale int
@@ -12,50 +15,211 @@ func TestStringToInt(t *testing.T) {
 	tests := []struct {
 		name    string
 		args    args
-		want    int
-		wantErr bool
+		want    Amount
+		wantErr error
 	}{
 		{
 			name:    "error - alpha",
 			args:    args{val: "aaa", scale: 5},
-			want:    0,
-			wantErr: true,
+			wantErr: ErrMoneyParseFail,
 		},
 		{
 			name:    "error - alpha numeric",
 			args:    args{val: "111aaa", scale: 5},
-			want:    0,
-			wantErr: true,
+			wantErr: ErrMoneyParseFail,
 		},
 		{
-			name:    "success",
-			args:    args{val: "100.23344", scale: 5},
-			want:    10_023_344,
-			wantErr: false,
+			name:    "error - exponent",
+			args:    args{val: "1e5", scale: 5},
+			wantErr: ErrMoneyParseFail,
 		},
 		{
-			name:    "success - int number",
-			args:    args{val: "100", scale: 5},
-			want:    10_000_000,
-			wantErr: false,
+			name:    "error - missing fraction",
+			args:    args{val: "1.", scale: 5},
+			wantErr: ErrMoneyParseFail,
 		},
 		{
-			name:    "success - negative number",
-			args:    args{val: "-100", scale: 5},
-			want:    -10_000_000,
-			wantErr: false,
+			name:    "error - too many fractional digits",
+			args:    args{val: "0.125", scale: 2},
+			wantErr: ErrMoneyTooPrecise,
+		},
+		{
+			name:    "error - overflow",
+			args:    args{val: "92233720368547.75808", scale: 5},
+			wantErr: ErrMoneyOverflow,
+		},
+		{
+			name: "success",
+			args: args{val: "100.23344", scale: 5},
+			want: New(10_023_344, 5),
+		},
+		{
+			name: "success - float unfriendly value",
+			args: args{val: "0.29", scale: 2},
+			want: New(29, 2),
+		},
+		{
+			name: "success - int number",
+			args: args{val: "100", scale: 5},
+			want: New(10_000_000, 5),
+		},
+		{
+			name: "success - negative number",
+			args: args{val: "-100", scale: 5},
+			want: New(-10_000_000, 5),
+		},
+		{
+			name: "success - trailing zeros dropped",
+			args: args{val: "1.50000", scale: 1},
+			want: New(15, 1),
+		},
+		{
+			name: "success - large balance",
+			args: args{val: "92233720368547.75807", scale: 5},
+			want: New(math.MaxInt64, 5),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseWithScale(tt.args.val, tt.args.scale)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseWithScale() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseWithScale() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmount_String(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Amount
+		want string
+	}{
+		{name: "scale 5", a: New(12_345, 5), want: "0.12345"},
+		{name: "scale 2", a: New(12_345, 2), want: "123.45"},
+		{name: "scale 0", a: New(12_345, 0), want: "12345"},
+		{name: "negative", a: New(-5, 2), want: "-0.05"},
+		{name: "min int64", a: New(math.MinInt64, 2), want: "-92233720368547758.08"},
+		{name: "zero value", a: Amount{}, want: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.String(); got != tt.want {
+				t.Errorf("Amount.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmount_Arithmetic(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      func() (Amount, error)
+		want    Amount
+		wantErr error
+	}{
+		{
+			name: "add - mixed scale",
+			op:   func() (Amount, error) { return New(150, 2).Add(New(1, 5)) },
+			want: New(150_001, 5),
+		},
+		{
+			name:    "add - overflow",
+			op:      func() (Amount, error) { return New(math.MaxInt64, 0).Add(New(1, 0)) },
+			wantErr: ErrMoneyOverflow,
+		},
+		{
+			name: "sub - negative result",
+			op:   func() (Amount, error) { return New(100, 2).Sub(New(250, 2)) },
+			want: New(-150, 2),
+		},
+		{
+			name:    "sub - overflow",
+			op:      func() (Amount, error) { return New(math.MinInt64, 0).Sub(New(1, 0)) },
+			wantErr: ErrMoneyOverflow,
+		},
+		{
+			name:    "sub - rescale overflow",
+			op:      func() (Amount, error) { return New(math.MaxInt64, 0).Sub(New(1, 2)) },
+			wantErr: ErrMoneyOverflow,
+		},
+		{
+			name: "neg",
+			op:   func() (Amount, error) { return New(100, 2).Neg() },
+			want: New(-100, 2),
+		},
+		{
+			name:    "neg - overflow",
+			op:      func() (Amount, error) { return New(math.MinInt64, 2).Neg() },
+			wantErr: ErrMoneyOverflow,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := StringToInt(tt.args.val, tt.args.scale)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("StringToInt() error = %v, wantErr %v", err, tt.wantErr)
+			got, err := tt.op()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("StringToInt() = %v, want %v", got, tt.want)
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmount_Cmp(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Amount
+		want int
+	}{
+		{name: "equal - different scale", a: New(15, 1), b: New(150, 2), want: 0},
+		{name: "less", a: New(1, 5), b: New(1, 2), want: -1},
+		{name: "greater - beyond int64 after rescale", a: New(math.MaxInt64, 0), b: New(1, 18), want: 1},
+		{name: "negative", a: New(-1, 0), b: New(0, 0), want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Cmp(tt.b); got != tt.want {
+				t.Errorf("Amount.Cmp() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestAmount_JSON(t *testing.T) {
+	var body struct {
+		Amount Amount `json:"amount"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":"10.50"}`), &body); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if body.Amount != New(1050, 2) {
+		t.Errorf("json.Unmarshal() = %v, want %v", body.Amount, New(1050, 2))
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":0.29}`), &body); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if body.Amount != New(29, 2) {
+		t.Errorf("json.Unmarshal() = %v, want %v", body.Amount, New(29, 2))
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":"abc"}`), &body); !errors.Is(err, ErrMoneyParseFail) {
+		t.Errorf("json.Unmarshal() error = %v, want %v", err, ErrMoneyParseFail)
+	}
+
+	got, err := json.Marshal(New(-1050, 2))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(got) != `"-10.50"` {
+		t.Errorf("json.Marshal() = %s, want %s", got, `"-10.50"`)
+	}
+}
@@ -0,0 +1,57 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// OutboxRepo defines the interface for outbox message persistence.
+type OutboxRepo interface {
+	Create(ctx context.Context, params MessageCreateParams) error
+	PendingForUpdate(ctx context.Context, now time.Time, limit int) ([]MessageRow, error)
+	MarkSent(ctx context.Context, messageIds []int) error
+	MarkFailed(ctx context.Context, params MessageFailParams) error
+	Stats(ctx context.Context) (StatsRow, error)
+}
+
+// UnitOfWork runs fn inside a single database transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// MessageCreateParams holds the parameters required to enqueue a message.
+type MessageCreateParams struct {
+	Kind    string
+	Payload json.RawMessage
+}
+
+// MessageRow represents a row in the outbox_messages table.
+type MessageRow struct {
+	MessageId     int             `db:"message_id"`
+	Kind          string          `db:"kind"`
+	Payload       json.RawMessage `db:"payload"`
+	Status        string          `db:"status"`
+	Attempts      int             `db:"attempts"`
+	LastError     *string         `db:"last_error"`
+	NextAttemptAt time.Time       `db:"next_attempt_at"`
+	SentAt        *time.Time      `db:"sent_at"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
+
+// MessageFailParams records a failed delivery attempt. Status is StatusPending to retry the
+// message at NextAttemptAt or StatusDead to stop retrying it.
+type MessageFailParams struct {
+	MessageId     int
+	Status        string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+}
+
+// StatsRow summarizes the outbox. OldestPendingAt is nil when nothing is pending.
+type StatsRow struct {
+	Pending         int        `db:"pending"`
+	Dead            int        `db:"dead"`
+	OldestPendingAt *time.Time `db:"oldest_pending_at"`
+}
@@ -0,0 +1,272 @@
+// Package outbox provides a transactional outbox for synchronizing the ledger to TigerBeetle.
+// Services enqueue a message in the same database transaction as the rows it describes, and
+// OutboxService relays the committed messages to TigerBeetle afterwards, retrying them until
+// they are delivered or dead-lettered.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Message kinds. Each kind has its own payload type.
+const (
+	KindAccountCreate  = "account.create"  // AccountPayload
+	KindAccountAdjust  = "account.adjust"  // AdjustmentPayload
+	KindAccountClose   = "account.close"   // AccountPayload
+	KindTransferCreate = "transfer.create" // TransferPayload
+	KindTransferBatch  = "transfer.batch"  // TransferBatchPayload
+	KindHoldCreate     = "hold.create"     // HoldPayload
+	KindHoldPost       = "hold.post"       // HoldPayload
+	KindHoldVoid       = "hold.void"       // HoldPayload
+)
+
+// Message statuses.
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusDead    = "dead"
+)
+
+// maxRetryDelay caps the exponential backoff between delivery attempts of a message.
+const maxRetryDelay = 5 * time.Minute
+
+var (
+	ErrOutboxRelayFailed = errors.New("outbox relay fail")
+	ErrOutboxStatsFailed = errors.New("outbox stats fail")
+)
+
+// AccountPayload creates an account with its initial balance funded from the treasury.
+// Overdraft accounts may go below zero, within the limit carried by the transfers debiting them.
+type AccountPayload struct {
+	AccountId      int    `json:"account_id"`
+	Currency       string `json:"currency"`
+	InitialBalance int64  `json:"initial_balance"`
+	Overdraft      bool   `json:"overdraft,omitempty"`
+}
+
+// AdjustmentPayload corrects the ledger balance of an account by Amount, which is negative to
+// lower it, against the suspense account. Adjustments are made by reconciliation repairs.
+type AdjustmentPayload struct {
+	AccountId int    `json:"account_id"`
+	Currency  string `json:"currency"`
+	Amount    int64  `json:"amount"`
+}
+
+// TransferPayload moves SourceAmount out of the source account and DestinationAmount into the
+// destination account. ReversalOf is set when the transfer reverses another transaction.
+// SourceOverdraftLimit is how far below zero the transfer may take the source account, enforced
+// by TigerBeetle when SourceOverdraftEnabled tells the source was created as an overdraft
+// account, even once its limit is back to zero. Fee is charged to the source on top of
+// SourceAmount and credited to the fees revenue account.
+type TransferPayload struct {
+	TransactionId          int    `json:"transaction_id"`
+	ReversalOf             int    `json:"reversal_of,omitempty"`
+	SourceAccountId        int    `json:"source_account_id"`
+	SourceAmount           int64  `json:"source_amount"`
+	SourceCurrency         string `json:"source_currency"`
+	Fee                    int64  `json:"fee,omitempty"`
+	SourceOverdraftEnabled bool   `json:"source_overdraft_enabled,omitempty"`
+	SourceOverdraftLimit   int64  `json:"source_overdraft_limit,omitempty"`
+	DestinationAccountId   int    `json:"destination_account_id"`
+	DestinationAmount      int64  `json:"destination_amount"`
+	DestinationCurrency    string `json:"destination_currency"`
+}
+
+// TransferBatchPayload holds the transfers of an atomic batch, which TigerBeetle applies all
+// or none.
+type TransferBatchPayload struct {
+	Transfers []TransferPayload `json:"transfers"`
+}
+
+// HoldPayload describes a hold. Amount is the held amount for KindHoldCreate and the captured
+// amount for KindHoldPost. SourceOverdraftLimit is how far below zero a new hold may take the
+// available balance of the source account, enforced like the limit of a TransferPayload when
+// SourceOverdraftEnabled is set. TimeoutSeconds is the timeout of the pending
+// transfer of a new hold, fixed when the message is queued so publishing it again creates the
+// same transfer.
+type HoldPayload struct {
+	HoldId                 int       `json:"hold_id"`
+	SourceAccountId        int       `json:"source_account_id"`
+	SourceOverdraftEnabled bool      `json:"source_overdraft_enabled,omitempty"`
+	SourceOverdraftLimit   int64     `json:"source_overdraft_limit,omitempty"`
+	DestinationAccountId   int       `json:"destination_account_id"`
+	Amount                 int64     `json:"amount"`
+	Currency               string    `json:"currency"`
+	ExpiresAt              time.Time `json:"expires_at"`
+	TimeoutSeconds         uint32    `json:"timeout_seconds,omitempty"`
+}
+
+// HoldTimeoutSeconds returns the time left at now until expiresAt in whole seconds, rounded up
+// so TigerBeetle never expires the pending transfer of a hold before Postgres expires the hold.
+// It is at least one second.
+func HoldTimeoutSeconds(expiresAt, now time.Time) uint32 {
+	left := expiresAt.Sub(now)
+	return uint32(max((left+time.Second-1)/time.Second, 1))
+}
+
+// Message is an outbox message handed to a Publisher.
+type Message struct {
+	MessageId int
+	Kind      string
+	Payload   json.RawMessage
+}
+
+// Publisher delivers messages to TigerBeetle. Publish returns one error per message, nil for
+// the delivered ones. It returns a non-nil error instead when the batch could not be submitted
+// at all, in which case none of the messages count as attempted. Publishing a message again
+// must not apply it twice.
+type Publisher interface {
+	Publish(ctx context.Context, messages []Message) ([]error, error)
+}
+
+// Stats summarizes the state of the outbox. Lag is the age of the oldest pending message.
+type Stats struct {
+	Pending    int     `json:"pending"`
+	Dead       int     `json:"dead"`
+	LagSeconds float64 `json:"lag_seconds"`
+}
+
+// OutboxService relays outbox messages to a Publisher.
+type OutboxService struct {
+	repo        OutboxRepo
+	uow         UnitOfWork
+	publisher   Publisher
+	batchSize   int
+	maxAttempts int
+	now         func() time.Time
+}
+
+// NewOutboxService creates a new OutboxService relaying up to batchSize messages at a time.
+// Messages rejected maxAttempts times are dead-lettered.
+func NewOutboxService(repo OutboxRepo, uow UnitOfWork, publisher Publisher, batchSize int, maxAttempts int) *OutboxService {
+	return &OutboxService{repo, uow, publisher, batchSize, maxAttempts, time.Now}
+}
+
+// Enqueue writes a message of the given kind through repo. Call it inside the unit of work
+// writing the rows the message describes, so the message is committed if and only if they are.
+func Enqueue(ctx context.Context, repo OutboxRepo, kind string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("outbox payload: %w", err)
+	}
+
+	return repo.Create(ctx, MessageCreateParams{Kind: kind, Payload: data})
+}
+
+// Relay publishes one batch of due messages and returns how many it processed. The messages
+// stay locked until the batch is recorded, so concurrent relays never publish the same
+// message at the same time. Delivered messages are marked sent; rejected ones are retried
+// with exponential backoff and dead-lettered after maxAttempts rejections.
+func (svc *OutboxService) Relay(ctx context.Context) (int, error) {
+	var count int
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		rows, err := svc.repo.PendingForUpdate(ctx, svc.now(), svc.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		messages := make([]Message, len(rows))
+		for i, row := range rows {
+			messages[i] = Message{MessageId: row.MessageId, Kind: row.Kind, Payload: row.Payload}
+		}
+
+		errs, err := svc.publisher.Publish(ctx, messages)
+		if err != nil {
+			return err
+		}
+		if len(errs) != len(rows) {
+			return fmt.Errorf("publisher returned %d results for %d messages", len(errs), len(rows))
+		}
+
+		var sent []int
+		for i, row := range rows {
+			if errs[i] == nil {
+				sent = append(sent, row.MessageId)
+				continue
+			}
+			if err := svc.repo.MarkFailed(ctx, svc.failure(row, errs[i])); err != nil {
+				return err
+			}
+		}
+
+		if len(sent) > 0 {
+			if err := svc.repo.MarkSent(ctx, sent); err != nil {
+				return err
+			}
+		}
+
+		count = len(rows)
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrOutboxRelayFailed.Error(), "error", err)
+		return 0, ErrOutboxRelayFailed
+	}
+
+	return count, nil
+}
+
+// Drain relays batches until no due message is left and returns how many it processed.
+func (svc *OutboxService) Drain(ctx context.Context) (int, error) {
+	var total int
+	for {
+		count, err := svc.Relay(ctx)
+		total += count
+		if err != nil || count < svc.batchSize {
+			return total, err
+		}
+	}
+}
+
+// Stats reports how many messages are pending and dead-lettered, and the outbox lag.
+func (svc *OutboxService) Stats(ctx context.Context) (Stats, error) {
+	row, err := svc.repo.Stats(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrOutboxStatsFailed.Error(), "error", err)
+		return Stats{}, ErrOutboxStatsFailed
+	}
+
+	stats := Stats{Pending: row.Pending, Dead: row.Dead}
+	if row.OldestPendingAt != nil {
+		stats.LagSeconds = max(svc.now().Sub(*row.OldestPendingAt).Seconds(), 0)
+	}
+
+	return stats, nil
+}
+
+// failure returns the update recording that row was rejected with err.
+func (svc *OutboxService) failure(row MessageRow, err error) MessageFailParams {
+	attempts := row.Attempts + 1
+	params := MessageFailParams{
+		MessageId:     row.MessageId,
+		Status:        StatusPending,
+		Attempts:      attempts,
+		LastError:     err.Error(),
+		NextAttemptAt: svc.now().Add(retryDelay(attempts)),
+	}
+	if attempts >= svc.maxAttempts {
+		params.Status = StatusDead
+		slog.Error("outbox message dead-lettered", "message_id", row.MessageId, "attempts", attempts, "error", err)
+	}
+
+	return params
+}
+
+// retryDelay returns the delay before the next attempt after the given number of attempts:
+// one second, doubling every attempt up to maxRetryDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRetryDelay)
+}
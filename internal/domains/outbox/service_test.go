@@ -0,0 +1,258 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOutboxService_Relay(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := []MessageRow{
+		{MessageId: 1, Kind: KindAccountCreate, Payload: []byte(`{}`), Status: StatusPending},
+		{MessageId: 2, Kind: KindTransferCreate, Payload: []byte(`{}`), Status: StatusPending, Attempts: 2},
+		{MessageId: 3, Kind: KindTransferCreate, Payload: []byte(`{}`), Status: StatusPending, Attempts: 4},
+		{MessageId: 4, Kind: KindHoldVoid, Payload: []byte(`{}`), Status: StatusPending},
+	}
+	rejected := errors.New("exceeds credits")
+
+	tests := []struct {
+		name       string
+		rows       []MessageRow
+		publish    func(ctx context.Context, messages []Message) ([]error, error)
+		want       int
+		wantSent   []int
+		wantFailed []MessageFailParams
+		wantErr    error
+	}{
+		{
+			name: "success - nothing due",
+			publish: func(ctx context.Context, messages []Message) ([]error, error) {
+				return nil, errors.New("unexpected publish")
+			},
+		},
+		{
+			name: "success - sent, retried and dead-lettered",
+			rows: rows,
+			publish: func(ctx context.Context, messages []Message) ([]error, error) {
+				return []error{nil, rejected, rejected, nil}, nil
+			},
+			want:     4,
+			wantSent: []int{1, 4},
+			wantFailed: []MessageFailParams{
+				{MessageId: 2, Status: StatusPending, Attempts: 3, LastError: "exceeds credits", NextAttemptAt: now.Add(4 * time.Second)},
+				{MessageId: 3, Status: StatusDead, Attempts: 5, LastError: "exceeds credits", NextAttemptAt: now.Add(16 * time.Second)},
+			},
+		},
+		{
+			name: "error - batch not submitted",
+			rows: rows,
+			publish: func(ctx context.Context, messages []Message) ([]error, error) {
+				return nil, errors.New("connection refused")
+			},
+			wantErr: ErrOutboxRelayFailed,
+		},
+		{
+			name: "error - result count mismatch",
+			rows: rows,
+			publish: func(ctx context.Context, messages []Message) ([]error, error) {
+				return []error{nil}, nil
+			},
+			wantErr: ErrOutboxRelayFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSent []int
+			var gotFailed []MessageFailParams
+			repo := &fakeOutboxRepo{
+				PendingForUpdateFunc: func(ctx context.Context, at time.Time, limit int) ([]MessageRow, error) {
+					if !at.Equal(now) || limit != 10 {
+						return nil, errors.New("unexpected pending query")
+					}
+					return tt.rows, nil
+				},
+				MarkSentFunc: func(ctx context.Context, messageIds []int) error {
+					gotSent = append(gotSent, messageIds...)
+					return nil
+				},
+				MarkFailedFunc: func(ctx context.Context, params MessageFailParams) error {
+					gotFailed = append(gotFailed, params)
+					return nil
+				},
+			}
+
+			svc := NewOutboxService(repo, &fakeUnitOfWork{}, &fakePublisher{PublishFunc: tt.publish}, 10, 5)
+			svc.now = func() time.Time { return now }
+
+			got, err := svc.Relay(t.Context())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("OutboxService.Relay() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("OutboxService.Relay() = %d, want %d", got, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(gotSent, tt.wantSent) {
+				t.Errorf("sent = %v, want %v", gotSent, tt.wantSent)
+			}
+			if !reflect.DeepEqual(gotFailed, tt.wantFailed) {
+				t.Errorf("failed = %+v, want %+v", gotFailed, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestOutboxService_Drain(t *testing.T) {
+	pending := make([]MessageRow, 5)
+	for i := range pending {
+		pending[i] = MessageRow{MessageId: i + 1, Status: StatusPending}
+	}
+
+	repo := &fakeOutboxRepo{
+		PendingForUpdateFunc: func(ctx context.Context, now time.Time, limit int) ([]MessageRow, error) {
+			return pending[:min(limit, len(pending))], nil
+		},
+		MarkSentFunc: func(ctx context.Context, messageIds []int) error {
+			pending = pending[len(messageIds):]
+			return nil
+		},
+	}
+	publisher := &fakePublisher{
+		PublishFunc: func(ctx context.Context, messages []Message) ([]error, error) {
+			return make([]error, len(messages)), nil
+		},
+	}
+
+	svc := NewOutboxService(repo, &fakeUnitOfWork{}, publisher, 2, 5)
+	got, err := svc.Drain(t.Context())
+	if err != nil || got != 5 {
+		t.Errorf("OutboxService.Drain() = %d, %v, want 5", got, err)
+	}
+}
+
+func TestOutboxService_Stats(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 1, 30, 0, time.UTC)
+	oldest := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		row  StatsRow
+		want Stats
+	}{
+		{
+			name: "success - empty",
+			want: Stats{},
+		},
+		{
+			name: "success - lagging",
+			row:  StatsRow{Pending: 3, Dead: 1, OldestPendingAt: &oldest},
+			want: Stats{Pending: 3, Dead: 1, LagSeconds: 90},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOutboxRepo{
+				StatsFunc: func(ctx context.Context) (StatsRow, error) {
+					return tt.row, nil
+				},
+			}
+
+			svc := NewOutboxService(repo, &fakeUnitOfWork{}, nil, 10, 5)
+			svc.now = func() time.Time { return now }
+
+			got, err := svc.Stats(t.Context())
+			if err != nil {
+				t.Fatalf("OutboxService.Stats() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("OutboxService.Stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_retryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 6, want: 32 * time.Second},
+		{attempts: 9, want: 256 * time.Second},
+		{attempts: 10, want: maxRetryDelay},
+		{attempts: 100, want: maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestHoldTimeoutSeconds(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		left time.Duration
+		want uint32
+	}{
+		{left: time.Minute, want: 60},
+		{left: time.Minute - time.Microsecond, want: 60},
+		{left: time.Minute + 100*time.Millisecond, want: 61},
+		{left: 0, want: 1},
+		{left: -time.Minute, want: 1},
+	}
+	for _, tt := range tests {
+		if got := HoldTimeoutSeconds(now.Add(tt.left), now); got != tt.want {
+			t.Errorf("HoldTimeoutSeconds(now+%s) = %d, want %d", tt.left, got, tt.want)
+		}
+	}
+}
+
+type fakeOutboxRepo struct {
+	CreateFunc           func(ctx context.Context, params MessageCreateParams) error
+	PendingForUpdateFunc func(ctx context.Context, now time.Time, limit int) ([]MessageRow, error)
+	MarkSentFunc         func(ctx context.Context, messageIds []int) error
+	MarkFailedFunc       func(ctx context.Context, params MessageFailParams) error
+	StatsFunc            func(ctx context.Context) (StatsRow, error)
+}
+
+func (f *fakeOutboxRepo) Create(ctx context.Context, params MessageCreateParams) error {
+	return f.CreateFunc(ctx, params)
+}
+
+func (f *fakeOutboxRepo) PendingForUpdate(ctx context.Context, now time.Time, limit int) ([]MessageRow, error) {
+	return f.PendingForUpdateFunc(ctx, now, limit)
+}
+
+func (f *fakeOutboxRepo) MarkSent(ctx context.Context, messageIds []int) error {
+	return f.MarkSentFunc(ctx, messageIds)
+}
+
+func (f *fakeOutboxRepo) MarkFailed(ctx context.Context, params MessageFailParams) error {
+	return f.MarkFailedFunc(ctx, params)
+}
+
+func (f *fakeOutboxRepo) Stats(ctx context.Context) (StatsRow, error) {
+	return f.StatsFunc(ctx)
+}
+
+type fakePublisher struct {
+	PublishFunc func(ctx context.Context, messages []Message) ([]error, error)
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, messages []Message) ([]error, error) {
+	return f.PublishFunc(ctx, messages)
+}
+
+type fakeUnitOfWork struct{}
+
+func (f *fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,36 @@
+package reconciliation
+
+import "context"
+
+// ReconciliationRepo defines the interface for reading the Postgres side of a reconciliation.
+type ReconciliationRepo interface {
+	Accounts(ctx context.Context, afterAccountId int, limit int) ([]AccountRow, error)
+}
+
+// AccountRow is an account as stored in Postgres. TransferCount is the number of TigerBeetle
+// transfers the account should take part in: one per journal posting on the account, one per
+// hold authorized on it and one more per voided hold. OverdraftEnabled accounts may go below
+// zero in TigerBeetle.
+type AccountRow struct {
+	AccountId        int    `db:"account_id"`
+	Balance          int64  `db:"balance"`
+	ScaleBalance     int    `db:"scale_balance"`
+	Currency         string `db:"currency"`
+	OverdraftEnabled bool   `db:"overdraft_enabled"`
+	TransferCount    int    `db:"transfer_count"`
+}
+
+// ReconciliationTBRepo defines the interface for reading the TigerBeetle side of a reconciliation.
+// LookupAccounts omits the accounts TigerBeetle does not know.
+type ReconciliationTBRepo interface {
+	LookupAccounts(ctx context.Context, accountIds []int) ([]LedgerAccountRow, error)
+	AccountTransferCount(ctx context.Context, accountId int) (int, error)
+}
+
+// LedgerAccountRow is an account as stored in TigerBeetle, with its posted amounts in the
+// minor units of the account.
+type LedgerAccountRow struct {
+	AccountId     int
+	DebitsPosted  int64
+	CreditsPosted int64
+}
@@ -0,0 +1,211 @@
+// Package reconciliation compares the balances kept in Postgres with the accounts held in
+// TigerBeetle and optionally repairs TigerBeetle from Postgres, which is the source of truth.
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+)
+
+// Discrepancy kinds.
+const (
+	KindMissingAccount        = "missing_account"
+	KindBalanceMismatch       = "balance_mismatch"
+	KindTransferCountMismatch = "transfer_count_mismatch"
+)
+
+// accountsBatch is the number of accounts looked up in TigerBeetle at a time.
+const accountsBatch = 500
+
+var (
+	ErrReconciliationFailed       = errors.New("reconciliation fail")
+	ErrReconciliationRepairUnsafe = errors.New("reconciliation repair refused while outbox messages are pending")
+)
+
+// Discrepancy is a difference between an account in Postgres and in TigerBeetle. Balance and
+// TransferCount are the Postgres side, LedgerBalance (credits posted minus debits posted) and
+// LedgerTransferCount the TigerBeetle side. Repaired reports whether a correction was queued.
+type Discrepancy struct {
+	AccountId           int          `json:"account_id"`
+	Currency            string       `json:"currency"`
+	Kind                string       `json:"kind"`
+	Balance             money.Amount `json:"balance"`
+	LedgerBalance       money.Amount `json:"ledger_balance"`
+	TransferCount       int          `json:"transfer_count"`
+	LedgerTransferCount int          `json:"ledger_transfer_count"`
+	Repaired            bool         `json:"repaired"`
+}
+
+// Report is the result of a reconciliation. Messages still pending in the outbox are not in
+// TigerBeetle yet, so discrepancies reported while PendingMessages is non-zero may be transient.
+type Report struct {
+	Accounts        int           `json:"accounts"`
+	PendingMessages int           `json:"pending_messages"`
+	Discrepancies   []Discrepancy `json:"discrepancies"`
+}
+
+// ReconciliationService compares Postgres with TigerBeetle.
+type ReconciliationService struct {
+	repo            ReconciliationRepo
+	outboxRepo      outbox.OutboxRepo
+	tigerbeetleRepo ReconciliationTBRepo
+}
+
+// NewReconciliationService creates a new ReconciliationService. Repairs are queued in outboxRepo.
+func NewReconciliationService(repo ReconciliationRepo, outboxRepo outbox.OutboxRepo, tigerbeetleRepo ReconciliationTBRepo) *ReconciliationService {
+	return &ReconciliationService{repo, outboxRepo, tigerbeetleRepo}
+}
+
+// Reconcile walks all accounts in Postgres and compares each one with TigerBeetle: whether it
+// exists, its balance and its number of transfers. With repair set, missing accounts are
+// created and balance differences corrected in TigerBeetle by queueing outbox messages;
+// transfer count differences can only be reported. Repairing is refused while the outbox still
+// has pending messages, since those would be applied on top of the corrections.
+func (svc *ReconciliationService) Reconcile(ctx context.Context, repair bool) (Report, error) {
+	stats, err := svc.outboxRepo.Stats(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrReconciliationFailed.Error(), "error", err)
+		return Report{}, ErrReconciliationFailed
+	}
+
+	if repair && stats.Pending > 0 {
+		slog.WarnContext(ctx, ErrReconciliationRepairUnsafe.Error(), "pending", stats.Pending)
+		return Report{}, ErrReconciliationRepairUnsafe
+	}
+
+	report := Report{PendingMessages: stats.Pending, Discrepancies: []Discrepancy{}}
+	afterAccountId := 0
+	for {
+		rows, err := svc.repo.Accounts(ctx, afterAccountId, accountsBatch)
+		if err != nil {
+			slog.ErrorContext(ctx, ErrReconciliationFailed.Error(), "error", err)
+			return Report{}, ErrReconciliationFailed
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		discrepancies, err := svc.compare(ctx, rows, repair)
+		if err != nil {
+			slog.ErrorContext(ctx, ErrReconciliationFailed.Error(), "error", err)
+			return Report{}, ErrReconciliationFailed
+		}
+
+		report.Accounts += len(rows)
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+		afterAccountId = rows[len(rows)-1].AccountId
+		if len(rows) < accountsBatch {
+			break
+		}
+	}
+
+	return report, nil
+}
+
+// compare returns the discrepancies of a batch of accounts, repairing them when asked to.
+func (svc *ReconciliationService) compare(ctx context.Context, rows []AccountRow, repair bool) ([]Discrepancy, error) {
+	accountIds := make([]int, len(rows))
+	for i, row := range rows {
+		accountIds[i] = row.AccountId
+	}
+
+	ledgerRows, err := svc.tigerbeetleRepo.LookupAccounts(ctx, accountIds)
+	if err != nil {
+		return nil, err
+	}
+	ledgerAccounts := make(map[int]LedgerAccountRow, len(ledgerRows))
+	for _, ledgerRow := range ledgerRows {
+		ledgerAccounts[ledgerRow.AccountId] = ledgerRow
+	}
+
+	var discrepancies []Discrepancy
+	for _, row := range rows {
+		d := Discrepancy{
+			AccountId:     row.AccountId,
+			Currency:      row.Currency,
+			Balance:       money.New(row.Balance, row.ScaleBalance),
+			LedgerBalance: money.New(0, row.ScaleBalance),
+			TransferCount: row.TransferCount,
+		}
+
+		ledgerRow, ok := ledgerAccounts[row.AccountId]
+		if !ok {
+			d.Kind = KindMissingAccount
+			if repair {
+				if err := svc.repairMissing(ctx, row); err != nil {
+					return nil, err
+				}
+				d.Repaired = true
+			}
+			discrepancies = append(discrepancies, d)
+			continue
+		}
+
+		ledgerBalance, err := ledgerRow.balance()
+		if err != nil {
+			return nil, err
+		}
+		d.LedgerBalance = money.New(ledgerBalance, row.ScaleBalance)
+
+		d.LedgerTransferCount, err = svc.tigerbeetleRepo.AccountTransferCount(ctx, row.AccountId)
+		if err != nil {
+			return nil, err
+		}
+
+		if ledgerBalance != row.Balance {
+			mismatch := d
+			mismatch.Kind = KindBalanceMismatch
+			if repair {
+				if err := svc.adjust(ctx, row, row.Balance-ledgerBalance); err != nil {
+					return nil, err
+				}
+				mismatch.Repaired = true
+			}
+			discrepancies = append(discrepancies, mismatch)
+		}
+
+		if d.LedgerTransferCount != row.TransferCount {
+			mismatch := d
+			mismatch.Kind = KindTransferCountMismatch
+			discrepancies = append(discrepancies, mismatch)
+		}
+	}
+
+	return discrepancies, nil
+}
+
+// repairMissing queues the creation of an account TigerBeetle does not know, with its whole
+// Postgres balance as a correction.
+func (svc *ReconciliationService) repairMissing(ctx context.Context, row AccountRow) error {
+	payload := outbox.AccountPayload{AccountId: row.AccountId, Currency: row.Currency, Overdraft: row.OverdraftEnabled}
+	if err := outbox.Enqueue(ctx, svc.outboxRepo, outbox.KindAccountCreate, payload); err != nil {
+		return err
+	}
+
+	return svc.adjust(ctx, row, row.Balance)
+}
+
+// adjust queues a correction moving the ledger balance of an account by amount.
+func (svc *ReconciliationService) adjust(ctx context.Context, row AccountRow, amount int64) error {
+	if amount == 0 {
+		return nil
+	}
+
+	payload := outbox.AdjustmentPayload{AccountId: row.AccountId, Currency: row.Currency, Amount: amount}
+	return outbox.Enqueue(ctx, svc.outboxRepo, outbox.KindAccountAdjust, payload)
+}
+
+// balance returns the credits posted minus the debits posted to the account.
+func (r LedgerAccountRow) balance() (int64, error) {
+	if (r.DebitsPosted < 0 && r.CreditsPosted > math.MaxInt64+r.DebitsPosted) || (r.DebitsPosted > 0 && r.CreditsPosted < math.MinInt64+r.DebitsPosted) {
+		return 0, fmt.Errorf("ledger balance of account %d overflows", r.AccountId)
+	}
+
+	return r.CreditsPosted - r.DebitsPosted, nil
+}
@@ -0,0 +1,216 @@
+package reconciliation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+)
+
+func TestReconciliationService_Reconcile(t *testing.T) {
+	accounts := []AccountRow{
+		{AccountId: 1, Balance: 10_000, ScaleBalance: 2, Currency: "USD", TransferCount: 3},
+		{AccountId: 2, Balance: 5_000, ScaleBalance: 2, Currency: "USD", TransferCount: 2},
+		{AccountId: 3, Balance: 700, ScaleBalance: 0, Currency: "JPY", TransferCount: 1},
+		{AccountId: 4, Balance: 2_500, ScaleBalance: 2, Currency: "EUR", TransferCount: 4},
+	}
+	ledger := &fakeTigerBeetle{
+		accounts: map[int]LedgerAccountRow{
+			1: {AccountId: 1, DebitsPosted: 2_000, CreditsPosted: 12_000},
+			2: {AccountId: 2, DebitsPosted: 0, CreditsPosted: 4_000},
+			4: {AccountId: 4, DebitsPosted: 0, CreditsPosted: 2_500},
+		},
+		transfers: map[int]int{1: 3, 2: 2, 4: 3},
+	}
+
+	tests := []struct {
+		name         string
+		pending      int
+		repair       bool
+		want         Report
+		wantMessages []message
+		wantErr      error
+	}{
+		{
+			name: "success - report only",
+			want: Report{
+				Accounts: 4,
+				Discrepancies: []Discrepancy{
+					{AccountId: 2, Currency: "USD", Kind: KindBalanceMismatch, Balance: money.New(5_000, 2), LedgerBalance: money.New(4_000, 2), TransferCount: 2, LedgerTransferCount: 2},
+					{AccountId: 3, Currency: "JPY", Kind: KindMissingAccount, Balance: money.New(700, 0), LedgerBalance: money.New(0, 0), TransferCount: 1},
+					{AccountId: 4, Currency: "EUR", Kind: KindTransferCountMismatch, Balance: money.New(2_500, 2), LedgerBalance: money.New(2_500, 2), TransferCount: 4, LedgerTransferCount: 3},
+				},
+			},
+		},
+		{
+			name:    "success - report while outbox pending",
+			pending: 2,
+			want: Report{
+				Accounts:        4,
+				PendingMessages: 2,
+				Discrepancies: []Discrepancy{
+					{AccountId: 2, Currency: "USD", Kind: KindBalanceMismatch, Balance: money.New(5_000, 2), LedgerBalance: money.New(4_000, 2), TransferCount: 2, LedgerTransferCount: 2},
+					{AccountId: 3, Currency: "JPY", Kind: KindMissingAccount, Balance: money.New(700, 0), LedgerBalance: money.New(0, 0), TransferCount: 1},
+					{AccountId: 4, Currency: "EUR", Kind: KindTransferCountMismatch, Balance: money.New(2_500, 2), LedgerBalance: money.New(2_500, 2), TransferCount: 4, LedgerTransferCount: 3},
+				},
+			},
+		},
+		{
+			name:   "success - repair",
+			repair: true,
+			want: Report{
+				Accounts: 4,
+				Discrepancies: []Discrepancy{
+					{AccountId: 2, Currency: "USD", Kind: KindBalanceMismatch, Balance: money.New(5_000, 2), LedgerBalance: money.New(4_000, 2), TransferCount: 2, LedgerTransferCount: 2, Repaired: true},
+					{AccountId: 3, Currency: "JPY", Kind: KindMissingAccount, Balance: money.New(700, 0), LedgerBalance: money.New(0, 0), TransferCount: 1, Repaired: true},
+					{AccountId: 4, Currency: "EUR", Kind: KindTransferCountMismatch, Balance: money.New(2_500, 2), LedgerBalance: money.New(2_500, 2), TransferCount: 4, LedgerTransferCount: 3},
+				},
+			},
+			wantMessages: []message{
+				{outbox.KindAccountAdjust, `{"account_id":2,"currency":"USD","amount":1000}`},
+				{outbox.KindAccountCreate, `{"account_id":3,"currency":"JPY","initial_balance":0}`},
+				{outbox.KindAccountAdjust, `{"account_id":3,"currency":"JPY","amount":700}`},
+			},
+		},
+		{
+			name:    "error - repair while outbox pending",
+			pending: 1,
+			repair:  true,
+			wantErr: ErrReconciliationRepairUnsafe,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outboxRepo := &memOutboxRepo{pending: tt.pending}
+			svc := NewReconciliationService(&memReconciliationRepo{accounts}, outboxRepo, ledger)
+
+			got, err := svc.Reconcile(t.Context(), tt.repair)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReconciliationService.Reconcile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReconciliationService.Reconcile() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(outboxRepo.messages, tt.wantMessages) {
+				t.Errorf("queued messages = %v, want %v", outboxRepo.messages, tt.wantMessages)
+			}
+		})
+	}
+}
+
+func TestReconciliationService_Reconcile_Batches(t *testing.T) {
+	ledger := &fakeTigerBeetle{accounts: map[int]LedgerAccountRow{}, transfers: map[int]int{}}
+	var accounts []AccountRow
+	for accountId := 1; accountId <= accountsBatch+20; accountId++ {
+		accounts = append(accounts, AccountRow{AccountId: accountId, Balance: 100, ScaleBalance: 2, Currency: "USD", TransferCount: 1})
+		ledger.accounts[accountId] = LedgerAccountRow{AccountId: accountId, CreditsPosted: 100}
+		ledger.transfers[accountId] = 1
+	}
+
+	svc := NewReconciliationService(&memReconciliationRepo{accounts}, &memOutboxRepo{}, ledger)
+	got, err := svc.Reconcile(t.Context(), false)
+	if err != nil {
+		t.Fatalf("ReconciliationService.Reconcile() error = %v", err)
+	}
+	if got.Accounts != accountsBatch+20 || len(got.Discrepancies) != 0 {
+		t.Errorf("ReconciliationService.Reconcile() = %d accounts, %d discrepancies", got.Accounts, len(got.Discrepancies))
+	}
+	if ledger.lookups != 2 {
+		t.Errorf("looked up accounts %d times, want 2", ledger.lookups)
+	}
+}
+
+func TestReconciliationService_Reconcile_LedgerFail(t *testing.T) {
+	accounts := []AccountRow{{AccountId: 1, Balance: 100, ScaleBalance: 2, Currency: "USD"}}
+	ledger := &fakeTigerBeetle{err: errors.New("connection refused")}
+
+	svc := NewReconciliationService(&memReconciliationRepo{accounts}, &memOutboxRepo{}, ledger)
+	if _, err := svc.Reconcile(t.Context(), false); !errors.Is(err, ErrReconciliationFailed) {
+		t.Errorf("ReconciliationService.Reconcile() error = %v, want %v", err, ErrReconciliationFailed)
+	}
+}
+
+// memReconciliationRepo serves accounts, which must be sorted by account ID.
+type memReconciliationRepo struct {
+	accounts []AccountRow
+}
+
+func (r *memReconciliationRepo) Accounts(ctx context.Context, afterAccountId int, limit int) ([]AccountRow, error) {
+	i := sort.Search(len(r.accounts), func(i int) bool { return r.accounts[i].AccountId > afterAccountId })
+	return r.accounts[i:min(i+limit, len(r.accounts))], nil
+}
+
+// fakeTigerBeetle is a TigerBeetle client holding accounts and per-account transfer counts in memory.
+type fakeTigerBeetle struct {
+	accounts  map[int]LedgerAccountRow
+	transfers map[int]int
+	err       error
+	lookups   int
+}
+
+func (f *fakeTigerBeetle) LookupAccounts(ctx context.Context, accountIds []int) ([]LedgerAccountRow, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.lookups++
+
+	var rows []LedgerAccountRow
+	for _, accountId := range accountIds {
+		if row, ok := f.accounts[accountId]; ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeTigerBeetle) AccountTransferCount(ctx context.Context, accountId int) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	return f.transfers[accountId], nil
+}
+
+type message struct {
+	Kind    string
+	Payload string
+}
+
+// memOutboxRepo records the messages queued by repairs.
+type memOutboxRepo struct {
+	pending  int
+	messages []message
+}
+
+func (r *memOutboxRepo) Create(ctx context.Context, params outbox.MessageCreateParams) error {
+	if !json.Valid(params.Payload) {
+		return errors.New("invalid payload")
+	}
+	r.messages = append(r.messages, message{params.Kind, string(params.Payload)})
+	return nil
+}
+
+func (r *memOutboxRepo) PendingForUpdate(ctx context.Context, now time.Time, limit int) ([]outbox.MessageRow, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *memOutboxRepo) MarkSent(ctx context.Context, messageIds []int) error {
+	return errors.New("not implemented")
+}
+
+func (r *memOutboxRepo) MarkFailed(ctx context.Context, params outbox.MessageFailParams) error {
+	return errors.New("not implemented")
+}
+
+func (r *memOutboxRepo) Stats(ctx context.Context) (outbox.StatsRow, error) {
+	return outbox.StatsRow{Pending: r.pending}, nil
+}
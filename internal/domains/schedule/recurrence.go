@@ -0,0 +1,245 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies of a schedule. Once runs at its start; daily and weekly repeat every day or week
+// from their start; monthly runs on DayOfMonth, or the last day of shorter months, at the time
+// of day of its start; cron runs on the minutes matching its Cron expression. Every time is UTC.
+const (
+	FrequencyOnce    = "once"
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyCron    = "cron"
+)
+
+// maxCronSearch bounds how far ahead the next minute matching a cron expression is looked for,
+// so expressions that never match, such as "0 0 31 2 *", fail instead of looping forever.
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// Recurrence tells when a schedule runs. DayOfMonth is only set for FrequencyMonthly and Cron,
+// a five-field expression of minute, hour, day of month, month and day of week, only for
+// FrequencyCron.
+type Recurrence struct {
+	Frequency  string `json:"frequency"`
+	DayOfMonth int    `json:"day_of_month,omitempty"`
+	Cron       string `json:"cron,omitempty"`
+}
+
+// validate checks that r is a known frequency with the fields it needs and nothing else.
+func (r Recurrence) validate() error {
+	switch r.Frequency {
+	case FrequencyOnce, FrequencyDaily, FrequencyWeekly:
+		if r.DayOfMonth != 0 || r.Cron != "" {
+			return fmt.Errorf("%s schedules set neither day_of_month nor cron", r.Frequency)
+		}
+	case FrequencyMonthly:
+		if r.DayOfMonth < 1 || r.DayOfMonth > 31 || r.Cron != "" {
+			return fmt.Errorf("monthly schedules set day_of_month between 1 and 31 and no cron")
+		}
+	case FrequencyCron:
+		if r.DayOfMonth != 0 {
+			return fmt.Errorf("cron schedules set no day_of_month")
+		}
+		if _, err := parseCron(r.Cron); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown frequency %q", r.Frequency)
+	}
+	return nil
+}
+
+// first returns the first time a schedule of r starting at start runs.
+func (r Recurrence) first(start time.Time) (time.Time, error) {
+	if r.Frequency == FrequencyOnce {
+		return start.UTC(), nil
+	}
+	next, _, err := r.next(start, start.Add(-time.Nanosecond))
+	return next, err
+}
+
+// next returns the first time after after that a schedule of r starting at start runs, and
+// false when it never runs again.
+func (r Recurrence) next(start, after time.Time) (time.Time, bool, error) {
+	start, after = start.UTC(), after.UTC()
+
+	switch r.Frequency {
+	case FrequencyOnce:
+		return time.Time{}, false, nil
+
+	case FrequencyDaily, FrequencyWeekly:
+		days := 1
+		if r.Frequency == FrequencyWeekly {
+			days = 7
+		}
+		if after.Before(start) {
+			return start, true, nil
+		}
+		periods := int(after.Sub(start)/(time.Duration(days)*24*time.Hour)) + 1
+		return start.AddDate(0, 0, periods*days), true, nil
+
+	case FrequencyMonthly:
+		year, month, _ := after.Date()
+		if after.Before(start) {
+			year, month, _ = start.Date()
+		}
+		for {
+			day := min(r.DayOfMonth, daysIn(year, month))
+			at := time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), time.UTC)
+			if at.After(after) && !at.Before(start) {
+				return at, true, nil
+			}
+			month++
+			if month > time.December {
+				year, month = year+1, time.January
+			}
+		}
+
+	case FrequencyCron:
+		expr, err := parseCron(r.Cron)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if after.Before(start) {
+			after = start.Add(-time.Nanosecond)
+		}
+		at, err := expr.next(after)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return at, true, nil
+	}
+
+	return time.Time{}, false, fmt.Errorf("unknown frequency %q", r.Frequency)
+}
+
+// daysIn returns the number of days of month in year.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// cronExpr is a parsed cron expression; each field is the set of values it matches.
+type cronExpr struct {
+	minutes, hours, days, months, weekdays [64]bool
+	anyDay, anyWeekday                     bool
+}
+
+// parseCron parses a five-field cron expression. Fields are "*", a value, a range "a-b", any of
+// those with a step "/n", or a comma separated list of them. Days of week run from 0 (Sunday) to
+// 6, and 7 is Sunday too.
+func parseCron(s string) (cronExpr, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return cronExpr{}, fmt.Errorf("cron %q needs 5 fields, got %d", s, len(fields))
+	}
+
+	var expr cronExpr
+	bounds := []struct {
+		set      *[64]bool
+		min, max int
+	}{
+		{&expr.minutes, 0, 59},
+		{&expr.hours, 0, 23},
+		{&expr.days, 1, 31},
+		{&expr.months, 1, 12},
+		{&expr.weekdays, 0, 7},
+	}
+	for i, field := range fields {
+		if err := parseCronField(field, bounds[i].set, bounds[i].min, bounds[i].max); err != nil {
+			return cronExpr{}, fmt.Errorf("cron %q: %w", s, err)
+		}
+	}
+	if expr.weekdays[7] {
+		expr.weekdays[0] = true
+	}
+	expr.anyDay, expr.anyWeekday = fields[2] == "*", fields[4] == "*"
+
+	return expr, nil
+}
+
+// parseCronField marks in set the values between min and max that field matches.
+func parseCronField(field string, set *[64]bool, min, max int) error {
+	for _, part := range strings.Split(field, ",") {
+		spec, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid step in %q", part)
+			}
+			spec, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		if spec != "*" {
+			var err error
+			from, to, isRange := strings.Cut(spec, "-")
+			if lo, err = strconv.Atoi(from); err != nil {
+				return fmt.Errorf("invalid value in %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return fmt.Errorf("invalid value in %q", part)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+// next returns the first minute after after that e matches. As in cron, when both the day of
+// month and the day of week are restricted a day matching either of them is enough.
+func (e cronExpr) next(after time.Time) (time.Time, error) {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronSearch)
+
+	for t.Before(limit) {
+		if !e.months[t.Month()] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !e.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !e.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !e.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("cron matches no time before %s", limit.Format(time.RFC3339))
+}
+
+func (e cronExpr) matchDay(t time.Time) bool {
+	day, weekday := e.days[t.Day()], e.weekdays[t.Weekday()]
+	switch {
+	case e.anyDay && e.anyWeekday:
+		return true
+	case e.anyDay:
+		return weekday
+	case e.anyWeekday:
+		return day
+	}
+	return day || weekday
+}
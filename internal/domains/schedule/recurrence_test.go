@@ -0,0 +1,146 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecurrence_Next(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	start := at("2025-01-31T09:30:00Z")
+
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		after      time.Time
+		want       time.Time
+		wantOk     bool
+	}{
+		{
+			name:       "once never repeats",
+			recurrence: Recurrence{Frequency: FrequencyOnce},
+			after:      start,
+		},
+		{
+			name:       "daily before start",
+			recurrence: Recurrence{Frequency: FrequencyDaily},
+			after:      at("2025-01-01T00:00:00Z"),
+			want:       start,
+			wantOk:     true,
+		},
+		{
+			name:       "daily on an occurrence",
+			recurrence: Recurrence{Frequency: FrequencyDaily},
+			after:      at("2025-02-02T09:30:00Z"),
+			want:       at("2025-02-03T09:30:00Z"),
+			wantOk:     true,
+		},
+		{
+			name:       "weekly between occurrences",
+			recurrence: Recurrence{Frequency: FrequencyWeekly},
+			after:      at("2025-02-03T00:00:00Z"),
+			want:       at("2025-02-07T09:30:00Z"),
+			wantOk:     true,
+		},
+		{
+			name:       "monthly clamped to the last day",
+			recurrence: Recurrence{Frequency: FrequencyMonthly, DayOfMonth: 31},
+			after:      start,
+			want:       at("2025-02-28T09:30:00Z"),
+			wantOk:     true,
+		},
+		{
+			name:       "monthly later in the month",
+			recurrence: Recurrence{Frequency: FrequencyMonthly, DayOfMonth: 15},
+			after:      at("2025-03-15T09:29:00Z"),
+			want:       at("2025-03-15T09:30:00Z"),
+			wantOk:     true,
+		},
+		{
+			name:       "monthly across the year",
+			recurrence: Recurrence{Frequency: FrequencyMonthly, DayOfMonth: 1},
+			after:      at("2025-12-01T10:00:00Z"),
+			want:       at("2026-01-01T09:30:00Z"),
+			wantOk:     true,
+		},
+		{
+			name:       "cron every 15 minutes",
+			recurrence: Recurrence{Frequency: FrequencyCron, Cron: "*/15 * * * *"},
+			after:      at("2025-02-01T10:07:00Z"),
+			want:       at("2025-02-01T10:15:00Z"),
+			wantOk:     true,
+		},
+		{
+			name:       "cron weekdays at nine",
+			recurrence: Recurrence{Frequency: FrequencyCron, Cron: "0 9 * * 1-5"},
+			after:      at("2025-02-01T10:00:00Z"),
+			want:       at("2025-02-03T09:00:00Z"),
+			wantOk:     true,
+		},
+		{
+			name:       "cron day of month or day of week",
+			recurrence: Recurrence{Frequency: FrequencyCron, Cron: "0 0 10 * 0"},
+			after:      at("2025-02-03T00:00:00Z"),
+			want:       at("2025-02-09T00:00:00Z"),
+			wantOk:     true,
+		},
+		{
+			name:       "cron list of months",
+			recurrence: Recurrence{Frequency: FrequencyCron, Cron: "30 12 1 1,7 *"},
+			after:      at("2025-02-01T00:00:00Z"),
+			want:       at("2025-07-01T12:30:00Z"),
+			wantOk:     true,
+		},
+		{
+			name:       "cron not before start",
+			recurrence: Recurrence{Frequency: FrequencyCron, Cron: "0 * * * *"},
+			after:      at("2025-01-01T00:00:00Z"),
+			want:       at("2025-01-31T10:00:00Z"),
+			wantOk:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := tt.recurrence.next(start, tt.after)
+			if err != nil {
+				t.Fatalf("Recurrence.next() error = %v", err)
+			}
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("Recurrence.next() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestRecurrence_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		wantErr    bool
+	}{
+		{name: "once", recurrence: Recurrence{Frequency: FrequencyOnce}},
+		{name: "monthly", recurrence: Recurrence{Frequency: FrequencyMonthly, DayOfMonth: 31}},
+		{name: "cron", recurrence: Recurrence{Frequency: FrequencyCron, Cron: "0-30/10 8,20 * 1-12 7"}},
+		{name: "unknown frequency", recurrence: Recurrence{Frequency: "hourly"}, wantErr: true},
+		{name: "daily with day of month", recurrence: Recurrence{Frequency: FrequencyDaily, DayOfMonth: 1}, wantErr: true},
+		{name: "monthly without day of month", recurrence: Recurrence{Frequency: FrequencyMonthly}, wantErr: true},
+		{name: "monthly day out of range", recurrence: Recurrence{Frequency: FrequencyMonthly, DayOfMonth: 32}, wantErr: true},
+		{name: "cron missing fields", recurrence: Recurrence{Frequency: FrequencyCron, Cron: "0 9 * *"}, wantErr: true},
+		{name: "cron value out of range", recurrence: Recurrence{Frequency: FrequencyCron, Cron: "60 9 * * *"}, wantErr: true},
+		{name: "cron step invalid", recurrence: Recurrence{Frequency: FrequencyCron, Cron: "*/0 9 * * *"}, wantErr: true},
+		{name: "cron range reversed", recurrence: Recurrence{Frequency: FrequencyCron, Cron: "0 9 * * 5-1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.recurrence.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Recurrence.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+)
+
+// ScheduleRepo defines the interface for transfer schedule persistence and for the log of the
+// runs of every schedule.
+type ScheduleRepo interface {
+	Create(ctx context.Context, params ScheduleCreateParams) (ScheduleRow, error)
+	ById(ctx context.Context, scheduleId int) (ScheduleRow, error)
+	ByIdForUpdate(ctx context.Context, scheduleId int) (ScheduleRow, error)
+	List(ctx context.Context, params ScheduleListParams) ([]ScheduleRow, error)
+	DueForUpdate(ctx context.Context, now time.Time, limit int) ([]ScheduleRow, error)
+	Update(ctx context.Context, params ScheduleUpdateParams) (ScheduleRow, error)
+	CreateRun(ctx context.Context, params RunCreateParams) (RunRow, error)
+	Runs(ctx context.Context, scheduleId int) ([]RunRow, error)
+}
+
+// TransferCreator executes the transfers of the runs of a schedule. Create must join the database
+// transaction carried by ctx so a run and its transfer commit or roll back together.
+type TransferCreator interface {
+	Create(ctx context.Context, data transaction.TransactionCreate) (transaction.Transaction, error)
+}
+
+// UnitOfWork groups repository calls so they commit or roll back together.
+// Repository calls made with the context passed to fn take part in the same
+// database transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// ScheduleCreateParams holds the parameters required to create a transfer schedule. Amount is in
+// the currency of the source and DestinationAmount, set only for cross-currency schedules, in the
+// currency of the destination. NextAttemptAt is the first time the schedule runs.
+type ScheduleCreateParams struct {
+	SourceAccountId        int
+	DestinationAccountId   int
+	Amount                 int64
+	AmountScale            int
+	DestinationAmount      *int64
+	DestinationAmountScale *int
+	Frequency              string
+	DayOfMonth             *int
+	Cron                   *string
+	StartAt                time.Time
+	Status                 string
+	NextAttemptAt          time.Time
+	RetryMax               int
+	RetryIntervalSeconds   int
+}
+
+// ScheduleRow represents a row in the transfer_schedules table. RunAt is the occurrence the next
+// run executes and NextAttemptAt when it is due, later than RunAt while a run is retried; both
+// are nil once the schedule is cancelled or completed. Attempt counts the retries of RunAt.
+type ScheduleRow struct {
+	ScheduleId             int        `db:"schedule_id"`
+	SourceAccountId        int        `db:"source_account_id"`
+	DestinationAccountId   int        `db:"destination_account_id"`
+	Amount                 int64      `db:"amount"`
+	AmountScale            int        `db:"scale_amount"`
+	DestinationAmount      *int64     `db:"destination_amount"`
+	DestinationAmountScale *int       `db:"scale_destination_amount"`
+	Frequency              string     `db:"frequency"`
+	DayOfMonth             *int       `db:"day_of_month"`
+	Cron                   *string    `db:"cron"`
+	StartAt                time.Time  `db:"start_at"`
+	Status                 string     `db:"status"`
+	RunAt                  *time.Time `db:"run_at"`
+	NextAttemptAt          *time.Time `db:"next_attempt_at"`
+	Attempt                int        `db:"attempt"`
+	RetryMax               int        `db:"retry_max"`
+	RetryIntervalSeconds   int        `db:"retry_interval_seconds"`
+	CreatedAt              time.Time  `db:"created_at"`
+	UpdatedAt              time.Time  `db:"updated_at"`
+}
+
+// ScheduleListParams holds the filters used to list transfer schedules. Zero values match every
+// schedule; AccountId matches schedules it is the source or the destination of.
+type ScheduleListParams struct {
+	AccountId int
+	Status    string
+}
+
+// ScheduleUpdateParams holds the new state of a transfer schedule.
+type ScheduleUpdateParams struct {
+	ScheduleId    int
+	Status        string
+	RunAt         *time.Time
+	NextAttemptAt *time.Time
+	Attempt       int
+}
+
+// RunCreateParams holds the parameters required to record a run of a transfer schedule.
+// TransactionId is set when the run created a transfer, ErrorCode and Error when it failed.
+type RunCreateParams struct {
+	ScheduleId    int
+	RunAt         time.Time
+	Attempt       int
+	Status        string
+	TransactionId *int
+	ErrorCode     *string
+	Error         *string
+}
+
+// RunRow represents a row in the transfer_schedule_runs table.
+type RunRow struct {
+	RunId         int       `db:"run_id"`
+	ScheduleId    int       `db:"schedule_id"`
+	RunAt         time.Time `db:"run_at"`
+	Attempt       int       `db:"attempt"`
+	Status        string    `db:"status"`
+	TransactionId *int      `db:"transaction_id"`
+	ErrorCode     *string   `db:"error_code"`
+	Error         *string   `db:"error"`
+	CreatedAt     time.Time `db:"created_at"`
+}
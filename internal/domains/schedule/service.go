@@ -0,0 +1,514 @@
+// Package schedule provides business logic for scheduled transfers: transfers executed once at a
+// future time or on a recurrence, and the log of every run that executed them.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+)
+
+// ScheduleService manages transfer schedules and runs the ones that are due.
+type ScheduleService struct {
+	repo        ScheduleRepo
+	accountRepo account.AccountRepo
+	uow         UnitOfWork
+	transfers   TransferCreator
+	now         func() time.Time
+}
+
+// Schedule statuses. Only active schedules run. Once schedules are completed after their run and
+// cancelled schedules never run again.
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+	StatusCompleted = "completed"
+)
+
+// Run statuses. A retrying run failed for lack of funds and is attempted again after the retry
+// interval of its schedule.
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+	RunRetrying  = "retrying"
+)
+
+// runDueBatch caps how many schedules a single RunDue call executes.
+const runDueBatch = 100
+
+// runFailedDelay is how long a schedule whose transfer failed for reasons of its own waits
+// before it is attempted again, so it does not hold up the schedules due after it.
+const runFailedDelay = time.Minute
+
+// errRunTransferFailed wraps the error of a transfer that failed for reasons of its own, rolling
+// its run back.
+var errRunTransferFailed = errors.New("schedule transfer fail")
+
+var (
+	ErrScheduleCreateFailed          = errors.New("schedule creation fail")
+	ErrScheduleUpdateFailed          = errors.New("schedule update fail")
+	ErrScheduleListFailed            = errors.New("schedule list fail")
+	ErrScheduleRunFailed             = errors.New("schedule run fail")
+	ErrScheduleNotFound              = errors.New("schedule not found")
+	ErrScheduleInvalid               = errors.New("schedule invalid")
+	ErrScheduleAmountInvalid         = errors.New("schedule amount invalid")
+	ErrScheduleAccountNotFound       = errors.New("schedule account not found")
+	ErrScheduleAccountForbidden      = errors.New("schedule account forbidden")
+	ErrScheduleSourceDestinationSame = errors.New("schedule source and destination account can not be the same")
+	ErrScheduleStatusInvalid         = errors.New("schedule status invalid")
+	ErrScheduleFilterInvalid         = errors.New("schedule filter invalid")
+)
+
+// ScheduleCreate represents a request to schedule a transfer. Amount and DestinationAmount are as
+// in transaction.TransactionCreate. StartAt anchors the recurrence and defaults to now; the first
+// run is its first occurrence from now on, or now for a once schedule starting in the past. Runs
+// failing for lack of funds are retried up to RetryMax times, RetryIntervalSeconds apart.
+type ScheduleCreate struct {
+	SourceAccountId      int           `json:"source_account_id"`
+	DestinationAccountId int           `json:"destination_account_id"`
+	Amount               money.Amount  `json:"amount"`
+	DestinationAmount    *money.Amount `json:"destination_amount,omitempty"`
+	Recurrence
+	StartAt              time.Time `json:"start_at"`
+	RetryMax             int       `json:"retry_max,omitempty"`
+	RetryIntervalSeconds int       `json:"retry_interval_seconds,omitempty"`
+}
+
+// ScheduleFilter selects the schedules to list. Zero values match every schedule; AccountId
+// matches schedules it is the source or the destination of.
+type ScheduleFilter struct {
+	AccountId int
+	Status    string
+}
+
+// Schedule represents a scheduled transfer. NextRunAt is the occurrence the next run executes and
+// NextAttemptAt when that run is due, later than NextRunAt while it is retried; Attempt counts the
+// retries so far. Both are left out once the schedule is cancelled or completed.
+type Schedule struct {
+	ScheduleId           int           `json:"schedule_id"`
+	SourceAccountId      int           `json:"source_account_id"`
+	DestinationAccountId int           `json:"destination_account_id"`
+	Amount               money.Amount  `json:"amount"`
+	DestinationAmount    *money.Amount `json:"destination_amount,omitempty"`
+	Recurrence
+	StartAt              time.Time  `json:"start_at"`
+	Status               string     `json:"status"`
+	NextRunAt            *time.Time `json:"next_run_at,omitempty"`
+	NextAttemptAt        *time.Time `json:"next_attempt_at,omitempty"`
+	Attempt              int        `json:"attempt"`
+	RetryMax             int        `json:"retry_max"`
+	RetryIntervalSeconds int        `json:"retry_interval_seconds"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// Run is the outcome of one attempt at an occurrence of a schedule. Succeeded runs carry the
+// transaction they created; failed and retrying runs carry the code and message of the error, the
+// codes being those of transaction.ErrorCode.
+type Run struct {
+	RunId         int       `json:"run_id"`
+	ScheduleId    int       `json:"schedule_id"`
+	RunAt         time.Time `json:"run_at"`
+	Attempt       int       `json:"attempt"`
+	Status        string    `json:"status"`
+	TransactionId *int      `json:"transaction_id,omitempty"`
+	ErrorCode     *string   `json:"error_code,omitempty"`
+	Error         *string   `json:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// NewScheduleService creates a new ScheduleService with the given dependency. Runs execute their
+// transfers through transfers.
+func NewScheduleService(repo ScheduleRepo, accountRepo account.AccountRepo, uow UnitOfWork, transfers TransferCreator) *ScheduleService {
+	return &ScheduleService{repo, accountRepo, uow, transfers, time.Now}
+}
+
+// Create validates and stores a transfer schedule. Balances are not checked until it runs.
+func (svc *ScheduleService) Create(ctx context.Context, data ScheduleCreate) (Schedule, error) {
+	if err := data.Recurrence.validate(); err != nil {
+		slog.WarnContext(ctx, ErrScheduleInvalid.Error(), "error", err)
+		return Schedule{}, ErrScheduleInvalid
+	}
+
+	if data.RetryMax < 0 || data.RetryIntervalSeconds < 0 || (data.RetryMax > 0) != (data.RetryIntervalSeconds > 0) {
+		slog.WarnContext(ctx, ErrScheduleInvalid.Error(), "reason", "retries set both a positive retry_max and retry_interval_seconds or neither")
+		return Schedule{}, ErrScheduleInvalid
+	}
+
+	if data.Amount.Sign() <= 0 || (data.DestinationAmount != nil && data.DestinationAmount.Sign() <= 0) {
+		slog.WarnContext(ctx, ErrScheduleAmountInvalid.Error(), "amount", data.Amount)
+		return Schedule{}, ErrScheduleAmountInvalid
+	}
+
+	if data.SourceAccountId == data.DestinationAccountId {
+		slog.WarnContext(ctx, ErrScheduleSourceDestinationSame.Error(), "account_id", data.SourceAccountId)
+		return Schedule{}, ErrScheduleSourceDestinationSame
+	}
+
+	if !auth.CanActOn(ctx, data.SourceAccountId) {
+		slog.WarnContext(ctx, ErrScheduleAccountForbidden.Error(), "account_id", data.SourceAccountId)
+		return Schedule{}, ErrScheduleAccountForbidden
+	}
+
+	for _, accountId := range []int{data.SourceAccountId, data.DestinationAccountId} {
+		if _, err := svc.accountRepo.ById(ctx, accountId); err != nil {
+			slog.WarnContext(ctx, ErrScheduleAccountNotFound.Error(), "account_id", accountId, "error", err)
+			return Schedule{}, ErrScheduleAccountNotFound
+		}
+	}
+
+	now := svc.now().UTC()
+	if data.StartAt.IsZero() {
+		data.StartAt = now
+	}
+	runAt, err := firstRun(data.Recurrence, data.StartAt.UTC(), now)
+	if err != nil {
+		slog.WarnContext(ctx, ErrScheduleInvalid.Error(), "error", err)
+		return Schedule{}, ErrScheduleInvalid
+	}
+
+	params := ScheduleCreateParams{
+		SourceAccountId:      data.SourceAccountId,
+		DestinationAccountId: data.DestinationAccountId,
+		Amount:               data.Amount.Value(),
+		AmountScale:          data.Amount.Scale(),
+		Frequency:            data.Frequency,
+		StartAt:              data.StartAt.UTC(),
+		Status:               StatusActive,
+		NextAttemptAt:        runAt,
+		RetryMax:             data.RetryMax,
+		RetryIntervalSeconds: data.RetryIntervalSeconds,
+	}
+	if data.DestinationAmount != nil {
+		value, scale := data.DestinationAmount.Value(), data.DestinationAmount.Scale()
+		params.DestinationAmount, params.DestinationAmountScale = &value, &scale
+	}
+	if data.DayOfMonth != 0 {
+		params.DayOfMonth = &data.DayOfMonth
+	}
+	if data.Cron != "" {
+		params.Cron = &data.Cron
+	}
+
+	row, err := svc.repo.Create(ctx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrScheduleCreateFailed.Error(), "error", err)
+		return Schedule{}, ErrScheduleCreateFailed
+	}
+
+	return toSchedule(row), nil
+}
+
+// ById retrieves a transfer schedule by its ID.
+func (svc *ScheduleService) ById(ctx context.Context, scheduleId int) (Schedule, error) {
+	row, err := svc.repo.ById(ctx, scheduleId)
+	if err != nil {
+		slog.WarnContext(ctx, ErrScheduleNotFound.Error(), "error", err)
+		return Schedule{}, ErrScheduleNotFound
+	}
+
+	return toSchedule(row), nil
+}
+
+// List returns the transfer schedules matching filter, oldest first.
+func (svc *ScheduleService) List(ctx context.Context, filter ScheduleFilter) ([]Schedule, error) {
+	if filter.Status != "" && !slices.Contains([]string{StatusActive, StatusPaused, StatusCancelled, StatusCompleted}, filter.Status) {
+		slog.WarnContext(ctx, ErrScheduleFilterInvalid.Error(), "status", filter.Status)
+		return nil, ErrScheduleFilterInvalid
+	}
+
+	rows, err := svc.repo.List(ctx, ScheduleListParams(filter))
+	if err != nil {
+		slog.ErrorContext(ctx, ErrScheduleListFailed.Error(), "error", err)
+		return nil, ErrScheduleListFailed
+	}
+
+	data := make([]Schedule, len(rows))
+	for i, row := range rows {
+		data[i] = toSchedule(row)
+	}
+
+	return data, nil
+}
+
+// Runs returns the runs of a transfer schedule, oldest first.
+func (svc *ScheduleService) Runs(ctx context.Context, scheduleId int) ([]Run, error) {
+	if _, err := svc.repo.ById(ctx, scheduleId); err != nil {
+		slog.WarnContext(ctx, ErrScheduleNotFound.Error(), "error", err)
+		return nil, ErrScheduleNotFound
+	}
+
+	rows, err := svc.repo.Runs(ctx, scheduleId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrScheduleListFailed.Error(), "error", err)
+		return nil, ErrScheduleListFailed
+	}
+
+	data := make([]Run, len(rows))
+	for i, row := range rows {
+		data[i] = toRun(row)
+	}
+
+	return data, nil
+}
+
+// Pause stops an active schedule from running until it is resumed.
+func (svc *ScheduleService) Pause(ctx context.Context, scheduleId int) (Schedule, error) {
+	return svc.transition(ctx, scheduleId, []string{StatusActive}, func(row ScheduleRow) (ScheduleUpdateParams, error) {
+		return ScheduleUpdateParams{ScheduleId: row.ScheduleId, Status: StatusPaused, RunAt: row.RunAt, NextAttemptAt: row.NextAttemptAt, Attempt: row.Attempt}, nil
+	})
+}
+
+// Resume makes a paused schedule active again. Occurrences missed while it was paused are skipped:
+// a recurring schedule next runs at its first occurrence from now on and a once schedule runs now.
+// Retries of a failed run are not resumed.
+func (svc *ScheduleService) Resume(ctx context.Context, scheduleId int) (Schedule, error) {
+	return svc.transition(ctx, scheduleId, []string{StatusPaused}, func(row ScheduleRow) (ScheduleUpdateParams, error) {
+		now := svc.now().UTC()
+		runAt := *row.RunAt
+		if runAt.Before(now) {
+			var err error
+			if runAt, err = firstRun(recurrence(row), row.StartAt, now); err != nil {
+				return ScheduleUpdateParams{}, err
+			}
+		}
+		return ScheduleUpdateParams{ScheduleId: row.ScheduleId, Status: StatusActive, RunAt: &runAt, NextAttemptAt: &runAt}, nil
+	})
+}
+
+// Cancel stops an active or paused schedule for good.
+func (svc *ScheduleService) Cancel(ctx context.Context, scheduleId int) (Schedule, error) {
+	return svc.transition(ctx, scheduleId, []string{StatusActive, StatusPaused}, func(row ScheduleRow) (ScheduleUpdateParams, error) {
+		return ScheduleUpdateParams{ScheduleId: row.ScheduleId, Status: StatusCancelled}, nil
+	})
+}
+
+// transition locks a schedule and, when it is in one of from, stores the update returned by fn.
+func (svc *ScheduleService) transition(ctx context.Context, scheduleId int, from []string, fn func(row ScheduleRow) (ScheduleUpdateParams, error)) (Schedule, error) {
+	var row ScheduleRow
+	var transitionErr error
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		current, err := svc.repo.ByIdForUpdate(ctx, scheduleId)
+		if err != nil {
+			slog.WarnContext(ctx, ErrScheduleNotFound.Error(), "error", err)
+			transitionErr = ErrScheduleNotFound
+			return transitionErr
+		}
+
+		if !slices.Contains(from, current.Status) {
+			slog.WarnContext(ctx, ErrScheduleStatusInvalid.Error(), "schedule_id", scheduleId, "status", current.Status)
+			transitionErr = ErrScheduleStatusInvalid
+			return transitionErr
+		}
+
+		params, err := fn(current)
+		if err != nil {
+			return err
+		}
+
+		row, err = svc.repo.Update(ctx, params)
+		return err
+	})
+	if transitionErr != nil {
+		return Schedule{}, transitionErr
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, ErrScheduleUpdateFailed.Error(), "error", err)
+		return Schedule{}, ErrScheduleUpdateFailed
+	}
+
+	return toSchedule(row), nil
+}
+
+// RunDue executes the schedules that are due and returns how many it ran. Every schedule runs in
+// its own database transaction, locked with SKIP LOCKED so several servers can run schedules at
+// once without running any twice. A run whose transfer fails is recorded as failed, or retrying
+// when it lacked funds and has retries left; a transfer failing for reasons of its own rolls the
+// run back and the schedule is attempted again after runFailedDelay.
+func (svc *ScheduleService) RunDue(ctx context.Context) (int, error) {
+	count := 0
+	for range runDueBatch {
+		var due *ScheduleRow
+		err := svc.uow.Do(ctx, func(ctx context.Context) error {
+			rows, err := svc.repo.DueForUpdate(ctx, svc.now(), 1)
+			if err != nil || len(rows) == 0 {
+				return err
+			}
+
+			due = &rows[0]
+			return svc.run(ctx, rows[0])
+		})
+		if errors.Is(err, errRunTransferFailed) {
+			slog.ErrorContext(ctx, ErrScheduleRunFailed.Error(), "schedule_id", due.ScheduleId, "error", err)
+			if err := svc.postpone(ctx, *due); err != nil {
+				slog.ErrorContext(ctx, ErrScheduleRunFailed.Error(), "schedule_id", due.ScheduleId, "error", err)
+				return count, ErrScheduleRunFailed
+			}
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, ErrScheduleRunFailed.Error(), "error", err)
+			return count, ErrScheduleRunFailed
+		}
+		if due == nil {
+			break
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// postpone moves the next attempt of row, whose run was rolled back, runFailedDelay from now.
+// A schedule changed since row was read is left as it is.
+func (svc *ScheduleService) postpone(ctx context.Context, row ScheduleRow) error {
+	return svc.uow.Do(ctx, func(ctx context.Context) error {
+		current, err := svc.repo.ByIdForUpdate(ctx, row.ScheduleId)
+		if err != nil {
+			return err
+		}
+		if current.Status != StatusActive || current.NextAttemptAt == nil || !current.NextAttemptAt.Equal(*row.NextAttemptAt) {
+			return nil
+		}
+
+		nextAttemptAt := svc.now().Add(runFailedDelay)
+		_, err = svc.repo.Update(ctx, ScheduleUpdateParams{
+			ScheduleId:    current.ScheduleId,
+			Status:        current.Status,
+			RunAt:         current.RunAt,
+			NextAttemptAt: &nextAttemptAt,
+			Attempt:       current.Attempt,
+		})
+		return err
+	})
+}
+
+// run executes the transfer of a due schedule, records the run and moves the schedule on to its
+// next attempt. It must run inside svc.uow so the transfer, the run and the schedule commit or
+// roll back together.
+func (svc *ScheduleService) run(ctx context.Context, row ScheduleRow) error {
+	now := svc.now().UTC()
+
+	data := transaction.TransactionCreate{
+		SourceAccountId:      row.SourceAccountId,
+		DestinationAccountId: row.DestinationAccountId,
+		Amount:               money.New(row.Amount, row.AmountScale),
+	}
+	if row.DestinationAmount != nil {
+		destinationAmount := money.New(*row.DestinationAmount, *row.DestinationAmountScale)
+		data.DestinationAmount = &destinationAmount
+	}
+
+	tx, transferErr := svc.transfers.Create(ctx, data)
+	var code string
+	if transferErr != nil {
+		if code = transaction.ErrorCode(transferErr); code == transaction.ErrorCodeCreateFailed {
+			return fmt.Errorf("%w: %w", errRunTransferFailed, transferErr)
+		}
+	}
+
+	runParams := RunCreateParams{ScheduleId: row.ScheduleId, RunAt: *row.RunAt, Attempt: row.Attempt, Status: RunSucceeded}
+	update := ScheduleUpdateParams{ScheduleId: row.ScheduleId, Status: row.Status}
+
+	switch {
+	case transferErr == nil:
+		runParams.TransactionId = &tx.TransactionId
+		svc.advance(row, now, &update)
+	case errors.Is(transferErr, transaction.ErrTransactionSourceBalanceNotEnough) && row.Attempt < row.RetryMax:
+		message := transferErr.Error()
+		runParams.Status, runParams.ErrorCode, runParams.Error = RunRetrying, &code, &message
+		nextAttemptAt := now.Add(time.Duration(row.RetryIntervalSeconds) * time.Second)
+		update.RunAt, update.NextAttemptAt, update.Attempt = row.RunAt, &nextAttemptAt, row.Attempt+1
+	default:
+		message := transferErr.Error()
+		runParams.Status, runParams.ErrorCode, runParams.Error = RunFailed, &code, &message
+		svc.advance(row, now, &update)
+	}
+
+	if _, err := svc.repo.CreateRun(ctx, runParams); err != nil {
+		return err
+	}
+	_, err := svc.repo.Update(ctx, update)
+	return err
+}
+
+// advance sets update to the next occurrence of row after its current one, skipping occurrences
+// already past at now, or completes row when it has none left.
+func (svc *ScheduleService) advance(row ScheduleRow, now time.Time, update *ScheduleUpdateParams) {
+	r := recurrence(row)
+	next, ok, err := r.next(row.StartAt, *row.RunAt)
+	if err == nil && ok && !next.After(now) {
+		next, ok, err = r.next(row.StartAt, now)
+	}
+	if err != nil {
+		slog.Error("schedule next run", "schedule_id", row.ScheduleId, "error", err)
+	}
+	if err != nil || !ok {
+		update.Status = StatusCompleted
+		return
+	}
+	update.RunAt, update.NextAttemptAt = &next, &next
+}
+
+// firstRun returns the first occurrence of r anchored at start that is not before now. A once
+// schedule starting in the past runs at now.
+func firstRun(r Recurrence, start, now time.Time) (time.Time, error) {
+	at, err := r.first(start)
+	if err != nil || !at.Before(now) {
+		return at, err
+	}
+	if r.Frequency == FrequencyOnce {
+		return now, nil
+	}
+	at, _, err = r.next(start, now.Add(-time.Nanosecond))
+	return at, err
+}
+
+// recurrence returns the recurrence stored in row.
+func recurrence(row ScheduleRow) Recurrence {
+	r := Recurrence{Frequency: row.Frequency}
+	if row.DayOfMonth != nil {
+		r.DayOfMonth = *row.DayOfMonth
+	}
+	if row.Cron != nil {
+		r.Cron = *row.Cron
+	}
+	return r
+}
+
+func toSchedule(row ScheduleRow) Schedule {
+	s := Schedule{
+		ScheduleId:           row.ScheduleId,
+		SourceAccountId:      row.SourceAccountId,
+		DestinationAccountId: row.DestinationAccountId,
+		Amount:               money.New(row.Amount, row.AmountScale),
+		Recurrence:           recurrence(row),
+		StartAt:              row.StartAt,
+		Status:               row.Status,
+		NextRunAt:            row.RunAt,
+		NextAttemptAt:        row.NextAttemptAt,
+		Attempt:              row.Attempt,
+		RetryMax:             row.RetryMax,
+		RetryIntervalSeconds: row.RetryIntervalSeconds,
+		CreatedAt:            row.CreatedAt,
+		UpdatedAt:            row.UpdatedAt,
+	}
+	if row.DestinationAmount != nil {
+		destinationAmount := money.New(*row.DestinationAmount, *row.DestinationAmountScale)
+		s.DestinationAmount = &destinationAmount
+	}
+	return s
+}
+
+func toRun(row RunRow) Run {
+	return Run(row)
+}
@@ -0,0 +1,490 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+)
+
+func TestScheduleService_Create(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		data          ScheduleCreate
+		wantNextRunAt time.Time
+		wantErr       error
+	}{
+		{
+			name:          "success - once in the future",
+			data:          ScheduleCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(1_000, 2), Recurrence: Recurrence{Frequency: FrequencyOnce}, StartAt: now.Add(time.Hour)},
+			wantNextRunAt: now.Add(time.Hour),
+		},
+		{
+			name:          "success - once defaults to now",
+			data:          ScheduleCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(1_000, 2), Recurrence: Recurrence{Frequency: FrequencyOnce}},
+			wantNextRunAt: now,
+		},
+		{
+			name:          "success - monthly started in the past",
+			data:          ScheduleCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(1_000, 2), Recurrence: Recurrence{Frequency: FrequencyMonthly, DayOfMonth: 1}, StartAt: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)},
+			wantNextRunAt: time.Date(2025, 2, 1, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "success - cron with retries",
+			data:          ScheduleCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(1_000, 2), Recurrence: Recurrence{Frequency: FrequencyCron, Cron: "0 9 * * 1"}, RetryMax: 3, RetryIntervalSeconds: 3600},
+			wantNextRunAt: time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "error - frequency invalid",
+			data:    ScheduleCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(1_000, 2), Recurrence: Recurrence{Frequency: "hourly"}},
+			wantErr: ErrScheduleInvalid,
+		},
+		{
+			name:    "error - retries without interval",
+			data:    ScheduleCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(1_000, 2), Recurrence: Recurrence{Frequency: FrequencyDaily}, RetryMax: 3},
+			wantErr: ErrScheduleInvalid,
+		},
+		{
+			name:    "error - amount not positive",
+			data:    ScheduleCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(0, 2), Recurrence: Recurrence{Frequency: FrequencyDaily}},
+			wantErr: ErrScheduleAmountInvalid,
+		},
+		{
+			name:    "error - same account",
+			data:    ScheduleCreate{SourceAccountId: 1, DestinationAccountId: 1, Amount: money.New(1_000, 2), Recurrence: Recurrence{Frequency: FrequencyDaily}},
+			wantErr: ErrScheduleSourceDestinationSame,
+		},
+		{
+			name:    "error - account not found",
+			data:    ScheduleCreate{SourceAccountId: 1, DestinationAccountId: 3, Amount: money.New(1_000, 2), Recurrence: Recurrence{Frequency: FrequencyDaily}},
+			wantErr: ErrScheduleAccountNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := &fakeAccountRepo{
+				ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
+					if accountId > 2 {
+						return account.AccountRow{}, fmt.Errorf("sql: no rows in result set")
+					}
+					return account.AccountRow{AccountId: accountId}, nil
+				},
+			}
+			repo := &fakeScheduleRepo{
+				CreateFunc: func(ctx context.Context, params ScheduleCreateParams) (ScheduleRow, error) {
+					return ScheduleRow{ScheduleId: 1, Frequency: params.Frequency, StartAt: params.StartAt, Status: params.Status, RunAt: &params.NextAttemptAt, NextAttemptAt: &params.NextAttemptAt}, nil
+				},
+			}
+
+			svc := NewScheduleService(repo, accountRepo, &fakeUnitOfWork{}, nil)
+			svc.now = func() time.Time { return now }
+			got, err := svc.Create(t.Context(), tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("ScheduleService.Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Status != StatusActive || got.NextRunAt == nil || !got.NextRunAt.Equal(tt.wantNextRunAt) {
+				t.Errorf("ScheduleService.Create() = %+v, want active with next run at %s", got, tt.wantNextRunAt)
+			}
+		})
+	}
+}
+
+func TestScheduleService_RunDue(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	runAt := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	retriedAt := time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		schedule        ScheduleRow
+		transferErr     error
+		wantRun         RunCreateParams
+		wantErrorCode   string
+		wantUpdate      ScheduleUpdateParams
+		wantNextAttempt time.Time
+		wantErr         error
+	}{
+		{
+			name:            "success - daily advances",
+			schedule:        ScheduleRow{ScheduleId: 1, Frequency: FrequencyDaily, StartAt: runAt.AddDate(0, 0, -7), Status: StatusActive, RunAt: &runAt, NextAttemptAt: &runAt},
+			wantRun:         RunCreateParams{ScheduleId: 1, RunAt: runAt, Status: RunSucceeded},
+			wantUpdate:      ScheduleUpdateParams{ScheduleId: 1, Status: StatusActive},
+			wantNextAttempt: runAt.AddDate(0, 0, 1),
+		},
+		{
+			name:            "success - missed occurrences skipped",
+			schedule:        ScheduleRow{ScheduleId: 1, Frequency: FrequencyDaily, StartAt: runAt.AddDate(0, 0, -7), Status: StatusActive, RunAt: ptr(runAt.AddDate(0, 0, -3)), NextAttemptAt: ptr(runAt.AddDate(0, 0, -3))},
+			wantRun:         RunCreateParams{ScheduleId: 1, RunAt: runAt.AddDate(0, 0, -3), Status: RunSucceeded},
+			wantUpdate:      ScheduleUpdateParams{ScheduleId: 1, Status: StatusActive},
+			wantNextAttempt: runAt.AddDate(0, 0, 1),
+		},
+		{
+			name:       "success - once completes",
+			schedule:   ScheduleRow{ScheduleId: 1, Frequency: FrequencyOnce, StartAt: runAt, Status: StatusActive, RunAt: &runAt, NextAttemptAt: &runAt},
+			wantRun:    RunCreateParams{ScheduleId: 1, RunAt: runAt, Status: RunSucceeded},
+			wantUpdate: ScheduleUpdateParams{ScheduleId: 1, Status: StatusCompleted},
+		},
+		{
+			name:            "success - insufficient funds retried",
+			schedule:        ScheduleRow{ScheduleId: 1, Frequency: FrequencyDaily, StartAt: runAt, Status: StatusActive, RunAt: &runAt, NextAttemptAt: &retriedAt, Attempt: 1, RetryMax: 2, RetryIntervalSeconds: 1800},
+			transferErr:     transaction.ErrTransactionSourceBalanceNotEnough,
+			wantRun:         RunCreateParams{ScheduleId: 1, RunAt: runAt, Attempt: 1, Status: RunRetrying},
+			wantErrorCode:   "source_balance_not_enough",
+			wantUpdate:      ScheduleUpdateParams{ScheduleId: 1, Status: StatusActive, RunAt: &runAt, Attempt: 2},
+			wantNextAttempt: now.Add(30 * time.Minute),
+		},
+		{
+			name:            "success - retries exhausted",
+			schedule:        ScheduleRow{ScheduleId: 1, Frequency: FrequencyDaily, StartAt: runAt, Status: StatusActive, RunAt: &runAt, NextAttemptAt: &retriedAt, Attempt: 2, RetryMax: 2, RetryIntervalSeconds: 1800},
+			transferErr:     transaction.ErrTransactionSourceBalanceNotEnough,
+			wantRun:         RunCreateParams{ScheduleId: 1, RunAt: runAt, Attempt: 2, Status: RunFailed},
+			wantErrorCode:   "source_balance_not_enough",
+			wantUpdate:      ScheduleUpdateParams{ScheduleId: 1, Status: StatusActive},
+			wantNextAttempt: runAt.AddDate(0, 0, 1),
+		},
+		{
+			name:            "success - other failures not retried",
+			schedule:        ScheduleRow{ScheduleId: 1, Frequency: FrequencyDaily, StartAt: runAt, Status: StatusActive, RunAt: &runAt, NextAttemptAt: &runAt, RetryMax: 2, RetryIntervalSeconds: 1800},
+			transferErr:     transaction.ErrTransactionSourceAccountFrozen,
+			wantRun:         RunCreateParams{ScheduleId: 1, RunAt: runAt, Status: RunFailed},
+			wantErrorCode:   "source_account_frozen",
+			wantUpdate:      ScheduleUpdateParams{ScheduleId: 1, Status: StatusActive},
+			wantNextAttempt: runAt.AddDate(0, 0, 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRuns []RunCreateParams
+			var gotUpdates []ScheduleUpdateParams
+			due := []ScheduleRow{tt.schedule}
+			repo := &fakeScheduleRepo{
+				DueForUpdateFunc: func(ctx context.Context, at time.Time, limit int) ([]ScheduleRow, error) {
+					if !at.Equal(now) || limit != 1 {
+						t.Errorf("ScheduleRepo.DueForUpdate() got %s %d, want %s 1", at, limit, now)
+					}
+					rows := due
+					due = nil
+					return rows, nil
+				},
+				CreateRunFunc: func(ctx context.Context, params RunCreateParams) (RunRow, error) {
+					gotRuns = append(gotRuns, params)
+					return RunRow{}, nil
+				},
+				UpdateFunc: func(ctx context.Context, params ScheduleUpdateParams) (ScheduleRow, error) {
+					gotUpdates = append(gotUpdates, params)
+					return ScheduleRow{}, nil
+				},
+			}
+			transfers := &fakeTransferCreator{
+				CreateFunc: func(ctx context.Context, data transaction.TransactionCreate) (transaction.Transaction, error) {
+					if tt.transferErr != nil {
+						return transaction.Transaction{}, tt.transferErr
+					}
+					return transaction.Transaction{TransactionId: 7}, nil
+				},
+			}
+
+			svc := NewScheduleService(repo, nil, &fakeUnitOfWork{}, transfers)
+			svc.now = func() time.Time { return now }
+			count, err := svc.RunDue(t.Context())
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("ScheduleService.RunDue() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(gotRuns) != 0 || len(gotUpdates) != 0 {
+					t.Errorf("ScheduleService.RunDue() recorded %+v %+v, want nothing", gotRuns, gotUpdates)
+				}
+				return
+			}
+			if count != 1 || len(gotRuns) != 1 || len(gotUpdates) != 1 {
+				t.Fatalf("ScheduleService.RunDue() = %d, runs %+v, updates %+v, want 1 of each", count, gotRuns, gotUpdates)
+			}
+
+			run := gotRuns[0]
+			if tt.wantErrorCode == "" {
+				if run.TransactionId == nil || *run.TransactionId != 7 || run.ErrorCode != nil {
+					t.Errorf("run = %+v, want transaction 7", run)
+				}
+			} else if run.ErrorCode == nil || *run.ErrorCode != tt.wantErrorCode || run.TransactionId != nil {
+				t.Errorf("run = %+v, want error code %s", run, tt.wantErrorCode)
+			}
+			run.TransactionId, run.ErrorCode, run.Error = nil, nil, nil
+			if run.ScheduleId != tt.wantRun.ScheduleId || !run.RunAt.Equal(tt.wantRun.RunAt) || run.Attempt != tt.wantRun.Attempt || run.Status != tt.wantRun.Status {
+				t.Errorf("run = %+v, want %+v", run, tt.wantRun)
+			}
+
+			update := gotUpdates[0]
+			if update.ScheduleId != tt.wantUpdate.ScheduleId || update.Status != tt.wantUpdate.Status || update.Attempt != tt.wantUpdate.Attempt {
+				t.Errorf("update = %+v, want %+v", update, tt.wantUpdate)
+			}
+			if tt.wantNextAttempt.IsZero() {
+				if update.NextAttemptAt != nil || update.RunAt != nil {
+					t.Errorf("update = %+v, want no next run", update)
+				}
+				return
+			}
+			wantRunAt := tt.wantNextAttempt
+			if tt.wantUpdate.RunAt != nil {
+				wantRunAt = *tt.wantUpdate.RunAt
+			}
+			if update.NextAttemptAt == nil || !update.NextAttemptAt.Equal(tt.wantNextAttempt) || update.RunAt == nil || !update.RunAt.Equal(wantRunAt) {
+				t.Errorf("update = %+v, want run at %s attempted at %s", update, wantRunAt, tt.wantNextAttempt)
+			}
+		})
+	}
+}
+
+func TestScheduleService_RunDue_TransferFailed(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	runAt := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	// Schedule 1 is due first and its transfer fails for reasons of its own; it is postponed
+	// instead of holding up schedule 2.
+	schedules := map[int]ScheduleRow{
+		1: {ScheduleId: 1, SourceAccountId: 1, Frequency: FrequencyDaily, StartAt: runAt, Status: StatusActive, RunAt: &runAt, NextAttemptAt: &runAt, Attempt: 1, RetryMax: 2},
+		2: {ScheduleId: 2, SourceAccountId: 2, Frequency: FrequencyDaily, StartAt: runAt, Status: StatusActive, RunAt: ptr(runAt.Add(time.Hour)), NextAttemptAt: ptr(runAt.Add(time.Hour))},
+	}
+	var gotRuns []RunCreateParams
+	repo := &fakeScheduleRepo{
+		DueForUpdateFunc: func(ctx context.Context, at time.Time, limit int) ([]ScheduleRow, error) {
+			var due []ScheduleRow
+			for _, id := range []int{1, 2} {
+				if row := schedules[id]; row.Status == StatusActive && !row.NextAttemptAt.After(at) {
+					due = append(due, row)
+				}
+			}
+			slices.SortFunc(due, func(a, b ScheduleRow) int { return a.NextAttemptAt.Compare(*b.NextAttemptAt) })
+			return due[:min(len(due), limit)], nil
+		},
+		ByIdForUpdateFunc: func(ctx context.Context, scheduleId int) (ScheduleRow, error) {
+			return schedules[scheduleId], nil
+		},
+		CreateRunFunc: func(ctx context.Context, params RunCreateParams) (RunRow, error) {
+			gotRuns = append(gotRuns, params)
+			return RunRow{}, nil
+		},
+		UpdateFunc: func(ctx context.Context, params ScheduleUpdateParams) (ScheduleRow, error) {
+			row := schedules[params.ScheduleId]
+			row.Status, row.RunAt, row.NextAttemptAt, row.Attempt = params.Status, params.RunAt, params.NextAttemptAt, params.Attempt
+			schedules[params.ScheduleId] = row
+			return row, nil
+		},
+	}
+	transfers := &fakeTransferCreator{
+		CreateFunc: func(ctx context.Context, data transaction.TransactionCreate) (transaction.Transaction, error) {
+			if data.SourceAccountId == 1 {
+				return transaction.Transaction{}, transaction.ErrTransactionCreateFailed
+			}
+			return transaction.Transaction{TransactionId: 7}, nil
+		},
+	}
+
+	svc := NewScheduleService(repo, nil, &fakeUnitOfWork{}, transfers)
+	svc.now = func() time.Time { return now }
+	count, err := svc.RunDue(t.Context())
+	if err != nil {
+		t.Fatalf("ScheduleService.RunDue() error = %v", err)
+	}
+	if count != 1 || len(gotRuns) != 1 || gotRuns[0].ScheduleId != 2 {
+		t.Fatalf("ScheduleService.RunDue() = %d, runs %+v, want schedule 2 run", count, gotRuns)
+	}
+
+	postponed := schedules[1]
+	if !postponed.NextAttemptAt.Equal(now.Add(runFailedDelay)) || !postponed.RunAt.Equal(runAt) || postponed.Attempt != 1 || postponed.Status != StatusActive {
+		t.Errorf("schedule 1 = %+v, want its run at %s attempted again at %s", postponed, runAt, now.Add(runFailedDelay))
+	}
+}
+
+func TestScheduleService_Transitions(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	missed := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		status        string
+		transition    func(svc *ScheduleService, ctx context.Context, scheduleId int) (Schedule, error)
+		wantStatus    string
+		wantNextRunAt *time.Time
+		wantErr       error
+	}{
+		{
+			name:          "success - pause",
+			status:        StatusActive,
+			transition:    (*ScheduleService).Pause,
+			wantStatus:    StatusPaused,
+			wantNextRunAt: &missed,
+		},
+		{
+			name:          "success - resume skips missed occurrences",
+			status:        StatusPaused,
+			transition:    (*ScheduleService).Resume,
+			wantStatus:    StatusActive,
+			wantNextRunAt: ptr(time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:       "success - cancel paused",
+			status:     StatusPaused,
+			transition: (*ScheduleService).Cancel,
+			wantStatus: StatusCancelled,
+		},
+		{
+			name:       "error - pause paused",
+			status:     StatusPaused,
+			transition: (*ScheduleService).Pause,
+			wantErr:    ErrScheduleStatusInvalid,
+		},
+		{
+			name:       "error - resume completed",
+			status:     StatusCompleted,
+			transition: (*ScheduleService).Resume,
+			wantErr:    ErrScheduleStatusInvalid,
+		},
+		{
+			name:       "error - cancel cancelled",
+			status:     StatusCancelled,
+			transition: (*ScheduleService).Cancel,
+			wantErr:    ErrScheduleStatusInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeScheduleRepo{
+				ByIdForUpdateFunc: func(ctx context.Context, scheduleId int) (ScheduleRow, error) {
+					return ScheduleRow{ScheduleId: scheduleId, Frequency: FrequencyDaily, StartAt: start, Status: tt.status, RunAt: &missed, NextAttemptAt: &missed, Attempt: 1}, nil
+				},
+				UpdateFunc: func(ctx context.Context, params ScheduleUpdateParams) (ScheduleRow, error) {
+					return ScheduleRow{ScheduleId: params.ScheduleId, Frequency: FrequencyDaily, StartAt: start, Status: params.Status, RunAt: params.RunAt, NextAttemptAt: params.NextAttemptAt, Attempt: params.Attempt}, nil
+				},
+			}
+
+			svc := NewScheduleService(repo, nil, &fakeUnitOfWork{}, nil)
+			svc.now = func() time.Time { return now }
+			got, err := tt.transition(svc, t.Context(), 1)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("transition error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Status != tt.wantStatus || (got.NextRunAt == nil) != (tt.wantNextRunAt == nil) || (got.NextRunAt != nil && !got.NextRunAt.Equal(*tt.wantNextRunAt)) {
+				t.Errorf("transition = %+v, want status %s next run at %v", got, tt.wantStatus, tt.wantNextRunAt)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+type fakeScheduleRepo struct {
+	CreateFunc        func(ctx context.Context, params ScheduleCreateParams) (ScheduleRow, error)
+	ByIdFunc          func(ctx context.Context, scheduleId int) (ScheduleRow, error)
+	ByIdForUpdateFunc func(ctx context.Context, scheduleId int) (ScheduleRow, error)
+	ListFunc          func(ctx context.Context, params ScheduleListParams) ([]ScheduleRow, error)
+	DueForUpdateFunc  func(ctx context.Context, now time.Time, limit int) ([]ScheduleRow, error)
+	UpdateFunc        func(ctx context.Context, params ScheduleUpdateParams) (ScheduleRow, error)
+	CreateRunFunc     func(ctx context.Context, params RunCreateParams) (RunRow, error)
+	RunsFunc          func(ctx context.Context, scheduleId int) ([]RunRow, error)
+}
+
+func (f *fakeScheduleRepo) Create(ctx context.Context, params ScheduleCreateParams) (ScheduleRow, error) {
+	return f.CreateFunc(ctx, params)
+}
+
+func (f *fakeScheduleRepo) ById(ctx context.Context, scheduleId int) (ScheduleRow, error) {
+	return f.ByIdFunc(ctx, scheduleId)
+}
+
+func (f *fakeScheduleRepo) ByIdForUpdate(ctx context.Context, scheduleId int) (ScheduleRow, error) {
+	return f.ByIdForUpdateFunc(ctx, scheduleId)
+}
+
+func (f *fakeScheduleRepo) List(ctx context.Context, params ScheduleListParams) ([]ScheduleRow, error) {
+	return f.ListFunc(ctx, params)
+}
+
+func (f *fakeScheduleRepo) DueForUpdate(ctx context.Context, now time.Time, limit int) ([]ScheduleRow, error) {
+	return f.DueForUpdateFunc(ctx, now, limit)
+}
+
+func (f *fakeScheduleRepo) Update(ctx context.Context, params ScheduleUpdateParams) (ScheduleRow, error) {
+	return f.UpdateFunc(ctx, params)
+}
+
+func (f *fakeScheduleRepo) CreateRun(ctx context.Context, params RunCreateParams) (RunRow, error) {
+	return f.CreateRunFunc(ctx, params)
+}
+
+func (f *fakeScheduleRepo) Runs(ctx context.Context, scheduleId int) ([]RunRow, error) {
+	return f.RunsFunc(ctx, scheduleId)
+}
+
+type fakeTransferCreator struct {
+	CreateFunc func(ctx context.Context, data transaction.TransactionCreate) (transaction.Transaction, error)
+}
+
+func (f *fakeTransferCreator) Create(ctx context.Context, data transaction.TransactionCreate) (transaction.Transaction, error) {
+	return f.CreateFunc(ctx, data)
+}
+
+type fakeUnitOfWork struct{}
+
+func (f *fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeAccountRepo struct {
+	CreateFunc                func(ctx context.Context, data account.AccountCreateParams) (bool, error)
+	ByIdFunc                  func(ctx context.Context, accountId int) (account.AccountRow, error)
+	ByIdsForUpdateFunc        func(ctx context.Context, accountIds []int) ([]account.AccountRow, error)
+	UpdateBalanceFunc         func(ctx context.Context, params account.AccountUpdateBalanceParams) error
+	UpdateHeldBalanceFunc     func(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error
+	UpdateStatusFunc          func(ctx context.Context, params account.AccountUpdateStatusParams) error
+	UpdateOverdraftLimitFunc  func(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error
+	OverdraftLimitChangesFunc func(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error)
+}
+
+func (f *fakeAccountRepo) Create(ctx context.Context, data account.AccountCreateParams) (bool, error) {
+	return f.CreateFunc(ctx, data)
+}
+
+func (f *fakeAccountRepo) ById(ctx context.Context, accountId int) (account.AccountRow, error) {
+	return f.ByIdFunc(ctx, accountId)
+}
+
+func (f *fakeAccountRepo) ByIdsForUpdate(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+	return f.ByIdsForUpdateFunc(ctx, accountIds)
+}
+
+func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+	return f.UpdateBalanceFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateHeldBalance(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+	return f.UpdateHeldBalanceFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateStatus(ctx context.Context, params account.AccountUpdateStatusParams) error {
+	return f.UpdateStatusFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateOverdraftLimit(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error {
+	return f.UpdateOverdraftLimitFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) OverdraftLimitChanges(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error) {
+	return f.OverdraftLimitChangesFunc(ctx, accountId)
+}
@@ -0,0 +1,219 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/limit"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+)
+
+var (
+	ErrTransactionBatchInvalid = errors.New("transaction batch invalid")
+	ErrTransactionBatchAborted = errors.New("transaction batch aborted")
+)
+
+// Batch modes. An atomic batch creates every transfer or none of them; an independent batch
+// creates each transfer on its own, so some may succeed while others fail.
+const (
+	BatchModeAtomic      = "atomic"
+	BatchModeIndependent = "independent"
+)
+
+// Batch item statuses. Aborted items of an atomic batch were valid but rolled back, or never
+// attempted, because another item failed.
+const (
+	BatchItemCompleted = "completed"
+	BatchItemFailed    = "failed"
+	BatchItemAborted   = "aborted"
+)
+
+// ErrorCodeCreateFailed is the code ErrorCode reports for errors that are not the fault of the
+// transfer itself, such as a database failure.
+const ErrorCodeCreateFailed = "create_failed"
+
+// MaxBatchTransfers is the largest number of transfers a batch may hold.
+const MaxBatchTransfers = 100
+
+// TransactionBatch represents a request to create several transfers at once.
+type TransactionBatch struct {
+	Mode      string              `json:"mode"`
+	Transfers []TransactionCreate `json:"transfers"`
+}
+
+// BatchItem is the outcome of one transfer of a batch, in the order it was requested. Failed
+// items carry a stable Code and the Error message; items exceeding a transfer limit also carry
+// the Limit they tripped.
+type BatchItem struct {
+	Index       int              `json:"index"`
+	Status      string           `json:"status"`
+	Transaction *Transaction     `json:"transaction,omitempty"`
+	Code        string           `json:"code,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	Limit       *limit.Violation `json:"limit,omitempty"`
+}
+
+// BatchResult is the outcome of a batch, with one item per requested transfer.
+type BatchResult struct {
+	Mode      string      `json:"mode"`
+	Completed int         `json:"completed"`
+	Failed    int         `json:"failed"`
+	Items     []BatchItem `json:"items"`
+}
+
+// errorCodes maps the errors a transfer can fail with to the codes reported by ErrorCode.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrTransactionAccountNumberInvalid, "account_number_invalid"},
+	{ErrTransactionAccountForbidden, "account_forbidden"},
+	{ErrTransactionSourceBalanceNegative, "amount_negative"},
+	{ErrTransactionSourceDestinationSame, "source_destination_same"},
+	{ErrTransactionSourceAccountNotFound, "source_account_not_found"},
+	{ErrTransactionDestinationAccountNotFound, "destination_account_not_found"},
+	{ErrTransactionSourceAccountFrozen, "source_account_frozen"},
+	{ErrTransactionSourceAccountDebitBlocked, "source_account_debit_blocked"},
+	{ErrTransactionSourceAccountClosed, "source_account_closed"},
+	{ErrTransactionDestinationAccountFrozen, "destination_account_frozen"},
+	{ErrTransactionDestinationAccountCreditBlocked, "destination_account_credit_blocked"},
+	{ErrTransactionDestinationAccountClosed, "destination_account_closed"},
+	{ErrTransactionCurrencyMismatch, "currency_mismatch"},
+	{ErrTransactionConversionInvalid, "conversion_invalid"},
+	{ErrTransactionLimitExceeded, "limit_exceeded"},
+	{ErrTransactionSourceBalanceNotEnough, "source_balance_not_enough"},
+	{money.ErrMoneyTooPrecise, "amount_too_precise"},
+	{money.ErrMoneyOverflow, "amount_overflow"},
+}
+
+// Batch creates the transfers of data. Atomic batches lock every account involved in ascending
+// id order and create all transfers in a single database transaction, queued to TigerBeetle as
+// one linked chain; when any transfer fails, none is created and Batch returns the result with
+// ErrTransactionBatchAborted. Independent batches create each transfer as Create does and report
+// failures per item only.
+func (svc *TransactionService) Batch(ctx context.Context, data TransactionBatch) (BatchResult, error) {
+	if data.Mode != BatchModeAtomic && data.Mode != BatchModeIndependent {
+		slog.WarnContext(ctx, ErrTransactionBatchInvalid.Error(), "mode", data.Mode)
+		return BatchResult{}, ErrTransactionBatchInvalid
+	}
+
+	if len(data.Transfers) == 0 || len(data.Transfers) > MaxBatchTransfers {
+		slog.WarnContext(ctx, ErrTransactionBatchInvalid.Error(), "transfers", len(data.Transfers))
+		return BatchResult{}, ErrTransactionBatchInvalid
+	}
+
+	result := BatchResult{Mode: data.Mode, Items: make([]BatchItem, len(data.Transfers))}
+	for i := range result.Items {
+		result.Items[i].Index = i
+	}
+
+	if data.Mode == BatchModeIndependent {
+		for i, item := range data.Transfers {
+			tx, err := svc.Create(ctx, item)
+			result.settle(i, tx, err)
+		}
+		return result, nil
+	}
+
+	return svc.batchAtomic(ctx, data.Transfers, result)
+}
+
+// batchAtomic creates transfers in one database transaction and fills result. The first failing
+// transfer fails the whole batch.
+func (svc *TransactionService) batchAtomic(ctx context.Context, transfers []TransactionCreate, result BatchResult) (BatchResult, error) {
+	failed := -1
+	var itemErr error
+
+	validated := make([]TransactionCreate, len(transfers))
+	for i, item := range transfers {
+		if validated[i], itemErr = validateCreate(ctx, item); itemErr != nil {
+			failed = i
+			break
+		}
+	}
+
+	var rows []TransactionRow
+	if failed < 0 {
+		err := svc.uow.Do(ctx, func(ctx context.Context) error {
+			if _, err := svc.accountRepo.ByIdsForUpdate(ctx, batchAccountIds(validated)); err != nil {
+				return err
+			}
+
+			rows = make([]TransactionRow, 0, len(validated))
+			payloads := make([]outbox.TransferPayload, 0, len(validated))
+			for i, item := range validated {
+				row, payload, err := svc.transfer(ctx, item)
+				if err != nil {
+					failed, itemErr = i, err
+					return err
+				}
+				rows = append(rows, row)
+				payloads = append(payloads, payload)
+			}
+
+			return svc.enqueue(ctx, outbox.KindTransferBatch, outbox.TransferBatchPayload{Transfers: payloads})
+		})
+		if failed < 0 && err != nil {
+			slog.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+			return BatchResult{}, ErrTransactionCreateFailed
+		}
+	}
+
+	if failed >= 0 {
+		for i := range result.Items {
+			result.Items[i].Status = BatchItemAborted
+		}
+		result.settle(failed, Transaction{}, itemErr)
+		slog.WarnContext(ctx, ErrTransactionBatchAborted.Error(), "transfer", failed, "error", itemErr)
+		return result, ErrTransactionBatchAborted
+	}
+
+	for i, row := range rows {
+		result.settle(i, toTransaction(row), nil)
+	}
+	return result, nil
+}
+
+// settle records the outcome of the transfer at index i of the batch.
+func (r *BatchResult) settle(i int, tx Transaction, err error) {
+	item := &r.Items[i]
+	if err == nil {
+		item.Status = BatchItemCompleted
+		item.Transaction = &tx
+		r.Completed++
+		return
+	}
+
+	item.Status = BatchItemFailed
+	item.Code, item.Error = ErrorCode(err), err.Error()
+	var limitErr *LimitExceededError
+	if errors.As(err, &limitErr) {
+		item.Limit = &limitErr.Violation
+	}
+	r.Failed++
+}
+
+// ErrorCode returns the stable code reported for a transfer that failed with err, such as
+// source_balance_not_enough. Errors that are not the fault of the transfer itself are reported
+// as ErrorCodeCreateFailed.
+func ErrorCode(err error) string {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return ErrorCodeCreateFailed
+}
+
+// batchAccountIds returns the distinct accounts of transfers in ascending order.
+func batchAccountIds(transfers []TransactionCreate) []int {
+	ids := make([]int, 0, 2*len(transfers))
+	for _, item := range transfers {
+		ids = append(ids, item.SourceAccountId, item.DestinationAccountId)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
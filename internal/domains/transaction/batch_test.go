@@ -0,0 +1,146 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+)
+
+func TestTransactionService_Batch(t *testing.T) {
+	transfer := func(source, destination int, amount int64) TransactionCreate {
+		return TransactionCreate{SourceAccountId: source, DestinationAccountId: destination, Amount: money.New(amount, 2)}
+	}
+
+	tests := []struct {
+		name         string
+		data         TransactionBatch
+		wantStatuses []string
+		wantCodes    []string
+		wantLocks    [][]int
+		wantMessages []string
+		wantErr      error
+	}{
+		{
+			name:         "success - atomic",
+			data:         TransactionBatch{Mode: BatchModeAtomic, Transfers: []TransactionCreate{transfer(3, 1, 100), transfer(1, 2, 200)}},
+			wantStatuses: []string{BatchItemCompleted, BatchItemCompleted},
+			wantLocks:    [][]int{{1, 2, 3}, {3, 1}, {1, 2}},
+			wantMessages: []string{outbox.KindTransferBatch},
+		},
+		{
+			name:         "error - atomic aborted by balance",
+			data:         TransactionBatch{Mode: BatchModeAtomic, Transfers: []TransactionCreate{transfer(1, 2, 100), transfer(1, 3, 2_000), transfer(2, 3, 100)}},
+			wantStatuses: []string{BatchItemAborted, BatchItemFailed, BatchItemAborted},
+			wantCodes:    []string{"", "source_balance_not_enough", ""},
+			wantLocks:    [][]int{{1, 2, 3}, {1, 2}, {1, 3}},
+			wantErr:      ErrTransactionBatchAborted,
+		},
+		{
+			name:         "error - atomic aborted before locking",
+			data:         TransactionBatch{Mode: BatchModeAtomic, Transfers: []TransactionCreate{transfer(1, 2, 100), transfer(2, 2, 100)}},
+			wantStatuses: []string{BatchItemAborted, BatchItemFailed},
+			wantCodes:    []string{"", "source_destination_same"},
+			wantErr:      ErrTransactionBatchAborted,
+		},
+		{
+			name:         "success - independent with failures",
+			data:         TransactionBatch{Mode: BatchModeIndependent, Transfers: []TransactionCreate{transfer(1, 2, 100), transfer(1, 4, 100), transfer(1, 3, 2_000), transfer(2, 1, 100)}},
+			wantStatuses: []string{BatchItemCompleted, BatchItemFailed, BatchItemFailed, BatchItemCompleted},
+			wantCodes:    []string{"", "destination_account_not_found", "source_balance_not_enough", ""},
+			wantLocks:    [][]int{{1, 2}, {1, 4}, {1, 3}, {2, 1}},
+			wantMessages: []string{outbox.KindTransferCreate, outbox.KindTransferCreate},
+		},
+		{
+			name:    "error - mode invalid",
+			data:    TransactionBatch{Mode: "best_effort", Transfers: []TransactionCreate{transfer(1, 2, 100)}},
+			wantErr: ErrTransactionBatchInvalid,
+		},
+		{
+			name:    "error - empty",
+			data:    TransactionBatch{Mode: BatchModeAtomic},
+			wantErr: ErrTransactionBatchInvalid,
+		},
+		{
+			name:    "error - too many transfers",
+			data:    TransactionBatch{Mode: BatchModeIndependent, Transfers: make([]TransactionCreate, MaxBatchTransfers+1)},
+			wantErr: ErrTransactionBatchInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotLocks [][]int
+			var gotMessages []string
+			var gotPayload outbox.TransferBatchPayload
+			accountRepo := &fakeAccountRepo{
+				ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+					gotLocks = append(gotLocks, accountIds)
+					var rows []account.AccountRow
+					for _, id := range accountIds {
+						if id <= 3 {
+							rows = append(rows, account.AccountRow{AccountId: id, Balance: 1_000, ScaleBalance: 2, Currency: "USD"})
+						}
+					}
+					return rows, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error { return nil },
+			}
+			transactionId := 0
+			repo := &fakeTransactionRepo{
+				CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+					transactionId++
+					return TransactionRow{TransactionId: transactionId, SourceAccountId: data.SourceAccountId, DestinationAccountId: data.DestinationAccountId, Amount: data.Amount, AmountScale: data.AmountScale}, nil
+				},
+			}
+			journalRepo := &fakeJournalRepo{
+				CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) { return 1, nil },
+			}
+			outboxRepo := &fakeOutboxRepo{
+				CreateFunc: func(ctx context.Context, params outbox.MessageCreateParams) error {
+					gotMessages = append(gotMessages, params.Kind)
+					if params.Kind == outbox.KindTransferBatch {
+						return json.Unmarshal(params.Payload, &gotPayload)
+					}
+					return nil
+				},
+			}
+
+			svc := NewTransactionService(repo, nil, accountRepo, journalRepo, &fakeUnitOfWork{}, nil, nil, nil, outboxRepo, true)
+			got, err := svc.Batch(t.Context(), tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("TransactionService.Batch() error = %v, want %v", err, tt.wantErr)
+			}
+			if !slices.EqualFunc(gotLocks, tt.wantLocks, slices.Equal) {
+				t.Errorf("accounts locked = %v, want %v", gotLocks, tt.wantLocks)
+			}
+			if !slices.Equal(gotMessages, tt.wantMessages) {
+				t.Errorf("outbox messages = %v, want %v", gotMessages, tt.wantMessages)
+			}
+			if len(gotMessages) > 0 && gotMessages[0] == outbox.KindTransferBatch && len(gotPayload.Transfers) != len(tt.data.Transfers) {
+				t.Errorf("batch payload = %+v, want %d transfers", gotPayload, len(tt.data.Transfers))
+			}
+
+			if len(got.Items) != len(tt.wantStatuses) {
+				t.Fatalf("TransactionService.Batch() items = %+v, want %d", got.Items, len(tt.wantStatuses))
+			}
+			for i, item := range got.Items {
+				var wantCode string
+				if tt.wantCodes != nil {
+					wantCode = tt.wantCodes[i]
+				}
+				if item.Index != i || item.Status != tt.wantStatuses[i] || item.Code != wantCode {
+					t.Errorf("item %d = %+v, want status %s, code %q", i, item, tt.wantStatuses[i], wantCode)
+				}
+				if (item.Transaction != nil) != (item.Status == BatchItemCompleted) {
+					t.Errorf("item %d transaction = %+v, want one only when completed", i, item.Transaction)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/webhook"
+)
+
+var (
+	ErrTransactionCloseFailed             = errors.New("transaction account close fail")
+	ErrTransactionAccountBalanceNotZero   = errors.New("transaction account balance not zero")
+	ErrTransactionAccountHasHolds         = errors.New("transaction account has pending holds")
+	ErrTransactionSweepAccountNotFound    = errors.New("transaction sweep account not found")
+	ErrTransactionSweepAccountSameAsClose = errors.New("transaction sweep account can not be the closed account")
+)
+
+// AccountClose represents a request to close an account. Reason is recorded in the status
+// history of the account. An account with a balance left can only be closed by sweeping the
+// balance to SweepAccountId, an account in the same currency.
+type AccountClose struct {
+	Reason         string `json:"reason"`
+	SweepAccountId *int   `json:"sweep_account_id,omitempty"`
+}
+
+// AccountClosure is the outcome of closing an account. Sweep is the transaction that moved the
+// remaining balance out, if there was any.
+type AccountClosure struct {
+	AccountId int          `json:"account_id"`
+	Status    string       `json:"status"`
+	Sweep     *Transaction `json:"sweep,omitempty"`
+}
+
+// CloseAccount closes an account for good. The balance left on the account is swept to the sweep
+// account first, and the sweep, the status change and the outbox messages closing the account in
+// TigerBeetle are written in a single database transaction. Accounts with pending holds can not
+// be closed until the holds are captured, voided or expire, and overdrawn accounts until they are
+// paid back to zero.
+func (svc *TransactionService) CloseAccount(ctx context.Context, accountId int, data AccountClose) (AccountClosure, error) {
+	if strings.TrimSpace(data.Reason) == "" {
+		slog.WarnContext(ctx, account.ErrAccountStatusReasonRequired.Error(), "account_id", accountId)
+		return AccountClosure{}, account.ErrAccountStatusReasonRequired
+	}
+
+	if !auth.CanActOn(ctx, accountId) {
+		slog.WarnContext(ctx, ErrTransactionAccountForbidden.Error(), "account_id", accountId)
+		return AccountClosure{}, ErrTransactionAccountForbidden
+	}
+
+	if data.SweepAccountId != nil && *data.SweepAccountId == accountId {
+		slog.WarnContext(ctx, ErrTransactionSweepAccountSameAsClose.Error(), "account_id", accountId)
+		return AccountClosure{}, ErrTransactionSweepAccountSameAsClose
+	}
+
+	var closure AccountClosure
+	var closeErr error
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		closure, closeErr = svc.closeAccount(ctx, accountId, data)
+		return closeErr
+	})
+	if closeErr != nil {
+		return AccountClosure{}, closeErr
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionCloseFailed.Error(), "error", err)
+		return AccountClosure{}, ErrTransactionCloseFailed
+	}
+
+	return closure, nil
+}
+
+// closeAccount locks the account and the sweep account, sweeps the balance and marks the account
+// closed. It must run inside svc.uow so the writes commit or roll back together.
+func (svc *TransactionService) closeAccount(ctx context.Context, accountId int, data AccountClose) (AccountClosure, error) {
+	logger := slog.With("account_id", accountId)
+
+	ids := []int{accountId}
+	var sweepAccountId int
+	if data.SweepAccountId != nil {
+		sweepAccountId = *data.SweepAccountId
+		ids = append(ids, sweepAccountId)
+	}
+
+	rows, err := svc.accountRepo.ByIdsForUpdate(ctx, ids)
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionCloseFailed.Error(), "error", err)
+		return AccountClosure{}, ErrTransactionCloseFailed
+	}
+
+	closed, sweepAccount := findAccounts(rows, accountId, sweepAccountId)
+	if closed == nil {
+		logger.WarnContext(ctx, ErrTransactionAccountNotFound.Error(), "account_id", accountId)
+		return AccountClosure{}, ErrTransactionAccountNotFound
+	}
+	if closed.Status == account.StatusClosed {
+		logger.WarnContext(ctx, account.ErrAccountClosed.Error(), "account_id", accountId)
+		return AccountClosure{}, account.ErrAccountClosed
+	}
+	if closed.HeldBalance != 0 {
+		logger.WarnContext(ctx, ErrTransactionAccountHasHolds.Error(), "account_id", accountId)
+		return AccountClosure{}, ErrTransactionAccountHasHolds
+	}
+
+	closure := AccountClosure{AccountId: accountId, Status: account.StatusClosed}
+	if closed.Balance != 0 {
+		if data.SweepAccountId == nil || closed.Balance < 0 {
+			logger.WarnContext(ctx, ErrTransactionAccountBalanceNotZero.Error(), "account_id", accountId)
+			return AccountClosure{}, ErrTransactionAccountBalanceNotZero
+		}
+
+		row, err := svc.sweep(ctx, rows, *closed, sweepAccount, sweepAccountId)
+		if err != nil {
+			return AccountClosure{}, err
+		}
+		if err := svc.enqueue(ctx, outbox.KindTransferCreate, transferPayload(row)); err != nil {
+			logger.ErrorContext(ctx, ErrTransactionCloseFailed.Error(), "error", err)
+			return AccountClosure{}, ErrTransactionCloseFailed
+		}
+		sweep := toTransaction(row)
+		closure.Sweep = &sweep
+	}
+
+	err = svc.accountRepo.UpdateStatus(ctx, account.AccountUpdateStatusParams{
+		AccountId:  accountId,
+		FromStatus: closed.Status,
+		ToStatus:   account.StatusClosed,
+		Reason:     data.Reason,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionCloseFailed.Error(), "error", err)
+		return AccountClosure{}, ErrTransactionCloseFailed
+	}
+
+	payload := outbox.AccountPayload{AccountId: accountId, Currency: closed.Currency}
+	if err := svc.enqueue(ctx, outbox.KindAccountClose, payload); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionCloseFailed.Error(), "error", err)
+		return AccountClosure{}, ErrTransactionCloseFailed
+	}
+
+	return closure, nil
+}
+
+// sweep moves the whole balance of closed to the sweep account and records it as a sweep
+// transaction. The status of closed is not checked since closing overrides it, but the sweep
+// account must be able to receive.
+func (svc *TransactionService) sweep(ctx context.Context, rows []account.AccountRow, closed account.AccountRow, sweepAccount *account.AccountRow, sweepAccountId int) (TransactionRow, error) {
+	if sweepAccount == nil {
+		slog.WarnContext(ctx, ErrTransactionSweepAccountNotFound.Error(), "account_id", sweepAccountId)
+		return TransactionRow{}, ErrTransactionSweepAccountNotFound
+	}
+
+	if err := checkStatus(account.AccountRow{}, *sweepAccount); err != nil {
+		slog.WarnContext(ctx, err.Error(), "account_id", sweepAccount.AccountId, "status", sweepAccount.Status)
+		return TransactionRow{}, err
+	}
+
+	if sweepAccount.Currency != closed.Currency {
+		slog.WarnContext(ctx, ErrTransactionCurrencyMismatch.Error(), "reason", "sweeps can not convert between currencies")
+		return TransactionRow{}, ErrTransactionCurrencyMismatch
+	}
+
+	amount := money.New(closed.Balance, closed.ScaleBalance)
+	postings := transferPostings(closed, amount, *sweepAccount, amount)
+	balances, err := applyPostings(rows, postings)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionCloseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionCloseFailed
+	}
+
+	row, err := svc.repo.Create(ctx, TransactionCreateParams{
+		SourceAccountId:        closed.AccountId,
+		DestinationAccountId:   sweepAccount.AccountId,
+		Amount:                 amount.Value(),
+		AmountScale:            amount.Scale(),
+		Currency:               closed.Currency,
+		DestinationAmount:      amount.Value(),
+		DestinationAmountScale: amount.Scale(),
+		DestinationCurrency:    sweepAccount.Currency,
+		Kind:                   KindSweep,
+		InitiatedBy:            auth.SubjectFrom(ctx),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionCloseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionCloseFailed
+	}
+
+	if err := svc.post(ctx, journal.Entry{Kind: journal.KindSweep, TransactionId: row.TransactionId, Postings: postings}, balances); err != nil {
+		slog.ErrorContext(ctx, ErrTransactionCloseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionCloseFailed
+	}
+
+	if err := svc.record(ctx, webhook.EventTransactionCreated, row); err != nil {
+		slog.ErrorContext(ctx, ErrTransactionCloseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionCloseFailed
+	}
+
+	return row, nil
+}
@@ -0,0 +1,232 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+)
+
+func TestTransactionService_Create_AccountStatus(t *testing.T) {
+	tests := []struct {
+		name              string
+		sourceStatus      string
+		destinationStatus string
+		wantErr           error
+	}{
+		{name: "success - active", sourceStatus: account.StatusActive, destinationStatus: account.StatusActive},
+		{name: "success - source credit blocked", sourceStatus: account.StatusCreditBlocked, destinationStatus: account.StatusActive},
+		{name: "success - destination debit blocked", sourceStatus: account.StatusActive, destinationStatus: account.StatusDebitBlocked},
+		{name: "error - source frozen", sourceStatus: account.StatusFrozen, destinationStatus: account.StatusActive, wantErr: ErrTransactionSourceAccountFrozen},
+		{name: "error - source debit blocked", sourceStatus: account.StatusDebitBlocked, destinationStatus: account.StatusActive, wantErr: ErrTransactionSourceAccountDebitBlocked},
+		{name: "error - source closed", sourceStatus: account.StatusClosed, destinationStatus: account.StatusActive, wantErr: ErrTransactionSourceAccountClosed},
+		{name: "error - destination frozen", sourceStatus: account.StatusActive, destinationStatus: account.StatusFrozen, wantErr: ErrTransactionDestinationAccountFrozen},
+		{name: "error - destination credit blocked", sourceStatus: account.StatusActive, destinationStatus: account.StatusCreditBlocked, wantErr: ErrTransactionDestinationAccountCreditBlocked},
+		{name: "error - destination closed", sourceStatus: account.StatusActive, destinationStatus: account.StatusClosed, wantErr: ErrTransactionDestinationAccountClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := &fakeAccountRepo{
+				ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+					return []account.AccountRow{
+						{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Status: tt.sourceStatus},
+						{AccountId: 2, Balance: 0, ScaleBalance: 2, Currency: "USD", Status: tt.destinationStatus},
+					}, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error { return nil },
+			}
+			repo := &fakeTransactionRepo{
+				CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+					return TransactionRow{TransactionId: 1}, nil
+				},
+			}
+			journalRepo := &fakeJournalRepo{
+				CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) { return 1, nil },
+			}
+
+			svc := NewTransactionService(repo, nil, accountRepo, journalRepo, &fakeUnitOfWork{}, nil, nil, nil, nil, false)
+			_, err := svc.Create(t.Context(), TransactionCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(3, 0)})
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Errorf("TransactionService.Create() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransactionService_CloseAccount(t *testing.T) {
+	sweepAccountId := 2
+	otherSweepAccountId := 3
+
+	accountRepo := func(closed account.AccountRow, gotStatus *account.AccountUpdateStatusParams) *fakeAccountRepo {
+		return &fakeAccountRepo{
+			ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+				rows := []account.AccountRow{
+					closed,
+					{AccountId: 2, Balance: 500, ScaleBalance: 2, Currency: "USD", Status: account.StatusActive},
+					{AccountId: 3, Balance: 500, ScaleBalance: 2, Currency: "EUR", Status: account.StatusActive},
+				}
+				var locked []account.AccountRow
+				for _, row := range rows {
+					for _, id := range accountIds {
+						if row.AccountId == id {
+							locked = append(locked, row)
+						}
+					}
+				}
+				return locked, nil
+			},
+			UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+				if (params.AccountId == 1 && params.Balance != 0) || (params.AccountId == 2 && params.Balance != 1_500) {
+					return fmt.Errorf("unexpected balance %+v", params)
+				}
+				return nil
+			},
+			UpdateStatusFunc: func(ctx context.Context, params account.AccountUpdateStatusParams) error {
+				*gotStatus = params
+				return nil
+			},
+		}
+	}
+	repo := &fakeTransactionRepo{
+		CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+			if data.Kind != KindSweep || data.Amount != 1_000 || data.DestinationAccountId != 2 {
+				return TransactionRow{}, fmt.Errorf("unexpected sweep %+v", data)
+			}
+			return TransactionRow{TransactionId: 9, Kind: data.Kind, SourceAccountId: data.SourceAccountId, DestinationAccountId: data.DestinationAccountId, Amount: data.Amount, AmountScale: data.AmountScale, Currency: data.Currency}, nil
+		},
+	}
+	journalRepo := &fakeJournalRepo{
+		CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+			if params.Kind != journal.KindSweep {
+				return 0, fmt.Errorf("unexpected entry %+v", params)
+			}
+			return 1, nil
+		},
+	}
+
+	tests := []struct {
+		name      string
+		closed    account.AccountRow
+		data      AccountClose
+		wantSweep bool
+		wantErr   error
+	}{
+		{
+			name:   "success - zero balance",
+			closed: account.AccountRow{AccountId: 1, ScaleBalance: 2, Currency: "USD", Status: account.StatusFrozen},
+			data:   AccountClose{Reason: "customer request"},
+		},
+		{
+			name:      "success - sweep",
+			closed:    account.AccountRow{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Status: account.StatusActive},
+			data:      AccountClose{Reason: "customer request", SweepAccountId: &sweepAccountId},
+			wantSweep: true,
+		},
+		{
+			name:    "error - reason required",
+			closed:  account.AccountRow{AccountId: 1, ScaleBalance: 2, Currency: "USD"},
+			data:    AccountClose{Reason: " "},
+			wantErr: account.ErrAccountStatusReasonRequired,
+		},
+		{
+			name:    "error - balance not zero",
+			closed:  account.AccountRow{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Status: account.StatusActive},
+			data:    AccountClose{Reason: "customer request"},
+			wantErr: ErrTransactionAccountBalanceNotZero,
+		},
+		{
+			name:    "error - overdrawn",
+			closed:  account.AccountRow{AccountId: 1, Balance: -1_000, ScaleBalance: 2, Currency: "USD", Status: account.StatusActive, OverdraftLimit: 5_000},
+			data:    AccountClose{Reason: "customer request", SweepAccountId: &sweepAccountId},
+			wantErr: ErrTransactionAccountBalanceNotZero,
+		},
+		{
+			name:    "error - pending holds",
+			closed:  account.AccountRow{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", HeldBalance: 100, Status: account.StatusActive},
+			data:    AccountClose{Reason: "customer request", SweepAccountId: &sweepAccountId},
+			wantErr: ErrTransactionAccountHasHolds,
+		},
+		{
+			name:    "error - already closed",
+			closed:  account.AccountRow{AccountId: 1, ScaleBalance: 2, Currency: "USD", Status: account.StatusClosed},
+			data:    AccountClose{Reason: "customer request"},
+			wantErr: account.ErrAccountClosed,
+		},
+		{
+			name:    "error - not found",
+			closed:  account.AccountRow{AccountId: 4},
+			data:    AccountClose{Reason: "customer request"},
+			wantErr: ErrTransactionAccountNotFound,
+		},
+		{
+			name:    "error - sweep currency differs",
+			closed:  account.AccountRow{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Status: account.StatusActive},
+			data:    AccountClose{Reason: "customer request", SweepAccountId: &otherSweepAccountId},
+			wantErr: ErrTransactionCurrencyMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotStatus account.AccountUpdateStatusParams
+			svc := NewTransactionService(repo, nil, accountRepo(tt.closed, &gotStatus), journalRepo, &fakeUnitOfWork{}, nil, nil, nil, nil, false)
+			got, err := svc.CloseAccount(t.Context(), 1, tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("TransactionService.CloseAccount() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Status != account.StatusClosed || (got.Sweep != nil) != tt.wantSweep {
+				t.Errorf("TransactionService.CloseAccount() = %+v, wantSweep %v", got, tt.wantSweep)
+			}
+			want := account.AccountUpdateStatusParams{AccountId: 1, FromStatus: tt.closed.Status, ToStatus: account.StatusClosed, Reason: tt.data.Reason}
+			if gotStatus != want {
+				t.Errorf("status change = %+v, want %+v", gotStatus, want)
+			}
+		})
+	}
+}
+
+func TestTransactionService_CloseAccount_TigerBeetle(t *testing.T) {
+	var kinds []string
+	outboxRepo := &fakeOutboxRepo{
+		CreateFunc: func(ctx context.Context, params outbox.MessageCreateParams) error {
+			kinds = append(kinds, params.Kind)
+			return nil
+		},
+	}
+	accountRepo := &fakeAccountRepo{
+		ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+			return []account.AccountRow{
+				{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD"},
+				{AccountId: 2, ScaleBalance: 2, Currency: "USD"},
+			}, nil
+		},
+		UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error { return nil },
+		UpdateStatusFunc:  func(ctx context.Context, params account.AccountUpdateStatusParams) error { return nil },
+	}
+	repo := &fakeTransactionRepo{
+		CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+			return TransactionRow{TransactionId: 9}, nil
+		},
+	}
+	journalRepo := &fakeJournalRepo{
+		CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) { return 1, nil },
+	}
+
+	sweepAccountId := 2
+	svc := NewTransactionService(repo, nil, accountRepo, journalRepo, &fakeUnitOfWork{}, nil, nil, nil, outboxRepo, true)
+	if _, err := svc.CloseAccount(t.Context(), 1, AccountClose{Reason: "fraud", SweepAccountId: &sweepAccountId}); err != nil {
+		t.Fatalf("TransactionService.CloseAccount() error = %v", err)
+	}
+
+	want := []string{outbox.KindTransferCreate, outbox.KindAccountClose}
+	if fmt.Sprint(kinds) != fmt.Sprint(want) {
+		t.Errorf("outbox messages = %v, want %v", kinds, want)
+	}
+}
@@ -0,0 +1,40 @@
+package transaction
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/fee"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+)
+
+// transferFee returns the fee source is charged for sending amount, in the scale of amount.
+// Transfers are free when no fee calculator is configured.
+func (svc *TransactionService) transferFee(ctx context.Context, source account.AccountRow, amount money.Amount) (money.Amount, error) {
+	if svc.fees == nil {
+		return money.New(0, amount.Scale()), nil
+	}
+
+	charged, err := svc.fees.Fee(ctx, fee.Quote{Tier: source.Tier, Currency: source.Currency, Amount: amount})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+		return money.Amount{}, ErrTransactionCreateFailed
+	}
+
+	return charged, nil
+}
+
+// feePostings returns the postings moving charged out of source into the fees revenue account
+// of its currency, or none for a free transfer.
+func feePostings(source account.AccountRow, charged money.Amount) []journal.Posting {
+	if charged.IsZero() {
+		return nil
+	}
+
+	return []journal.Posting{
+		{AccountId: source.AccountId, Direction: journal.Debit, Amount: charged, Currency: source.Currency},
+		{SystemAccount: journal.FeesAccount(source.Currency), Direction: journal.Credit, Amount: charged, Currency: source.Currency},
+	}
+}
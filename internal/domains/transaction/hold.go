@@ -0,0 +1,555 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/webhook"
+)
+
+var (
+	ErrTransactionHoldFailed         = errors.New("transaction hold fail")
+	ErrTransactionHoldNotFound       = errors.New("transaction hold not found")
+	ErrTransactionHoldNotPending     = errors.New("transaction hold is not pending")
+	ErrTransactionHoldExpired        = errors.New("transaction hold expired")
+	ErrTransactionHoldAmountInvalid  = errors.New("transaction hold amount invalid")
+	ErrTransactionHoldTimeoutInvalid = errors.New("transaction hold timeout invalid")
+)
+
+// Hold statuses. A hold is pending until it is captured, voided or expires.
+const (
+	HoldStatusPending  = "pending"
+	HoldStatusCaptured = "captured"
+	HoldStatusVoided   = "voided"
+	HoldStatusExpired  = "expired"
+)
+
+const (
+	defaultHoldTimeout = 7 * 24 * time.Hour
+	maxHoldTimeout     = 30 * 24 * time.Hour
+	expireHoldsBatch   = 100
+)
+
+// HoldCreate represents the required information to reserve funds on the source account.
+// Holds are single-currency. TimeoutSeconds defaults to 7 days with a maximum of 30 days.
+type HoldCreate struct {
+	SourceAccountId      int          `json:"source_account_id"`
+	DestinationAccountId int          `json:"destination_account_id"`
+	Amount               money.Amount `json:"amount"`
+	TimeoutSeconds       int          `json:"timeout_seconds,omitempty"`
+}
+
+// HoldCapture represents a request to capture a hold. Amount defaults to the whole hold;
+// whatever is not captured is released.
+type HoldCapture struct {
+	Amount *money.Amount `json:"amount,omitempty"`
+}
+
+// Hold represents funds reserved on the source account for a later transfer to the destination.
+// TransactionId is the transaction that recorded the capture.
+type Hold struct {
+	HoldId               int          `json:"hold_id"`
+	SourceAccountId      int          `json:"source_account_id"`
+	DestinationAccountId int          `json:"destination_account_id"`
+	Amount               money.Amount `json:"amount"`
+	Currency             string       `json:"currency"`
+	CapturedAmount       money.Amount `json:"captured_amount"`
+	Status               string       `json:"status"`
+	TransactionId        *int         `json:"transaction_id,omitempty"`
+	ExpiresAt            time.Time    `json:"expires_at"`
+	CreatedAt            time.Time    `json:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at"`
+}
+
+// Authorize reserves the amount on the source account. The reserved amount reduces the
+// available balance of the source but not its ledger balance until the hold is captured.
+func (svc *TransactionService) Authorize(ctx context.Context, data HoldCreate) (Hold, error) {
+	if data.Amount.Sign() < 0 {
+		slog.WarnContext(ctx, ErrTransactionSourceBalanceNegative.Error(), "amount", data.Amount)
+		return Hold{}, ErrTransactionSourceBalanceNegative
+	}
+
+	if data.SourceAccountId == data.DestinationAccountId {
+		slog.WarnContext(ctx, ErrTransactionSourceDestinationSame.Error(), "account_id", data.SourceAccountId)
+		return Hold{}, ErrTransactionSourceDestinationSame
+	}
+
+	timeout := time.Duration(data.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = defaultHoldTimeout
+	}
+	if timeout < 0 || timeout > maxHoldTimeout {
+		slog.WarnContext(ctx, ErrTransactionHoldTimeoutInvalid.Error(), "timeout_seconds", data.TimeoutSeconds)
+		return Hold{}, ErrTransactionHoldTimeoutInvalid
+	}
+
+	var row HoldRow
+	var holdErr error
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		row, holdErr = svc.authorize(ctx, data, svc.now().Add(timeout))
+		return holdErr
+	})
+	if holdErr != nil {
+		return Hold{}, holdErr
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return Hold{}, ErrTransactionHoldFailed
+	}
+
+	return toHold(row), nil
+}
+
+// Capture transfers all or part of a pending hold to its destination and releases the rest.
+// The transfer is recorded as a regular transaction with its journal entry. Captures are free:
+// the fee schedule of the source is not applied to them.
+func (svc *TransactionService) Capture(ctx context.Context, holdId int, data HoldCapture) (Hold, error) {
+	var row HoldRow
+	var holdErr error
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		row, holdErr = svc.capture(ctx, holdId, data)
+		if holdErr != nil {
+			return holdErr
+		}
+		return svc.enqueue(ctx, outbox.KindHoldPost, holdPayload(row, row.CapturedAmount))
+	})
+	if holdErr != nil {
+		return Hold{}, holdErr
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return Hold{}, ErrTransactionHoldFailed
+	}
+
+	return toHold(row), nil
+}
+
+// Void releases a pending hold without moving any money.
+func (svc *TransactionService) Void(ctx context.Context, holdId int) (Hold, error) {
+	var row HoldRow
+	var holdErr error
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		row, holdErr = svc.settle(ctx, holdId, HoldStatusVoided)
+		if holdErr != nil {
+			return holdErr
+		}
+		return svc.enqueue(ctx, outbox.KindHoldVoid, holdPayload(row, 0))
+	})
+	if holdErr != nil {
+		return Hold{}, holdErr
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return Hold{}, ErrTransactionHoldFailed
+	}
+
+	return toHold(row), nil
+}
+
+// HoldById retrieves a hold by its ID.
+func (svc *TransactionService) HoldById(ctx context.Context, holdId int) (Hold, error) {
+	row, err := svc.holdRepo.ById(ctx, holdId)
+	if err != nil {
+		slog.WarnContext(ctx, ErrTransactionHoldNotFound.Error(), "error", err)
+		return Hold{}, ErrTransactionHoldNotFound
+	}
+
+	return toHold(row), nil
+}
+
+// ExpireHolds releases the pending holds whose expiry has passed and returns how many it
+// released. The source accounts of the holds are locked once, in ascending id order like every
+// other lock of accounts. A hold that can not be released is logged and left pending so it does
+// not keep the others from expiring. TigerBeetle expires its pending transfers on its own
+// through their timeout.
+func (svc *TransactionService) ExpireHolds(ctx context.Context) (int, error) {
+	var count int
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		count = 0
+		holds, err := svc.holdRepo.ExpiredForUpdate(ctx, svc.now(), expireHoldsBatch)
+		if err != nil || len(holds) == 0 {
+			return err
+		}
+
+		accountIds := make([]int, len(holds))
+		for i, hold := range holds {
+			accountIds[i] = hold.SourceAccountId
+		}
+		slices.Sort(accountIds)
+		rows, err := svc.accountRepo.ByIdsForUpdate(ctx, slices.Compact(accountIds))
+		if err != nil {
+			return err
+		}
+		sources := map[int]*account.AccountRow{}
+		for i := range rows {
+			sources[rows[i].AccountId] = &rows[i]
+		}
+
+		for _, hold := range holds {
+			source := sources[hold.SourceAccountId]
+			if source == nil {
+				slog.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "reason", "source account of hold not found", "hold_id", hold.HoldId)
+				continue
+			}
+			heldBalance, err := released(hold, *source)
+			if err != nil {
+				slog.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "reason", "hold can not be released", "hold_id", hold.HoldId, "error", err)
+				continue
+			}
+
+			source.HeldBalance = heldBalance.Value()
+			err = svc.accountRepo.UpdateHeldBalance(ctx, account.AccountUpdateHeldBalanceParams{AccountId: source.AccountId, HeldBalance: source.HeldBalance})
+			if err != nil {
+				return err
+			}
+			if err := svc.holdRepo.UpdateStatus(ctx, HoldUpdateStatusParams{HoldId: hold.HoldId, Status: HoldStatusExpired}); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return 0, ErrTransactionHoldFailed
+	}
+
+	return count, nil
+}
+
+// authorize locks both accounts, reserves the amount on the source and records the hold with its
+// outbox message. It must run inside svc.uow so the writes commit or roll back together.
+func (svc *TransactionService) authorize(ctx context.Context, data HoldCreate, expiresAt time.Time) (HoldRow, error) {
+	logger := slog.With("source_account_id", data.SourceAccountId, "destination_account_id", data.DestinationAccountId, "amount", data.Amount)
+
+	if !auth.CanActOn(ctx, data.SourceAccountId) {
+		logger.WarnContext(ctx, ErrTransactionAccountForbidden.Error())
+		return HoldRow{}, ErrTransactionAccountForbidden
+	}
+
+	rows, err := svc.accountRepo.ByIdsForUpdate(ctx, []int{data.SourceAccountId, data.DestinationAccountId})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	sourceAccount, destinationAccount := findAccounts(rows, data.SourceAccountId, data.DestinationAccountId)
+	if destinationAccount == nil {
+		logger.WarnContext(ctx, ErrTransactionDestinationAccountNotFound.Error(), "account_id", data.DestinationAccountId)
+		return HoldRow{}, ErrTransactionDestinationAccountNotFound
+	}
+	if sourceAccount == nil {
+		logger.WarnContext(ctx, ErrTransactionSourceAccountNotFound.Error(), "account_id", data.SourceAccountId)
+		return HoldRow{}, ErrTransactionSourceAccountNotFound
+	}
+
+	if err := checkStatus(*sourceAccount, *destinationAccount); err != nil {
+		logger.WarnContext(ctx, err.Error(), "source_account_id", sourceAccount.AccountId, "source_status", sourceAccount.Status, "destination_account_id", destinationAccount.AccountId, "destination_status", destinationAccount.Status)
+		return HoldRow{}, err
+	}
+
+	if sourceAccount.Currency != destinationAccount.Currency {
+		logger.WarnContext(ctx, ErrTransactionCurrencyMismatch.Error(), "reason", "holds can not convert between currencies")
+		return HoldRow{}, ErrTransactionCurrencyMismatch
+	}
+
+	currency, err := money.LookupCurrency(sourceAccount.Currency)
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+	amount, err := data.Amount.Rescale(currency.Scale)
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, err
+	}
+	if amount.IsZero() {
+		logger.WarnContext(ctx, ErrTransactionHoldAmountInvalid.Error(), "reason", "zero amount")
+		return HoldRow{}, ErrTransactionHoldAmountInvalid
+	}
+
+	// The held amount is checked against the transfer limits of the source, as the capture
+	// moves it without checking them again.
+	if err := svc.checkLimits(ctx, *sourceAccount, amount); err != nil {
+		return HoldRow{}, err
+	}
+
+	heldBalance, err := addHeld(*sourceAccount, amount)
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+	held := *sourceAccount
+	held.HeldBalance = heldBalance.Value()
+	available, err := availableBalance(held, money.New(held.Balance, held.ScaleBalance))
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+	if available.Sign() < 0 {
+		logger.WarnContext(ctx, ErrTransactionSourceBalanceNotEnough.Error())
+		return HoldRow{}, ErrTransactionSourceBalanceNotEnough
+	}
+
+	err = svc.accountRepo.UpdateHeldBalance(ctx, account.AccountUpdateHeldBalanceParams{
+		AccountId:   sourceAccount.AccountId,
+		HeldBalance: heldBalance.Value(),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	row, err := svc.holdRepo.Create(ctx, HoldCreateParams{
+		SourceAccountId:      data.SourceAccountId,
+		DestinationAccountId: data.DestinationAccountId,
+		Amount:               amount.Value(),
+		AmountScale:          amount.Scale(),
+		Currency:             currency.Code,
+		ExpiresAt:            expiresAt,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	payload := holdPayload(row, row.Amount)
+	payload.SourceOverdraftEnabled = sourceAccount.OverdraftEnabled
+	payload.SourceOverdraftLimit = sourceAccount.OverdraftLimit
+	payload.TimeoutSeconds = outbox.HoldTimeoutSeconds(row.ExpiresAt, svc.now())
+	if err := svc.enqueue(ctx, outbox.KindHoldCreate, payload); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	return row, nil
+}
+
+// capture locks the hold and both accounts, releases the hold and transfers the captured
+// amount without a fee. It must run inside svc.uow so the writes commit or roll back together.
+func (svc *TransactionService) capture(ctx context.Context, holdId int, data HoldCapture) (HoldRow, error) {
+	logger := slog.With("hold_id", holdId)
+
+	hold, err := svc.pendingHold(ctx, holdId)
+	if err != nil {
+		return HoldRow{}, err
+	}
+
+	amount := money.New(hold.Amount, hold.AmountScale)
+	if data.Amount != nil {
+		captured, err := data.Amount.Rescale(hold.AmountScale)
+		if err != nil {
+			logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+			return HoldRow{}, err
+		}
+		if captured.Sign() <= 0 || captured.Cmp(amount) > 0 {
+			logger.WarnContext(ctx, ErrTransactionHoldAmountInvalid.Error(), "capture", captured, "amount", amount)
+			return HoldRow{}, ErrTransactionHoldAmountInvalid
+		}
+		amount = captured
+	}
+
+	rows, err := svc.accountRepo.ByIdsForUpdate(ctx, []int{hold.SourceAccountId, hold.DestinationAccountId})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	sourceAccount, destinationAccount := findAccounts(rows, hold.SourceAccountId, hold.DestinationAccountId)
+	if sourceAccount == nil || destinationAccount == nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "reason", "accounts of hold not found", "hold_id", holdId)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	if err := checkStatus(*sourceAccount, *destinationAccount); err != nil {
+		logger.WarnContext(ctx, err.Error(), "source_account_id", sourceAccount.AccountId, "source_status", sourceAccount.Status, "destination_account_id", destinationAccount.AccountId, "destination_status", destinationAccount.Status)
+		return HoldRow{}, err
+	}
+
+	if err := svc.release(ctx, hold, sourceAccount); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	postings := transferPostings(*sourceAccount, amount, *destinationAccount, amount)
+	balances, err := applyPostings(rows, postings)
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+	available, err := availableBalance(*sourceAccount, balances[hold.SourceAccountId])
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+	if available.Sign() < 0 {
+		logger.WarnContext(ctx, ErrTransactionSourceBalanceNotEnough.Error())
+		return HoldRow{}, ErrTransactionSourceBalanceNotEnough
+	}
+
+	transactionRow, err := svc.repo.Create(ctx, TransactionCreateParams{
+		SourceAccountId:        hold.SourceAccountId,
+		DestinationAccountId:   hold.DestinationAccountId,
+		Amount:                 amount.Value(),
+		AmountScale:            amount.Scale(),
+		Currency:               hold.Currency,
+		DestinationAmount:      amount.Value(),
+		DestinationAmountScale: amount.Scale(),
+		DestinationCurrency:    hold.Currency,
+		Kind:                   KindTransfer,
+		InitiatedBy:            auth.SubjectFrom(ctx),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	if err := svc.post(ctx, journal.Entry{Kind: journal.KindTransfer, TransactionId: transactionRow.TransactionId, Postings: postings}, balances); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	if err := svc.record(ctx, webhook.EventTransactionCreated, transactionRow); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	hold.Status = HoldStatusCaptured
+	hold.CapturedAmount = amount.Value()
+	hold.TransactionId = &transactionRow.TransactionId
+	err = svc.holdRepo.UpdateStatus(ctx, HoldUpdateStatusParams{
+		HoldId:         hold.HoldId,
+		Status:         hold.Status,
+		CapturedAmount: hold.CapturedAmount,
+		TransactionId:  hold.TransactionId,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	return hold, nil
+}
+
+// settle locks the hold and its source account and releases the hold with status. It must run
+// inside svc.uow.
+func (svc *TransactionService) settle(ctx context.Context, holdId int, status string) (HoldRow, error) {
+	hold, err := svc.pendingHold(ctx, holdId)
+	if err != nil {
+		return HoldRow{}, err
+	}
+
+	rows, err := svc.accountRepo.ByIdsForUpdate(ctx, []int{hold.SourceAccountId})
+	if err != nil || len(rows) == 0 {
+		slog.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "reason", "source account of hold", "hold_id", holdId, "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	if err := svc.release(ctx, hold, &rows[0]); err != nil {
+		slog.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	hold.Status = status
+	if err := svc.holdRepo.UpdateStatus(ctx, HoldUpdateStatusParams{HoldId: hold.HoldId, Status: status}); err != nil {
+		slog.ErrorContext(ctx, ErrTransactionHoldFailed.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldFailed
+	}
+
+	return hold, nil
+}
+
+// pendingHold locks the hold and checks that it can still be captured or voided.
+// An expired hold is returned together with ErrTransactionHoldExpired.
+func (svc *TransactionService) pendingHold(ctx context.Context, holdId int) (HoldRow, error) {
+	hold, err := svc.holdRepo.ByIdForUpdate(ctx, holdId)
+	if err != nil {
+		slog.WarnContext(ctx, ErrTransactionHoldNotFound.Error(), "error", err)
+		return HoldRow{}, ErrTransactionHoldNotFound
+	}
+
+	if !auth.CanActOn(ctx, hold.SourceAccountId) {
+		slog.WarnContext(ctx, ErrTransactionAccountForbidden.Error(), "hold_id", holdId, "account_id", hold.SourceAccountId)
+		return HoldRow{}, ErrTransactionAccountForbidden
+	}
+
+	if hold.Status != HoldStatusPending {
+		slog.WarnContext(ctx, ErrTransactionHoldNotPending.Error(), "hold_id", holdId, "status", hold.Status)
+		return HoldRow{}, ErrTransactionHoldNotPending
+	}
+
+	if !svc.now().Before(hold.ExpiresAt) {
+		slog.WarnContext(ctx, ErrTransactionHoldExpired.Error(), "hold_id", holdId)
+		return hold, ErrTransactionHoldExpired
+	}
+
+	return hold, nil
+}
+
+// release returns the amount of hold to the available balance of its locked source account.
+func (svc *TransactionService) release(ctx context.Context, hold HoldRow, source *account.AccountRow) error {
+	heldBalance, err := released(hold, *source)
+	if err != nil {
+		return err
+	}
+
+	source.HeldBalance = heldBalance.Value()
+	return svc.accountRepo.UpdateHeldBalance(ctx, account.AccountUpdateHeldBalanceParams{
+		AccountId:   source.AccountId,
+		HeldBalance: source.HeldBalance,
+	})
+}
+
+// released returns the held balance of source once hold is released, in the scale of source.
+func released(hold HoldRow, source account.AccountRow) (money.Amount, error) {
+	amount, err := money.New(hold.Amount, hold.AmountScale).Neg()
+	if err != nil {
+		return money.Amount{}, err
+	}
+	return addHeld(source, amount)
+}
+
+// addHeld returns the held balance of row increased by amount, in the scale of the row.
+func addHeld(row account.AccountRow, amount money.Amount) (money.Amount, error) {
+	held, err := money.New(row.HeldBalance, row.ScaleBalance).Add(amount)
+	if err != nil {
+		return money.Amount{}, err
+	}
+	return held.Rescale(row.ScaleBalance)
+}
+
+func toHold(row HoldRow) Hold {
+	return Hold{
+		HoldId:               row.HoldId,
+		SourceAccountId:      row.SourceAccountId,
+		DestinationAccountId: row.DestinationAccountId,
+		Amount:               money.New(row.Amount, row.AmountScale),
+		Currency:             row.Currency,
+		CapturedAmount:       money.New(row.CapturedAmount, row.AmountScale),
+		Status:               row.Status,
+		TransactionId:        row.TransactionId,
+		ExpiresAt:            row.ExpiresAt,
+		CreatedAt:            row.CreatedAt,
+		UpdatedAt:            row.UpdatedAt,
+	}
+}
+
+// holdPayload returns the outbox payload of a hold carrying amount.
+func holdPayload(row HoldRow, amount int64) outbox.HoldPayload {
+	return outbox.HoldPayload{
+		HoldId:               row.HoldId,
+		SourceAccountId:      row.SourceAccountId,
+		DestinationAccountId: row.DestinationAccountId,
+		Amount:               amount,
+		Currency:             row.Currency,
+		ExpiresAt:            row.ExpiresAt,
+	}
+}
@@ -0,0 +1,536 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/fee"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/limit"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+)
+
+func TestTransactionService_Authorize(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	accountRepo := func(balance, held int64, destinationCurrency string, gotHeld *int64) *fakeAccountRepo {
+		return &fakeAccountRepo{
+			ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+				return []account.AccountRow{
+					{AccountId: 1, Balance: balance, ScaleBalance: 2, Currency: "USD", HeldBalance: held},
+					{AccountId: 2, Balance: 0, ScaleBalance: 2, Currency: destinationCurrency},
+				}, nil
+			},
+			UpdateHeldBalanceFunc: func(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+				*gotHeld = params.HeldBalance
+				return nil
+			},
+		}
+	}
+	holdRepo := &fakeHoldRepo{
+		CreateFunc: func(ctx context.Context, params HoldCreateParams) (HoldRow, error) {
+			return HoldRow{
+				HoldId:               1,
+				SourceAccountId:      params.SourceAccountId,
+				DestinationAccountId: params.DestinationAccountId,
+				Amount:               params.Amount,
+				AmountScale:          params.AmountScale,
+				Currency:             params.Currency,
+				Status:               HoldStatusPending,
+				ExpiresAt:            params.ExpiresAt,
+			}, nil
+		},
+	}
+	// limits allow at most 4.00 per transfer.
+	limits := &fakeLimitChecker{
+		CheckFunc: func(ctx context.Context, transfer limit.Transfer) (*limit.Violation, error) {
+			if transfer.Amount.Cmp(money.New(400, 2)) > 0 {
+				return &limit.Violation{LimitId: 1, Kind: limit.KindSingleAmount, Scope: limit.ScopeAccount}, nil
+			}
+			return nil, nil
+		},
+	}
+
+	tests := []struct {
+		name            string
+		balance, held   int64
+		currency        string
+		data            HoldCreate
+		isTigerBeetleOn bool
+		wantHeld        int64
+		wantErr         error
+	}{
+		{
+			name:     "error - timeout too long",
+			currency: "USD",
+			data:     HoldCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(1, 0), TimeoutSeconds: 31 * 24 * 3600},
+			wantErr:  ErrTransactionHoldTimeoutInvalid,
+		},
+		{
+			name:     "error - zero amount",
+			balance:  1_000,
+			currency: "USD",
+			data:     HoldCreate{SourceAccountId: 1, DestinationAccountId: 2},
+			wantErr:  ErrTransactionHoldAmountInvalid,
+		},
+		{
+			name:     "error - cross currency",
+			balance:  1_000,
+			currency: "EUR",
+			data:     HoldCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(1, 0)},
+			wantErr:  ErrTransactionCurrencyMismatch,
+		},
+		{
+			name:     "error - available balance not enough",
+			balance:  1_000,
+			held:     700,
+			currency: "USD",
+			data:     HoldCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(4, 0)},
+			wantErr:  ErrTransactionSourceBalanceNotEnough,
+		},
+		{
+			name:     "error - available balance overflows",
+			balance:  1_000,
+			held:     math.MinInt64,
+			currency: "USD",
+			data:     HoldCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(4, 0)},
+			wantErr:  ErrTransactionHoldFailed,
+		},
+		{
+			name:     "error - over the single transfer limit",
+			balance:  1_000,
+			currency: "USD",
+			data:     HoldCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(401, 2)},
+			wantErr:  ErrTransactionLimitExceeded,
+		},
+		{
+			name:            "success - with tigerbeetle",
+			balance:         1_000,
+			held:            500,
+			currency:        "USD",
+			data:            HoldCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(4, 0), TimeoutSeconds: 60},
+			isTigerBeetleOn: true,
+			wantHeld:        900,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeld int64
+			outboxRepo := messageOutboxRepo(outbox.KindHoldCreate, func(payload outbox.HoldPayload) error {
+				if payload.HoldId != 1 || payload.Amount != 400 || !payload.ExpiresAt.Equal(now.Add(time.Minute)) || payload.TimeoutSeconds != 60 {
+					return fmt.Errorf("unexpected hold %d amount %d expiring at %s after %ds", payload.HoldId, payload.Amount, payload.ExpiresAt, payload.TimeoutSeconds)
+				}
+				return nil
+			})
+			svc := NewTransactionService(nil, holdRepo, accountRepo(tt.balance, tt.held, tt.currency, &gotHeld), nil, &fakeUnitOfWork{}, limits, nil, nil, outboxRepo, tt.isTigerBeetleOn)
+			svc.now = func() time.Time { return now }
+
+			got, err := svc.Authorize(t.Context(), tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TransactionService.Authorize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if gotHeld != tt.wantHeld {
+				t.Errorf("held balance = %d, want %d", gotHeld, tt.wantHeld)
+			}
+			if want := now.Add(time.Duration(tt.data.TimeoutSeconds) * time.Second); !got.ExpiresAt.Equal(want) {
+				t.Errorf("TransactionService.Authorize() expires at = %v, want %v", got.ExpiresAt, want)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Authorize_DailyLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var pending []HoldRow
+	holdRepo := &fakeHoldRepo{
+		CreateFunc: func(ctx context.Context, params HoldCreateParams) (HoldRow, error) {
+			row := HoldRow{
+				HoldId:          len(pending) + 1,
+				SourceAccountId: params.SourceAccountId,
+				Amount:          params.Amount,
+				AmountScale:     params.AmountScale,
+				Currency:        params.Currency,
+				Status:          HoldStatusPending,
+				ExpiresAt:       params.ExpiresAt,
+				CreatedAt:       now,
+			}
+			pending = append(pending, row)
+			return row, nil
+		},
+	}
+	accountRepo := &fakeAccountRepo{
+		ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+			return []account.AccountRow{
+				{AccountId: 1, Balance: 100_000, ScaleBalance: 2, Currency: "USD"},
+				{AccountId: 2, Balance: 0, ScaleBalance: 2, Currency: "USD"},
+			}, nil
+		},
+		UpdateHeldBalanceFunc: func(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+			return nil
+		},
+	}
+	// limitRepo allows 10.00 a day and, like the database, counts pending holds as outgoing.
+	daily, scale := int64(1_000), 2
+	limitRepo := &fakeLimitRepo{
+		ApplicableFunc: func(ctx context.Context, params limit.LimitApplicableParams) ([]limit.LimitRow, error) {
+			return []limit.LimitRow{{LimitId: 1, Kind: limit.KindDailyAmount, Scope: limit.ScopeGlobal, Amount: &daily, AmountScale: &scale}}, nil
+		},
+		OutgoingFunc: func(ctx context.Context, params limit.OutgoingParams) ([]limit.OutgoingRow, error) {
+			var rows []limit.OutgoingRow
+			for _, hold := range pending {
+				rows = append(rows, limit.OutgoingRow{HoldId: hold.HoldId, Amount: hold.Amount, AmountScale: hold.AmountScale, Currency: hold.Currency, CreatedAt: hold.CreatedAt})
+			}
+			return rows, nil
+		},
+	}
+
+	svc := NewTransactionService(nil, holdRepo, accountRepo, nil, &fakeUnitOfWork{}, limit.NewLimitService(limitRepo, nil), nil, nil, nil, false)
+	svc.now = func() time.Time { return now }
+
+	data := HoldCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(6, 0)}
+	if _, err := svc.Authorize(t.Context(), data); err != nil {
+		t.Fatalf("TransactionService.Authorize() first hold error = %v", err)
+	}
+	_, err := svc.Authorize(t.Context(), data)
+	if !errors.Is(err, ErrTransactionLimitExceeded) {
+		t.Fatalf("TransactionService.Authorize() second hold error = %v, want %v", err, ErrTransactionLimitExceeded)
+	}
+	if len(pending) != 1 {
+		t.Errorf("holds created = %d, want 1", len(pending))
+	}
+}
+
+func TestTransactionService_Capture(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	pending := HoldRow{
+		HoldId:               1,
+		SourceAccountId:      1,
+		DestinationAccountId: 2,
+		Amount:               500,
+		AmountScale:          2,
+		Currency:             "USD",
+		Status:               HoldStatusPending,
+		ExpiresAt:            now.Add(time.Hour),
+	}
+
+	holdRepo := func(row HoldRow, got *HoldUpdateStatusParams) *fakeHoldRepo {
+		return &fakeHoldRepo{
+			ByIdForUpdateFunc: func(ctx context.Context, holdId int) (HoldRow, error) {
+				return row, nil
+			},
+			UpdateStatusFunc: func(ctx context.Context, params HoldUpdateStatusParams) error {
+				*got = params
+				return nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		hold         HoldRow
+		data         HoldCapture
+		wantCaptured int64
+		wantErr      error
+	}{
+		{
+			name: "error - not pending",
+			hold: func() HoldRow {
+				row := pending
+				row.Status = HoldStatusVoided
+				return row
+			}(),
+			wantErr: ErrTransactionHoldNotPending,
+		},
+		{
+			name: "error - expired",
+			hold: func() HoldRow {
+				row := pending
+				row.ExpiresAt = now
+				return row
+			}(),
+			wantErr: ErrTransactionHoldExpired,
+		},
+		{
+			name:    "error - more than held",
+			hold:    pending,
+			data:    HoldCapture{Amount: ptr(money.New(501, 2))},
+			wantErr: ErrTransactionHoldAmountInvalid,
+		},
+		{
+			name:         "success - partial",
+			hold:         pending,
+			data:         HoldCapture{Amount: ptr(money.New(3, 0))},
+			wantCaptured: 300,
+		},
+		{
+			name:         "success - full",
+			hold:         pending,
+			wantCaptured: 500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotStatus HoldUpdateStatusParams
+			var gotHeld int64 = -1
+			balances := map[int]int64{}
+			accountRepo := &fakeAccountRepo{
+				ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+					return []account.AccountRow{
+						{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", HeldBalance: 500},
+						{AccountId: 2, Balance: 0, ScaleBalance: 2, Currency: "USD"},
+					}, nil
+				},
+				UpdateHeldBalanceFunc: func(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+					gotHeld = params.HeldBalance
+					return nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+					balances[params.AccountId] = params.Balance
+					return nil
+				},
+			}
+			var gotFee int64 = -1
+			repo := &fakeTransactionRepo{
+				CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+					gotFee = data.Fee
+					return TransactionRow{TransactionId: 9}, nil
+				},
+			}
+			journalRepo := &fakeJournalRepo{
+				CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+					return 1, nil
+				},
+			}
+			// Transfers of the source would be charged 1.00; captures are free.
+			fees := &fakeFeeCalculator{
+				FeeFunc: func(ctx context.Context, quote fee.Quote) (money.Amount, error) {
+					return money.New(100, 2), nil
+				},
+			}
+			svc := NewTransactionService(repo, holdRepo(tt.hold, &gotStatus), accountRepo, journalRepo, &fakeUnitOfWork{}, nil, fees, nil, nil, false)
+			svc.now = func() time.Time { return now }
+
+			got, err := svc.Capture(t.Context(), 1, tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TransactionService.Capture() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			if got.Status != HoldStatusCaptured || gotStatus.CapturedAmount != tt.wantCaptured || *gotStatus.TransactionId != 9 {
+				t.Errorf("hold update = %+v, want captured %d by transaction 9", gotStatus, tt.wantCaptured)
+			}
+			if gotHeld != 0 {
+				t.Errorf("held balance = %d, want the whole hold released", gotHeld)
+			}
+			if balances[1] != 1_000-tt.wantCaptured || balances[2] != tt.wantCaptured {
+				t.Errorf("balances = %v, want %d moved", balances, tt.wantCaptured)
+			}
+			if gotFee != 0 {
+				t.Errorf("transaction fee = %d, want captures free", gotFee)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Void(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var gotStatus HoldUpdateStatusParams
+	var gotHeld int64
+	holdRepo := &fakeHoldRepo{
+		ByIdForUpdateFunc: func(ctx context.Context, holdId int) (HoldRow, error) {
+			return HoldRow{HoldId: holdId, SourceAccountId: 1, Amount: 500, AmountScale: 2, Currency: "USD", Status: HoldStatusPending, ExpiresAt: now.Add(time.Hour)}, nil
+		},
+		UpdateStatusFunc: func(ctx context.Context, params HoldUpdateStatusParams) error {
+			gotStatus = params
+			return nil
+		},
+	}
+	accountRepo := &fakeAccountRepo{
+		ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+			return []account.AccountRow{{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", HeldBalance: 700}}, nil
+		},
+		UpdateHeldBalanceFunc: func(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+			gotHeld = params.HeldBalance
+			return nil
+		},
+	}
+	var voided int
+	outboxRepo := messageOutboxRepo(outbox.KindHoldVoid, func(payload outbox.HoldPayload) error {
+		voided = payload.HoldId
+		return nil
+	})
+
+	svc := NewTransactionService(nil, holdRepo, accountRepo, nil, &fakeUnitOfWork{}, nil, nil, nil, outboxRepo, true)
+	svc.now = func() time.Time { return now }
+
+	got, err := svc.Void(t.Context(), 3)
+	if err != nil {
+		t.Fatalf("TransactionService.Void() error = %v", err)
+	}
+	if got.Status != HoldStatusVoided || gotStatus.Status != HoldStatusVoided {
+		t.Errorf("TransactionService.Void() status = %s, stored %s", got.Status, gotStatus.Status)
+	}
+	if gotHeld != 200 {
+		t.Errorf("held balance = %d, want 200", gotHeld)
+	}
+	if voided != 3 {
+		t.Errorf("queued void of hold %d, want 3", voided)
+	}
+}
+
+func TestTransactionService_ExpireHolds(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Hold 3 belongs to an account that no longer exists and can not be released.
+	expired := []HoldRow{
+		{HoldId: 1, SourceAccountId: 3, Amount: 100, AmountScale: 2, Currency: "USD", Status: HoldStatusPending, ExpiresAt: now.Add(-time.Hour)},
+		{HoldId: 2, SourceAccountId: 1, Amount: 200, AmountScale: 2, Currency: "USD", Status: HoldStatusPending, ExpiresAt: now.Add(-time.Minute)},
+		{HoldId: 3, SourceAccountId: 9, Amount: 300, AmountScale: 2, Currency: "USD", Status: HoldStatusPending, ExpiresAt: now.Add(-time.Minute)},
+		{HoldId: 4, SourceAccountId: 3, Amount: 50, AmountScale: 2, Currency: "USD", Status: HoldStatusPending, ExpiresAt: now},
+	}
+
+	statuses := map[int]string{}
+	holdRepo := &fakeHoldRepo{
+		ExpiredForUpdateFunc: func(ctx context.Context, at time.Time, limit int) ([]HoldRow, error) {
+			if !at.Equal(now) {
+				return nil, fmt.Errorf("expired at %v, want %v", at, now)
+			}
+			return expired, nil
+		},
+		UpdateStatusFunc: func(ctx context.Context, params HoldUpdateStatusParams) error {
+			statuses[params.HoldId] = params.Status
+			return nil
+		},
+	}
+	var locks [][]int
+	held := map[int]int64{1: 200, 3: 150}
+	accountRepo := &fakeAccountRepo{
+		ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+			locks = append(locks, accountIds)
+			return []account.AccountRow{
+				{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", HeldBalance: held[1]},
+				{AccountId: 3, Balance: 1_000, ScaleBalance: 2, Currency: "USD", HeldBalance: held[3]},
+			}, nil
+		},
+		UpdateHeldBalanceFunc: func(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+			held[params.AccountId] = params.HeldBalance
+			return nil
+		},
+	}
+
+	svc := NewTransactionService(nil, holdRepo, accountRepo, nil, &fakeUnitOfWork{}, nil, nil, nil, nil, false)
+	svc.now = func() time.Time { return now }
+
+	got, err := svc.ExpireHolds(t.Context())
+	if err != nil {
+		t.Fatalf("TransactionService.ExpireHolds() error = %v", err)
+	}
+	if len(locks) != 1 || !slices.Equal(locks[0], []int{1, 3, 9}) {
+		t.Errorf("accounts locked %v, want [[1 3 9]] locked at once in ascending order", locks)
+	}
+	want := map[int]string{1: HoldStatusExpired, 2: HoldStatusExpired, 4: HoldStatusExpired}
+	if got != 3 || !maps.Equal(statuses, want) {
+		t.Errorf("TransactionService.ExpireHolds() = %d, statuses %v, want 3, %v", got, statuses, want)
+	}
+	if held[1] != 0 || held[3] != 0 {
+		t.Errorf("held balances = %v, want 0", held)
+	}
+}
+
+func TestTransactionService_Create_HeldBalance(t *testing.T) {
+	accountRepo := &fakeAccountRepo{
+		ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+			return []account.AccountRow{
+				{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", HeldBalance: 800},
+				{AccountId: 2, Balance: 0, ScaleBalance: 2, Currency: "USD"},
+			}, nil
+		},
+	}
+
+	svc := NewTransactionService(&fakeTransactionRepo{}, nil, accountRepo, nil, &fakeUnitOfWork{}, nil, nil, nil, nil, false)
+	_, err := svc.Create(t.Context(), TransactionCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(3, 0)})
+	if !errors.Is(err, ErrTransactionSourceBalanceNotEnough) {
+		t.Errorf("TransactionService.Create() error = %v, want %v", err, ErrTransactionSourceBalanceNotEnough)
+	}
+}
+
+type fakeLimitRepo struct {
+	CreateFunc     func(ctx context.Context, params limit.LimitCreateParams) (limit.LimitRow, bool, error)
+	ByIdFunc       func(ctx context.Context, limitId int) (limit.LimitRow, error)
+	ListFunc       func(ctx context.Context, params limit.LimitListParams) ([]limit.LimitRow, error)
+	UpdateFunc     func(ctx context.Context, params limit.LimitUpdateParams) (limit.LimitRow, error)
+	DeleteFunc     func(ctx context.Context, limitId int) (bool, error)
+	ApplicableFunc func(ctx context.Context, params limit.LimitApplicableParams) ([]limit.LimitRow, error)
+	OutgoingFunc   func(ctx context.Context, params limit.OutgoingParams) ([]limit.OutgoingRow, error)
+}
+
+func (f *fakeLimitRepo) Create(ctx context.Context, params limit.LimitCreateParams) (limit.LimitRow, bool, error) {
+	return f.CreateFunc(ctx, params)
+}
+
+func (f *fakeLimitRepo) ById(ctx context.Context, limitId int) (limit.LimitRow, error) {
+	return f.ByIdFunc(ctx, limitId)
+}
+
+func (f *fakeLimitRepo) List(ctx context.Context, params limit.LimitListParams) ([]limit.LimitRow, error) {
+	return f.ListFunc(ctx, params)
+}
+
+func (f *fakeLimitRepo) Update(ctx context.Context, params limit.LimitUpdateParams) (limit.LimitRow, error) {
+	return f.UpdateFunc(ctx, params)
+}
+
+func (f *fakeLimitRepo) Delete(ctx context.Context, limitId int) (bool, error) {
+	return f.DeleteFunc(ctx, limitId)
+}
+
+func (f *fakeLimitRepo) Applicable(ctx context.Context, params limit.LimitApplicableParams) ([]limit.LimitRow, error) {
+	return f.ApplicableFunc(ctx, params)
+}
+
+func (f *fakeLimitRepo) Outgoing(ctx context.Context, params limit.OutgoingParams) ([]limit.OutgoingRow, error) {
+	return f.OutgoingFunc(ctx, params)
+}
+
+type fakeHoldRepo struct {
+	CreateFunc           func(ctx context.Context, params HoldCreateParams) (HoldRow, error)
+	ByIdFunc             func(ctx context.Context, holdId int) (HoldRow, error)
+	ByIdForUpdateFunc    func(ctx context.Context, holdId int) (HoldRow, error)
+	ExpiredForUpdateFunc func(ctx context.Context, now time.Time, limit int) ([]HoldRow, error)
+	UpdateStatusFunc     func(ctx context.Context, params HoldUpdateStatusParams) error
+}
+
+func (f *fakeHoldRepo) Create(ctx context.Context, params HoldCreateParams) (HoldRow, error) {
+	return f.CreateFunc(ctx, params)
+}
+
+func (f *fakeHoldRepo) ById(ctx context.Context, holdId int) (HoldRow, error) {
+	return f.ByIdFunc(ctx, holdId)
+}
+
+func (f *fakeHoldRepo) ByIdForUpdate(ctx context.Context, holdId int) (HoldRow, error) {
+	return f.ByIdForUpdateFunc(ctx, holdId)
+}
+
+func (f *fakeHoldRepo) ExpiredForUpdate(ctx context.Context, now time.Time, limit int) ([]HoldRow, error) {
+	return f.ExpiredForUpdateFunc(ctx, now, limit)
+}
+
+func (f *fakeHoldRepo) UpdateStatus(ctx context.Context, params HoldUpdateStatusParams) error {
+	return f.UpdateStatusFunc(ctx, params)
+}
@@ -0,0 +1,56 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/limit"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+)
+
+var ErrTransactionLimitExceeded = errors.New("transaction limit exceeded")
+
+// LimitExceededError is returned by Create and Authorize when a transfer or hold would exceed a
+// transfer limit of its source account. Violation tells which limit tripped and when it resets.
+// It matches ErrTransactionLimitExceeded with errors.Is.
+type LimitExceededError struct {
+	Violation limit.Violation
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s: [limit_id: %d, kind: %s, scope: %s]", ErrTransactionLimitExceeded, e.Violation.LimitId, e.Violation.Kind, e.Violation.Scope)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrTransactionLimitExceeded
+}
+
+// checkLimits checks amount leaving source against the transfer limits of source. Source must be
+// locked so concurrent transfers are measured against each other.
+func (svc *TransactionService) checkLimits(ctx context.Context, source account.AccountRow, amount money.Amount) error {
+	if svc.limits == nil {
+		return nil
+	}
+
+	violation, err := svc.limits.Check(ctx, limit.Transfer{
+		AccountId: source.AccountId,
+		Tier:      source.Tier,
+		Currency:  source.Currency,
+		Amount:    amount,
+		At:        svc.now(),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+		return ErrTransactionCreateFailed
+	}
+	if violation != nil {
+		err := &LimitExceededError{*violation}
+		slog.WarnContext(ctx, err.Error(), "limit_id", violation.LimitId, "kind", violation.Kind, "scope", violation.Scope, "account_id", source.AccountId, "amount", amount)
+		return err
+	}
+
+	return nil
+}
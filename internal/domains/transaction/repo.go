@@ -2,21 +2,151 @@ package transaction
 
 import (
 	"context"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/fee"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/limit"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
 )
 
 // TransactionRepo defines the interface for transaction repository operations.
 type TransactionRepo interface {
-	Create(ctx context.Context, data TransactionCreateParams) error
+	Create(ctx context.Context, data TransactionCreateParams) (TransactionRow, error)
+	ById(ctx context.Context, transactionId int) (TransactionRow, error)
+	ByIdForUpdate(ctx context.Context, transactionId int) (TransactionRow, error)
+	ByAccount(ctx context.Context, params TransactionListParams) ([]TransactionRow, error)
+	UpdateReversal(ctx context.Context, params TransactionUpdateReversalParams) error
+}
+
+// HoldRepo defines the interface for persisting holds, the pending transfers that reserve
+// funds on the source account until they are captured, voided or expire.
+type HoldRepo interface {
+	Create(ctx context.Context, params HoldCreateParams) (HoldRow, error)
+	ById(ctx context.Context, holdId int) (HoldRow, error)
+	ByIdForUpdate(ctx context.Context, holdId int) (HoldRow, error)
+	ExpiredForUpdate(ctx context.Context, now time.Time, limit int) ([]HoldRow, error)
+	UpdateStatus(ctx context.Context, params HoldUpdateStatusParams) error
+}
+
+// LimitChecker checks a transfer against the transfer limits of its source account and returns
+// the limit it would exceed, or nil when it stays within all of them.
+type LimitChecker interface {
+	Check(ctx context.Context, transfer limit.Transfer) (*limit.Violation, error)
+}
+
+// FeeCalculator returns the fee charged on top of a transfer, in the scale of its amount.
+type FeeCalculator interface {
+	Fee(ctx context.Context, quote fee.Quote) (money.Amount, error)
+}
+
+// EventRecorder records the events of ledger changes for webhook subscribers. Record must join
+// the database transaction carried by ctx so an event is committed if and only if its change is.
+type EventRecorder interface {
+	Record(ctx context.Context, eventType string, data any) error
+}
+
+// UnitOfWork groups repository calls so they commit or roll back together.
+// Repository calls made with the context passed to fn take part in the same
+// database transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
-// AccountCreateParams holds the parameters required to create a new transaction.
+// TransactionCreateParams holds the parameters required to create a new transaction.
+// Amount is debited from the source in Currency and DestinationAmount is credited to the
+// destination in DestinationCurrency; both are equal for same-currency transfers. Fee is
+// debited from the source on top of Amount, in the same currency and scale. InitiatedBy is the
+// subject of the caller that made the transaction, nil for the background workers.
 type TransactionCreateParams struct {
+	SourceAccountId        int
+	DestinationAccountId   int
+	Amount                 int64
+	AmountScale            int
+	Currency               string
+	Fee                    int64
+	DestinationAmount      int64
+	DestinationAmountScale int
+	DestinationCurrency    string
+	Kind                   string
+	ReversalOf             *int
+	InitiatedBy            *string
+}
+
+// TransactionRow represents a row in the transactions table. Amounts are stored in
+// minor units together with the scale needed to format them; Fee shares the scale of Amount.
+type TransactionRow struct {
+	TransactionId             int       `db:"transaction_id"`
+	SourceAccountId           int       `db:"source_account_id"`
+	DestinationAccountId      int       `db:"destination_account_id"`
+	Amount                    int64     `db:"amount"`
+	AmountScale               int       `db:"scale_amount"`
+	Currency                  string    `db:"currency"`
+	Fee                       int64     `db:"fee"`
+	DestinationAmount         int64     `db:"destination_amount"`
+	DestinationAmountScale    int       `db:"scale_destination_amount"`
+	DestinationCurrency       string    `db:"destination_currency"`
+	Kind                      string    `db:"kind"`
+	Status                    string    `db:"status"`
+	ReversalOf                *int      `db:"reversal_of"`
+	ReversedAmount            int64     `db:"reversed_amount"`
+	ReversedDestinationAmount int64     `db:"reversed_destination_amount"`
+	InitiatedBy               *string   `db:"initiated_by"`
+	CreatedAt                 time.Time `db:"created_at"`
+	UpdatedAt                 time.Time `db:"updated_at"`
+}
+
+// TransactionListParams holds the filters used to list the transactions of an account.
+// Results are ordered by transaction_id descending; BeforeId, when non-zero, only returns
+// transactions older than it. Direction is empty, DirectionIncoming or DirectionOutgoing.
+type TransactionListParams struct {
+	AccountId int
+	Direction string
+	From      *time.Time
+	To        *time.Time
+	BeforeId  int
+	Limit     int
+}
+
+// TransactionUpdateReversalParams holds the running totals reversed from a transaction, in
+// the scales of its amount and destination amount, and the status they result in.
+type TransactionUpdateReversalParams struct {
+	TransactionId             int
+	ReversedAmount            int64
+	ReversedDestinationAmount int64
+	Status                    string
+}
+
+// HoldCreateParams holds the parameters required to create a pending hold.
+type HoldCreateParams struct {
 	SourceAccountId      int
 	DestinationAccountId int
-	Amount               int
+	Amount               int64
 	AmountScale          int
+	Currency             string
+	ExpiresAt            time.Time
+}
+
+// HoldRow represents a row in the holds table. CapturedAmount is in the scale of Amount and
+// TransactionId is the transaction recording the capture.
+type HoldRow struct {
+	HoldId               int       `db:"hold_id"`
+	SourceAccountId      int       `db:"source_account_id"`
+	DestinationAccountId int       `db:"destination_account_id"`
+	Amount               int64     `db:"amount"`
+	AmountScale          int       `db:"scale_amount"`
+	Currency             string    `db:"currency"`
+	CapturedAmount       int64     `db:"captured_amount"`
+	Status               string    `db:"status"`
+	TransactionId        *int      `db:"transaction_id"`
+	ExpiresAt            time.Time `db:"expires_at"`
+	CreatedAt            time.Time `db:"created_at"`
+	UpdatedAt            time.Time `db:"updated_at"`
 }
 
-type TransactionTBRepo interface {
-	CreateTransaction(debitAccountId int, creditAccountId int, amount int) error
+// HoldUpdateStatusParams holds the parameters required to settle a hold.
+type HoldUpdateStatusParams struct {
+	HoldId         int
+	Status         string
+	CapturedAmount int64
+	TransactionId  *int
 }
@@ -2,20 +2,44 @@ package transaction
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
 	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/webhook"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/gustialfian/transfer-system-golang/internal/domains/transaction")
+
 // TransactionService provides methods for managing transactions.
 type TransactionService struct {
 	repo        TransactionRepo
+	holdRepo    HoldRepo
 	accountRepo account.AccountRepo
+	journalRepo journal.JournalRepo
+	uow         UnitOfWork
+	limits      LimitChecker
+	fees        FeeCalculator
+	events      EventRecorder
+	now         func() time.Time
 
 	isTigerBeetleOn bool
-	tigerbeetleRepo TransactionTBRepo
+	outboxRepo      outbox.OutboxRepo
 }
 
 var (
@@ -25,93 +49,861 @@ var (
 	ErrTransactionSourceBalanceNotEnough     = errors.New("transaction source balance not enough")
 	ErrTransactionSourceBalanceNegative      = errors.New("transaction source balance negative")
 	ErrTransactionSourceDestinationSame      = errors.New("transaction source and destination account can not be the same")
+	ErrTransactionCurrencyMismatch           = errors.New("transaction source and destination currency differ")
+	ErrTransactionConversionInvalid          = errors.New("transaction conversion invalid")
+	ErrTransactionByIdFailed                 = errors.New("transaction by id fail")
+	ErrTransactionListFailed                 = errors.New("transaction list fail")
+	ErrTransactionAccountNotFound            = errors.New("transaction account not found")
+	ErrTransactionFilterInvalid              = errors.New("transaction filter invalid")
+	ErrTransactionNotFound                   = errors.New("transaction not found")
+	ErrTransactionNotReversible              = errors.New("transaction can not be reversed")
+	ErrTransactionAlreadyReversed            = errors.New("transaction already fully reversed")
+	ErrTransactionReversalExceedsOriginal    = errors.New("transaction reversal exceeds original amount")
+	ErrTransactionReverseFailed              = errors.New("transaction reversal fail")
+	ErrTransactionAccountNumberInvalid       = errors.New("transaction account number invalid")
+	ErrTransactionAccountForbidden           = errors.New("transaction account forbidden")
+
+	ErrTransactionSourceAccountFrozen             = errors.New("transaction source account frozen")
+	ErrTransactionSourceAccountDebitBlocked       = errors.New("transaction source account debit blocked")
+	ErrTransactionSourceAccountClosed             = errors.New("transaction source account closed")
+	ErrTransactionDestinationAccountFrozen        = errors.New("transaction destination account frozen")
+	ErrTransactionDestinationAccountCreditBlocked = errors.New("transaction destination account credit blocked")
+	ErrTransactionDestinationAccountClosed        = errors.New("transaction destination account closed")
+)
+
+// Transaction kinds. A reversal moves money back along a transfer and links to it through ReversalOf.
+// A sweep moves the balance left on an account being closed to another account.
+const (
+	KindTransfer = "transfer"
+	KindReversal = "reversal"
+	KindSweep    = "sweep"
+)
+
+// Transaction statuses. A transfer is partially reversed until its whole amount has been reversed.
+const (
+	StatusCompleted         = "completed"
+	StatusPartiallyReversed = "partially_reversed"
+	StatusReversed          = "reversed"
+)
+
+// Directions accepted by TransactionFilter, relative to the filtered account.
+const (
+	DirectionIncoming = "incoming"
+	DirectionOutgoing = "outgoing"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
 )
 
-// NewTransactionService creates a new TransactionService with the given dependency.
-func NewTransactionService(repo TransactionRepo, accountRepo account.AccountRepo, tigerbeetleRepo TransactionTBRepo, isTigerBeetleOn bool) *TransactionService {
-	return &TransactionService{repo, accountRepo, isTigerBeetleOn, tigerbeetleRepo}
+// NewTransactionService creates a new TransactionService with the given dependency. Transfers
+// are checked against limits and charged the fee of fees unless they are nil. New transactions
+// and reversals are recorded as webhook events in events unless it is nil. When isTigerBeetleOn is set, every ledger change
+// is queued in outboxRepo for synchronization to TigerBeetle.
+func NewTransactionService(repo TransactionRepo, holdRepo HoldRepo, accountRepo account.AccountRepo, journalRepo journal.JournalRepo, uow UnitOfWork, limits LimitChecker, fees FeeCalculator, events EventRecorder, outboxRepo outbox.OutboxRepo, isTigerBeetleOn bool) *TransactionService {
+	return &TransactionService{repo, holdRepo, accountRepo, journalRepo, uow, limits, fees, events, time.Now, isTigerBeetleOn, outboxRepo}
 }
 
-// TransactionCreate represents the required information to create a new transaction
+// TransactionCreate represents the required information to create a new transaction.
+// Accounts are given by id or by account number; numbers have their check digit validated and
+// must match the id when both are given. Amount is in the source account currency. Transfers
+// between accounts of different currencies must set DestinationAmount, the converted amount
+// credited to the destination.
 type TransactionCreate struct {
-	SourceAccountId      int    `json:"source_account_id"`
-	DestinationAccountId int    `json:"destination_account_id"`
-	Amount               string `json:"amount"`
+	SourceAccountId          int           `json:"source_account_id,omitempty"`
+	SourceAccountNumber      string        `json:"source_account_number,omitempty"`
+	DestinationAccountId     int           `json:"destination_account_id,omitempty"`
+	DestinationAccountNumber string        `json:"destination_account_number,omitempty"`
+	Amount                   money.Amount  `json:"amount"`
+	DestinationAmount        *money.Amount `json:"destination_amount,omitempty"`
+}
+
+// TransactionReverse represents a request to reverse a transfer. Amount is the part of the
+// original amount, in its source currency, credited back to the source; nil reverses everything
+// not reversed yet. Partial reversals of cross-currency transfers must also set DestinationAmount,
+// the part of the original destination amount debited back from the destination.
+type TransactionReverse struct {
+	Amount            *money.Amount `json:"amount,omitempty"`
+	DestinationAmount *money.Amount `json:"destination_amount,omitempty"`
+}
+
+// Transaction represents a recorded transaction. Amount is in Currency and DestinationAmount
+// in DestinationCurrency, both formatted with the scale they were stored with. Reversals set
+// ReversalOf to the transaction they reverse; the reversed totals of a transfer are in
+// ReversedAmount and ReversedDestinationAmount. Fee is charged to the source on top of Amount and
+// TotalDebited is their sum; reversals refund Amount but never Fee.
+type Transaction struct {
+	TransactionId             int          `json:"transaction_id"`
+	Kind                      string       `json:"kind"`
+	Status                    string       `json:"status"`
+	SourceAccountId           int          `json:"source_account_id"`
+	DestinationAccountId      int          `json:"destination_account_id"`
+	Amount                    money.Amount `json:"amount"`
+	Fee                       money.Amount `json:"fee"`
+	TotalDebited              money.Amount `json:"total_debited"`
+	Currency                  string       `json:"currency"`
+	DestinationAmount         money.Amount `json:"destination_amount"`
+	DestinationCurrency       string       `json:"destination_currency"`
+	ReversalOf                *int         `json:"reversal_of,omitempty"`
+	ReversedAmount            money.Amount `json:"reversed_amount"`
+	ReversedDestinationAmount money.Amount `json:"reversed_destination_amount"`
+	InitiatedBy               *string      `json:"initiated_by,omitempty"`
+	CreatedAt                 time.Time    `json:"created_at"`
+	UpdatedAt                 time.Time    `json:"updated_at"`
+}
+
+// TransactionFilter selects the transactions of an account. Direction is empty for both
+// directions, DirectionIncoming or DirectionOutgoing. From is inclusive and To exclusive.
+// Cursor is the NextCursor of a previous page, and Limit defaults to 20 with a maximum of 100.
+type TransactionFilter struct {
+	AccountId int
+	Direction string
+	From      *time.Time
+	To        *time.Time
+	Cursor    string
+	Limit     int
+}
+
+// TransactionPage is one page of transactions, newest first. NextCursor is empty on the last page.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+// Create executes a transaction by validating input, checking balances and transfer limits, charging the transfer
+// fee, updating accounts, and recording the transaction. A transfer exceeding a limit fails with a *LimitExceededError.
+// The source must cover the amount and the fee. The transaction record, its journal entry, the balance updates and the
+// outbox message for TigerBeetle are written in a single database transaction.
+func (svc *TransactionService) Create(ctx context.Context, data TransactionCreate) (Transaction, error) {
+	ctx, span := tracer.Start(ctx, "TransactionService.Create", trace.WithAttributes(
+		attribute.Int("transaction.source_account_id", data.SourceAccountId),
+		attribute.Int("transaction.destination_account_id", data.DestinationAccountId),
+	))
+	defer span.End()
+	if subject := auth.SubjectFrom(ctx); subject != nil {
+		span.SetAttributes(attribute.String("transaction.initiated_by", *subject))
+	}
+
+	tx, err := svc.create(ctx, data)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, ErrorCode(err))
+		return Transaction{}, err
+	}
+
+	span.SetAttributes(attribute.Int("transaction.id", tx.TransactionId))
+	return tx, nil
 }
 
-// Create executes a transaction by validating input, checking balances, updating accounts, and recording the transaction.
-func (svc *TransactionService) Create(ctx context.Context, data TransactionCreate) error {
-	amount, err := money.StringToInt(data.Amount, money.Scale)
+// create executes a transaction for Create.
+func (svc *TransactionService) create(ctx context.Context, data TransactionCreate) (Transaction, error) {
+	data, err := validateCreate(ctx, data)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	var row TransactionRow
+	var transferErr error
+	err = svc.uow.Do(ctx, func(ctx context.Context) error {
+		var payload outbox.TransferPayload
+		row, payload, transferErr = svc.transfer(ctx, data)
+		if transferErr != nil {
+			return transferErr
+		}
+		return svc.enqueue(ctx, outbox.KindTransferCreate, payload)
+	})
+	if transferErr != nil {
+		return Transaction{}, transferErr
+	}
 	if err != nil {
-		log.Printf("%s: %s\n", money.ErrMoneyParseFail, err)
-		return money.ErrMoneyParseFail
+		slog.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+		return Transaction{}, ErrTransactionCreateFailed
 	}
 
-	if amount < 0 {
-		log.Printf("%s\n", ErrTransactionSourceBalanceNegative)
-		return ErrTransactionSourceBalanceNegative
+	return toTransaction(row), nil
+}
+
+// validateCreate checks the parts of a transfer that do not depend on its accounts and returns it
+// with the accounts given by number resolved to their ids.
+func validateCreate(ctx context.Context, data TransactionCreate) (TransactionCreate, error) {
+	var err error
+	if data.SourceAccountId, err = resolveAccountNumber(data.SourceAccountId, data.SourceAccountNumber); err != nil {
+		slog.WarnContext(ctx, ErrTransactionAccountNumberInvalid.Error(), "error", err)
+		return TransactionCreate{}, ErrTransactionAccountNumberInvalid
+	}
+	if data.DestinationAccountId, err = resolveAccountNumber(data.DestinationAccountId, data.DestinationAccountNumber); err != nil {
+		slog.WarnContext(ctx, ErrTransactionAccountNumberInvalid.Error(), "error", err)
+		return TransactionCreate{}, ErrTransactionAccountNumberInvalid
+	}
+
+	if data.Amount.Sign() < 0 {
+		slog.WarnContext(ctx, ErrTransactionSourceBalanceNegative.Error(), "amount", data.Amount)
+		return TransactionCreate{}, ErrTransactionSourceBalanceNegative
 	}
 
 	if data.SourceAccountId == data.DestinationAccountId {
-		log.Printf("%s\n", ErrTransactionSourceDestinationSame)
-		return ErrTransactionSourceDestinationSame
+		slog.WarnContext(ctx, ErrTransactionSourceDestinationSame.Error(), "account_id", data.SourceAccountId)
+		return TransactionCreate{}, ErrTransactionSourceDestinationSame
+	}
+
+	return data, nil
+}
+
+// Reverse moves all or part of a transfer back from its destination to its source. The reversal
+// is recorded as a new transaction linked to the original, and the reversed totals and status
+// of the original are updated in the same database transaction. Cumulative reversals can never
+// exceed the original amounts.
+func (svc *TransactionService) Reverse(ctx context.Context, transactionId int, data TransactionReverse) (Transaction, error) {
+	if (data.Amount != nil && data.Amount.Sign() < 0) || (data.DestinationAmount != nil && data.DestinationAmount.Sign() < 0) {
+		slog.WarnContext(ctx, ErrTransactionSourceBalanceNegative.Error(), "transaction_id", transactionId, "amount", data.Amount, "destination_amount", data.DestinationAmount)
+		return Transaction{}, ErrTransactionSourceBalanceNegative
+	}
+
+	var row TransactionRow
+	var reverseErr error
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		row, reverseErr = svc.reverse(ctx, transactionId, data)
+		return reverseErr
+	})
+	if reverseErr != nil {
+		return Transaction{}, reverseErr
 	}
+	if err != nil {
+		slog.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return Transaction{}, ErrTransactionReverseFailed
+	}
+
+	return toTransaction(row), nil
+}
 
-	destinationAccount, err := svc.accountRepo.ById(ctx, data.DestinationAccountId)
+// ById retrieves a transaction by its ID.
+func (svc *TransactionService) ById(ctx context.Context, transactionId int) (Transaction, error) {
+	row, err := svc.repo.ById(ctx, transactionId)
+	if errors.Is(err, ErrTransactionNotFound) {
+		slog.WarnContext(ctx, ErrTransactionNotFound.Error(), "error", err)
+		return Transaction{}, ErrTransactionNotFound
+	}
 	if err != nil {
-		log.Printf("%s: %s\n", ErrTransactionDestinationAccountNotFound, err)
-		return ErrTransactionDestinationAccountNotFound
+		slog.ErrorContext(ctx, ErrTransactionByIdFailed.Error(), "error", err)
+		return Transaction{}, ErrTransactionByIdFailed
+	}
+
+	return toTransaction(row), nil
+}
+
+// ByAccount lists the transactions sent or received by an account, newest first, one page at a time.
+func (svc *TransactionService) ByAccount(ctx context.Context, filter TransactionFilter) (TransactionPage, error) {
+	if filter.Direction != "" && filter.Direction != DirectionIncoming && filter.Direction != DirectionOutgoing {
+		slog.WarnContext(ctx, ErrTransactionFilterInvalid.Error(), "direction", filter.Direction)
+		return TransactionPage{}, ErrTransactionFilterInvalid
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		slog.WarnContext(ctx, ErrTransactionFilterInvalid.Error(), "reason", "from is not before to", "from", filter.From, "to", filter.To)
+		return TransactionPage{}, ErrTransactionFilterInvalid
+	}
+
+	if filter.Limit < 0 || filter.Limit > maxListLimit {
+		slog.WarnContext(ctx, ErrTransactionFilterInvalid.Error(), "limit", filter.Limit)
+		return TransactionPage{}, ErrTransactionFilterInvalid
+	}
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultListLimit
 	}
 
-	sourceAccount, err := svc.accountRepo.ById(ctx, data.SourceAccountId)
+	beforeId, err := decodeCursor(filter.Cursor)
 	if err != nil {
-		log.Printf("%s: %s\n", ErrTransactionSourceAccountNotFound, err)
-		return ErrTransactionSourceAccountNotFound
+		slog.WarnContext(ctx, ErrTransactionFilterInvalid.Error(), "error", err)
+		return TransactionPage{}, ErrTransactionFilterInvalid
 	}
 
-	destinationBalance := destinationAccount.Balance + amount
-	sourceBalance := sourceAccount.Balance - amount
-	if sourceBalance < 0 {
-		log.Printf("%s\n", ErrTransactionSourceBalanceNotEnough)
-		return ErrTransactionSourceBalanceNotEnough
+	if _, err := svc.accountRepo.ById(ctx, filter.AccountId); err != nil {
+		slog.WarnContext(ctx, ErrTransactionAccountNotFound.Error(), "error", err)
+		return TransactionPage{}, ErrTransactionAccountNotFound
 	}
 
-	err = svc.accountRepo.UpdateBalance(ctx, account.AccountUpdateBalanceParams{
-		AccountId: data.SourceAccountId,
-		Balance:   sourceBalance,
+	rows, err := svc.repo.ByAccount(ctx, TransactionListParams{
+		AccountId: filter.AccountId,
+		Direction: filter.Direction,
+		From:      filter.From,
+		To:        filter.To,
+		BeforeId:  beforeId,
+		Limit:     limit + 1,
 	})
 	if err != nil {
-		log.Printf("%s: %s\n", ErrTransactionCreateFailed, err)
-		return ErrTransactionCreateFailed
+		slog.ErrorContext(ctx, ErrTransactionListFailed.Error(), "error", err)
+		return TransactionPage{}, ErrTransactionListFailed
 	}
 
-	err = svc.accountRepo.UpdateBalance(ctx, account.AccountUpdateBalanceParams{
-		AccountId: data.DestinationAccountId,
-		Balance:   destinationBalance,
-	})
+	page := TransactionPage{Transactions: make([]Transaction, 0, min(len(rows), limit))}
+	for i, row := range rows {
+		if i == limit {
+			page.NextCursor = encodeCursor(rows[i-1].TransactionId)
+			break
+		}
+		page.Transactions = append(page.Transactions, toTransaction(row))
+	}
+
+	return page, nil
+}
+
+func toTransaction(row TransactionRow) Transaction {
+	amount := money.New(row.Amount, row.AmountScale)
+	fee := money.New(row.Fee, row.AmountScale)
+	totalDebited, err := amount.Add(fee)
 	if err != nil {
-		log.Printf("%s: %s\n", ErrTransactionCreateFailed, err)
-		return ErrTransactionCreateFailed
+		slog.Error("transaction total debited", "transaction_id", row.TransactionId, "error", err)
+	}
+
+	return Transaction{
+		TransactionId:             row.TransactionId,
+		Kind:                      row.Kind,
+		Status:                    row.Status,
+		SourceAccountId:           row.SourceAccountId,
+		DestinationAccountId:      row.DestinationAccountId,
+		Amount:                    amount,
+		Fee:                       fee,
+		TotalDebited:              totalDebited,
+		Currency:                  row.Currency,
+		DestinationAmount:         money.New(row.DestinationAmount, row.DestinationAmountScale),
+		DestinationCurrency:       row.DestinationCurrency,
+		ReversalOf:                row.ReversalOf,
+		ReversedAmount:            money.New(row.ReversedAmount, row.AmountScale),
+		ReversedDestinationAmount: money.New(row.ReversedDestinationAmount, row.DestinationAmountScale),
+		InitiatedBy:               row.InitiatedBy,
+		CreatedAt:                 row.CreatedAt,
+		UpdatedAt:                 row.UpdatedAt,
+	}
+}
+
+// encodeCursor returns the opaque cursor resuming a listing after transactionId.
+func encodeCursor(transactionId int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(transactionId)))
+}
+
+// decodeCursor returns the transaction id encoded by encodeCursor, or 0 for an empty cursor.
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %q: %w", cursor, err)
+	}
+	id, err := strconv.Atoi(string(b))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return id, nil
+}
+
+// transfer locks both accounts, moves the amount from source to destination and records the
+// transaction together with the journal entry that derives the new balances. It returns the
+// payload of the outbox message for TigerBeetle, which the caller queues. It must run inside
+// svc.uow so the writes commit or roll back together.
+func (svc *TransactionService) transfer(ctx context.Context, data TransactionCreate) (TransactionRow, outbox.TransferPayload, error) {
+	logger := slog.With("source_account_id", data.SourceAccountId, "destination_account_id", data.DestinationAccountId, "amount", data.Amount)
+
+	if !auth.CanActOn(ctx, data.SourceAccountId) {
+		logger.WarnContext(ctx, ErrTransactionAccountForbidden.Error())
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionAccountForbidden
+	}
+
+	rows, err := svc.accountRepo.ByIdsForUpdate(ctx, []int{data.SourceAccountId, data.DestinationAccountId})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionCreateFailed
+	}
+
+	sourceAccount, destinationAccount := findAccounts(rows, data.SourceAccountId, data.DestinationAccountId)
+
+	if destinationAccount == nil {
+		logger.WarnContext(ctx, ErrTransactionDestinationAccountNotFound.Error())
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionDestinationAccountNotFound
+	}
+
+	if sourceAccount == nil {
+		logger.WarnContext(ctx, ErrTransactionSourceAccountNotFound.Error())
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionSourceAccountNotFound
+	}
+
+	if err := checkStatus(*sourceAccount, *destinationAccount); err != nil {
+		logger.WarnContext(ctx, err.Error(), "source_status", sourceAccount.Status, "destination_status", destinationAccount.Status)
+		return TransactionRow{}, outbox.TransferPayload{}, err
+	}
+
+	amount, destinationAmount, err := convert(data, *sourceAccount, *destinationAccount)
+	if errors.Is(err, ErrTransactionCreateFailed) {
+		logger.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "reason", "account currency unknown", "source_currency", sourceAccount.Currency, "destination_currency", destinationAccount.Currency)
+		return TransactionRow{}, outbox.TransferPayload{}, err
+	}
+	if err != nil {
+		logger.WarnContext(ctx, err.Error(), "source_currency", sourceAccount.Currency, "destination_currency", destinationAccount.Currency)
+		return TransactionRow{}, outbox.TransferPayload{}, err
+	}
+
+	if err := svc.checkLimits(ctx, *sourceAccount, amount); err != nil {
+		return TransactionRow{}, outbox.TransferPayload{}, err
+	}
+
+	charged, err := svc.transferFee(ctx, *sourceAccount, amount)
+	if err != nil {
+		return TransactionRow{}, outbox.TransferPayload{}, err
+	}
+
+	postings := append(transferPostings(*sourceAccount, amount, *destinationAccount, destinationAmount), feePostings(*sourceAccount, charged)...)
+	balances, err := applyPostings(rows, postings)
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionCreateFailed
+	}
+	available, err := availableBalance(*sourceAccount, balances[data.SourceAccountId])
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionCreateFailed
+	}
+	if available.Sign() < 0 {
+		logger.WarnContext(ctx, ErrTransactionSourceBalanceNotEnough.Error(), "available_balance", available, "fee", charged)
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionSourceBalanceNotEnough
 	}
 
 	params := TransactionCreateParams{
-		SourceAccountId:      data.SourceAccountId,
-		DestinationAccountId: data.DestinationAccountId,
-		Amount:               amount,
-		AmountScale:          money.Scale,
+		SourceAccountId:        data.SourceAccountId,
+		DestinationAccountId:   data.DestinationAccountId,
+		Amount:                 amount.Value(),
+		AmountScale:            amount.Scale(),
+		Currency:               sourceAccount.Currency,
+		Fee:                    charged.Value(),
+		DestinationAmount:      destinationAmount.Value(),
+		DestinationAmountScale: destinationAmount.Scale(),
+		DestinationCurrency:    destinationAccount.Currency,
+		Kind:                   KindTransfer,
+		InitiatedBy:            auth.SubjectFrom(ctx),
+	}
+
+	row, err := svc.repo.Create(ctx, params)
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionCreateFailed
+	}
+
+	if err := svc.post(ctx, journal.Entry{Kind: journal.KindTransfer, TransactionId: row.TransactionId, Postings: postings}, balances); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionCreateFailed
 	}
 
-	if err := svc.repo.Create(ctx, params); err != nil {
-		log.Printf("%s: %s\n", ErrTransactionCreateFailed, err)
-		return ErrTransactionCreateFailed
+	if err := svc.record(ctx, webhook.EventTransactionCreated, row); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionCreateFailed.Error(), "error", err)
+		return TransactionRow{}, outbox.TransferPayload{}, ErrTransactionCreateFailed
 	}
 
-	if svc.isTigerBeetleOn {
-		if err := svc.tigerbeetleRepo.CreateTransaction(data.DestinationAccountId, data.SourceAccountId, amount); err != nil {
-			log.Printf("%s: %s\n", ErrTransactionCreateFailed, err)
-			return ErrTransactionCreateFailed
+	payload := transferPayload(row)
+	payload.SourceOverdraftEnabled = sourceAccount.OverdraftEnabled
+	payload.SourceOverdraftLimit = sourceAccount.OverdraftLimit
+
+	return row, payload, nil
+}
+
+// post writes entry to the journal and stores the balances it produced. A transfer of zero
+// moves nothing and writes no entry.
+func (svc *TransactionService) post(ctx context.Context, entry journal.Entry, balances map[int]money.Amount) error {
+	if len(entry.Postings) == 0 {
+		return nil
+	}
+
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := svc.journalRepo.Create(ctx, entry.Params()); err != nil {
+		return err
+	}
+
+	for _, accountId := range slices.Sorted(maps.Keys(balances)) {
+		err := svc.accountRepo.UpdateBalance(ctx, account.AccountUpdateBalanceParams{
+			AccountId: accountId,
+			Balance:   balances[accountId].Value(),
+		})
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
+
+// transferPostings returns the postings moving amount out of source and destinationAmount
+// into destination. Cross-currency transfers go through the exchange account of each
+// currency so the postings balance per currency.
+func transferPostings(source account.AccountRow, amount money.Amount, destination account.AccountRow, destinationAmount money.Amount) []journal.Posting {
+	if amount.IsZero() {
+		return nil
+	}
+
+	if source.Currency == destination.Currency {
+		return []journal.Posting{
+			{AccountId: source.AccountId, Direction: journal.Debit, Amount: amount, Currency: source.Currency},
+			{AccountId: destination.AccountId, Direction: journal.Credit, Amount: destinationAmount, Currency: destination.Currency},
+		}
+	}
+
+	return []journal.Posting{
+		{AccountId: source.AccountId, Direction: journal.Debit, Amount: amount, Currency: source.Currency},
+		{SystemAccount: journal.ExchangeAccount(source.Currency), Direction: journal.Credit, Amount: amount, Currency: source.Currency},
+		{SystemAccount: journal.ExchangeAccount(destination.Currency), Direction: journal.Debit, Amount: destinationAmount, Currency: destination.Currency},
+		{AccountId: destination.AccountId, Direction: journal.Credit, Amount: destinationAmount, Currency: destination.Currency},
+	}
+}
+
+// reverse locks the original transaction and both accounts, moves the reversed amounts back and
+// records the reversal with its outbox message. It must run inside svc.uow so the writes commit
+// or roll back together.
+func (svc *TransactionService) reverse(ctx context.Context, transactionId int, data TransactionReverse) (TransactionRow, error) {
+	logger := slog.With("transaction_id", transactionId)
+
+	original, err := svc.repo.ByIdForUpdate(ctx, transactionId)
+	if errors.Is(err, ErrTransactionNotFound) {
+		logger.WarnContext(ctx, ErrTransactionNotFound.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+
+	if original.Kind != KindTransfer {
+		logger.WarnContext(ctx, ErrTransactionNotReversible.Error(), "transaction_id", transactionId, "kind", original.Kind)
+		return TransactionRow{}, ErrTransactionNotReversible
+	}
+
+	if !auth.CanActOn(ctx, original.DestinationAccountId) {
+		logger.WarnContext(ctx, ErrTransactionAccountForbidden.Error(), "account_id", original.DestinationAccountId)
+		return TransactionRow{}, ErrTransactionAccountForbidden
+	}
+
+	amount, destinationAmount, err := reversalAmounts(original, data)
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, err
+	}
+
+	rows, err := svc.accountRepo.ByIdsForUpdate(ctx, []int{original.SourceAccountId, original.DestinationAccountId})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+
+	sourceAccount, destinationAccount := findAccounts(rows, original.SourceAccountId, original.DestinationAccountId)
+	if sourceAccount == nil || destinationAccount == nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "reason", "accounts of transaction not found", "transaction_id", transactionId)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+
+	if err := checkStatus(*destinationAccount, *sourceAccount); err != nil {
+		logger.WarnContext(ctx, err.Error(), "source_account_id", destinationAccount.AccountId, "source_status", destinationAccount.Status, "destination_account_id", sourceAccount.AccountId, "destination_status", sourceAccount.Status)
+		return TransactionRow{}, err
+	}
+
+	postings := transferPostings(*destinationAccount, destinationAmount, *sourceAccount, amount)
+	balances, err := applyPostings(rows, postings)
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+	available, err := availableBalance(*destinationAccount, balances[original.DestinationAccountId])
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+	if available.Sign() < 0 {
+		logger.WarnContext(ctx, ErrTransactionSourceBalanceNotEnough.Error())
+		return TransactionRow{}, ErrTransactionSourceBalanceNotEnough
+	}
+
+	row, err := svc.repo.Create(ctx, TransactionCreateParams{
+		SourceAccountId:        original.DestinationAccountId,
+		DestinationAccountId:   original.SourceAccountId,
+		Amount:                 destinationAmount.Value(),
+		AmountScale:            destinationAmount.Scale(),
+		Currency:               original.DestinationCurrency,
+		DestinationAmount:      amount.Value(),
+		DestinationAmountScale: amount.Scale(),
+		DestinationCurrency:    original.Currency,
+		Kind:                   KindReversal,
+		ReversalOf:             &original.TransactionId,
+		InitiatedBy:            auth.SubjectFrom(ctx),
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+
+	if err := svc.post(ctx, journal.Entry{Kind: journal.KindReversal, TransactionId: row.TransactionId, Postings: postings}, balances); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+
+	reversedAmount := original.ReversedAmount + amount.Value()
+	status := StatusPartiallyReversed
+	if reversedAmount == original.Amount {
+		status = StatusReversed
+	}
+
+	err = svc.repo.UpdateReversal(ctx, TransactionUpdateReversalParams{
+		TransactionId:             original.TransactionId,
+		ReversedAmount:            reversedAmount,
+		ReversedDestinationAmount: original.ReversedDestinationAmount + destinationAmount.Value(),
+		Status:                    status,
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+
+	payload := transferPayload(row)
+	payload.SourceOverdraftEnabled = destinationAccount.OverdraftEnabled
+	payload.SourceOverdraftLimit = destinationAccount.OverdraftLimit
+	if err := svc.enqueue(ctx, outbox.KindTransferCreate, payload); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+
+	if err := svc.record(ctx, webhook.EventTransactionReversed, row); err != nil {
+		logger.ErrorContext(ctx, ErrTransactionReverseFailed.Error(), "error", err)
+		return TransactionRow{}, ErrTransactionReverseFailed
+	}
+
+	return row, nil
+}
+
+// reversalAmounts returns the part of the original amount credited back to the source and the
+// part of the original destination amount debited back from the destination, each in the scale
+// the original stored it with. Without an explicit amount everything not reversed yet is returned.
+func reversalAmounts(original TransactionRow, data TransactionReverse) (money.Amount, money.Amount, error) {
+	remaining := money.New(original.Amount-original.ReversedAmount, original.AmountScale)
+	remainingDestination := money.New(original.DestinationAmount-original.ReversedDestinationAmount, original.DestinationAmountScale)
+	if remaining.IsZero() || remainingDestination.IsZero() {
+		return money.Amount{}, money.Amount{}, ErrTransactionAlreadyReversed
+	}
+
+	if data.Amount == nil {
+		if data.DestinationAmount != nil {
+			return money.Amount{}, money.Amount{}, ErrTransactionConversionInvalid
+		}
+		return remaining, remainingDestination, nil
+	}
+
+	amount, err := data.Amount.Rescale(original.AmountScale)
+	if err != nil {
+		return money.Amount{}, money.Amount{}, err
+	}
+
+	destinationAmount := amount
+	if original.Currency == original.DestinationCurrency {
+		if data.DestinationAmount != nil && data.DestinationAmount.Cmp(amount) != 0 {
+			return money.Amount{}, money.Amount{}, ErrTransactionConversionInvalid
+		}
+	} else {
+		if data.DestinationAmount == nil {
+			return money.Amount{}, money.Amount{}, ErrTransactionCurrencyMismatch
+		}
+		if destinationAmount, err = data.DestinationAmount.Rescale(original.DestinationAmountScale); err != nil {
+			return money.Amount{}, money.Amount{}, err
+		}
+	}
+
+	if amount.Cmp(remaining) > 0 || destinationAmount.Cmp(remainingDestination) > 0 {
+		return money.Amount{}, money.Amount{}, ErrTransactionReversalExceedsOriginal
+	}
+
+	// Both sides must be settled by the same reversal, so the original is never left with
+	// an unreversed destination amount and nothing to credit back to the source.
+	if amount.IsZero() || destinationAmount.IsZero() || (amount.Cmp(remaining) == 0) != (destinationAmount.Cmp(remainingDestination) == 0) {
+		return money.Amount{}, money.Amount{}, ErrTransactionConversionInvalid
+	}
+
+	return amount, destinationAmount, nil
+}
+
+// convert returns the amount debited from source and the amount credited to destination,
+// each expressed in the scale of its account currency. Cross-currency transfers require
+// an explicit DestinationAmount; same-currency transfers must not carry a different one.
+func convert(data TransactionCreate, source, destination account.AccountRow) (money.Amount, money.Amount, error) {
+	sourceCurrency, err := money.LookupCurrency(source.Currency)
+	if err != nil {
+		return money.Amount{}, money.Amount{}, ErrTransactionCreateFailed
+	}
+	destinationCurrency, err := money.LookupCurrency(destination.Currency)
+	if err != nil {
+		return money.Amount{}, money.Amount{}, ErrTransactionCreateFailed
+	}
+
+	amount, err := data.Amount.Rescale(sourceCurrency.Scale)
+	if err != nil {
+		return money.Amount{}, money.Amount{}, err
+	}
+
+	if sourceCurrency == destinationCurrency {
+		if data.DestinationAmount != nil && data.DestinationAmount.Cmp(amount) != 0 {
+			return money.Amount{}, money.Amount{}, ErrTransactionConversionInvalid
+		}
+		return amount, amount, nil
+	}
+
+	if data.DestinationAmount == nil {
+		return money.Amount{}, money.Amount{}, ErrTransactionCurrencyMismatch
+	}
+
+	destinationAmount, err := data.DestinationAmount.Rescale(destinationCurrency.Scale)
+	if err != nil {
+		return money.Amount{}, money.Amount{}, err
+	}
+	if destinationAmount.Sign() < 0 || destinationAmount.IsZero() != amount.IsZero() {
+		return money.Amount{}, money.Amount{}, ErrTransactionConversionInvalid
+	}
+
+	return amount, destinationAmount, nil
+}
+
+// resolveAccountNumber returns the account id of accountNumber, or accountId when no number is
+// given. A number whose check digit fails or that names another account than a non-zero
+// accountId is rejected.
+func resolveAccountNumber(accountId int, accountNumber string) (int, error) {
+	if accountNumber == "" {
+		return accountId, nil
+	}
+
+	id, err := account.ParseAccountNumber(accountNumber)
+	if err != nil {
+		return 0, err
+	}
+	if accountId != 0 && accountId != id {
+		return 0, fmt.Errorf("account number %s is not account %d", accountNumber, accountId)
+	}
+	return id, nil
+}
+
+// findAccounts returns pointers to the rows of the source and destination accounts, or nil
+// for an account missing from rows.
+func findAccounts(rows []account.AccountRow, sourceAccountId, destinationAccountId int) (*account.AccountRow, *account.AccountRow) {
+	var source, destination *account.AccountRow
+	for i := range rows {
+		switch rows[i].AccountId {
+		case sourceAccountId:
+			source = &rows[i]
+		case destinationAccountId:
+			destination = &rows[i]
+		}
+	}
+	return source, destination
+}
+
+// checkStatus returns the error for the first of source and destination whose status does not
+// allow money to leave source or reach destination.
+func checkStatus(source, destination account.AccountRow) error {
+	switch source.Status {
+	case account.StatusFrozen:
+		return ErrTransactionSourceAccountFrozen
+	case account.StatusDebitBlocked:
+		return ErrTransactionSourceAccountDebitBlocked
+	case account.StatusClosed:
+		return ErrTransactionSourceAccountClosed
+	}
+
+	switch destination.Status {
+	case account.StatusFrozen:
+		return ErrTransactionDestinationAccountFrozen
+	case account.StatusCreditBlocked:
+		return ErrTransactionDestinationAccountCreditBlocked
+	case account.StatusClosed:
+		return ErrTransactionDestinationAccountClosed
+	}
+
+	return nil
+}
+
+// availableBalance returns balance minus the amount of row reserved by pending holds, plus the
+// overdraft limit of row. A negative result means the account would exceed its limit.
+func availableBalance(row account.AccountRow, balance money.Amount) (money.Amount, error) {
+	available, err := balance.Sub(money.New(row.HeldBalance, row.ScaleBalance))
+	if err != nil {
+		return money.Amount{}, err
+	}
+	return available.Add(money.New(row.OverdraftLimit, row.ScaleBalance))
+}
+
+// applyPostings returns the balance every locked account in rows ends up with once postings
+// are applied, expressed in the scale the row is stored with. Postings to system accounts
+// are skipped since those accounts only exist in the journal.
+func applyPostings(rows []account.AccountRow, postings []journal.Posting) (map[int]money.Amount, error) {
+	balances := map[int]money.Amount{}
+	for _, p := range postings {
+		if p.AccountId == 0 {
+			continue
+		}
+
+		i := slices.IndexFunc(rows, func(row account.AccountRow) bool { return row.AccountId == p.AccountId })
+		if i < 0 {
+			return nil, fmt.Errorf("account %d is not locked", p.AccountId)
+		}
+		if rows[i].Currency != p.Currency {
+			return nil, fmt.Errorf("account %d holds %s, posting is in %s", p.AccountId, rows[i].Currency, p.Currency)
+		}
+
+		balance, ok := balances[p.AccountId]
+		if !ok {
+			balance = money.New(rows[i].Balance, rows[i].ScaleBalance)
+		}
+		delta, err := p.Delta()
+		if err != nil {
+			return nil, err
+		}
+		if balance, err = balance.Add(delta); err != nil {
+			return nil, err
+		}
+		if balances[p.AccountId], err = balance.Rescale(rows[i].ScaleBalance); err != nil {
+			return nil, err
+		}
+	}
+	return balances, nil
+}
+
+// enqueue queues an outbox message synchronizing the change to TigerBeetle when it is enabled.
+func (svc *TransactionService) enqueue(ctx context.Context, kind string, payload any) error {
+	if !svc.isTigerBeetleOn {
+		return nil
+	}
+
+	return outbox.Enqueue(ctx, svc.outboxRepo, kind, payload)
+}
+
+// record records a webhook event of eventType about the transaction of row, unless events are
+// not recorded.
+func (svc *TransactionService) record(ctx context.Context, eventType string, row TransactionRow) error {
+	if svc.events == nil {
+		return nil
+	}
+
+	return svc.events.Record(ctx, eventType, toTransaction(row))
+}
+
+// transferPayload returns the outbox payload moving the amounts of row.
+func transferPayload(row TransactionRow) outbox.TransferPayload {
+	payload := outbox.TransferPayload{
+		TransactionId:        row.TransactionId,
+		SourceAccountId:      row.SourceAccountId,
+		SourceAmount:         row.Amount,
+		SourceCurrency:       row.Currency,
+		Fee:                  row.Fee,
+		DestinationAccountId: row.DestinationAccountId,
+		DestinationAmount:    row.DestinationAmount,
+		DestinationCurrency:  row.DestinationCurrency,
+	}
+	if row.ReversalOf != nil {
+		payload.ReversalOf = *row.ReversalOf
+	}
+
+	return payload
+}
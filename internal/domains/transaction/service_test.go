@@ -2,18 +2,56 @@ package transaction
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/fee"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/limit"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/webhook"
 )
 
 func TestTransactionService_Create(t *testing.T) {
+	transferJournalRepo := &fakeJournalRepo{
+		CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+			if params.Kind != journal.KindTransfer || len(params.Postings) != 2 {
+				return 0, fmt.Errorf("unexpected entry %+v", params)
+			}
+			return 1, nil
+		},
+	}
+	exchangeJournalRepo := &fakeJournalRepo{
+		CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+			if params.Kind != journal.KindTransfer || len(params.Postings) != 4 {
+				return 0, fmt.Errorf("unexpected entry %+v", params)
+			}
+			if *params.Postings[1].SystemAccount != "exchange:USD" || *params.Postings[2].SystemAccount != "exchange:JPY" {
+				return 0, fmt.Errorf("unexpected postings %+v", params.Postings)
+			}
+			return 1, nil
+		},
+	}
+
 	type fields struct {
 		repo            TransactionRepo
 		accountRepo     account.AccountRepo
+		journalRepo     journal.JournalRepo
+		uow             UnitOfWork
 		isTigerBeetleOn bool
-		tigerbeetleRepo TransactionTBRepo
+		outboxRepo      outbox.OutboxRepo
 	}
 	type args struct {
 		ctx  context.Context
@@ -26,17 +64,25 @@ func TestTransactionService_Create(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name: "error - invalid amount",
+			name: "error - amount too precise",
 			fields: fields{
-				repo:        &fakeTransactionRepo{},
-				accountRepo: &fakeAccountRepo{},
+				repo: &fakeTransactionRepo{},
+				accountRepo: &fakeAccountRepo{
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 1_000_000, ScaleBalance: 5, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 5, Currency: "USD"},
+						}, nil
+					},
+				},
+				uow: &fakeUnitOfWork{},
 			},
 			args: args{
 				ctx: t.Context(),
 				data: TransactionCreate{
 					SourceAccountId:      1,
 					DestinationAccountId: 2,
-					Amount:               "aaa",
+					Amount:               money.New(1, 3),
 				},
 			},
 			wantErr: true,
@@ -52,7 +98,7 @@ func TestTransactionService_Create(t *testing.T) {
 				data: TransactionCreate{
 					SourceAccountId:      1,
 					DestinationAccountId: 2,
-					Amount:               "-1",
+					Amount:               money.New(-1, 0),
 				},
 			},
 			wantErr: true,
@@ -68,7 +114,45 @@ func TestTransactionService_Create(t *testing.T) {
 				data: TransactionCreate{
 					SourceAccountId:      1,
 					DestinationAccountId: 1,
-					Amount:               "1",
+					Amount:               money.New(1, 0),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - source account not owned by caller",
+			fields: fields{
+				repo:        &fakeTransactionRepo{},
+				accountRepo: &fakeAccountRepo{},
+				uow:         &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx: auth.WithPrincipal(t.Context(), auth.Principal{Subject: "api_key:1", AccountIds: []int{2}}),
+				data: TransactionCreate{
+					SourceAccountId:      1,
+					DestinationAccountId: 2,
+					Amount:               money.New(1, 0),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - fail lock accounts",
+			fields: fields{
+				repo: &fakeTransactionRepo{},
+				accountRepo: &fakeAccountRepo{
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return nil, fmt.Errorf("test-error")
+					},
+				},
+				uow: &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx: t.Context(),
+				data: TransactionCreate{
+					SourceAccountId:      1,
+					DestinationAccountId: 2,
+					Amount:               money.New(1, 0),
 				},
 			},
 			wantErr: true,
@@ -78,17 +162,18 @@ func TestTransactionService_Create(t *testing.T) {
 			fields: fields{
 				repo: &fakeTransactionRepo{},
 				accountRepo: &fakeAccountRepo{
-					ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
-						return account.AccountRow{}, fmt.Errorf("test-error")
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{{AccountId: 1, Balance: 1_000_000, ScaleBalance: 5, Currency: "USD"}}, nil
 					},
 				},
+				uow: &fakeUnitOfWork{},
 			},
 			args: args{
 				ctx: t.Context(),
 				data: TransactionCreate{
 					SourceAccountId:      1,
 					DestinationAccountId: 2,
-					Amount:               "1",
+					Amount:               money.New(1, 0),
 				},
 			},
 			wantErr: true,
@@ -98,21 +183,21 @@ func TestTransactionService_Create(t *testing.T) {
 			fields: fields{
 				repo: &fakeTransactionRepo{},
 				accountRepo: &fakeAccountRepo{
-					ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
-						return account.AccountRow{
-							AccountId:    1,
-							Balance:      100_000,
-							ScaleBalance: 5,
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 100_000, ScaleBalance: 5, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 5, Currency: "USD"},
 						}, nil
 					},
 				},
+				uow: &fakeUnitOfWork{},
 			},
 			args: args{
 				ctx: t.Context(),
 				data: TransactionCreate{
 					SourceAccountId:      1,
 					DestinationAccountId: 2,
-					Amount:               "10",
+					Amount:               money.New(10, 0),
 				},
 			},
 			wantErr: true,
@@ -120,26 +205,31 @@ func TestTransactionService_Create(t *testing.T) {
 		{
 			name: "error - fail update account balance",
 			fields: fields{
-				repo: &fakeTransactionRepo{},
+				repo: &fakeTransactionRepo{
+					CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+						return TransactionRow{TransactionId: 1}, nil
+					},
+				},
+				journalRepo: transferJournalRepo,
 				accountRepo: &fakeAccountRepo{
-					ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
-						return account.AccountRow{
-							AccountId:    1,
-							Balance:      1_000_000,
-							ScaleBalance: 5,
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 1_000_000, ScaleBalance: 5, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 5, Currency: "USD"},
 						}, nil
 					},
 					UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
 						return fmt.Errorf("test-error")
 					},
 				},
+				uow: &fakeUnitOfWork{},
 			},
 			args: args{
 				ctx: t.Context(),
 				data: TransactionCreate{
 					SourceAccountId:      1,
 					DestinationAccountId: 2,
-					Amount:               "1",
+					Amount:               money.New(1, 0),
 				},
 			},
 			wantErr: true,
@@ -148,60 +238,205 @@ func TestTransactionService_Create(t *testing.T) {
 			name: "error - fail create transaction",
 			fields: fields{
 				repo: &fakeTransactionRepo{
-					CreateFunc: func(ctx context.Context, data TransactionCreateParams) error {
-						return fmt.Errorf("test-error")
+					CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+						return TransactionRow{}, fmt.Errorf("test-error")
 					},
 				},
 				accountRepo: &fakeAccountRepo{
-					ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
-						return account.AccountRow{
-							AccountId:    1,
-							Balance:      1_000_000,
-							ScaleBalance: 5,
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 1_000_000, ScaleBalance: 5, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 5, Currency: "USD"},
 						}, nil
 					},
 					UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
 						return nil
 					},
 				},
+				uow: &fakeUnitOfWork{},
 			},
 			args: args{
 				ctx: t.Context(),
 				data: TransactionCreate{
 					SourceAccountId:      1,
 					DestinationAccountId: 2,
-					Amount:               "1",
+					Amount:               money.New(1, 0),
 				},
 			},
 			wantErr: true,
 		},
 		{
-			name: "success",
+			name: "error - fail write journal",
+			fields: fields{
+				repo: &fakeTransactionRepo{
+					CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+						return TransactionRow{TransactionId: 1}, nil
+					},
+				},
+				accountRepo: &fakeAccountRepo{
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 1_000_000, ScaleBalance: 5, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 5, Currency: "USD"},
+						}, nil
+					},
+				},
+				journalRepo: &fakeJournalRepo{
+					CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+						return 0, fmt.Errorf("test-error")
+					},
+				},
+				uow: &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx: t.Context(),
+				data: TransactionCreate{
+					SourceAccountId:      1,
+					DestinationAccountId: 2,
+					Amount:               money.New(1, 0),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - cross currency without conversion",
+			fields: fields{
+				repo: &fakeTransactionRepo{},
+				accountRepo: &fakeAccountRepo{
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 100_000, ScaleBalance: 2, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 0, Currency: "JPY"},
+						}, nil
+					},
+				},
+				uow: &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx: t.Context(),
+				data: TransactionCreate{
+					SourceAccountId:      1,
+					DestinationAccountId: 2,
+					Amount:               money.New(1, 0),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - same currency with different destination amount",
+			fields: fields{
+				repo: &fakeTransactionRepo{},
+				accountRepo: &fakeAccountRepo{
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 100_000, ScaleBalance: 2, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 2, Currency: "USD"},
+						}, nil
+					},
+				},
+				uow: &fakeUnitOfWork{},
+			},
+			args: args{
+				ctx: t.Context(),
+				data: TransactionCreate{
+					SourceAccountId:      1,
+					DestinationAccountId: 2,
+					Amount:               money.New(1, 0),
+					DestinationAmount:    ptr(money.New(2, 0)),
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "success - cross currency with conversion",
 			fields: fields{
 				repo: &fakeTransactionRepo{
-					CreateFunc: func(ctx context.Context, data TransactionCreateParams) error {
+					CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+						if data.Amount != 100 || data.Currency != "USD" || data.DestinationAmount != 150 || data.DestinationCurrency != "JPY" {
+							return TransactionRow{}, fmt.Errorf("unexpected params %+v", data)
+						}
+						return TransactionRow{
+							TransactionId:          1,
+							SourceAccountId:        data.SourceAccountId,
+							DestinationAccountId:   data.DestinationAccountId,
+							Amount:                 data.Amount,
+							AmountScale:            data.AmountScale,
+							Currency:               data.Currency,
+							DestinationAmount:      data.DestinationAmount,
+							DestinationAmountScale: data.DestinationAmountScale,
+							DestinationCurrency:    data.DestinationCurrency,
+						}, nil
+					},
+				},
+				accountRepo: &fakeAccountRepo{
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 100_000, ScaleBalance: 2, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 0, Currency: "JPY"},
+						}, nil
+					},
+					UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
 						return nil
 					},
 				},
+				journalRepo:     exchangeJournalRepo,
+				uow:             &fakeUnitOfWork{},
+				isTigerBeetleOn: true,
+				outboxRepo: messageOutboxRepo(outbox.KindTransferCreate, func(payload outbox.TransferPayload) error {
+					want := outbox.TransferPayload{
+						TransactionId:        1,
+						SourceAccountId:      1,
+						SourceAmount:         100,
+						SourceCurrency:       "USD",
+						DestinationAccountId: 2,
+						DestinationAmount:    150,
+						DestinationCurrency:  "JPY",
+					}
+					if payload != want {
+						return fmt.Errorf("payload = %+v, want %+v", payload, want)
+					}
+					return nil
+				}),
+			},
+			args: args{
+				ctx: t.Context(),
+				data: TransactionCreate{
+					SourceAccountId:      1,
+					DestinationAccountId: 2,
+					Amount:               money.New(1, 0),
+					DestinationAmount:    ptr(money.New(150, 0)),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "success",
+			fields: fields{
+				repo: &fakeTransactionRepo{
+					CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+						return TransactionRow{}, nil
+					},
+				},
 				accountRepo: &fakeAccountRepo{
-					ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
-						return account.AccountRow{
-							AccountId:    1,
-							Balance:      1_000_000,
-							ScaleBalance: 5,
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 1_000_000, ScaleBalance: 5, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 5, Currency: "USD"},
 						}, nil
 					},
 					UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
 						return nil
 					},
 				},
+				journalRepo: transferJournalRepo,
+				uow:         &fakeUnitOfWork{},
 			},
 			args: args{
 				ctx: t.Context(),
 				data: TransactionCreate{
 					SourceAccountId:      1,
 					DestinationAccountId: 2,
-					Amount:               "1",
+					Amount:               money.New(1, 0),
 				},
 			},
 			wantErr: false,
@@ -210,33 +445,34 @@ func TestTransactionService_Create(t *testing.T) {
 			name: "success - with tigerbeetle",
 			fields: fields{
 				repo: &fakeTransactionRepo{
-					CreateFunc: func(ctx context.Context, data TransactionCreateParams) error {
-						return nil
+					CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+						return TransactionRow{}, nil
 					},
 				},
 				accountRepo: &fakeAccountRepo{
-					ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
-						return account.AccountRow{
-							AccountId:    1,
-							Balance:      1_000_000,
-							ScaleBalance: 5,
+					ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+						return []account.AccountRow{
+							{AccountId: 1, Balance: 1_000_000, ScaleBalance: 5, Currency: "USD"},
+							{AccountId: 2, Balance: 0, ScaleBalance: 5, Currency: "USD"},
 						}, nil
 					},
 					UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
 						return nil
 					},
 				},
+				journalRepo:     transferJournalRepo,
+				uow:             &fakeUnitOfWork{},
 				isTigerBeetleOn: true,
-				tigerbeetleRepo: &fakeAccountTBRepo{
-					CreateTransactionFunc: func(debitAccountId, creditAccountId, amount int) error { return nil },
-				},
+				outboxRepo: messageOutboxRepo(outbox.KindTransferCreate, func(payload outbox.TransferPayload) error {
+					return nil
+				}),
 			},
 			args: args{
 				ctx: t.Context(),
 				data: TransactionCreate{
 					SourceAccountId:      1,
 					DestinationAccountId: 2,
-					Amount:               "1",
+					Amount:               money.New(1, 0),
 				},
 			},
 			wantErr: false,
@@ -244,45 +480,1175 @@ func TestTransactionService_Create(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := NewTransactionService(tt.fields.repo, tt.fields.accountRepo, tt.fields.tigerbeetleRepo, tt.fields.isTigerBeetleOn)
-			if err := svc.Create(tt.args.ctx, tt.args.data); (err != nil) != tt.wantErr {
+			svc := NewTransactionService(tt.fields.repo, nil, tt.fields.accountRepo, tt.fields.journalRepo, tt.fields.uow, nil, nil, nil, tt.fields.outboxRepo, tt.fields.isTigerBeetleOn)
+			if _, err := svc.Create(tt.args.ctx, tt.args.data); (err != nil) != tt.wantErr {
 				t.Errorf("TransactionService.Create() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
-type fakeTransactionRepo struct {
-	CreateFunc func(ctx context.Context, data TransactionCreateParams) error
-}
+func TestTransactionService_Create_Concurrent(t *testing.T) {
+	const (
+		accountCount   = 10
+		initialBalance = 100_000_000
+		transferCount  = 500
+	)
 
-func (f *fakeTransactionRepo) Create(ctx context.Context, data TransactionCreateParams) error {
-	return f.CreateFunc(ctx, data)
+	ledger := newMemLedger(accountCount, initialBalance)
+	svc := NewTransactionService(&memTransactionRepo{ledger}, nil, &memAccountRepo{ledger}, &memJournalRepo{ledger}, ledger, nil, nil, nil, nil, false)
+
+	var wg sync.WaitGroup
+	var succeeded atomic.Int64
+	for i := range transferCount {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data := TransactionCreate{
+				SourceAccountId:      i%accountCount + 1,
+				DestinationAccountId: (i*7+3)%accountCount + 1,
+				Amount:               money.New(int64(i%50+1), 0),
+			}
+			if data.SourceAccountId == data.DestinationAccountId {
+				data.DestinationAccountId = data.SourceAccountId%accountCount + 1
+			}
+			_, err := svc.Create(t.Context(), data)
+			if err == nil {
+				succeeded.Add(1)
+			} else if !errors.Is(err, ErrTransactionSourceBalanceNotEnough) {
+				t.Errorf("TransactionService.Create() unexpected error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := int64(0)
+	for id, balance := range ledger.balances {
+		if balance < 0 {
+			t.Errorf("account %d balance = %d, want >= 0", id, balance)
+		}
+		total += balance
+	}
+	if want := int64(accountCount * initialBalance); total != want {
+		t.Errorf("total balance = %d, want %d", total, want)
+	}
+	for id, balance := range ledger.balances {
+		derived := money.New(initialBalance, 5)
+		for _, delta := range ledger.postings[id] {
+			derived, _ = derived.Add(delta)
+		}
+		if derived.Cmp(money.New(balance, 5)) != 0 {
+			t.Errorf("account %d balance = %d, journal derives %s", id, balance, derived)
+		}
+	}
+	if int64(ledger.transactionCount) != succeeded.Load() {
+		t.Errorf("transaction rows = %d, want %d", ledger.transactionCount, succeeded.Load())
+	}
 }
 
-type fakeAccountRepo struct {
-	CreateFunc        func(ctx context.Context, data account.AccountCreateParams) error
-	ByIdFunc          func(ctx context.Context, accountId int) (account.AccountRow, error)
-	UpdateBalanceFunc func(ctx context.Context, params account.AccountUpdateBalanceParams) error
+func TestTransactionService_Create_Limits(t *testing.T) {
+	resetsAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	violation := limit.Violation{LimitId: 7, Kind: limit.KindDailyAmount, Scope: limit.ScopeTier, ResetsAt: &resetsAt}
+
+	tests := []struct {
+		name          string
+		violation     *limit.Violation
+		checkErr      error
+		wantViolation bool
+		wantErr       error
+	}{
+		{name: "success - within limits"},
+		{name: "error - limit exceeded", violation: &violation, wantViolation: true, wantErr: ErrTransactionLimitExceeded},
+		{name: "error - check fail", checkErr: limit.ErrLimitCheckFailed, wantErr: ErrTransactionCreateFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created bool
+			accountRepo := &fakeAccountRepo{
+				ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+					return []account.AccountRow{
+						{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Tier: "premium"},
+						{AccountId: 2, ScaleBalance: 2, Currency: "USD"},
+					}, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error { return nil },
+			}
+			repo := &fakeTransactionRepo{
+				CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+					created = true
+					return TransactionRow{TransactionId: 1}, nil
+				},
+			}
+			journalRepo := &fakeJournalRepo{
+				CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) { return 1, nil },
+			}
+			limits := &fakeLimitChecker{
+				CheckFunc: func(ctx context.Context, transfer limit.Transfer) (*limit.Violation, error) {
+					if transfer.AccountId != 1 || transfer.Tier != "premium" || transfer.Currency != "USD" || transfer.Amount != money.New(300, 2) {
+						return nil, fmt.Errorf("unexpected transfer %+v", transfer)
+					}
+					return tt.violation, tt.checkErr
+				},
+			}
+
+			svc := NewTransactionService(repo, nil, accountRepo, journalRepo, &fakeUnitOfWork{}, limits, nil, nil, nil, false)
+			_, err := svc.Create(t.Context(), TransactionCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(3, 0)})
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("TransactionService.Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if created != (err == nil) {
+				t.Errorf("transaction created = %v, want %v", created, err == nil)
+			}
+
+			var limitErr *LimitExceededError
+			if errors.As(err, &limitErr) != tt.wantViolation {
+				t.Fatalf("TransactionService.Create() error = %v, want violation %v", err, tt.wantViolation)
+			}
+			if tt.wantViolation && (limitErr.Violation.LimitId != violation.LimitId || limitErr.Violation.ResetsAt != &resetsAt) {
+				t.Errorf("violation = %+v, want %+v", limitErr.Violation, violation)
+			}
+		})
+	}
 }
 
-func (f *fakeAccountRepo) Create(ctx context.Context, data account.AccountCreateParams) error {
-	return f.CreateFunc(ctx, data)
+func TestTransactionService_Create_Events(t *testing.T) {
+	tests := []struct {
+		name      string
+		recordErr error
+		wantErr   error
+	}{
+		{name: "success - transaction.created recorded"},
+		{name: "error - record fail", recordErr: webhook.ErrWebhookEventRecordFailed, wantErr: ErrTransactionCreateFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := &fakeAccountRepo{
+				ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+					return []account.AccountRow{
+						{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD"},
+						{AccountId: 2, ScaleBalance: 2, Currency: "USD"},
+					}, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error { return nil },
+			}
+			repo := &fakeTransactionRepo{
+				CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+					return TransactionRow{TransactionId: 9, SourceAccountId: 1, DestinationAccountId: 2, Amount: data.Amount, AmountScale: 2, Currency: "USD"}, nil
+				},
+			}
+			journalRepo := &fakeJournalRepo{
+				CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) { return 1, nil },
+			}
+			var gotTypes []string
+			var gotData []any
+			events := &fakeEventRecorder{
+				RecordFunc: func(ctx context.Context, eventType string, data any) error {
+					gotTypes = append(gotTypes, eventType)
+					gotData = append(gotData, data)
+					return tt.recordErr
+				},
+			}
+
+			svc := NewTransactionService(repo, nil, accountRepo, journalRepo, &fakeUnitOfWork{}, nil, nil, events, nil, false)
+			_, err := svc.Create(t.Context(), TransactionCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(3, 0)})
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("TransactionService.Create() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(gotTypes, []string{webhook.EventTransactionCreated}) {
+				t.Fatalf("recorded events = %v, want %v", gotTypes, []string{webhook.EventTransactionCreated})
+			}
+			if tx, ok := gotData[0].(Transaction); !ok || tx.TransactionId != 9 || tx.Amount != money.New(300, 2) {
+				t.Errorf("recorded data = %+v, want transaction 9 of 3.00", gotData[0])
+			}
+		})
+	}
 }
 
-func (f *fakeAccountRepo) ById(ctx context.Context, accountId int) (account.AccountRow, error) {
-	return f.ByIdFunc(ctx, accountId)
+func TestTransactionService_Create_Fees(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      money.Amount
+		fee         money.Amount
+		feeErr      error
+		wantBalance int64
+		wantFees    int64
+		wantErr     error
+	}{
+		{name: "success - free", amount: money.New(3, 0), fee: money.New(0, 2), wantBalance: 700},
+		{name: "success - fee charged on top", amount: money.New(3, 0), fee: money.New(50, 2), wantBalance: 650, wantFees: 50},
+		{name: "success - amount and fee use the whole balance", amount: money.New(950, 2), fee: money.New(50, 2), wantBalance: 0, wantFees: 50},
+		{name: "error - fee exceeds what is left", amount: money.New(951, 2), fee: money.New(50, 2), wantErr: ErrTransactionSourceBalanceNotEnough},
+		{name: "error - fee quote fail", amount: money.New(3, 0), feeErr: fee.ErrFeeQuoteFailed, wantErr: ErrTransactionCreateFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBalance, gotFees int64
+			var gotParams TransactionCreateParams
+			var gotPayload outbox.TransferPayload
+			accountRepo := &fakeAccountRepo{
+				ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+					return []account.AccountRow{
+						{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", Tier: "premium"},
+						{AccountId: 2, ScaleBalance: 2, Currency: "USD"},
+					}, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+					if params.AccountId == 1 {
+						gotBalance = params.Balance
+					}
+					return nil
+				},
+			}
+			repo := &fakeTransactionRepo{
+				CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+					gotParams = data
+					return TransactionRow{TransactionId: 1, SourceAccountId: 1, DestinationAccountId: 2, Amount: data.Amount, AmountScale: data.AmountScale, Currency: data.Currency, Fee: data.Fee}, nil
+				},
+			}
+			journalRepo := &fakeJournalRepo{
+				CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+					for _, p := range params.Postings {
+						if p.SystemAccount != nil && *p.SystemAccount == journal.FeesAccount("USD") && p.Direction == string(journal.Credit) {
+							gotFees += p.Amount
+						}
+					}
+					return 1, nil
+				},
+			}
+			outboxRepo := &fakeOutboxRepo{
+				CreateFunc: func(ctx context.Context, params outbox.MessageCreateParams) error {
+					return json.Unmarshal(params.Payload, &gotPayload)
+				},
+			}
+			fees := &fakeFeeCalculator{
+				FeeFunc: func(ctx context.Context, quote fee.Quote) (money.Amount, error) {
+					if quote.Tier != "premium" || quote.Currency != "USD" || quote.Amount.Scale() != 2 {
+						return money.Amount{}, fmt.Errorf("unexpected quote %+v", quote)
+					}
+					return tt.fee, tt.feeErr
+				},
+			}
+
+			svc := NewTransactionService(repo, nil, accountRepo, journalRepo, &fakeUnitOfWork{}, nil, fees, nil, outboxRepo, true)
+			got, err := svc.Create(t.Context(), TransactionCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: tt.amount})
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("TransactionService.Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if gotBalance != tt.wantBalance {
+				t.Errorf("source balance = %d, want %d", gotBalance, tt.wantBalance)
+			}
+			if gotFees != tt.wantFees || gotParams.Fee != tt.wantFees || gotPayload.Fee != tt.wantFees {
+				t.Errorf("fee posted = %d, recorded = %d, queued = %d, want %d", gotFees, gotParams.Fee, gotPayload.Fee, tt.wantFees)
+			}
+
+			totalDebited, _ := tt.amount.Add(tt.fee)
+			if got.Fee.Cmp(tt.fee) != 0 || got.TotalDebited.Cmp(totalDebited) != 0 {
+				t.Errorf("TransactionService.Create() fee = %s, total debited = %s, want %s, %s", got.Fee, got.TotalDebited, tt.fee, totalDebited)
+			}
+		})
+	}
 }
 
-func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, params account.AccountUpdateBalanceParams) error {
-	return f.UpdateBalanceFunc(ctx, params)
+func TestTransactionService_Create_Overdraft(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      money.Amount
+		held        int64
+		wantBalance int64
+		wantErr     error
+	}{
+		{name: "success - within limit", amount: money.New(30, 0), wantBalance: -2_000},
+		{name: "success - up to limit", amount: money.New(60, 0), wantBalance: -5_000},
+		{name: "error - beyond limit", amount: money.New(6_001, 2), wantErr: ErrTransactionSourceBalanceNotEnough},
+		{name: "error - available balance overflows", amount: money.New(30, 0), held: math.MinInt64, wantErr: ErrTransactionCreateFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotBalance int64
+			var gotPayload outbox.TransferPayload
+			accountRepo := &fakeAccountRepo{
+				ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+					return []account.AccountRow{
+						{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD", HeldBalance: tt.held, OverdraftLimit: 5_000, OverdraftEnabled: true},
+						{AccountId: 2, ScaleBalance: 2, Currency: "USD"},
+					}, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+					if params.AccountId == 1 {
+						gotBalance = params.Balance
+					}
+					return nil
+				},
+			}
+			repo := &fakeTransactionRepo{
+				CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+					return TransactionRow{TransactionId: 1, SourceAccountId: 1, DestinationAccountId: 2, Amount: data.Amount, Currency: data.Currency}, nil
+				},
+			}
+			journalRepo := &fakeJournalRepo{
+				CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) { return 1, nil },
+			}
+			outboxRepo := &fakeOutboxRepo{
+				CreateFunc: func(ctx context.Context, params outbox.MessageCreateParams) error {
+					return json.Unmarshal(params.Payload, &gotPayload)
+				},
+			}
+
+			svc := NewTransactionService(repo, nil, accountRepo, journalRepo, &fakeUnitOfWork{}, nil, nil, nil, outboxRepo, true)
+			_, err := svc.Create(t.Context(), TransactionCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: tt.amount})
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("TransactionService.Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if gotBalance != tt.wantBalance {
+				t.Errorf("source balance = %d, want %d", gotBalance, tt.wantBalance)
+			}
+			if !gotPayload.SourceOverdraftEnabled || gotPayload.SourceOverdraftLimit != 5_000 {
+				t.Errorf("outbox payload = %+v, want source overdraft enabled with limit 5000", gotPayload)
+			}
+		})
+	}
 }
 
-type fakeAccountTBRepo struct {
-	CreateAccountFunc     func(accountId int) error
-	CreateTransactionFunc func(debitAccountId int, creditAccountId int, amount int) error
+func TestTransactionService_Create_AccountNumber(t *testing.T) {
+	accountRepo := &fakeAccountRepo{
+		ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+			if !slices.Equal(accountIds, []int{1, 42}) {
+				return nil, fmt.Errorf("unexpected accounts %v", accountIds)
+			}
+			return []account.AccountRow{
+				{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD"},
+				{AccountId: 42, ScaleBalance: 2, Currency: "USD"},
+			}, nil
+		},
+		UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error { return nil },
+	}
+	repo := &fakeTransactionRepo{
+		CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+			return TransactionRow{TransactionId: 1, SourceAccountId: data.SourceAccountId, DestinationAccountId: data.DestinationAccountId}, nil
+		},
+	}
+	journalRepo := &fakeJournalRepo{
+		CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) { return 1, nil },
+	}
+
+	tests := []struct {
+		name    string
+		data    TransactionCreate
+		wantErr error
+	}{
+		{
+			name: "success - numbers",
+			data: TransactionCreate{SourceAccountNumber: "18", DestinationAccountNumber: "422", Amount: money.New(1, 0)},
+		},
+		{
+			name: "success - number matching id",
+			data: TransactionCreate{SourceAccountId: 1, SourceAccountNumber: "18", DestinationAccountId: 42, Amount: money.New(1, 0)},
+		},
+		{
+			name:    "error - wrong check digit",
+			data:    TransactionCreate{SourceAccountNumber: "18", DestinationAccountNumber: "421", Amount: money.New(1, 0)},
+			wantErr: ErrTransactionAccountNumberInvalid,
+		},
+		{
+			name:    "error - number of another account",
+			data:    TransactionCreate{SourceAccountId: 2, SourceAccountNumber: "18", DestinationAccountId: 42, Amount: money.New(1, 0)},
+			wantErr: ErrTransactionAccountNumberInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTransactionService(repo, nil, accountRepo, journalRepo, &fakeUnitOfWork{}, nil, nil, nil, nil, false)
+			got, err := svc.Create(t.Context(), tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("TransactionService.Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got.SourceAccountId != 1 || got.DestinationAccountId != 42) {
+				t.Errorf("TransactionService.Create() = %+v, want accounts 1 and 42", got)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Create_InitiatedBy(t *testing.T) {
+	subject := "service:payouts"
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want *string
+	}{
+		{name: "success - caller recorded", ctx: auth.WithPrincipal(t.Context(), auth.Principal{Subject: subject}), want: &subject},
+		{name: "success - no caller", ctx: t.Context()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accountRepo := &fakeAccountRepo{
+				ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+					return []account.AccountRow{
+						{AccountId: 1, Balance: 1_000, ScaleBalance: 2, Currency: "USD"},
+						{AccountId: 2, ScaleBalance: 2, Currency: "USD"},
+					}, nil
+				},
+				UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error { return nil },
+			}
+			repo := &fakeTransactionRepo{
+				CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+					return TransactionRow{TransactionId: 1, SourceAccountId: 1, DestinationAccountId: 2, InitiatedBy: data.InitiatedBy}, nil
+				},
+			}
+			journalRepo := &fakeJournalRepo{
+				CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) { return 1, nil },
+			}
+
+			svc := NewTransactionService(repo, nil, accountRepo, journalRepo, &fakeUnitOfWork{}, nil, nil, nil, nil, false)
+			got, err := svc.Create(tt.ctx, TransactionCreate{SourceAccountId: 1, DestinationAccountId: 2, Amount: money.New(1, 0)})
+			if err != nil {
+				t.Fatalf("TransactionService.Create() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.InitiatedBy, tt.want) {
+				t.Errorf("TransactionService.Create() InitiatedBy = %v, want %v", got.InitiatedBy, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Reverse(t *testing.T) {
+	original := TransactionRow{
+		TransactionId:             7,
+		SourceAccountId:           1,
+		DestinationAccountId:      2,
+		Amount:                    1_000,
+		AmountScale:               2,
+		Currency:                  "USD",
+		DestinationAmount:         1_000,
+		DestinationAmountScale:    2,
+		DestinationCurrency:       "USD",
+		Kind:                      KindTransfer,
+		Status:                    StatusPartiallyReversed,
+		ReversedAmount:            250,
+		ReversedDestinationAmount: 250,
+	}
+	exchange := original
+	exchange.DestinationAmount, exchange.DestinationAmountScale, exchange.DestinationCurrency = 1_500, 0, "JPY"
+	exchange.Status, exchange.ReversedAmount, exchange.ReversedDestinationAmount = StatusCompleted, 0, 0
+
+	originalRepo := func(row TransactionRow, wantStatus string) *fakeTransactionRepo {
+		return &fakeTransactionRepo{
+			ByIdForUpdateFunc: func(ctx context.Context, transactionId int) (TransactionRow, error) {
+				return row, nil
+			},
+			CreateFunc: func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+				if data.Kind != KindReversal || *data.ReversalOf != row.TransactionId || data.SourceAccountId != row.DestinationAccountId {
+					return TransactionRow{}, fmt.Errorf("unexpected params %+v", data)
+				}
+				return TransactionRow{
+					TransactionId:        8,
+					SourceAccountId:      data.SourceAccountId,
+					DestinationAccountId: data.DestinationAccountId,
+					Amount:               data.Amount,
+					AmountScale:          data.AmountScale,
+					Currency:             data.Currency,
+					DestinationAmount:    data.DestinationAmount,
+					DestinationCurrency:  data.DestinationCurrency,
+					Kind:                 data.Kind,
+					ReversalOf:           data.ReversalOf,
+				}, nil
+			},
+			UpdateReversalFunc: func(ctx context.Context, params TransactionUpdateReversalParams) error {
+				if params.Status != wantStatus {
+					return fmt.Errorf("status = %s, want %s", params.Status, wantStatus)
+				}
+				return nil
+			},
+		}
+	}
+	accountRepo := func(destinationBalance int64, destinationCurrency string) *fakeAccountRepo {
+		return &fakeAccountRepo{
+			ByIdsForUpdateFunc: func(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+				return []account.AccountRow{
+					{AccountId: 1, Balance: 0, ScaleBalance: 2, Currency: "USD"},
+					{AccountId: 2, Balance: destinationBalance, ScaleBalance: 2, Currency: destinationCurrency},
+				}, nil
+			},
+			UpdateBalanceFunc: func(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+				return nil
+			},
+		}
+	}
+	journalRepo := &fakeJournalRepo{
+		CreateFunc: func(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+			if params.Kind != journal.KindReversal {
+				return 0, fmt.Errorf("unexpected entry %+v", params)
+			}
+			return 1, nil
+		},
+	}
+
+	type fields struct {
+		repo            TransactionRepo
+		accountRepo     account.AccountRepo
+		isTigerBeetleOn bool
+		outboxRepo      outbox.OutboxRepo
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		data    TransactionReverse
+		want    money.Amount
+		wantErr error
+	}{
+		{
+			name: "error - transaction not found",
+			fields: fields{repo: &fakeTransactionRepo{
+				ByIdForUpdateFunc: func(ctx context.Context, transactionId int) (TransactionRow, error) {
+					return TransactionRow{}, fmt.Errorf("%w [transaction_id: %d]", ErrTransactionNotFound, transactionId)
+				},
+			}},
+			wantErr: ErrTransactionNotFound,
+		},
+		{
+			name: "error - db fail",
+			fields: fields{repo: &fakeTransactionRepo{
+				ByIdForUpdateFunc: func(ctx context.Context, transactionId int) (TransactionRow, error) {
+					return TransactionRow{}, fmt.Errorf("test-error")
+				},
+			}},
+			wantErr: ErrTransactionReverseFailed,
+		},
+		{
+			name: "error - reversal can not be reversed",
+			fields: fields{repo: &fakeTransactionRepo{
+				ByIdForUpdateFunc: func(ctx context.Context, transactionId int) (TransactionRow, error) {
+					row := original
+					row.Kind = KindReversal
+					return row, nil
+				},
+			}},
+			wantErr: ErrTransactionNotReversible,
+		},
+		{
+			name: "error - already fully reversed",
+			fields: fields{repo: &fakeTransactionRepo{
+				ByIdForUpdateFunc: func(ctx context.Context, transactionId int) (TransactionRow, error) {
+					row := original
+					row.ReversedAmount, row.ReversedDestinationAmount = row.Amount, row.DestinationAmount
+					return row, nil
+				},
+			}},
+			wantErr: ErrTransactionAlreadyReversed,
+		},
+		{
+			name:    "error - exceeds amount not reversed yet",
+			fields:  fields{repo: originalRepo(original, "")},
+			data:    TransactionReverse{Amount: ptr(money.New(751, 2))},
+			wantErr: ErrTransactionReversalExceedsOriginal,
+		},
+		{
+			name:    "error - partial cross currency without destination amount",
+			fields:  fields{repo: originalRepo(exchange, "")},
+			data:    TransactionReverse{Amount: ptr(money.New(5, 0))},
+			wantErr: ErrTransactionCurrencyMismatch,
+		},
+		{
+			name: "error - destination balance not enough",
+			fields: fields{
+				repo:        originalRepo(original, ""),
+				accountRepo: accountRepo(100, "USD"),
+			},
+			wantErr: ErrTransactionSourceBalanceNotEnough,
+		},
+		{
+			name: "success - partial",
+			fields: fields{
+				repo:        originalRepo(original, StatusPartiallyReversed),
+				accountRepo: accountRepo(1_000, "USD"),
+			},
+			data: TransactionReverse{Amount: ptr(money.New(1, 0))},
+			want: money.New(100, 2),
+		},
+		{
+			name: "success - remaining amount with tigerbeetle",
+			fields: fields{
+				repo:            originalRepo(original, StatusReversed),
+				accountRepo:     accountRepo(1_000, "USD"),
+				isTigerBeetleOn: true,
+				outboxRepo: messageOutboxRepo(outbox.KindTransferCreate, func(payload outbox.TransferPayload) error {
+					if payload.ReversalOf != 7 || payload.SourceAccountId != 2 || payload.SourceAmount != 750 {
+						return fmt.Errorf("unexpected reversal of %d from %d amount %d", payload.ReversalOf, payload.SourceAccountId, payload.SourceAmount)
+					}
+					return nil
+				}),
+			},
+			want: money.New(750, 2),
+		},
+		{
+			name: "success - cross currency",
+			fields: fields{
+				repo:        originalRepo(exchange, StatusReversed),
+				accountRepo: accountRepo(150_000, "JPY"),
+			},
+			want: money.New(1_500, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTransactionService(tt.fields.repo, nil, tt.fields.accountRepo, journalRepo, &fakeUnitOfWork{}, nil, nil, nil, tt.fields.outboxRepo, tt.fields.isTigerBeetleOn)
+			got, err := svc.Reverse(t.Context(), 7, tt.data)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TransactionService.Reverse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.Amount.Cmp(tt.want) != 0 {
+				t.Errorf("TransactionService.Reverse() amount = %v, want %v", got.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ById(t *testing.T) {
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type fields struct {
+		repo TransactionRepo
+	}
+	type args struct {
+		ctx           context.Context
+		transactionId int
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    Transaction
+		wantErr error
+	}{
+		{
+			name: "error - not found",
+			fields: fields{repo: &fakeTransactionRepo{
+				ByIdFunc: func(ctx context.Context, transactionId int) (TransactionRow, error) {
+					return TransactionRow{}, fmt.Errorf("%w [transaction_id: %d]", ErrTransactionNotFound, transactionId)
+				},
+			}},
+			args:    args{ctx: t.Context(), transactionId: 1},
+			wantErr: ErrTransactionNotFound,
+		},
+		{
+			name: "error - db fail",
+			fields: fields{repo: &fakeTransactionRepo{
+				ByIdFunc: func(ctx context.Context, transactionId int) (TransactionRow, error) {
+					return TransactionRow{}, fmt.Errorf("test-error")
+				},
+			}},
+			args:    args{ctx: t.Context(), transactionId: 1},
+			wantErr: ErrTransactionByIdFailed,
+		},
+		{
+			name: "success - formats with stored scale",
+			fields: fields{repo: &fakeTransactionRepo{
+				ByIdFunc: func(ctx context.Context, transactionId int) (TransactionRow, error) {
+					return TransactionRow{
+						TransactionId:          transactionId,
+						SourceAccountId:        1,
+						DestinationAccountId:   2,
+						Amount:                 1_000_000,
+						AmountScale:            5,
+						Currency:               "USD",
+						Fee:                    5_000,
+						DestinationAmount:      1_000_000,
+						DestinationAmountScale: 5,
+						DestinationCurrency:    "USD",
+						Kind:                   KindTransfer,
+						Status:                 StatusPartiallyReversed,
+						ReversedAmount:         250_000,
+						CreatedAt:              createdAt,
+						UpdatedAt:              createdAt,
+					}, nil
+				},
+			}},
+			args: args{ctx: t.Context(), transactionId: 7},
+			want: Transaction{
+				TransactionId:             7,
+				Kind:                      KindTransfer,
+				Status:                    StatusPartiallyReversed,
+				SourceAccountId:           1,
+				DestinationAccountId:      2,
+				Amount:                    money.New(1_000_000, 5),
+				Fee:                       money.New(5_000, 5),
+				TotalDebited:              money.New(1_005_000, 5),
+				Currency:                  "USD",
+				DestinationAmount:         money.New(1_000_000, 5),
+				DestinationCurrency:       "USD",
+				ReversedAmount:            money.New(250_000, 5),
+				ReversedDestinationAmount: money.New(0, 5),
+				CreatedAt:                 createdAt,
+				UpdatedAt:                 createdAt,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTransactionService(tt.fields.repo, nil, &fakeAccountRepo{}, &fakeJournalRepo{}, &fakeUnitOfWork{}, nil, nil, nil, nil, false)
+			got, err := svc.ById(tt.args.ctx, tt.args.transactionId)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Errorf("TransactionService.ById() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TransactionService.ById() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ByAccount(t *testing.T) {
+	from := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	accountRepo := &fakeAccountRepo{
+		ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
+			return account.AccountRow{AccountId: accountId}, nil
+		},
+	}
+	// threeRows serves transactions 3, 2 and 1 of account 1, newest first.
+	threeRows := &fakeTransactionRepo{
+		ByAccountFunc: func(ctx context.Context, params TransactionListParams) ([]TransactionRow, error) {
+			var rows []TransactionRow
+			for id := 3; id >= 1 && len(rows) < params.Limit; id-- {
+				if params.BeforeId == 0 || id < params.BeforeId {
+					rows = append(rows, TransactionRow{TransactionId: id, SourceAccountId: 1, DestinationAccountId: 2})
+				}
+			}
+			return rows, nil
+		},
+	}
+
+	type fields struct {
+		repo        TransactionRepo
+		accountRepo account.AccountRepo
+	}
+	type args struct {
+		ctx    context.Context
+		filter TransactionFilter
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantIds []int
+		wantErr error
+	}{
+		{
+			name:    "error - invalid direction",
+			fields:  fields{repo: threeRows, accountRepo: accountRepo},
+			args:    args{ctx: t.Context(), filter: TransactionFilter{AccountId: 1, Direction: "sideways"}},
+			wantErr: ErrTransactionFilterInvalid,
+		},
+		{
+			name:    "error - from after to",
+			fields:  fields{repo: threeRows, accountRepo: accountRepo},
+			args:    args{ctx: t.Context(), filter: TransactionFilter{AccountId: 1, From: &from, To: &to}},
+			wantErr: ErrTransactionFilterInvalid,
+		},
+		{
+			name:    "error - invalid cursor",
+			fields:  fields{repo: threeRows, accountRepo: accountRepo},
+			args:    args{ctx: t.Context(), filter: TransactionFilter{AccountId: 1, Cursor: "!!"}},
+			wantErr: ErrTransactionFilterInvalid,
+		},
+		{
+			name: "error - account not found",
+			fields: fields{repo: threeRows, accountRepo: &fakeAccountRepo{
+				ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
+					return account.AccountRow{}, fmt.Errorf("test-error")
+				},
+			}},
+			args:    args{ctx: t.Context(), filter: TransactionFilter{AccountId: 1}},
+			wantErr: ErrTransactionAccountNotFound,
+		},
+		{
+			name:    "success - all",
+			fields:  fields{repo: threeRows, accountRepo: accountRepo},
+			args:    args{ctx: t.Context(), filter: TransactionFilter{AccountId: 1, Direction: DirectionOutgoing}},
+			wantIds: []int{3, 2, 1},
+		},
+		{
+			name:    "success - second page",
+			fields:  fields{repo: threeRows, accountRepo: accountRepo},
+			args:    args{ctx: t.Context(), filter: TransactionFilter{AccountId: 1, Cursor: encodeCursor(3), Limit: 1}},
+			wantIds: []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTransactionService(tt.fields.repo, nil, tt.fields.accountRepo, &fakeJournalRepo{}, &fakeUnitOfWork{}, nil, nil, nil, nil, false)
+			got, err := svc.ByAccount(tt.args.ctx, tt.args.filter)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("TransactionService.ByAccount() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			var gotIds []int
+			for _, tx := range got.Transactions {
+				gotIds = append(gotIds, tx.TransactionId)
+			}
+			if !reflect.DeepEqual(gotIds, tt.wantIds) {
+				t.Errorf("TransactionService.ByAccount() ids = %v, want %v", gotIds, tt.wantIds)
+			}
+		})
+	}
+}
+
+func TestTransactionService_ByAccount_Pagination(t *testing.T) {
+	accountRepo := &fakeAccountRepo{
+		ByIdFunc: func(ctx context.Context, accountId int) (account.AccountRow, error) {
+			return account.AccountRow{AccountId: accountId}, nil
+		},
+	}
+	repo := &fakeTransactionRepo{
+		ByAccountFunc: func(ctx context.Context, params TransactionListParams) ([]TransactionRow, error) {
+			var rows []TransactionRow
+			for id := 5; id >= 1 && len(rows) < params.Limit; id-- {
+				if params.BeforeId == 0 || id < params.BeforeId {
+					rows = append(rows, TransactionRow{TransactionId: id})
+				}
+			}
+			return rows, nil
+		},
+	}
+	svc := NewTransactionService(repo, nil, accountRepo, &fakeJournalRepo{}, &fakeUnitOfWork{}, nil, nil, nil, nil, false)
+
+	var gotIds []int
+	filter := TransactionFilter{AccountId: 1, Limit: 2}
+	for range 5 {
+		page, err := svc.ByAccount(t.Context(), filter)
+		if err != nil {
+			t.Fatalf("TransactionService.ByAccount() error = %v", err)
+		}
+		for _, tx := range page.Transactions {
+			gotIds = append(gotIds, tx.TransactionId)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	if want := []int{5, 4, 3, 2, 1}; !reflect.DeepEqual(gotIds, want) {
+		t.Errorf("paginated ids = %v, want %v", gotIds, want)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+type fakeTransactionRepo struct {
+	CreateFunc         func(ctx context.Context, data TransactionCreateParams) (TransactionRow, error)
+	ByIdFunc           func(ctx context.Context, transactionId int) (TransactionRow, error)
+	ByIdForUpdateFunc  func(ctx context.Context, transactionId int) (TransactionRow, error)
+	ByAccountFunc      func(ctx context.Context, params TransactionListParams) ([]TransactionRow, error)
+	UpdateReversalFunc func(ctx context.Context, params TransactionUpdateReversalParams) error
+}
+
+func (f *fakeTransactionRepo) Create(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+	return f.CreateFunc(ctx, data)
+}
+
+func (f *fakeTransactionRepo) ById(ctx context.Context, transactionId int) (TransactionRow, error) {
+	return f.ByIdFunc(ctx, transactionId)
+}
+
+func (f *fakeTransactionRepo) ByIdForUpdate(ctx context.Context, transactionId int) (TransactionRow, error) {
+	return f.ByIdForUpdateFunc(ctx, transactionId)
+}
+
+func (f *fakeTransactionRepo) ByAccount(ctx context.Context, params TransactionListParams) ([]TransactionRow, error) {
+	return f.ByAccountFunc(ctx, params)
+}
+
+func (f *fakeTransactionRepo) UpdateReversal(ctx context.Context, params TransactionUpdateReversalParams) error {
+	return f.UpdateReversalFunc(ctx, params)
+}
+
+type fakeLimitChecker struct {
+	CheckFunc func(ctx context.Context, transfer limit.Transfer) (*limit.Violation, error)
+}
+
+func (f *fakeLimitChecker) Check(ctx context.Context, transfer limit.Transfer) (*limit.Violation, error) {
+	return f.CheckFunc(ctx, transfer)
+}
+
+type fakeFeeCalculator struct {
+	FeeFunc func(ctx context.Context, quote fee.Quote) (money.Amount, error)
+}
+
+func (f *fakeFeeCalculator) Fee(ctx context.Context, quote fee.Quote) (money.Amount, error) {
+	return f.FeeFunc(ctx, quote)
+}
+
+type fakeEventRecorder struct {
+	RecordFunc func(ctx context.Context, eventType string, data any) error
+}
+
+func (f *fakeEventRecorder) Record(ctx context.Context, eventType string, data any) error {
+	return f.RecordFunc(ctx, eventType, data)
+}
+
+type fakeUnitOfWork struct{}
+
+func (f *fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeAccountRepo struct {
+	CreateFunc            func(ctx context.Context, data account.AccountCreateParams) (bool, error)
+	ByIdFunc              func(ctx context.Context, accountId int) (account.AccountRow, error)
+	ByIdsForUpdateFunc    func(ctx context.Context, accountIds []int) ([]account.AccountRow, error)
+	UpdateBalanceFunc     func(ctx context.Context, params account.AccountUpdateBalanceParams) error
+	UpdateHeldBalanceFunc func(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error
+	UpdateStatusFunc      func(ctx context.Context, params account.AccountUpdateStatusParams) error
+
+	UpdateOverdraftLimitFunc  func(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error
+	OverdraftLimitChangesFunc func(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error)
+}
+
+func (f *fakeAccountRepo) Create(ctx context.Context, data account.AccountCreateParams) (bool, error) {
+	return f.CreateFunc(ctx, data)
+}
+
+func (f *fakeAccountRepo) ById(ctx context.Context, accountId int) (account.AccountRow, error) {
+	return f.ByIdFunc(ctx, accountId)
+}
+
+func (f *fakeAccountRepo) ByIdsForUpdate(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+	return f.ByIdsForUpdateFunc(ctx, accountIds)
+}
+
+func (f *fakeAccountRepo) UpdateBalance(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+	return f.UpdateBalanceFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateHeldBalance(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+	return f.UpdateHeldBalanceFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateStatus(ctx context.Context, params account.AccountUpdateStatusParams) error {
+	return f.UpdateStatusFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) UpdateOverdraftLimit(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error {
+	return f.UpdateOverdraftLimitFunc(ctx, params)
+}
+
+func (f *fakeAccountRepo) OverdraftLimitChanges(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error) {
+	return f.OverdraftLimitChangesFunc(ctx, accountId)
+}
+
+type fakeJournalRepo struct {
+	CreateFunc         func(ctx context.Context, params journal.EntryCreateParams) (int, error)
+	AccountBalanceFunc func(ctx context.Context, accountId int) ([]journal.AccountBalanceRow, error)
+}
+
+func (f *fakeJournalRepo) Create(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+	return f.CreateFunc(ctx, params)
+}
+
+func (f *fakeJournalRepo) AccountBalance(ctx context.Context, accountId int) ([]journal.AccountBalanceRow, error) {
+	return f.AccountBalanceFunc(ctx, accountId)
+}
+
+type fakeOutboxRepo struct {
+	CreateFunc           func(ctx context.Context, params outbox.MessageCreateParams) error
+	PendingForUpdateFunc func(ctx context.Context, now time.Time, limit int) ([]outbox.MessageRow, error)
+	MarkSentFunc         func(ctx context.Context, messageIds []int) error
+	MarkFailedFunc       func(ctx context.Context, params outbox.MessageFailParams) error
+	StatsFunc            func(ctx context.Context) (outbox.StatsRow, error)
+}
+
+func (f *fakeOutboxRepo) Create(ctx context.Context, params outbox.MessageCreateParams) error {
+	return f.CreateFunc(ctx, params)
+}
+
+func (f *fakeOutboxRepo) PendingForUpdate(ctx context.Context, now time.Time, limit int) ([]outbox.MessageRow, error) {
+	return f.PendingForUpdateFunc(ctx, now, limit)
+}
+
+func (f *fakeOutboxRepo) MarkSent(ctx context.Context, messageIds []int) error {
+	return f.MarkSentFunc(ctx, messageIds)
+}
+
+func (f *fakeOutboxRepo) MarkFailed(ctx context.Context, params outbox.MessageFailParams) error {
+	return f.MarkFailedFunc(ctx, params)
+}
+
+func (f *fakeOutboxRepo) Stats(ctx context.Context) (outbox.StatsRow, error) {
+	return f.StatsFunc(ctx)
+}
+
+// messageOutboxRepo returns a fakeOutboxRepo accepting messages of a single kind and passing
+// their decoded payload to check.
+func messageOutboxRepo[T any](kind string, check func(payload T) error) *fakeOutboxRepo {
+	return &fakeOutboxRepo{
+		CreateFunc: func(ctx context.Context, params outbox.MessageCreateParams) error {
+			if params.Kind != kind {
+				return fmt.Errorf("message kind = %s, want %s", params.Kind, kind)
+			}
+			var payload T
+			if err := json.Unmarshal(params.Payload, &payload); err != nil {
+				return err
+			}
+			return check(payload)
+		},
+	}
+}
+
+// memLedger is an in-memory UnitOfWork shared by memAccountRepo and memTransactionRepo.
+// It emulates row locks held until the end of the transaction and discards the writes
+// of transactions that fail, so concurrent transfers can be tested without PostgreSQL.
+type memLedger struct {
+	mu               sync.Mutex
+	locks            map[int]*sync.Mutex
+	balances         map[int]int64
+	postings         map[int][]money.Amount
+	transactionCount int
+}
+
+type memTx struct {
+	held             []int
+	balances         map[int]int64
+	postings         map[int][]money.Amount
+	transactionCount int
+}
+
+type memTxKey struct{}
+
+func newMemLedger(accountCount int, initialBalance int64) *memLedger {
+	l := &memLedger{locks: map[int]*sync.Mutex{}, balances: map[int]int64{}, postings: map[int][]money.Amount{}}
+	for id := 1; id <= accountCount; id++ {
+		l.locks[id] = &sync.Mutex{}
+		l.balances[id] = initialBalance
+	}
+	return l
+}
+
+func (l *memLedger) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx := &memTx{balances: map[int]int64{}, postings: map[int][]money.Amount{}}
+	err := fn(context.WithValue(ctx, memTxKey{}, tx))
+
+	l.mu.Lock()
+	if err == nil {
+		maps.Copy(l.balances, tx.balances)
+		for id, deltas := range tx.postings {
+			l.postings[id] = append(l.postings[id], deltas...)
+		}
+		l.transactionCount += tx.transactionCount
+	}
+	l.mu.Unlock()
+
+	for _, id := range tx.held {
+		l.locks[id].Unlock()
+	}
+	return err
+}
+
+type memAccountRepo struct{ l *memLedger }
+
+func (r *memAccountRepo) Create(ctx context.Context, data account.AccountCreateParams) (bool, error) {
+	return false, fmt.Errorf("not implemented")
+}
+
+func (r *memAccountRepo) ById(ctx context.Context, accountId int) (account.AccountRow, error) {
+	return account.AccountRow{}, fmt.Errorf("not implemented")
+}
+
+func (r *memAccountRepo) ByIdsForUpdate(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+	tx := ctx.Value(memTxKey{}).(*memTx)
+
+	var rows []account.AccountRow
+	for _, id := range slices.Sorted(slices.Values(accountIds)) {
+		lock, ok := r.l.locks[id]
+		if !ok {
+			continue
+		}
+		lock.Lock()
+		tx.held = append(tx.held, id)
+
+		r.l.mu.Lock()
+		rows = append(rows, account.AccountRow{AccountId: id, Balance: r.l.balances[id], ScaleBalance: 5, Currency: "USD"})
+		r.l.mu.Unlock()
+	}
+	return rows, nil
+}
+
+func (r *memAccountRepo) UpdateBalance(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+	tx := ctx.Value(memTxKey{}).(*memTx)
+	tx.balances[params.AccountId] = params.Balance
+	return nil
+}
+
+func (r *memAccountRepo) UpdateHeldBalance(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (r *memAccountRepo) UpdateStatus(ctx context.Context, params account.AccountUpdateStatusParams) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (r *memAccountRepo) UpdateOverdraftLimit(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (r *memAccountRepo) OverdraftLimitChanges(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+type memTransactionRepo struct{ l *memLedger }
+
+func (r *memTransactionRepo) Create(ctx context.Context, data TransactionCreateParams) (TransactionRow, error) {
+	tx := ctx.Value(memTxKey{}).(*memTx)
+	tx.transactionCount++
+	return TransactionRow{}, nil
+}
+
+func (r *memTransactionRepo) ById(ctx context.Context, transactionId int) (TransactionRow, error) {
+	return TransactionRow{}, fmt.Errorf("not implemented")
+}
+
+func (r *memTransactionRepo) ByIdForUpdate(ctx context.Context, transactionId int) (TransactionRow, error) {
+	return TransactionRow{}, fmt.Errorf("not implemented")
+}
+
+func (r *memTransactionRepo) ByAccount(ctx context.Context, params TransactionListParams) ([]TransactionRow, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (r *memTransactionRepo) UpdateReversal(ctx context.Context, params TransactionUpdateReversalParams) error {
+	return fmt.Errorf("not implemented")
+}
+
+type memJournalRepo struct{ l *memLedger }
+
+func (r *memJournalRepo) Create(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+	tx := ctx.Value(memTxKey{}).(*memTx)
+	for _, p := range params.Postings {
+		if p.AccountId == nil {
+			continue
+		}
+		delta := money.New(p.Amount, p.AmountScale)
+		if p.Direction == string(journal.Debit) {
+			delta = money.New(-p.Amount, p.AmountScale)
+		}
+		tx.postings[*p.AccountId] = append(tx.postings[*p.AccountId], delta)
+	}
+	return 0, nil
 }
 
-func (f *fakeAccountTBRepo) CreateTransaction(debitAccountId int, creditAccountId int, amount int) error {
-	return f.CreateTransactionFunc(debitAccountId, creditAccountId, amount)
+func (r *memJournalRepo) AccountBalance(ctx context.Context, accountId int) ([]journal.AccountBalanceRow, error) {
+	return nil, fmt.Errorf("not implemented")
 }
@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers of a webhook request. The signature is "v1=" followed by the hex HMAC-SHA256, keyed by
+// the subscription secret, of the timestamp header, a dot and the request body. Receivers should
+// reject requests whose timestamp is too old and deduplicate on the event id, since a delivery
+// may arrive more than once.
+const (
+	HeaderEventId    = "X-Webhook-Event-Id"
+	HeaderEventType  = "X-Webhook-Event-Type"
+	HeaderDeliveryId = "X-Webhook-Delivery-Id"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// minRetryDelay and maxRetryDelay bound the exponential backoff between delivery attempts.
+const (
+	minRetryDelay = 10 * time.Second
+	maxRetryDelay = time.Hour
+)
+
+// maxResponseBody is how much of a response body is kept in the delivery log.
+const maxResponseBody = 1024
+
+// Event is the body of a webhook request.
+type Event struct {
+	EventId   int             `json:"event_id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// outcome is the result of one attempt at a delivery.
+type outcome struct {
+	statusCode   *int
+	err          error
+	responseBody *string
+	duration     time.Duration
+}
+
+// Dispatch sends one batch of due deliveries and returns how many it attempted. The deliveries
+// stay locked, with SKIP LOCKED, until their attempts are recorded, so concurrent dispatchers
+// never send the same delivery at the same time. Deliveries answered with a 2xx status succeed;
+// the others are retried with exponential backoff and fail after maxAttempts attempts.
+func (svc *WebhookService) Dispatch(ctx context.Context) (int, error) {
+	var count int
+	err := svc.uow.Do(ctx, func(ctx context.Context) error {
+		rows, err := svc.repo.DueDeliveriesForUpdate(ctx, svc.now(), svc.batchSize)
+		if err != nil {
+			return err
+		}
+
+		outcomes := make([]outcome, len(rows))
+		var wg sync.WaitGroup
+		for i, row := range rows {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				outcomes[i] = svc.send(ctx, row)
+			}()
+		}
+		wg.Wait()
+
+		for i, row := range rows {
+			if err := svc.record(ctx, row, outcomes[i]); err != nil {
+				return err
+			}
+		}
+
+		count = len(rows)
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrWebhookDispatchFailed.Error(), "error", err)
+		return 0, ErrWebhookDispatchFailed
+	}
+
+	return count, nil
+}
+
+// Drain dispatches batches until no due delivery is left and returns how many it attempted.
+func (svc *WebhookService) Drain(ctx context.Context) (int, error) {
+	var total int
+	for {
+		count, err := svc.Dispatch(ctx)
+		total += count
+		if err != nil || count < svc.batchSize {
+			return total, err
+		}
+	}
+}
+
+// send makes one attempt at delivering row.
+func (svc *WebhookService) send(ctx context.Context, row DueDeliveryRow) outcome {
+	start := svc.now()
+
+	body, err := json.Marshal(Event{EventId: row.EventId, Type: row.EventType, CreatedAt: row.EventCreatedAt, Data: row.EventData})
+	if err != nil {
+		return outcome{err: err}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, row.URL, bytes.NewReader(body))
+	if err != nil {
+		return outcome{err: err}
+	}
+	timestamp := strconv.FormatInt(start.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventId, strconv.Itoa(row.EventId))
+	req.Header.Set(HeaderEventType, row.EventType)
+	req.Header.Set(HeaderDeliveryId, strconv.Itoa(row.DeliveryId))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(row.Secret, timestamp, body))
+
+	res, err := svc.client.Do(req)
+	if err != nil {
+		return outcome{err: err, duration: svc.now().Sub(start)}
+	}
+	defer res.Body.Close()
+
+	b, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	result := outcome{statusCode: &res.StatusCode, duration: svc.now().Sub(start)}
+	if len(b) > 0 {
+		responseBody := string(b)
+		result.responseBody = &responseBody
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		result.err = fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return result
+}
+
+// record writes the attempt at row to the delivery log and moves the delivery on.
+func (svc *WebhookService) record(ctx context.Context, row DueDeliveryRow, result outcome) error {
+	attempts := row.Attempts + 1
+
+	attempt := AttemptCreateParams{
+		DeliveryId:   row.DeliveryId,
+		Attempt:      attempts,
+		StatusCode:   result.statusCode,
+		ResponseBody: result.responseBody,
+		DurationMs:   int(result.duration.Milliseconds()),
+	}
+	update := DeliveryUpdateParams{
+		DeliveryId:     row.DeliveryId,
+		Status:         DeliverySucceeded,
+		Attempts:       attempts,
+		LastStatusCode: result.statusCode,
+		NextAttemptAt:  svc.now(),
+	}
+	if result.err != nil {
+		message := result.err.Error()
+		attempt.Error, update.LastError = &message, &message
+		update.Status = DeliveryPending
+		update.NextAttemptAt = svc.now().Add(retryDelay(attempts))
+		if attempts >= svc.maxAttempts {
+			update.Status = DeliveryFailed
+			slog.WarnContext(ctx, "webhook delivery failed", "delivery_id", row.DeliveryId, "attempts", attempts, "error", result.err)
+		}
+	}
+
+	if err := svc.repo.CreateAttempt(ctx, attempt); err != nil {
+		return err
+	}
+	return svc.repo.UpdateDelivery(ctx, update)
+}
+
+// Sign returns the signature header value of a webhook request with body sent at timestamp, in
+// Unix seconds, to a subscription with secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay returns the delay before the next attempt after the given number of attempts:
+// minRetryDelay, doubling every attempt up to maxRetryDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := minRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxRetryDelay)
+}
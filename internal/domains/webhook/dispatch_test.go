@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookService_Dispatch(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		attempts      int
+		statusCode    int
+		sendErr       error
+		wantStatus    string
+		wantNextAt    time.Time
+		wantLastError bool
+	}{
+		{
+			name:       "success - 2xx succeeds",
+			statusCode: http.StatusNoContent,
+			wantStatus: DeliverySucceeded,
+			wantNextAt: now,
+		},
+		{
+			name:          "retry - 5xx is retried after the first delay",
+			statusCode:    http.StatusBadGateway,
+			wantStatus:    DeliveryPending,
+			wantNextAt:    now.Add(10 * time.Second),
+			wantLastError: true,
+		},
+		{
+			name:          "retry - network error backs off exponentially",
+			attempts:      3,
+			sendErr:       fmt.Errorf("connection refused"),
+			wantStatus:    DeliveryPending,
+			wantNextAt:    now.Add(80 * time.Second),
+			wantLastError: true,
+		},
+		{
+			name:          "failed - last attempt",
+			attempts:      4,
+			statusCode:    http.StatusInternalServerError,
+			wantStatus:    DeliveryFailed,
+			wantNextAt:    now.Add(160 * time.Second),
+			wantLastError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := mustJSON(t, map[string]int{"account_id": 7})
+			due := DueDeliveryRow{DeliveryId: 3, SubscriptionId: 2, Attempts: tt.attempts, URL: "https://example.com/hooks", Secret: "0123456789abcdef", EventId: 9, EventType: EventAccountCreated, EventData: data, EventCreatedAt: now}
+
+			var (
+				gotAttempt AttemptCreateParams
+				gotUpdate  DeliveryUpdateParams
+			)
+			repo := &fakeWebhookRepo{
+				DueDeliveriesForUpdateFunc: func(ctx context.Context, now time.Time, limit int) ([]DueDeliveryRow, error) {
+					return []DueDeliveryRow{due}, nil
+				},
+				CreateAttemptFunc: func(ctx context.Context, params AttemptCreateParams) error {
+					gotAttempt = params
+					return nil
+				},
+				UpdateDeliveryFunc: func(ctx context.Context, params DeliveryUpdateParams) error {
+					gotUpdate = params
+					return nil
+				},
+			}
+			client := &fakeHTTPClient{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					body, err := io.ReadAll(req.Body)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if want := Sign(due.Secret, req.Header.Get(HeaderTimestamp), body); req.Header.Get(HeaderSignature) != want {
+						t.Errorf("signature = %q, want %q", req.Header.Get(HeaderSignature), want)
+					}
+					if req.Header.Get(HeaderEventType) != EventAccountCreated || req.Header.Get(HeaderDeliveryId) != "3" {
+						t.Errorf("headers = %v, want event type and delivery id", req.Header)
+					}
+					if !strings.Contains(string(body), `"data":{"account_id":7}`) {
+						t.Errorf("body = %s, want the event data", body)
+					}
+
+					if tt.sendErr != nil {
+						return nil, tt.sendErr
+					}
+					return &http.Response{StatusCode: tt.statusCode, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+				},
+			}
+
+			svc := NewWebhookService(repo, &fakeUnitOfWork{}, client, 10, 5)
+			svc.now = func() time.Time { return now }
+			count, err := svc.Dispatch(t.Context())
+			if err != nil || count != 1 {
+				t.Fatalf("WebhookService.Dispatch() = %d, %v, want 1, nil", count, err)
+			}
+
+			if gotAttempt.DeliveryId != 3 || gotAttempt.Attempt != tt.attempts+1 {
+				t.Errorf("attempt = %+v, want attempt %d of delivery 3", gotAttempt, tt.attempts+1)
+			}
+			if gotUpdate.Status != tt.wantStatus || gotUpdate.Attempts != tt.attempts+1 || !gotUpdate.NextAttemptAt.Equal(tt.wantNextAt) {
+				t.Errorf("update = %+v, want status %s, attempts %d, next attempt at %s", gotUpdate, tt.wantStatus, tt.attempts+1, tt.wantNextAt)
+			}
+			if (gotUpdate.LastError != nil) != tt.wantLastError {
+				t.Errorf("update last error = %v, want set %t", gotUpdate.LastError, tt.wantLastError)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Drain(t *testing.T) {
+	var mu sync.Mutex
+	pending := 25
+	repo := &fakeWebhookRepo{
+		DueDeliveriesForUpdateFunc: func(ctx context.Context, now time.Time, limit int) ([]DueDeliveryRow, error) {
+			rows := make([]DueDeliveryRow, min(pending, limit))
+			for i := range rows {
+				rows[i] = DueDeliveryRow{DeliveryId: i + 1, URL: "https://example.com/hooks", Secret: "0123456789abcdef"}
+			}
+			pending -= len(rows)
+			return rows, nil
+		},
+		CreateAttemptFunc:  func(ctx context.Context, params AttemptCreateParams) error { return nil },
+		UpdateDeliveryFunc: func(ctx context.Context, params DeliveryUpdateParams) error { return nil },
+	}
+	var sent int
+	client := &fakeHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			sent++
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+		},
+	}
+
+	svc := NewWebhookService(repo, &fakeUnitOfWork{}, client, 10, 5)
+	got, err := svc.Drain(t.Context())
+	if err != nil || got != 25 || sent != 25 {
+		t.Fatalf("WebhookService.Drain() = %d, %v with %d sent, want 25, nil with 25 sent", got, err, sent)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"a":1}' | openssl dgst -sha256 -hmac 'secret'
+	want := "v1=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got := Sign("secret", "1700000000", []byte(`{"a":1}`)); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
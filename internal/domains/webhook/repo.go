@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// WebhookRepo defines the interface for webhook subscription, event and delivery persistence.
+type WebhookRepo interface {
+	CreateSubscription(ctx context.Context, params SubscriptionCreateParams) (SubscriptionRow, error)
+	SubscriptionById(ctx context.Context, subscriptionId int) (SubscriptionRow, error)
+	Subscriptions(ctx context.Context) ([]SubscriptionRow, error)
+	DisableSubscription(ctx context.Context, subscriptionId int) (bool, error)
+	CreateEvent(ctx context.Context, params EventCreateParams) (int, error)
+	Deliveries(ctx context.Context, params DeliveryListParams) ([]DeliveryRow, error)
+	DeliveryById(ctx context.Context, deliveryId int) (DeliveryRow, error)
+	DueDeliveriesForUpdate(ctx context.Context, now time.Time, limit int) ([]DueDeliveryRow, error)
+	UpdateDelivery(ctx context.Context, params DeliveryUpdateParams) error
+	ReplayDelivery(ctx context.Context, deliveryId int, now time.Time) (bool, error)
+	CreateAttempt(ctx context.Context, params AttemptCreateParams) error
+	Attempts(ctx context.Context, deliveryId int) ([]AttemptRow, error)
+}
+
+// UnitOfWork runs fn inside a single database transaction.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// HTTPClient sends webhook requests; *http.Client satisfies it.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// SubscriptionCreateParams holds the parameters required to create a webhook subscription.
+type SubscriptionCreateParams struct {
+	URL        string
+	EventTypes []string
+	Secret     string
+}
+
+// SubscriptionRow represents a row in the webhook_subscriptions table.
+type SubscriptionRow struct {
+	SubscriptionId int       `db:"subscription_id"`
+	URL            string    `db:"url"`
+	EventTypes     []string  `db:"event_types"`
+	Secret         string    `db:"secret"`
+	Status         string    `db:"status"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+// EventCreateParams holds the parameters required to record an event. Data is the JSON encoding
+// of the resource the event is about.
+type EventCreateParams struct {
+	Type string
+	Data json.RawMessage
+}
+
+// DeliveryListParams selects the deliveries of SubscriptionId, newest first. Status is empty
+// for every status. At most Limit deliveries are returned.
+type DeliveryListParams struct {
+	SubscriptionId int
+	Status         string
+	Limit          int
+}
+
+// DeliveryRow represents a row in the webhook_deliveries table together with the type of its
+// event.
+type DeliveryRow struct {
+	DeliveryId     int        `db:"delivery_id"`
+	SubscriptionId int        `db:"subscription_id"`
+	EventId        int        `db:"event_id"`
+	EventType      string     `db:"event_type"`
+	Status         string     `db:"status"`
+	Attempts       int        `db:"attempts"`
+	LastStatusCode *int       `db:"last_status_code"`
+	LastError      *string    `db:"last_error"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at"`
+	DeliveredAt    *time.Time `db:"delivered_at"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+}
+
+// DueDeliveryRow is a pending delivery of an active subscription, with what is needed to send
+// it: the subscription URL and secret and the event.
+type DueDeliveryRow struct {
+	DeliveryId     int             `db:"delivery_id"`
+	SubscriptionId int             `db:"subscription_id"`
+	Attempts       int             `db:"attempts"`
+	URL            string          `db:"url"`
+	Secret         string          `db:"secret"`
+	EventId        int             `db:"event_id"`
+	EventType      string          `db:"event_type"`
+	EventData      json.RawMessage `db:"event_data"`
+	EventCreatedAt time.Time       `db:"event_created_at"`
+}
+
+// DeliveryUpdateParams records the outcome of a delivery attempt. Status is DeliveryPending to
+// retry the delivery at NextAttemptAt, DeliverySucceeded or DeliveryFailed.
+type DeliveryUpdateParams struct {
+	DeliveryId     int
+	Status         string
+	Attempts       int
+	LastStatusCode *int
+	LastError      *string
+	NextAttemptAt  time.Time
+}
+
+// AttemptCreateParams holds one attempt at a delivery for the delivery log. StatusCode is nil
+// when no response was received, and ResponseBody holds the start of the response.
+type AttemptCreateParams struct {
+	DeliveryId   int
+	Attempt      int
+	StatusCode   *int
+	Error        *string
+	ResponseBody *string
+	DurationMs   int
+}
+
+// AttemptRow represents a row in the webhook_delivery_attempts table.
+type AttemptRow struct {
+	AttemptId    int       `db:"attempt_id"`
+	DeliveryId   int       `db:"delivery_id"`
+	Attempt      int       `db:"attempt"`
+	StatusCode   *int      `db:"status_code"`
+	Error        *string   `db:"error"`
+	ResponseBody *string   `db:"response_body"`
+	DurationMs   int       `db:"duration_ms"`
+	CreatedAt    time.Time `db:"created_at"`
+}
@@ -0,0 +1,369 @@
+// Package webhook provides business logic for webhook notifications: the subscriptions of
+// downstream systems, the events recorded alongside ledger changes, and their signed delivery
+// with retries, delivery logs and replay.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// Event types. The data of account events is an account.Account and the data of transaction
+// events a transaction.Transaction; reversals are the new reversal transaction, whose
+// reversal_of names the transaction they reverse.
+const (
+	EventAccountCreated      = "account.created"
+	EventTransactionCreated  = "transaction.created"
+	EventTransactionReversed = "transaction.reversed"
+)
+
+// EventTypes lists every event type a subscription can receive.
+var EventTypes = []string{EventAccountCreated, EventTransactionCreated, EventTransactionReversed}
+
+// Subscription statuses. Disabled subscriptions receive no new events and their pending
+// deliveries are no longer sent.
+const (
+	SubscriptionActive   = "active"
+	SubscriptionDisabled = "disabled"
+)
+
+// Delivery statuses. Failed deliveries were attempted maxAttempts times without success.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// defaultDeliveryLimit and maxDeliveryLimit bound the number of deliveries listed at once.
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+var (
+	ErrWebhookSubscriptionCreateFailed  = errors.New("webhook subscription creation fail")
+	ErrWebhookSubscriptionListFailed    = errors.New("webhook subscription list fail")
+	ErrWebhookSubscriptionDisableFailed = errors.New("webhook subscription disable fail")
+	ErrWebhookSubscriptionNotFound      = errors.New("webhook subscription not found")
+	ErrWebhookURLInvalid                = errors.New("webhook url invalid")
+	ErrWebhookEventTypeInvalid          = errors.New("webhook event type invalid")
+	ErrWebhookSecretInvalid             = errors.New("webhook secret invalid")
+	ErrWebhookEventRecordFailed         = errors.New("webhook event record fail")
+	ErrWebhookDeliveryListFailed        = errors.New("webhook delivery list fail")
+	ErrWebhookDeliveryNotFound          = errors.New("webhook delivery not found")
+	ErrWebhookDeliveryReplayFailed      = errors.New("webhook delivery replay fail")
+	ErrWebhookDeliveryFilterInvalid     = errors.New("webhook delivery filter invalid")
+	ErrWebhookDispatchFailed            = errors.New("webhook dispatch fail")
+)
+
+// SubscriptionCreate represents a request to subscribe URL to events of EventTypes. Secret signs
+// the deliveries; a random one is generated when it is empty.
+type SubscriptionCreate struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+}
+
+// Subscription represents a webhook subscription. Secret is only returned when the subscription
+// is created.
+type Subscription struct {
+	SubscriptionId int       `json:"subscription_id"`
+	URL            string    `json:"url"`
+	EventTypes     []string  `json:"event_types"`
+	Secret         string    `json:"secret,omitempty"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// DeliveryFilter selects the deliveries of a subscription to list, newest first. Status is
+// empty for every status and Limit defaults to 50 with a maximum of 500.
+type DeliveryFilter struct {
+	Status string
+	Limit  int
+}
+
+// Delivery is the delivery of an event to a subscription. LastStatusCode and LastError describe
+// the latest attempt.
+type Delivery struct {
+	DeliveryId     int        `json:"delivery_id"`
+	SubscriptionId int        `json:"subscription_id"`
+	EventId        int        `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Attempt is one entry of the log of a delivery.
+type Attempt struct {
+	Attempt      int       `json:"attempt"`
+	StatusCode   *int      `json:"status_code,omitempty"`
+	Error        *string   `json:"error,omitempty"`
+	ResponseBody *string   `json:"response_body,omitempty"`
+	DurationMs   int       `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// DeliveryLog is a delivery together with every attempt made at it, oldest first.
+type DeliveryLog struct {
+	Delivery
+	Attempts []Attempt `json:"attempt_log"`
+}
+
+// WebhookService manages webhook subscriptions, records events and dispatches their deliveries.
+type WebhookService struct {
+	repo        WebhookRepo
+	uow         UnitOfWork
+	client      HTTPClient
+	batchSize   int
+	maxAttempts int
+	now         func() time.Time
+}
+
+// NewWebhookService creates a new WebhookService sending up to batchSize deliveries at a time
+// through client. Deliveries failing maxAttempts times are given up.
+func NewWebhookService(repo WebhookRepo, uow UnitOfWork, client HTTPClient, batchSize int, maxAttempts int) *WebhookService {
+	return &WebhookService{repo, uow, client, batchSize, maxAttempts, time.Now}
+}
+
+// CreateSubscription validates and stores a webhook subscription. URL must be an absolute http
+// or https URL and EventTypes a non-empty list of known event types.
+func (svc *WebhookService) CreateSubscription(ctx context.Context, data SubscriptionCreate) (Subscription, error) {
+	u, err := url.Parse(data.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		slog.WarnContext(ctx, ErrWebhookURLInvalid.Error(), "url", data.URL)
+		return Subscription{}, ErrWebhookURLInvalid
+	}
+
+	if len(data.EventTypes) == 0 {
+		slog.WarnContext(ctx, ErrWebhookEventTypeInvalid.Error(), "reason", "no event types")
+		return Subscription{}, ErrWebhookEventTypeInvalid
+	}
+	for _, eventType := range data.EventTypes {
+		if !slices.Contains(EventTypes, eventType) {
+			slog.WarnContext(ctx, ErrWebhookEventTypeInvalid.Error(), "event_type", eventType)
+			return Subscription{}, ErrWebhookEventTypeInvalid
+		}
+	}
+	eventTypes := slices.Clone(data.EventTypes)
+	slices.Sort(eventTypes)
+	eventTypes = slices.Compact(eventTypes)
+
+	if data.Secret == "" {
+		if data.Secret, err = newSecret(); err != nil {
+			slog.ErrorContext(ctx, ErrWebhookSubscriptionCreateFailed.Error(), "error", err)
+			return Subscription{}, ErrWebhookSubscriptionCreateFailed
+		}
+	}
+	if len(data.Secret) < 16 || len(data.Secret) > 128 {
+		slog.WarnContext(ctx, ErrWebhookSecretInvalid.Error(), "reason", "secrets are 16 to 128 characters")
+		return Subscription{}, ErrWebhookSecretInvalid
+	}
+
+	row, err := svc.repo.CreateSubscription(ctx, SubscriptionCreateParams{URL: data.URL, EventTypes: eventTypes, Secret: data.Secret})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrWebhookSubscriptionCreateFailed.Error(), "error", err)
+		return Subscription{}, ErrWebhookSubscriptionCreateFailed
+	}
+
+	subscription := toSubscription(row)
+	subscription.Secret = row.Secret
+	return subscription, nil
+}
+
+// SubscriptionById retrieves a webhook subscription by its ID.
+func (svc *WebhookService) SubscriptionById(ctx context.Context, subscriptionId int) (Subscription, error) {
+	row, err := svc.repo.SubscriptionById(ctx, subscriptionId)
+	if err != nil {
+		slog.WarnContext(ctx, ErrWebhookSubscriptionNotFound.Error(), "error", err)
+		return Subscription{}, ErrWebhookSubscriptionNotFound
+	}
+
+	return toSubscription(row), nil
+}
+
+// Subscriptions returns every webhook subscription, oldest first.
+func (svc *WebhookService) Subscriptions(ctx context.Context) ([]Subscription, error) {
+	rows, err := svc.repo.Subscriptions(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrWebhookSubscriptionListFailed.Error(), "error", err)
+		return nil, ErrWebhookSubscriptionListFailed
+	}
+
+	data := make([]Subscription, len(rows))
+	for i, row := range rows {
+		data[i] = toSubscription(row)
+	}
+
+	return data, nil
+}
+
+// DisableSubscription stops a subscription from receiving events. Its delivery log is kept.
+func (svc *WebhookService) DisableSubscription(ctx context.Context, subscriptionId int) error {
+	disabled, err := svc.repo.DisableSubscription(ctx, subscriptionId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrWebhookSubscriptionDisableFailed.Error(), "error", err)
+		return ErrWebhookSubscriptionDisableFailed
+	}
+	if !disabled {
+		slog.WarnContext(ctx, ErrWebhookSubscriptionNotFound.Error(), "subscription_id", subscriptionId)
+		return ErrWebhookSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// Record stores an event of eventType about data, and a pending delivery of it for every active
+// subscription to eventType. Call it inside the unit of work writing the change the event
+// describes, so the event is committed if and only if the change is.
+func (svc *WebhookService) Record(ctx context.Context, eventType string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrWebhookEventRecordFailed, err)
+	}
+
+	if _, err := svc.repo.CreateEvent(ctx, EventCreateParams{Type: eventType, Data: b}); err != nil {
+		return fmt.Errorf("%w: %s", ErrWebhookEventRecordFailed, err)
+	}
+
+	return nil
+}
+
+// Deliveries returns the deliveries of a subscription matching filter, newest first.
+func (svc *WebhookService) Deliveries(ctx context.Context, subscriptionId int, filter DeliveryFilter) ([]Delivery, error) {
+	if filter.Status != "" && filter.Status != DeliveryPending && filter.Status != DeliverySucceeded && filter.Status != DeliveryFailed {
+		slog.WarnContext(ctx, ErrWebhookDeliveryFilterInvalid.Error(), "status", filter.Status)
+		return nil, ErrWebhookDeliveryFilterInvalid
+	}
+
+	if filter.Limit < 0 || filter.Limit > maxDeliveryLimit {
+		slog.WarnContext(ctx, ErrWebhookDeliveryFilterInvalid.Error(), "limit", filter.Limit)
+		return nil, ErrWebhookDeliveryFilterInvalid
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultDeliveryLimit
+	}
+
+	if _, err := svc.repo.SubscriptionById(ctx, subscriptionId); err != nil {
+		slog.WarnContext(ctx, ErrWebhookSubscriptionNotFound.Error(), "error", err)
+		return nil, ErrWebhookSubscriptionNotFound
+	}
+
+	rows, err := svc.repo.Deliveries(ctx, DeliveryListParams{SubscriptionId: subscriptionId, Status: filter.Status, Limit: filter.Limit})
+	if err != nil {
+		slog.ErrorContext(ctx, ErrWebhookDeliveryListFailed.Error(), "error", err)
+		return nil, ErrWebhookDeliveryListFailed
+	}
+
+	data := make([]Delivery, len(rows))
+	for i, row := range rows {
+		data[i] = toDelivery(row)
+	}
+
+	return data, nil
+}
+
+// DeliveryById retrieves a delivery with its attempt log.
+func (svc *WebhookService) DeliveryById(ctx context.Context, deliveryId int) (DeliveryLog, error) {
+	row, err := svc.repo.DeliveryById(ctx, deliveryId)
+	if err != nil {
+		slog.WarnContext(ctx, ErrWebhookDeliveryNotFound.Error(), "error", err)
+		return DeliveryLog{}, ErrWebhookDeliveryNotFound
+	}
+
+	attempts, err := svc.repo.Attempts(ctx, deliveryId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrWebhookDeliveryListFailed.Error(), "error", err)
+		return DeliveryLog{}, ErrWebhookDeliveryListFailed
+	}
+
+	data := DeliveryLog{Delivery: toDelivery(row), Attempts: make([]Attempt, len(attempts))}
+	for i, attempt := range attempts {
+		data.Attempts[i] = Attempt{
+			Attempt:      attempt.Attempt,
+			StatusCode:   attempt.StatusCode,
+			Error:        attempt.Error,
+			ResponseBody: attempt.ResponseBody,
+			DurationMs:   attempt.DurationMs,
+			CreatedAt:    attempt.CreatedAt,
+		}
+	}
+
+	return data, nil
+}
+
+// ReplayDelivery sends a delivery again, whatever its status, on the next dispatch. Its attempts
+// start over, so a failed delivery gets maxAttempts new attempts; the attempt log is kept.
+func (svc *WebhookService) ReplayDelivery(ctx context.Context, deliveryId int) (Delivery, error) {
+	replayed, err := svc.repo.ReplayDelivery(ctx, deliveryId, svc.now())
+	if err != nil {
+		slog.ErrorContext(ctx, ErrWebhookDeliveryReplayFailed.Error(), "error", err)
+		return Delivery{}, ErrWebhookDeliveryReplayFailed
+	}
+	if !replayed {
+		slog.WarnContext(ctx, ErrWebhookDeliveryNotFound.Error(), "delivery_id", deliveryId)
+		return Delivery{}, ErrWebhookDeliveryNotFound
+	}
+
+	row, err := svc.repo.DeliveryById(ctx, deliveryId)
+	if err != nil {
+		slog.ErrorContext(ctx, ErrWebhookDeliveryReplayFailed.Error(), "error", err)
+		return Delivery{}, ErrWebhookDeliveryReplayFailed
+	}
+
+	return toDelivery(row), nil
+}
+
+// newSecret returns a random signing secret.
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func toSubscription(row SubscriptionRow) Subscription {
+	return Subscription{
+		SubscriptionId: row.SubscriptionId,
+		URL:            row.URL,
+		EventTypes:     row.EventTypes,
+		Status:         row.Status,
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+}
+
+func toDelivery(row DeliveryRow) Delivery {
+	d := Delivery{
+		DeliveryId:     row.DeliveryId,
+		SubscriptionId: row.SubscriptionId,
+		EventId:        row.EventId,
+		EventType:      row.EventType,
+		Status:         row.Status,
+		Attempts:       row.Attempts,
+		LastStatusCode: row.LastStatusCode,
+		LastError:      row.LastError,
+		DeliveredAt:    row.DeliveredAt,
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+	if row.Status == DeliveryPending {
+		d.NextAttemptAt = &row.NextAttemptAt
+	}
+	return d
+}
@@ -0,0 +1,261 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           SubscriptionCreate
+		wantEventTypes []string
+		wantErr        error
+	}{
+		{
+			name:           "success - event types sorted and deduplicated",
+			data:           SubscriptionCreate{URL: "https://example.com/hooks", EventTypes: []string{EventTransactionCreated, EventAccountCreated, EventTransactionCreated}, Secret: "0123456789abcdef"},
+			wantEventTypes: []string{EventAccountCreated, EventTransactionCreated},
+		},
+		{
+			name:           "success - secret generated",
+			data:           SubscriptionCreate{URL: "http://localhost:9000", EventTypes: []string{EventTransactionReversed}},
+			wantEventTypes: []string{EventTransactionReversed},
+		},
+		{
+			name:    "error - url not http",
+			data:    SubscriptionCreate{URL: "ftp://example.com", EventTypes: []string{EventAccountCreated}},
+			wantErr: ErrWebhookURLInvalid,
+		},
+		{
+			name:    "error - url relative",
+			data:    SubscriptionCreate{URL: "/hooks", EventTypes: []string{EventAccountCreated}},
+			wantErr: ErrWebhookURLInvalid,
+		},
+		{
+			name:    "error - no event types",
+			data:    SubscriptionCreate{URL: "https://example.com/hooks"},
+			wantErr: ErrWebhookEventTypeInvalid,
+		},
+		{
+			name:    "error - unknown event type",
+			data:    SubscriptionCreate{URL: "https://example.com/hooks", EventTypes: []string{"account.deleted"}},
+			wantErr: ErrWebhookEventTypeInvalid,
+		},
+		{
+			name:    "error - secret too short",
+			data:    SubscriptionCreate{URL: "https://example.com/hooks", EventTypes: []string{EventAccountCreated}, Secret: "short"},
+			wantErr: ErrWebhookSecretInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeWebhookRepo{
+				CreateSubscriptionFunc: func(ctx context.Context, params SubscriptionCreateParams) (SubscriptionRow, error) {
+					return SubscriptionRow{SubscriptionId: 1, URL: params.URL, EventTypes: params.EventTypes, Secret: params.Secret, Status: SubscriptionActive}, nil
+				},
+			}
+
+			svc := NewWebhookService(repo, &fakeUnitOfWork{}, nil, 10, 3)
+			got, err := svc.CreateSubscription(t.Context(), tt.data)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("WebhookService.CreateSubscription() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(got.EventTypes, tt.wantEventTypes) {
+				t.Errorf("WebhookService.CreateSubscription() event types = %v, want %v", got.EventTypes, tt.wantEventTypes)
+			}
+			if tt.data.Secret != "" && got.Secret != tt.data.Secret {
+				t.Errorf("WebhookService.CreateSubscription() secret = %q, want %q", got.Secret, tt.data.Secret)
+			}
+			if tt.data.Secret == "" && !strings.HasPrefix(got.Secret, "whsec_") {
+				t.Errorf("WebhookService.CreateSubscription() secret = %q, want a generated one", got.Secret)
+			}
+		})
+	}
+}
+
+func TestWebhookService_Record(t *testing.T) {
+	tests := []struct {
+		name      string
+		createErr error
+		wantErr   error
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:      "error - repo fails",
+			createErr: fmt.Errorf("sql insert: connection reset"),
+			wantErr:   ErrWebhookEventRecordFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got EventCreateParams
+			repo := &fakeWebhookRepo{
+				CreateEventFunc: func(ctx context.Context, params EventCreateParams) (int, error) {
+					got = params
+					return 1, tt.createErr
+				},
+			}
+
+			svc := NewWebhookService(repo, &fakeUnitOfWork{}, nil, 10, 3)
+			err := svc.Record(t.Context(), EventAccountCreated, map[string]int{"account_id": 7})
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("WebhookService.Record() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got.Type != EventAccountCreated || string(got.Data) != `{"account_id":7}` {
+				t.Errorf("WebhookService.Record() event = %s %s, want %s %s", got.Type, got.Data, EventAccountCreated, `{"account_id":7}`)
+			}
+		})
+	}
+}
+
+func TestWebhookService_ReplayDelivery(t *testing.T) {
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		replayed bool
+		wantErr  error
+	}{
+		{
+			name:     "success",
+			replayed: true,
+		},
+		{
+			name:    "error - delivery not found",
+			wantErr: ErrWebhookDeliveryNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotNow time.Time
+			repo := &fakeWebhookRepo{
+				ReplayDeliveryFunc: func(ctx context.Context, deliveryId int, now time.Time) (bool, error) {
+					gotNow = now
+					return tt.replayed, nil
+				},
+				DeliveryByIdFunc: func(ctx context.Context, deliveryId int) (DeliveryRow, error) {
+					return DeliveryRow{DeliveryId: deliveryId, Status: DeliveryPending, NextAttemptAt: now}, nil
+				},
+			}
+
+			svc := NewWebhookService(repo, &fakeUnitOfWork{}, nil, 10, 3)
+			svc.now = func() time.Time { return now }
+			got, err := svc.ReplayDelivery(t.Context(), 5)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("WebhookService.ReplayDelivery() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if !gotNow.Equal(now) {
+				t.Errorf("WebhookService.ReplayDelivery() due at %s, want %s", gotNow, now)
+			}
+			if got.DeliveryId != 5 || got.Status != DeliveryPending || got.NextAttemptAt == nil {
+				t.Errorf("WebhookService.ReplayDelivery() = %+v, want pending delivery 5", got)
+			}
+		})
+	}
+}
+
+type fakeWebhookRepo struct {
+	CreateSubscriptionFunc     func(ctx context.Context, params SubscriptionCreateParams) (SubscriptionRow, error)
+	SubscriptionByIdFunc       func(ctx context.Context, subscriptionId int) (SubscriptionRow, error)
+	SubscriptionsFunc          func(ctx context.Context) ([]SubscriptionRow, error)
+	DisableSubscriptionFunc    func(ctx context.Context, subscriptionId int) (bool, error)
+	CreateEventFunc            func(ctx context.Context, params EventCreateParams) (int, error)
+	DeliveriesFunc             func(ctx context.Context, params DeliveryListParams) ([]DeliveryRow, error)
+	DeliveryByIdFunc           func(ctx context.Context, deliveryId int) (DeliveryRow, error)
+	DueDeliveriesForUpdateFunc func(ctx context.Context, now time.Time, limit int) ([]DueDeliveryRow, error)
+	UpdateDeliveryFunc         func(ctx context.Context, params DeliveryUpdateParams) error
+	ReplayDeliveryFunc         func(ctx context.Context, deliveryId int, now time.Time) (bool, error)
+	CreateAttemptFunc          func(ctx context.Context, params AttemptCreateParams) error
+	AttemptsFunc               func(ctx context.Context, deliveryId int) ([]AttemptRow, error)
+}
+
+func (f *fakeWebhookRepo) CreateSubscription(ctx context.Context, params SubscriptionCreateParams) (SubscriptionRow, error) {
+	return f.CreateSubscriptionFunc(ctx, params)
+}
+
+func (f *fakeWebhookRepo) SubscriptionById(ctx context.Context, subscriptionId int) (SubscriptionRow, error) {
+	return f.SubscriptionByIdFunc(ctx, subscriptionId)
+}
+
+func (f *fakeWebhookRepo) Subscriptions(ctx context.Context) ([]SubscriptionRow, error) {
+	return f.SubscriptionsFunc(ctx)
+}
+
+func (f *fakeWebhookRepo) DisableSubscription(ctx context.Context, subscriptionId int) (bool, error) {
+	return f.DisableSubscriptionFunc(ctx, subscriptionId)
+}
+
+func (f *fakeWebhookRepo) CreateEvent(ctx context.Context, params EventCreateParams) (int, error) {
+	return f.CreateEventFunc(ctx, params)
+}
+
+func (f *fakeWebhookRepo) Deliveries(ctx context.Context, params DeliveryListParams) ([]DeliveryRow, error) {
+	return f.DeliveriesFunc(ctx, params)
+}
+
+func (f *fakeWebhookRepo) DeliveryById(ctx context.Context, deliveryId int) (DeliveryRow, error) {
+	return f.DeliveryByIdFunc(ctx, deliveryId)
+}
+
+func (f *fakeWebhookRepo) DueDeliveriesForUpdate(ctx context.Context, now time.Time, limit int) ([]DueDeliveryRow, error) {
+	return f.DueDeliveriesForUpdateFunc(ctx, now, limit)
+}
+
+func (f *fakeWebhookRepo) UpdateDelivery(ctx context.Context, params DeliveryUpdateParams) error {
+	return f.UpdateDeliveryFunc(ctx, params)
+}
+
+func (f *fakeWebhookRepo) ReplayDelivery(ctx context.Context, deliveryId int, now time.Time) (bool, error) {
+	return f.ReplayDeliveryFunc(ctx, deliveryId, now)
+}
+
+func (f *fakeWebhookRepo) CreateAttempt(ctx context.Context, params AttemptCreateParams) error {
+	return f.CreateAttemptFunc(ctx, params)
+}
+
+func (f *fakeWebhookRepo) Attempts(ctx context.Context, deliveryId int) ([]AttemptRow, error) {
+	return f.AttemptsFunc(ctx, deliveryId)
+}
+
+type fakeUnitOfWork struct{}
+
+func (f *fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeHTTPClient struct {
+	DoFunc func(req *http.Request) (*http.Response, error)
+}
+
+func (f *fakeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return f.DoFunc(req)
+}
+
+// mustJSON returns the JSON encoding of v.
+func mustJSON(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -5,12 +5,19 @@
 package config
 
 import (
+	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config struct which holds configuration values such as server port and PostgreSQL connection details,
 type Config struct {
 	Port             string
+	ShutdownTimeout  time.Duration
+	LogLevel         string
+	LogFormat        string
+	TraceExporter    string
 	PostgresHost     string
 	PostgresUser     string
 	PostgresPassword string
@@ -18,6 +25,28 @@ type Config struct {
 
 	IsTigerBeetleOn    bool
 	TigerbeetleAddress string
+
+	AccountIdStrategy   string
+	AccountIdNode       int
+	IsClientAccountIdOn bool
+
+	IsAuthOn    bool
+	JwtJwks     string
+	JwtIssuer   string
+	JwtAudience string
+
+	OutboxBatchSize    int
+	OutboxMaxAttempts  int
+	OutboxPollInterval time.Duration
+
+	IdempotencyKeyTTL time.Duration
+
+	SchedulerPollInterval time.Duration
+
+	WebhookBatchSize    int
+	WebhookMaxAttempts  int
+	WebhookPollInterval time.Duration
+	WebhookTimeout      time.Duration
 }
 
 // LoadConfig initializes and returns a pointer to a Config struct populated with
@@ -26,6 +55,10 @@ func LoadConfig() *Config {
 	isTigerBeetleOn := getEnv("FEATURE_FLAG_TIGERBEETLE", "OFF") == "ON"
 	return &Config{
 		Port:             getEnv("APP_PORT", "8000"),
+		ShutdownTimeout:  getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		LogLevel:         getEnv("LOG_LEVEL", "info"),
+		LogFormat:        getEnv("LOG_FORMAT", "json"),
+		TraceExporter:    getEnv("TRACE_EXPORTER", "none"),
 		PostgresHost:     getEnv("POSTGRES_HOST", "localhost"),
 		PostgresUser:     getEnv("POSTGRES_USER", ""),
 		PostgresPassword: getEnv("POSTGRES_PASSWORD", ""),
@@ -33,6 +66,28 @@ func LoadConfig() *Config {
 
 		IsTigerBeetleOn:    isTigerBeetleOn,
 		TigerbeetleAddress: getEnv("TIGERBEETLE_ADDRESS", ""),
+
+		AccountIdStrategy:   getEnv("ACCOUNT_ID_STRATEGY", "sequence"),
+		AccountIdNode:       getEnvInt("ACCOUNT_ID_NODE", 1),
+		IsClientAccountIdOn: getEnv("FEATURE_FLAG_CLIENT_ACCOUNT_ID", "OFF") == "ON",
+
+		IsAuthOn:    getEnv("FEATURE_FLAG_AUTH", "OFF") == "ON",
+		JwtJwks:     getEnv("JWT_JWKS", ""),
+		JwtIssuer:   getEnv("JWT_ISSUER", ""),
+		JwtAudience: getEnv("JWT_AUDIENCE", ""),
+
+		OutboxBatchSize:    getEnvInt("OUTBOX_BATCH_SIZE", 100),
+		OutboxMaxAttempts:  getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+		OutboxPollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+
+		IdempotencyKeyTTL: getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+
+		SchedulerPollInterval: getEnvDuration("SCHEDULER_POLL_INTERVAL", 10*time.Second),
+
+		WebhookBatchSize:    getEnvInt("WEBHOOK_BATCH_SIZE", 20),
+		WebhookMaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookPollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", time.Second),
+		WebhookTimeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
 	}
 }
 
@@ -42,3 +97,31 @@ func getEnv(key, defaultVal string) string {
 	}
 	return defaultVal
 }
+
+// getEnvDuration reads key as a time.Duration such as "24h". An unparsable value
+// terminates the application so misconfiguration is caught at startup.
+func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultVal
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("config: invalid duration for %s: %v", key, err)
+	}
+	return d
+}
+
+// getEnvInt reads key as a positive integer. An unparsable value terminates the
+// application so misconfiguration is caught at startup.
+func getEnvInt(key string, defaultVal int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultVal
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Fatalf("config: invalid positive integer for %s: %q", key, value)
+	}
+	return n
+}
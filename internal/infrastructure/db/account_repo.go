@@ -6,6 +6,7 @@ import (
 
 	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // AccountDB provides methods for interacting with the accounts table in the database.
@@ -19,15 +20,35 @@ func NewAccountDB(db *sqlx.DB) *AccountDB {
 }
 
 // Create inserts a new account record into the accounts table with the provided parameters.
-func (db *AccountDB) Create(ctx context.Context, params account.AccountCreateParams) error {
+// It reports false without error when an account with the same id already exists.
+func (db *AccountDB) Create(ctx context.Context, params account.AccountCreateParams) (bool, error) {
 	q := `
-	INSERT INTO accounts (account_id, balance, scale_balance, created_at, updated_at)
-	VALUES ($1, $2, $3, NOW(), NOW())`
-	if _, err := db.db.ExecContext(ctx, q, params.AccountId, params.Balance, params.ScaleBalance); err != nil {
-		return fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	INSERT INTO accounts (account_id, balance, scale_balance, currency, overdraft_limit, overdraft_enabled, tier, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $5 > 0, $6, NOW(), NOW())
+	ON CONFLICT (account_id) DO NOTHING`
+	res, err := conn(ctx, db.db).ExecContext(ctx, q, params.AccountId, params.Balance, params.ScaleBalance, params.Currency, params.OverdraftLimit, params.Tier)
+	if err != nil {
+		return false, fmt.Errorf("sql insert: %w [query: %s]", err, q)
 	}
 
-	return nil
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql rows affected: %w [query: %s]", err, q)
+	}
+
+	return n == 1, nil
+}
+
+// NextId allocates an account id from the accounts_account_id_seq sequence.
+func (db *AccountDB) NextId(ctx context.Context) (int, error) {
+	var id int
+
+	q := `SELECT nextval('accounts_account_id_seq')`
+	if err := sqlx.GetContext(ctx, conn(ctx, db.db), &id, q); err != nil {
+		return 0, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return id, nil
 }
 
 // ById retrieves an account record from the database by its account ID.
@@ -38,9 +59,15 @@ func (db *AccountDB) ById(ctx context.Context, accountId int) (account.AccountRo
 	SELECT x.account_id
 		, x.balance
 		, x.scale_balance
+		, x.currency
+		, x.held_balance
+		, x.status
+		, x.overdraft_limit
+		, x.overdraft_enabled
+		, x.tier
 	FROM accounts AS x
 	WHERE x.account_id = $1`
-	err := sqlx.SelectContext(ctx, db.db, &rows, q, accountId)
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, accountId)
 	if err != nil {
 		return account.AccountRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
 	}
@@ -52,6 +79,40 @@ func (db *AccountDB) ById(ctx context.Context, accountId int) (account.AccountRo
 	return rows[0], nil
 }
 
+// ByIdsForUpdate retrieves the account records with the given IDs and locks them with
+// SELECT ... FOR UPDATE until the surrounding transaction ends. Rows are locked in
+// ascending account_id order so concurrent callers can not deadlock each other.
+// Missing accounts are simply absent from the result.
+func (db *AccountDB) ByIdsForUpdate(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+	ids := make([]int64, len(accountIds))
+	for i, id := range accountIds {
+		ids[i] = int64(id)
+	}
+
+	var rows []account.AccountRow
+
+	q := `
+	SELECT x.account_id
+		, x.balance
+		, x.scale_balance
+		, x.currency
+		, x.held_balance
+		, x.status
+		, x.overdraft_limit
+		, x.overdraft_enabled
+		, x.tier
+	FROM accounts AS x
+	WHERE x.account_id = ANY($1)
+	ORDER BY x.account_id
+	FOR UPDATE`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
 // UpdateBalance updates the balance of an account identified by AccountId in the database.
 // It sets the new balance and updates the updated_at timestamp to the current time.
 func (db *AccountDB) UpdateBalance(ctx context.Context, params account.AccountUpdateBalanceParams) error {
@@ -60,9 +121,91 @@ func (db *AccountDB) UpdateBalance(ctx context.Context, params account.AccountUp
 	SET balance = $2
 		, updated_at = NOW()
 	WHERE account_id = $1`
-	if _, err := db.db.ExecContext(ctx, q, params.AccountId, params.Balance); err != nil {
+	if _, err := conn(ctx, db.db).ExecContext(ctx, q, params.AccountId, params.Balance); err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// UpdateHeldBalance updates the amount of an account reserved by pending holds.
+func (db *AccountDB) UpdateHeldBalance(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+	q := `
+	UPDATE accounts
+	SET held_balance = $2
+		, updated_at = NOW()
+	WHERE account_id = $1`
+	if _, err := conn(ctx, db.db).ExecContext(ctx, q, params.AccountId, params.HeldBalance); err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// UpdateStatus sets the status of an account and appends the change to account_status_changes.
+// It must run inside a transaction so both writes commit together.
+func (db *AccountDB) UpdateStatus(ctx context.Context, params account.AccountUpdateStatusParams) error {
+	q := `
+	UPDATE accounts
+	SET status = $2
+		, updated_at = NOW()
+	WHERE account_id = $1`
+	if _, err := conn(ctx, db.db).ExecContext(ctx, q, params.AccountId, params.ToStatus); err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	q = `
+	INSERT INTO account_status_changes (account_id, from_status, to_status, reason, created_at)
+	VALUES ($1, $2, $3, $4, NOW())`
+	if _, err := conn(ctx, db.db).ExecContext(ctx, q, params.AccountId, params.FromStatus, params.ToStatus, params.Reason); err != nil {
+		return fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// UpdateOverdraftLimit sets the overdraft limit of an account and appends the change to
+// account_overdraft_limit_changes. A positive limit marks the account overdraft enabled for
+// good. It must run inside a transaction so both writes commit together.
+func (db *AccountDB) UpdateOverdraftLimit(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error {
+	q := `
+	UPDATE accounts
+	SET overdraft_limit = $2
+		, overdraft_enabled = overdraft_enabled OR $2 > 0
+		, updated_at = NOW()
+	WHERE account_id = $1`
+	if _, err := conn(ctx, db.db).ExecContext(ctx, q, params.AccountId, params.ToLimit); err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	q = `
+	INSERT INTO account_overdraft_limit_changes (account_id, from_limit, to_limit, reason, created_at)
+	VALUES ($1, $2, $3, $4, NOW())`
+	if _, err := conn(ctx, db.db).ExecContext(ctx, q, params.AccountId, params.FromLimit, params.ToLimit, params.Reason); err != nil {
 		return fmt.Errorf("sql insert: %w [query: %s]", err, q)
 	}
 
 	return nil
 }
+
+// OverdraftLimitChanges returns the overdraft limit history of an account, newest first.
+func (db *AccountDB) OverdraftLimitChanges(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error) {
+	var rows []account.OverdraftLimitChangeRow
+
+	q := `
+	SELECT x.change_id
+		, x.account_id
+		, x.from_limit
+		, x.to_limit
+		, x.reason
+		, x.created_at
+	FROM account_overdraft_limit_changes AS x
+	WHERE x.account_id = $1
+	ORDER BY x.change_id DESC`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/apikey"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// apiKeyColumns lists the columns selected into apiKeyRow.
+const apiKeyColumns = `x.api_key_id
+		, x.name
+		, x.key_prefix
+		, x.key_hash
+		, x.scopes
+		, x.account_ids
+		, x.revoked_at
+		, x.created_at
+		, x.updated_at`
+
+// apiKeyRow scans an api_keys row, whose scopes and account_ids are Postgres arrays. A NULL
+// account_ids scans to nil, a key allowed on every account.
+type apiKeyRow struct {
+	ApiKeyId   int            `db:"api_key_id"`
+	Name       string         `db:"name"`
+	KeyPrefix  string         `db:"key_prefix"`
+	KeyHash    string         `db:"key_hash"`
+	Scopes     pq.StringArray `db:"scopes"`
+	AccountIds pq.Int64Array  `db:"account_ids"`
+	RevokedAt  *time.Time     `db:"revoked_at"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+func (row apiKeyRow) toRow() apikey.ApiKeyRow {
+	var accountIds []int
+	if row.AccountIds != nil {
+		accountIds = make([]int, len(row.AccountIds))
+		for i, accountId := range row.AccountIds {
+			accountIds[i] = int(accountId)
+		}
+	}
+
+	return apikey.ApiKeyRow{
+		ApiKeyId:   row.ApiKeyId,
+		Name:       row.Name,
+		KeyPrefix:  row.KeyPrefix,
+		KeyHash:    row.KeyHash,
+		Scopes:     []string(row.Scopes),
+		AccountIds: accountIds,
+		RevokedAt:  row.RevokedAt,
+		CreatedAt:  row.CreatedAt,
+		UpdatedAt:  row.UpdatedAt,
+	}
+}
+
+// ApiKeyDB provides methods for interacting with the api_keys table in the database.
+type ApiKeyDB struct {
+	db *sqlx.DB
+}
+
+// NewApiKeyDB creates and returns a new instance of ApiKeyDB
+func NewApiKeyDB(db *sqlx.DB) *ApiKeyDB {
+	return &ApiKeyDB{db}
+}
+
+// Create inserts a new API key and returns the stored row including its generated api_key_id.
+func (db *ApiKeyDB) Create(ctx context.Context, params apikey.ApiKeyCreateParams) (apikey.ApiKeyRow, error) {
+	var row apiKeyRow
+
+	// A nil slice is stored as NULL, an unrestricted key; pq.Array would store it as an empty
+	// array, a key allowed on no account.
+	var accountIds any
+	if params.AccountIds != nil {
+		accountIds = pq.Array(params.AccountIds)
+	}
+
+	q := `
+	INSERT INTO api_keys AS x (name, key_prefix, key_hash, scopes, account_ids, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+	RETURNING ` + apiKeyColumns
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q, params.Name, params.KeyPrefix, params.KeyHash, pq.Array(params.Scopes), accountIds)
+	if err != nil {
+		return apikey.ApiKeyRow{}, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return row.toRow(), nil
+}
+
+// ById retrieves an API key from the database by its API key ID.
+func (db *ApiKeyDB) ById(ctx context.Context, apiKeyId int) (apikey.ApiKeyRow, error) {
+	var rows []apiKeyRow
+
+	q := `
+	SELECT ` + apiKeyColumns + `
+	FROM api_keys AS x
+	WHERE x.api_key_id = $1`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, apiKeyId)
+	if err != nil {
+		return apikey.ApiKeyRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return apikey.ApiKeyRow{}, fmt.Errorf("api key not found [api_key_id: %d]", apiKeyId)
+	}
+
+	return rows[0].toRow(), nil
+}
+
+// ByHash retrieves the API key whose key hashes to keyHash. It reports false when there is none.
+func (db *ApiKeyDB) ByHash(ctx context.Context, keyHash string) (apikey.ApiKeyRow, bool, error) {
+	var rows []apiKeyRow
+
+	q := `
+	SELECT ` + apiKeyColumns + `
+	FROM api_keys AS x
+	WHERE x.key_hash = $1`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, keyHash)
+	if err != nil {
+		return apikey.ApiKeyRow{}, false, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return apikey.ApiKeyRow{}, false, nil
+	}
+
+	return rows[0].toRow(), true, nil
+}
+
+// List returns every API key ordered by api_key_id.
+func (db *ApiKeyDB) List(ctx context.Context) ([]apikey.ApiKeyRow, error) {
+	var rows []apiKeyRow
+
+	q := `
+	SELECT ` + apiKeyColumns + `
+	FROM api_keys AS x
+	ORDER BY x.api_key_id`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	data := make([]apikey.ApiKeyRow, len(rows))
+	for i, row := range rows {
+		data[i] = row.toRow()
+	}
+
+	return data, nil
+}
+
+// UpdateKey replaces the key of an API key that is not revoked. It reports false when there is
+// no such key.
+func (db *ApiKeyDB) UpdateKey(ctx context.Context, params apikey.ApiKeyUpdateKeyParams) (bool, error) {
+	q := `
+	UPDATE api_keys
+	SET key_prefix = $2
+		, key_hash = $3
+		, updated_at = NOW()
+	WHERE api_key_id = $1
+		AND revoked_at IS NULL`
+	res, err := conn(ctx, db.db).ExecContext(ctx, q, params.ApiKeyId, params.KeyPrefix, params.KeyHash)
+	if err != nil {
+		return false, fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql rows affected: %w [query: %s]", err, q)
+	}
+
+	return n == 1, nil
+}
+
+// Revoke marks an API key revoked. It reports false when the key does not exist or is already
+// revoked.
+func (db *ApiKeyDB) Revoke(ctx context.Context, apiKeyId int) (bool, error) {
+	q := `
+	UPDATE api_keys
+	SET revoked_at = NOW()
+		, updated_at = NOW()
+	WHERE api_key_id = $1
+		AND revoked_at IS NULL`
+	res, err := conn(ctx, db.db).ExecContext(ctx, q, apiKeyId)
+	if err != nil {
+		return false, fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql rows affected: %w [query: %s]", err, q)
+	}
+
+	return n == 1, nil
+}
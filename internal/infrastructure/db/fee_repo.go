@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/fee"
+	"github.com/jmoiron/sqlx"
+)
+
+// feeScheduleColumns lists the columns selected into fee.ScheduleRow.
+const feeScheduleColumns = `x.tier
+		, x.currency
+		, x.kind
+		, x.rules
+		, x.created_at
+		, x.updated_at`
+
+// FeeDB provides methods for interacting with the fee_schedules table in the database.
+type FeeDB struct {
+	db *sqlx.DB
+}
+
+// NewFeeDB creates and returns a new instance of FeeDB
+func NewFeeDB(db *sqlx.DB) *FeeDB {
+	return &FeeDB{db}
+}
+
+// Upsert stores the fee schedule of a tier and currency, replacing the one they had, and returns
+// the stored row.
+func (db *FeeDB) Upsert(ctx context.Context, params fee.ScheduleUpsertParams) (fee.ScheduleRow, error) {
+	var row fee.ScheduleRow
+
+	q := `
+	INSERT INTO fee_schedules AS x (tier, currency, kind, rules, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, NOW(), NOW())
+	ON CONFLICT (tier, currency) DO UPDATE
+	SET kind = EXCLUDED.kind
+		, rules = EXCLUDED.rules
+		, updated_at = NOW()
+	RETURNING ` + feeScheduleColumns
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q, params.Tier, params.Currency, params.Kind, params.Rules)
+	if err != nil {
+		return fee.ScheduleRow{}, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return row, nil
+}
+
+// ByTier returns the fee schedule of a tier and currency, or no rows when they have none.
+func (db *FeeDB) ByTier(ctx context.Context, tier, currency string) ([]fee.ScheduleRow, error) {
+	var rows []fee.ScheduleRow
+
+	q := `
+	SELECT ` + feeScheduleColumns + `
+	FROM fee_schedules AS x
+	WHERE x.tier = $1
+		AND x.currency = $2`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, tier, currency)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// List returns every fee schedule ordered by tier and currency.
+func (db *FeeDB) List(ctx context.Context) ([]fee.ScheduleRow, error) {
+	var rows []fee.ScheduleRow
+
+	q := `
+	SELECT ` + feeScheduleColumns + `
+	FROM fee_schedules AS x
+	ORDER BY x.tier, x.currency`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// Delete removes the fee schedule of a tier and currency. It reports false without error when
+// they have none.
+func (db *FeeDB) Delete(ctx context.Context, tier, currency string) (bool, error) {
+	q := `
+	DELETE FROM fee_schedules
+	WHERE tier = $1
+		AND currency = $2`
+	res, err := conn(ctx, db.db).ExecContext(ctx, q, tier, currency)
+	if err != nil {
+		return false, fmt.Errorf("sql delete: %w [query: %s]", err, q)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql rows affected: %w [query: %s]", err, q)
+	}
+
+	return n == 1, nil
+}
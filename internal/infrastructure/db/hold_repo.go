@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+	"github.com/jmoiron/sqlx"
+)
+
+// holdColumns lists the columns selected into transaction.HoldRow.
+const holdColumns = `x.hold_id
+		, x.source_account_id
+		, x.destination_account_id
+		, x.amount
+		, x.scale_amount
+		, x.currency
+		, x.captured_amount
+		, x.status
+		, x.transaction_id
+		, x.expires_at
+		, x.created_at
+		, x.updated_at`
+
+// HoldDB provides methods for interacting with the holds table in the database.
+type HoldDB struct {
+	db *sqlx.DB
+}
+
+// NewHoldDB creates and returns a new instance of HoldDB
+func NewHoldDB(db *sqlx.DB) *HoldDB {
+	return &HoldDB{db}
+}
+
+// Create inserts a new pending hold and returns the stored row including its generated hold_id.
+func (db *HoldDB) Create(ctx context.Context, params transaction.HoldCreateParams) (transaction.HoldRow, error) {
+	var row transaction.HoldRow
+
+	q := `
+	INSERT INTO holds AS x (source_account_id, destination_account_id, amount, scale_amount, currency
+		, status, expires_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, 'pending', $6, NOW(), NOW())
+	RETURNING ` + holdColumns
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q, params.SourceAccountId, params.DestinationAccountId, params.Amount, params.AmountScale, params.Currency,
+		params.ExpiresAt)
+	if err != nil {
+		return transaction.HoldRow{}, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return row, nil
+}
+
+// ById retrieves a hold record from the database by its hold ID.
+func (db *HoldDB) ById(ctx context.Context, holdId int) (transaction.HoldRow, error) {
+	return db.byId(ctx, holdId, "")
+}
+
+// ByIdForUpdate retrieves a hold record by its hold ID and locks it with SELECT ... FOR UPDATE
+// until the surrounding transaction ends.
+func (db *HoldDB) ByIdForUpdate(ctx context.Context, holdId int) (transaction.HoldRow, error) {
+	return db.byId(ctx, holdId, "FOR UPDATE")
+}
+
+func (db *HoldDB) byId(ctx context.Context, holdId int, lock string) (transaction.HoldRow, error) {
+	var rows []transaction.HoldRow
+
+	q := `
+	SELECT ` + holdColumns + `
+	FROM holds AS x
+	WHERE x.hold_id = $1
+	` + lock
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, holdId)
+	if err != nil {
+		return transaction.HoldRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return transaction.HoldRow{}, fmt.Errorf("hold not found [hold_id: %d]", holdId)
+	}
+
+	return rows[0], nil
+}
+
+// ExpiredForUpdate locks up to limit pending holds that expired at or before now. Holds locked
+// by another caller are skipped so concurrent sweepers do not wait on each other.
+func (db *HoldDB) ExpiredForUpdate(ctx context.Context, now time.Time, limit int) ([]transaction.HoldRow, error) {
+	var rows []transaction.HoldRow
+
+	q := `
+	SELECT ` + holdColumns + `
+	FROM holds AS x
+	WHERE x.status = 'pending'
+		AND x.expires_at <= $1
+	ORDER BY x.expires_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// UpdateStatus stores the status a hold was settled with, the amount captured and the
+// transaction recording the capture.
+func (db *HoldDB) UpdateStatus(ctx context.Context, params transaction.HoldUpdateStatusParams) error {
+	q := `
+	UPDATE holds
+	SET status = $2
+		, captured_amount = $3
+		, transaction_id = $4
+		, updated_at = NOW()
+	WHERE hold_id = $1`
+	_, err := conn(ctx, db.db).ExecContext(ctx, q, params.HoldId, params.Status, params.CapturedAmount, params.TransactionId)
+	if err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
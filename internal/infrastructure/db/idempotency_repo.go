@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/idempotency"
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyDB provides methods for interacting with the idempotency_keys table in the database.
+type IdempotencyDB struct {
+	db *sqlx.DB
+}
+
+// NewIdempotencyDB creates and returns a new instance of IdempotencyDB
+func NewIdempotencyDB(db *sqlx.DB) *IdempotencyDB {
+	return &IdempotencyDB{db}
+}
+
+// Create inserts a new in-progress idempotency key. It reports false without error when
+// the key already exists in the scope.
+func (db *IdempotencyDB) Create(ctx context.Context, params idempotency.KeyCreateParams) (bool, error) {
+	q := `
+	INSERT INTO idempotency_keys (idempotency_key, scope, fingerprint, expires_at, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, NOW(), NOW())
+	ON CONFLICT (idempotency_key, scope) DO NOTHING`
+	res, err := conn(ctx, db.db).ExecContext(ctx, q, params.Key, params.Scope, params.Fingerprint, params.ExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql rows affected: %w [query: %s]", err, q)
+	}
+
+	return n == 1, nil
+}
+
+// ByKey retrieves an idempotency key record by its key and scope.
+func (db *IdempotencyDB) ByKey(ctx context.Context, key, scope string) (idempotency.KeyRow, error) {
+	var rows []idempotency.KeyRow
+
+	q := `
+	SELECT x.idempotency_key
+		, x.scope
+		, x.fingerprint
+		, x.status_code
+		, x.response_body
+		, x.expires_at
+	FROM idempotency_keys AS x
+	WHERE x.idempotency_key = $1
+		AND x.scope = $2`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, key, scope)
+	if err != nil {
+		return idempotency.KeyRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return idempotency.KeyRow{}, fmt.Errorf("idempotency key not found [key: %s, scope: %s]", key, scope)
+	}
+
+	return rows[0], nil
+}
+
+// Complete stores the response produced for an in-progress idempotency key.
+func (db *IdempotencyDB) Complete(ctx context.Context, params idempotency.KeyCompleteParams) error {
+	q := `
+	UPDATE idempotency_keys
+	SET status_code = $3
+		, response_body = $4
+		, updated_at = NOW()
+	WHERE idempotency_key = $1
+		AND scope = $2`
+	if _, err := conn(ctx, db.db).ExecContext(ctx, q, params.Key, params.Scope, params.StatusCode, params.ResponseBody); err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// Delete removes an idempotency key record.
+func (db *IdempotencyDB) Delete(ctx context.Context, key, scope string) error {
+	q := `
+	DELETE FROM idempotency_keys
+	WHERE idempotency_key = $1
+		AND scope = $2`
+	if _, err := conn(ctx, db.db).ExecContext(ctx, q, key, scope); err != nil {
+		return fmt.Errorf("sql delete: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes every idempotency key that expired at or before now and returns how many were removed.
+func (db *IdempotencyDB) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	q := `
+	DELETE FROM idempotency_keys
+	WHERE expires_at <= $1`
+	res, err := conn(ctx, db.db).ExecContext(ctx, q, now)
+	if err != nil {
+		return 0, fmt.Errorf("sql delete: %w [query: %s]", err, q)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sql rows affected: %w [query: %s]", err, q)
+	}
+
+	return int(n), nil
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/journal"
+	"github.com/jmoiron/sqlx"
+)
+
+// JournalDB provides methods for interacting with the journal_entries and postings tables in the database.
+type JournalDB struct {
+	db *sqlx.DB
+}
+
+// NewJournalDB creates and returns a new instance of JournalDB
+func NewJournalDB(db *sqlx.DB) *JournalDB {
+	return &JournalDB{db}
+}
+
+// Create inserts a journal entry and its postings and returns the generated journal_entry_id.
+// The inserts join the transaction bound to ctx, or run in their own transaction when there
+// is none, so an entry is never stored without its postings.
+func (db *JournalDB) Create(ctx context.Context, params journal.EntryCreateParams) (int, error) {
+	var entryId int
+
+	err := NewUnitOfWorkDB(db.db).Do(ctx, func(ctx context.Context) error {
+		q := `
+	INSERT INTO journal_entries (kind, transaction_id, created_at)
+	VALUES ($1, $2, NOW())
+	RETURNING journal_entry_id`
+		if err := sqlx.GetContext(ctx, conn(ctx, db.db), &entryId, q, params.Kind, params.TransactionId); err != nil {
+			return fmt.Errorf("sql insert: %w [query: %s]", err, q)
+		}
+
+		q = `
+	INSERT INTO postings (journal_entry_id, account_id, system_account, direction, amount, scale_amount, currency, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())`
+		for _, p := range params.Postings {
+			_, err := conn(ctx, db.db).ExecContext(ctx, q, entryId, p.AccountId, p.SystemAccount, p.Direction, p.Amount, p.AmountScale, p.Currency)
+			if err != nil {
+				return fmt.Errorf("sql insert: %w [query: %s]", err, q)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return entryId, nil
+}
+
+// AccountBalance returns the credits minus the debits posted to an account, grouped by
+// currency and scale.
+func (db *JournalDB) AccountBalance(ctx context.Context, accountId int) ([]journal.AccountBalanceRow, error) {
+	var rows []journal.AccountBalanceRow
+
+	q := `
+	SELECT x.currency
+		, x.scale_amount
+		, SUM(CASE x.direction WHEN 'credit' THEN x.amount ELSE -x.amount END) AS balance
+	FROM postings AS x
+	WHERE x.account_id = $1
+	GROUP BY x.currency, x.scale_amount
+	ORDER BY x.currency, x.scale_amount`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
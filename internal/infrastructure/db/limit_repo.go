@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/limit"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+	"github.com/jmoiron/sqlx"
+)
+
+// limitColumns lists the columns selected into limit.LimitRow.
+const limitColumns = `x.limit_id
+		, x.kind
+		, x.scope
+		, x.account_id
+		, x.tier
+		, x.currency
+		, x.amount
+		, x.scale_amount
+		, x.count
+		, x.created_at
+		, x.updated_at`
+
+// LimitDB provides methods for interacting with the transfer_limits table in the database.
+type LimitDB struct {
+	db *sqlx.DB
+}
+
+// NewLimitDB creates and returns a new instance of LimitDB
+func NewLimitDB(db *sqlx.DB) *LimitDB {
+	return &LimitDB{db}
+}
+
+// Create inserts a new transfer limit and returns the stored row including its generated
+// limit_id. It reports false without error when a limit of the same kind is already configured
+// for the same target and currency.
+func (db *LimitDB) Create(ctx context.Context, params limit.LimitCreateParams) (limit.LimitRow, bool, error) {
+	var rows []limit.LimitRow
+
+	q := `
+	INSERT INTO transfer_limits AS x (kind, scope, account_id, tier, currency, amount, scale_amount, count
+		, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+	ON CONFLICT DO NOTHING
+	RETURNING ` + limitColumns
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, params.Kind, params.Scope, params.AccountId, params.Tier, params.Currency,
+		params.Amount, params.AmountScale, params.Count)
+	if err != nil {
+		return limit.LimitRow{}, false, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return limit.LimitRow{}, false, nil
+	}
+
+	return rows[0], true, nil
+}
+
+// ById retrieves a transfer limit from the database by its limit ID.
+func (db *LimitDB) ById(ctx context.Context, limitId int) (limit.LimitRow, error) {
+	var rows []limit.LimitRow
+
+	q := `
+	SELECT ` + limitColumns + `
+	FROM transfer_limits AS x
+	WHERE x.limit_id = $1`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, limitId)
+	if err != nil {
+		return limit.LimitRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return limit.LimitRow{}, fmt.Errorf("limit not found [limit_id: %d]", limitId)
+	}
+
+	return rows[0], nil
+}
+
+// List returns the transfer limits matching params ordered by limit_id. Zero values of params
+// match every limit.
+func (db *LimitDB) List(ctx context.Context, params limit.LimitListParams) ([]limit.LimitRow, error) {
+	var rows []limit.LimitRow
+
+	q := `
+	SELECT ` + limitColumns + `
+	FROM transfer_limits AS x
+	WHERE ($1 = '' OR x.scope = $1)
+		AND ($2 = 0 OR x.account_id = $2)
+		AND ($3 = '' OR x.tier = $3)
+	ORDER BY x.limit_id`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, params.Scope, params.AccountId, params.Tier)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// Update sets the value of a transfer limit and returns the updated row.
+func (db *LimitDB) Update(ctx context.Context, params limit.LimitUpdateParams) (limit.LimitRow, error) {
+	var row limit.LimitRow
+
+	q := `
+	UPDATE transfer_limits AS x
+	SET amount = $2
+		, count = $3
+		, updated_at = NOW()
+	WHERE x.limit_id = $1
+	RETURNING ` + limitColumns
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q, params.LimitId, params.Amount, params.Count)
+	if err != nil {
+		return limit.LimitRow{}, fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	return row, nil
+}
+
+// Delete removes a transfer limit. It reports false without error when the limit does not exist.
+func (db *LimitDB) Delete(ctx context.Context, limitId int) (bool, error) {
+	q := `
+	DELETE FROM transfer_limits
+	WHERE limit_id = $1`
+	res, err := conn(ctx, db.db).ExecContext(ctx, q, limitId)
+	if err != nil {
+		return false, fmt.Errorf("sql delete: %w [query: %s]", err, q)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql rows affected: %w [query: %s]", err, q)
+	}
+
+	return n == 1, nil
+}
+
+// Applicable returns the limits of the account, of its tier and the global ones that are in the
+// currency of params or in no currency.
+func (db *LimitDB) Applicable(ctx context.Context, params limit.LimitApplicableParams) ([]limit.LimitRow, error) {
+	var rows []limit.LimitRow
+
+	q := `
+	SELECT ` + limitColumns + `
+	FROM transfer_limits AS x
+	WHERE (x.scope = 'account' AND x.account_id = $1
+		OR x.scope = 'tier' AND x.tier = $2
+		OR x.scope = 'global')
+		AND (x.currency IS NULL OR x.currency = $3)
+	ORDER BY x.limit_id`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, params.AccountId, params.Tier, params.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// Outgoing returns the transfers sent by an account and the holds still pending on it after
+// params.Since, oldest first. Reversals and sweeps are not counted against transfer limits; a
+// captured hold counts through the transfer that recorded its capture.
+func (db *LimitDB) Outgoing(ctx context.Context, params limit.OutgoingParams) ([]limit.OutgoingRow, error) {
+	var rows []limit.OutgoingRow
+
+	q := `
+	SELECT x.transaction_id
+		, 0 AS hold_id
+		, x.amount
+		, x.scale_amount
+		, x.currency
+		, x.created_at
+	FROM transactions AS x
+	WHERE x.source_account_id = $1
+		AND x.kind = $2
+		AND x.created_at > $3
+	UNION ALL
+	SELECT 0 AS transaction_id
+		, h.hold_id
+		, h.amount
+		, h.scale_amount
+		, h.currency
+		, h.created_at
+	FROM holds AS h
+	WHERE h.source_account_id = $1
+		AND h.status = $4
+		AND h.created_at > $3
+	ORDER BY created_at, transaction_id, hold_id`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, params.AccountId, transaction.KindTransfer, params.Since, transaction.HoldStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
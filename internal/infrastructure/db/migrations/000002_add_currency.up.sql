@@ -0,0 +1,20 @@
+ALTER TABLE accounts ADD COLUMN currency varchar(8) NOT NULL DEFAULT 'USD';
+ALTER TABLE accounts ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE transactions
+    ADD COLUMN currency                  varchar(8),
+    ADD COLUMN destination_amount        bigint,
+    ADD COLUMN scale_destination_amount  smallint,
+    ADD COLUMN destination_currency      varchar(8);
+
+UPDATE transactions
+SET currency = 'USD'
+    , destination_amount = amount
+    , scale_destination_amount = scale_amount
+    , destination_currency = 'USD';
+
+ALTER TABLE transactions
+    ALTER COLUMN currency SET NOT NULL,
+    ALTER COLUMN destination_amount SET NOT NULL,
+    ALTER COLUMN scale_destination_amount SET NOT NULL,
+    ALTER COLUMN destination_currency SET NOT NULL;
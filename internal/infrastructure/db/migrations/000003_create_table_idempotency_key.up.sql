@@ -0,0 +1,13 @@
+CREATE TABLE idempotency_keys (
+    idempotency_key     varchar(255) NOT NULL,
+    scope               varchar(255) NOT NULL,
+    fingerprint         char(64) NOT NULL,
+    status_code         smallint,
+    response_body       bytea,
+    expires_at          timestamp with time zone NOT NULL,
+    created_at          timestamp with time zone NOT NULL,
+    updated_at          timestamp with time zone NOT NULL,
+    PRIMARY KEY (idempotency_key, scope)
+);
+
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,2 @@
+CREATE INDEX transactions_source_account_id_idx ON transactions (source_account_id, transaction_id);
+CREATE INDEX transactions_destination_account_id_idx ON transactions (destination_account_id, transaction_id);
@@ -0,0 +1,84 @@
+CREATE TABLE journal_entries (
+    journal_entry_id    bigserial PRIMARY KEY,
+    kind                varchar(32) NOT NULL,
+    transaction_id      bigint,
+    created_at          timestamp with time zone NOT NULL
+);
+
+CREATE INDEX journal_entries_transaction_id_idx ON journal_entries (transaction_id);
+
+CREATE TABLE postings (
+    posting_id          bigserial PRIMARY KEY,
+    journal_entry_id    bigint NOT NULL REFERENCES journal_entries (journal_entry_id),
+    account_id          bigint,
+    system_account      varchar(64),
+    direction           varchar(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount              bigint NOT NULL CHECK (amount > 0),
+    scale_amount        smallint NOT NULL,
+    currency            varchar(8) NOT NULL,
+    created_at          timestamp with time zone NOT NULL,
+    CHECK ((account_id IS NULL) <> (system_account IS NULL))
+);
+
+CREATE INDEX postings_journal_entry_id_idx ON postings (journal_entry_id);
+CREATE INDEX postings_account_id_idx ON postings (account_id);
+
+-- Debits must equal credits per currency within an entry. The check is deferred to
+-- commit so the postings of an entry can be inserted one by one.
+CREATE FUNCTION postings_check_balanced() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM postings AS x
+        WHERE x.journal_entry_id = NEW.journal_entry_id
+        GROUP BY x.currency
+        HAVING SUM(CASE x.direction WHEN 'credit' THEN x.amount ELSE -x.amount END * 10::numeric ^ (18 - x.scale_amount)) <> 0
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER postings_balanced
+    AFTER INSERT ON postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION postings_check_balanced();
+
+-- Balances that existed before the journal are opened against the treasury account of
+-- their currency so every balance can be verified against its postings.
+DO $$
+DECLARE
+    entry_id bigint;
+BEGIN
+    IF EXISTS (SELECT 1 FROM accounts WHERE balance <> 0) THEN
+        INSERT INTO journal_entries (kind, created_at)
+        VALUES ('opening', NOW())
+        RETURNING journal_entry_id INTO entry_id;
+
+        INSERT INTO postings (journal_entry_id, account_id, direction, amount, scale_amount, currency, created_at)
+        SELECT entry_id
+            , x.account_id
+            , CASE WHEN x.balance > 0 THEN 'credit' ELSE 'debit' END
+            , ABS(x.balance)
+            , x.scale_balance
+            , x.currency
+            , NOW()
+        FROM accounts AS x
+        WHERE x.balance <> 0;
+
+        INSERT INTO postings (journal_entry_id, system_account, direction, amount, scale_amount, currency, created_at)
+        SELECT entry_id
+            , 'treasury:' || x.currency
+            , CASE WHEN SUM(x.balance) > 0 THEN 'debit' ELSE 'credit' END
+            , ABS(SUM(x.balance))
+            , x.scale_balance
+            , x.currency
+            , NOW()
+        FROM accounts AS x
+        WHERE x.balance <> 0
+        GROUP BY x.currency, x.scale_balance
+        HAVING SUM(x.balance) <> 0;
+    END IF;
+END;
+$$;
@@ -0,0 +1,14 @@
+ALTER TABLE transactions
+    ADD COLUMN kind                         varchar(16) NOT NULL DEFAULT 'transfer',
+    ADD COLUMN status                       varchar(24) NOT NULL DEFAULT 'completed',
+    ADD COLUMN reversal_of                  bigint,
+    ADD COLUMN reversed_amount              bigint NOT NULL DEFAULT 0,
+    ADD COLUMN reversed_destination_amount  bigint NOT NULL DEFAULT 0,
+    ADD CONSTRAINT transactions_reversed_amount_check
+        CHECK (reversed_amount <= amount AND reversed_destination_amount <= destination_amount);
+
+ALTER TABLE transactions
+    ALTER COLUMN kind DROP DEFAULT,
+    ALTER COLUMN status DROP DEFAULT;
+
+CREATE INDEX transactions_reversal_of_idx ON transactions (reversal_of);
@@ -0,0 +1,19 @@
+ALTER TABLE accounts ADD COLUMN held_balance bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE holds (
+    hold_id                 bigserial PRIMARY KEY,
+    source_account_id       bigint NOT NULL,
+    destination_account_id  bigint NOT NULL,
+    amount                  bigint NOT NULL CHECK (amount > 0),
+    scale_amount            smallint NOT NULL,
+    currency                varchar(8) NOT NULL,
+    captured_amount         bigint NOT NULL DEFAULT 0,
+    status                  varchar(16) NOT NULL,
+    transaction_id          bigint,
+    expires_at              timestamp with time zone NOT NULL,
+    created_at              timestamp with time zone NOT NULL,
+    updated_at              timestamp with time zone NOT NULL,
+    CHECK (captured_amount <= amount)
+);
+
+CREATE INDEX holds_pending_expires_at_idx ON holds (expires_at) WHERE status = 'pending';
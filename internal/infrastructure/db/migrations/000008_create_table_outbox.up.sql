@@ -0,0 +1,15 @@
+CREATE TABLE outbox_messages (
+    message_id          bigserial PRIMARY KEY,
+    kind                varchar(32) NOT NULL,
+    payload             jsonb NOT NULL,
+    status              varchar(16) NOT NULL,
+    attempts            integer NOT NULL DEFAULT 0,
+    last_error          text,
+    next_attempt_at     timestamp with time zone NOT NULL,
+    sent_at             timestamp with time zone,
+    created_at          timestamp with time zone NOT NULL,
+    updated_at          timestamp with time zone NOT NULL
+);
+
+CREATE INDEX outbox_messages_pending_idx ON outbox_messages (next_attempt_at, message_id) WHERE status = 'pending';
+CREATE INDEX outbox_messages_dead_idx ON outbox_messages (message_id) WHERE status = 'dead';
@@ -0,0 +1,2 @@
+CREATE INDEX holds_source_account_id_idx ON holds (source_account_id);
+CREATE INDEX holds_destination_account_id_idx ON holds (destination_account_id);
@@ -0,0 +1,12 @@
+ALTER TABLE accounts ADD COLUMN status varchar(16) NOT NULL DEFAULT 'active';
+
+CREATE TABLE account_status_changes (
+    change_id       bigserial PRIMARY KEY,
+    account_id      bigint NOT NULL,
+    from_status     varchar(16) NOT NULL,
+    to_status       varchar(16) NOT NULL,
+    reason          text NOT NULL,
+    created_at      timestamp with time zone NOT NULL
+);
+
+CREATE INDEX account_status_changes_account_id_idx ON account_status_changes (account_id, change_id);
@@ -0,0 +1,3 @@
+CREATE SEQUENCE accounts_account_id_seq OWNED BY accounts.account_id;
+
+SELECT setval('accounts_account_id_seq', COALESCE((SELECT MAX(account_id) FROM accounts), 0) + 1, false);
@@ -0,0 +1,13 @@
+ALTER TABLE accounts ADD COLUMN overdraft_limit bigint NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN overdraft_enabled boolean NOT NULL DEFAULT false;
+
+CREATE TABLE account_overdraft_limit_changes (
+    change_id       bigserial PRIMARY KEY,
+    account_id      bigint NOT NULL,
+    from_limit      bigint NOT NULL,
+    to_limit        bigint NOT NULL,
+    reason          text NOT NULL,
+    created_at      timestamp with time zone NOT NULL
+);
+
+CREATE INDEX account_overdraft_limit_changes_account_id_idx ON account_overdraft_limit_changes (account_id, change_id);
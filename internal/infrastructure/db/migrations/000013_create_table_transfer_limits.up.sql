@@ -0,0 +1,22 @@
+ALTER TABLE accounts ADD COLUMN tier varchar(32) NOT NULL DEFAULT 'standard';
+
+CREATE TABLE transfer_limits (
+    limit_id        bigserial PRIMARY KEY,
+    kind            varchar(32) NOT NULL,
+    scope           varchar(16) NOT NULL,
+    account_id      bigint,
+    tier            varchar(32),
+    currency        varchar(8),
+    amount          bigint,
+    scale_amount    smallint,
+    count           integer,
+    created_at      timestamp with time zone NOT NULL,
+    updated_at      timestamp with time zone NOT NULL,
+    CHECK ((scope = 'account') = (account_id IS NOT NULL)),
+    CHECK ((scope = 'tier') = (tier IS NOT NULL)),
+    CHECK ((amount IS NULL) = (count IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX transfer_limits_target_idx ON transfer_limits (kind, scope, COALESCE(account_id, 0), COALESCE(tier, ''), COALESCE(currency, ''));
+
+CREATE INDEX transactions_source_account_id_created_at_idx ON transactions (source_account_id, created_at);
@@ -0,0 +1,11 @@
+ALTER TABLE transactions ADD COLUMN fee bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE fee_schedules (
+    tier            varchar(32) NOT NULL,
+    currency        varchar(8) NOT NULL,
+    kind            varchar(16) NOT NULL,
+    rules           jsonb NOT NULL,
+    created_at      timestamp with time zone NOT NULL,
+    updated_at      timestamp with time zone NOT NULL,
+    PRIMARY KEY (tier, currency)
+);
@@ -0,0 +1,39 @@
+CREATE TABLE transfer_schedules (
+    schedule_id                 bigserial PRIMARY KEY,
+    source_account_id           bigint NOT NULL,
+    destination_account_id      bigint NOT NULL,
+    amount                      bigint NOT NULL,
+    scale_amount                smallint NOT NULL,
+    destination_amount          bigint,
+    scale_destination_amount    smallint,
+    frequency                   varchar(16) NOT NULL,
+    day_of_month                smallint,
+    cron                        varchar(128),
+    start_at                    timestamp with time zone NOT NULL,
+    status                      varchar(16) NOT NULL,
+    run_at                      timestamp with time zone,
+    next_attempt_at             timestamp with time zone,
+    attempt                     integer NOT NULL DEFAULT 0,
+    retry_max                   integer NOT NULL DEFAULT 0,
+    retry_interval_seconds      integer NOT NULL DEFAULT 0,
+    created_at                  timestamp with time zone NOT NULL,
+    updated_at                  timestamp with time zone NOT NULL,
+    CHECK ((destination_amount IS NULL) = (scale_destination_amount IS NULL))
+);
+
+CREATE INDEX transfer_schedules_due_idx ON transfer_schedules (next_attempt_at) WHERE status = 'active';
+CREATE INDEX transfer_schedules_source_account_id_idx ON transfer_schedules (source_account_id);
+
+CREATE TABLE transfer_schedule_runs (
+    run_id              bigserial PRIMARY KEY,
+    schedule_id         bigint NOT NULL REFERENCES transfer_schedules (schedule_id),
+    run_at              timestamp with time zone NOT NULL,
+    attempt             integer NOT NULL,
+    status              varchar(16) NOT NULL,
+    transaction_id      bigint,
+    error_code          varchar(64),
+    error               text,
+    created_at          timestamp with time zone NOT NULL
+);
+
+CREATE INDEX transfer_schedule_runs_schedule_id_idx ON transfer_schedule_runs (schedule_id, run_id);
@@ -0,0 +1,46 @@
+CREATE TABLE webhook_subscriptions (
+    subscription_id     bigserial PRIMARY KEY,
+    url                 text NOT NULL,
+    event_types         text[] NOT NULL,
+    secret              varchar(128) NOT NULL,
+    status              varchar(16) NOT NULL,
+    created_at          timestamp with time zone NOT NULL,
+    updated_at          timestamp with time zone NOT NULL
+);
+
+CREATE TABLE webhook_events (
+    event_id            bigserial PRIMARY KEY,
+    type                varchar(64) NOT NULL,
+    data                jsonb NOT NULL,
+    created_at          timestamp with time zone NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    delivery_id         bigserial PRIMARY KEY,
+    subscription_id     bigint NOT NULL REFERENCES webhook_subscriptions (subscription_id),
+    event_id            bigint NOT NULL REFERENCES webhook_events (event_id),
+    status              varchar(16) NOT NULL,
+    attempts            integer NOT NULL DEFAULT 0,
+    last_status_code    integer,
+    last_error          text,
+    next_attempt_at     timestamp with time zone NOT NULL,
+    delivered_at        timestamp with time zone,
+    created_at          timestamp with time zone NOT NULL,
+    updated_at          timestamp with time zone NOT NULL
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at, delivery_id) WHERE status = 'pending';
+CREATE INDEX webhook_deliveries_subscription_id_idx ON webhook_deliveries (subscription_id, delivery_id);
+
+CREATE TABLE webhook_delivery_attempts (
+    attempt_id          bigserial PRIMARY KEY,
+    delivery_id         bigint NOT NULL REFERENCES webhook_deliveries (delivery_id),
+    attempt             integer NOT NULL,
+    status_code         integer,
+    error               text,
+    response_body       text,
+    duration_ms         integer NOT NULL,
+    created_at          timestamp with time zone NOT NULL
+);
+
+CREATE INDEX webhook_delivery_attempts_delivery_id_idx ON webhook_delivery_attempts (delivery_id, attempt_id);
@@ -0,0 +1,11 @@
+CREATE TABLE api_keys (
+    api_key_id          bigserial PRIMARY KEY,
+    name                varchar(128) NOT NULL,
+    key_prefix          varchar(16) NOT NULL,
+    key_hash            char(64) NOT NULL UNIQUE,
+    scopes              text[] NOT NULL,
+    account_ids         bigint[],
+    revoked_at          timestamp with time zone,
+    created_at          timestamp with time zone NOT NULL,
+    updated_at          timestamp with time zone NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE transactions
+    ADD COLUMN initiated_by varchar(255);
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// OutboxDB provides methods for interacting with the outbox_messages table in the database.
+type OutboxDB struct {
+	db *sqlx.DB
+}
+
+// NewOutboxDB creates and returns a new instance of OutboxDB
+func NewOutboxDB(db *sqlx.DB) *OutboxDB {
+	return &OutboxDB{db}
+}
+
+// Create inserts a new pending message that is due immediately.
+func (db *OutboxDB) Create(ctx context.Context, params outbox.MessageCreateParams) error {
+	q := `
+	INSERT INTO outbox_messages (kind, payload, status, next_attempt_at, created_at, updated_at)
+	VALUES ($1, $2, 'pending', NOW(), NOW(), NOW())`
+	_, err := conn(ctx, db.db).ExecContext(ctx, q, params.Kind, []byte(params.Payload))
+	if err != nil {
+		return fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// PendingForUpdate locks up to limit pending messages due at or before now, oldest first.
+// Messages locked by another relay are skipped so concurrent relays do not wait on each other.
+func (db *OutboxDB) PendingForUpdate(ctx context.Context, now time.Time, limit int) ([]outbox.MessageRow, error) {
+	var rows []outbox.MessageRow
+
+	q := `
+	SELECT x.message_id
+		, x.kind
+		, x.payload
+		, x.status
+		, x.attempts
+		, x.last_error
+		, x.next_attempt_at
+		, x.sent_at
+		, x.created_at
+	FROM outbox_messages AS x
+	WHERE x.status = 'pending'
+		AND x.next_attempt_at <= $1
+	ORDER BY x.message_id
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// MarkSent marks the given messages as delivered.
+func (db *OutboxDB) MarkSent(ctx context.Context, messageIds []int) error {
+	q := `
+	UPDATE outbox_messages
+	SET status = 'sent'
+		, attempts = attempts + 1
+		, last_error = NULL
+		, sent_at = NOW()
+		, updated_at = NOW()
+	WHERE message_id = ANY($1)`
+	_, err := conn(ctx, db.db).ExecContext(ctx, q, pq.Array(messageIds))
+	if err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// MarkFailed records a rejected delivery attempt of a message.
+func (db *OutboxDB) MarkFailed(ctx context.Context, params outbox.MessageFailParams) error {
+	q := `
+	UPDATE outbox_messages
+	SET status = $2
+		, attempts = $3
+		, last_error = $4
+		, next_attempt_at = $5
+		, updated_at = NOW()
+	WHERE message_id = $1`
+	_, err := conn(ctx, db.db).ExecContext(ctx, q, params.MessageId, params.Status, params.Attempts, params.LastError, params.NextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// Stats counts the pending and dead-lettered messages and finds the oldest pending one.
+func (db *OutboxDB) Stats(ctx context.Context) (outbox.StatsRow, error) {
+	var row outbox.StatsRow
+
+	q := `
+	SELECT COUNT(*) FILTER (WHERE x.status = 'pending') AS pending
+		, COUNT(*) FILTER (WHERE x.status = 'dead') AS dead
+		, MIN(x.created_at) FILTER (WHERE x.status = 'pending') AS oldest_pending_at
+	FROM outbox_messages AS x
+	WHERE x.status IN ('pending', 'dead')`
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q)
+	if err != nil {
+		return outbox.StatsRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return row, nil
+}
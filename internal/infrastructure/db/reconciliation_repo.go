@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/reconciliation"
+	"github.com/jmoiron/sqlx"
+)
+
+// ReconciliationDB provides the Postgres side of a reconciliation.
+type ReconciliationDB struct {
+	db *sqlx.DB
+}
+
+// NewReconciliationDB creates and returns a new instance of ReconciliationDB
+func NewReconciliationDB(db *sqlx.DB) *ReconciliationDB {
+	return &ReconciliationDB{db}
+}
+
+// Accounts returns up to limit accounts with an account_id greater than afterAccountId, in
+// account_id order, together with the number of TigerBeetle transfers each should take part in.
+func (db *ReconciliationDB) Accounts(ctx context.Context, afterAccountId int, limit int) ([]reconciliation.AccountRow, error) {
+	var rows []reconciliation.AccountRow
+
+	q := `
+	SELECT x.account_id
+		, x.balance
+		, x.scale_balance
+		, x.currency
+		, x.overdraft_enabled
+		, (SELECT COUNT(*) FROM postings AS p WHERE p.account_id = x.account_id)
+			+ (SELECT COUNT(*) + COUNT(*) FILTER (WHERE h.status = 'voided')
+				FROM holds AS h
+				WHERE x.account_id IN (h.source_account_id, h.destination_account_id)) AS transfer_count
+	FROM accounts AS x
+	WHERE x.account_id > $1
+	ORDER BY x.account_id
+	LIMIT $2`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, afterAccountId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/schedule"
+	"github.com/jmoiron/sqlx"
+)
+
+// scheduleColumns lists the columns selected into schedule.ScheduleRow.
+const scheduleColumns = `x.schedule_id
+		, x.source_account_id
+		, x.destination_account_id
+		, x.amount
+		, x.scale_amount
+		, x.destination_amount
+		, x.scale_destination_amount
+		, x.frequency
+		, x.day_of_month
+		, x.cron
+		, x.start_at
+		, x.status
+		, x.run_at
+		, x.next_attempt_at
+		, x.attempt
+		, x.retry_max
+		, x.retry_interval_seconds
+		, x.created_at
+		, x.updated_at`
+
+// scheduleRunColumns lists the columns selected into schedule.RunRow.
+const scheduleRunColumns = `x.run_id
+		, x.schedule_id
+		, x.run_at
+		, x.attempt
+		, x.status
+		, x.transaction_id
+		, x.error_code
+		, x.error
+		, x.created_at`
+
+// ScheduleDB provides methods for interacting with the transfer_schedules and
+// transfer_schedule_runs tables in the database.
+type ScheduleDB struct {
+	db *sqlx.DB
+}
+
+// NewScheduleDB creates and returns a new instance of ScheduleDB
+func NewScheduleDB(db *sqlx.DB) *ScheduleDB {
+	return &ScheduleDB{db}
+}
+
+// Create inserts a new transfer schedule, due at params.NextAttemptAt, and returns the stored row
+// including its generated schedule_id.
+func (db *ScheduleDB) Create(ctx context.Context, params schedule.ScheduleCreateParams) (schedule.ScheduleRow, error) {
+	var row schedule.ScheduleRow
+
+	q := `
+	INSERT INTO transfer_schedules AS x (source_account_id, destination_account_id, amount, scale_amount
+		, destination_amount, scale_destination_amount, frequency, day_of_month, cron, start_at, status
+		, run_at, next_attempt_at, retry_max, retry_interval_seconds, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12, $13, $14, NOW(), NOW())
+	RETURNING ` + scheduleColumns
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q, params.SourceAccountId, params.DestinationAccountId,
+		params.Amount, params.AmountScale, params.DestinationAmount, params.DestinationAmountScale, params.Frequency,
+		params.DayOfMonth, params.Cron, params.StartAt, params.Status, params.NextAttemptAt, params.RetryMax,
+		params.RetryIntervalSeconds)
+	if err != nil {
+		return schedule.ScheduleRow{}, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return row, nil
+}
+
+// ById retrieves a transfer schedule from the database by its schedule ID.
+func (db *ScheduleDB) ById(ctx context.Context, scheduleId int) (schedule.ScheduleRow, error) {
+	return db.byId(ctx, scheduleId, "")
+}
+
+// ByIdForUpdate retrieves a transfer schedule by its schedule ID and locks it with
+// SELECT ... FOR UPDATE until the surrounding transaction ends.
+func (db *ScheduleDB) ByIdForUpdate(ctx context.Context, scheduleId int) (schedule.ScheduleRow, error) {
+	return db.byId(ctx, scheduleId, "FOR UPDATE")
+}
+
+func (db *ScheduleDB) byId(ctx context.Context, scheduleId int, lock string) (schedule.ScheduleRow, error) {
+	var rows []schedule.ScheduleRow
+
+	q := `
+	SELECT ` + scheduleColumns + `
+	FROM transfer_schedules AS x
+	WHERE x.schedule_id = $1
+	` + lock
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, scheduleId)
+	if err != nil {
+		return schedule.ScheduleRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return schedule.ScheduleRow{}, fmt.Errorf("schedule not found [schedule_id: %d]", scheduleId)
+	}
+
+	return rows[0], nil
+}
+
+// List returns the transfer schedules matching params ordered by schedule_id. Zero values of
+// params match every schedule.
+func (db *ScheduleDB) List(ctx context.Context, params schedule.ScheduleListParams) ([]schedule.ScheduleRow, error) {
+	var rows []schedule.ScheduleRow
+
+	q := `
+	SELECT ` + scheduleColumns + `
+	FROM transfer_schedules AS x
+	WHERE ($1 = 0 OR x.source_account_id = $1 OR x.destination_account_id = $1)
+		AND ($2 = '' OR x.status = $2)
+	ORDER BY x.schedule_id`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, params.AccountId, params.Status)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// DueForUpdate locks up to limit active schedules whose next attempt is due at or before now.
+// Schedules locked by another caller are skipped so concurrent schedulers do not wait on each
+// other nor run the same schedule twice.
+func (db *ScheduleDB) DueForUpdate(ctx context.Context, now time.Time, limit int) ([]schedule.ScheduleRow, error) {
+	var rows []schedule.ScheduleRow
+
+	q := `
+	SELECT ` + scheduleColumns + `
+	FROM transfer_schedules AS x
+	WHERE x.status = 'active'
+		AND x.next_attempt_at <= $1
+	ORDER BY x.next_attempt_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// Update stores the status and next attempt of a transfer schedule and returns the updated row.
+func (db *ScheduleDB) Update(ctx context.Context, params schedule.ScheduleUpdateParams) (schedule.ScheduleRow, error) {
+	var row schedule.ScheduleRow
+
+	q := `
+	UPDATE transfer_schedules AS x
+	SET status = $2
+		, run_at = $3
+		, next_attempt_at = $4
+		, attempt = $5
+		, updated_at = NOW()
+	WHERE x.schedule_id = $1
+	RETURNING ` + scheduleColumns
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q, params.ScheduleId, params.Status, params.RunAt,
+		params.NextAttemptAt, params.Attempt)
+	if err != nil {
+		return schedule.ScheduleRow{}, fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	return row, nil
+}
+
+// CreateRun records a run of a transfer schedule and returns the stored row including its
+// generated run_id.
+func (db *ScheduleDB) CreateRun(ctx context.Context, params schedule.RunCreateParams) (schedule.RunRow, error) {
+	var row schedule.RunRow
+
+	q := `
+	INSERT INTO transfer_schedule_runs AS x (schedule_id, run_at, attempt, status, transaction_id, error_code, error
+		, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+	RETURNING ` + scheduleRunColumns
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q, params.ScheduleId, params.RunAt, params.Attempt,
+		params.Status, params.TransactionId, params.ErrorCode, params.Error)
+	if err != nil {
+		return schedule.RunRow{}, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return row, nil
+}
+
+// Runs returns the runs of a transfer schedule ordered by run_id.
+func (db *ScheduleDB) Runs(ctx context.Context, scheduleId int) ([]schedule.RunRow, error) {
+	var rows []schedule.RunRow
+
+	q := `
+	SELECT ` + scheduleRunColumns + `
+	FROM transfer_schedule_runs AS x
+	WHERE x.schedule_id = $1
+	ORDER BY x.run_id`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, scheduleId)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
@@ -8,6 +8,26 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// transactionColumns lists the columns selected into transaction.TransactionRow.
+const transactionColumns = `x.transaction_id
+		, x.source_account_id
+		, x.destination_account_id
+		, x.amount
+		, x.scale_amount
+		, x.currency
+		, x.fee
+		, x.destination_amount
+		, x.scale_destination_amount
+		, x.destination_currency
+		, x.kind
+		, x.status
+		, x.reversal_of
+		, x.reversed_amount
+		, x.reversed_destination_amount
+		, x.initiated_by
+		, x.created_at
+		, x.updated_at`
+
 // TransactionDB provides methods for interacting with the transactions table in the database.
 type TransactionDB struct {
 	db *sqlx.DB
@@ -18,13 +38,102 @@ func NewTransactionDB(db *sqlx.DB) *TransactionDB {
 	return &TransactionDB{db}
 }
 
-// Create inserts a new transaction record into the transactions table with the provided parameters.
-func (db *TransactionDB) Create(ctx context.Context, params transaction.TransactionCreateParams) error {
+// Create inserts a new transaction record into the transactions table with the provided parameters
+// and returns the stored row including its generated transaction_id and timestamps.
+func (db *TransactionDB) Create(ctx context.Context, params transaction.TransactionCreateParams) (transaction.TransactionRow, error) {
+	var row transaction.TransactionRow
+
+	q := `
+	INSERT INTO transactions AS x (source_account_id, destination_account_id, amount, scale_amount, currency, fee
+		, destination_amount, scale_destination_amount, destination_currency, kind, status, reversal_of, initiated_by, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, 'completed', $11, $12, NOW(), NOW())
+	RETURNING ` + transactionColumns
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q, params.SourceAccountId, params.DestinationAccountId, params.Amount, params.AmountScale, params.Currency, params.Fee,
+		params.DestinationAmount, params.DestinationAmountScale, params.DestinationCurrency, params.Kind, params.ReversalOf, params.InitiatedBy)
+	if err != nil {
+		return transaction.TransactionRow{}, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return row, nil
+}
+
+// ById retrieves a transaction record from the database by its transaction ID.
+func (db *TransactionDB) ById(ctx context.Context, transactionId int) (transaction.TransactionRow, error) {
+	var rows []transaction.TransactionRow
+
+	q := `
+	SELECT ` + transactionColumns + `
+	FROM transactions AS x
+	WHERE x.transaction_id = $1`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, transactionId)
+	if err != nil {
+		return transaction.TransactionRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return transaction.TransactionRow{}, fmt.Errorf("%w [transaction_id: %d]", transaction.ErrTransactionNotFound, transactionId)
+	}
+
+	return rows[0], nil
+}
+
+// ByIdForUpdate retrieves a transaction record by its transaction ID and locks it with
+// SELECT ... FOR UPDATE until the surrounding transaction ends.
+func (db *TransactionDB) ByIdForUpdate(ctx context.Context, transactionId int) (transaction.TransactionRow, error) {
+	var rows []transaction.TransactionRow
+
+	q := `
+	SELECT ` + transactionColumns + `
+	FROM transactions AS x
+	WHERE x.transaction_id = $1
+	FOR UPDATE`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, transactionId)
+	if err != nil {
+		return transaction.TransactionRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return transaction.TransactionRow{}, fmt.Errorf("%w [transaction_id: %d]", transaction.ErrTransactionNotFound, transactionId)
+	}
+
+	return rows[0], nil
+}
+
+// ByAccount retrieves the transactions an account sent or received, newest first,
+// filtered by direction, creation time range and keyset cursor.
+func (db *TransactionDB) ByAccount(ctx context.Context, params transaction.TransactionListParams) ([]transaction.TransactionRow, error) {
+	var rows []transaction.TransactionRow
+
+	q := `
+	SELECT ` + transactionColumns + `
+	FROM transactions AS x
+	WHERE ((x.source_account_id = $1 AND $2 <> 'incoming')
+			OR (x.destination_account_id = $1 AND $2 <> 'outgoing'))
+		AND ($3::timestamptz IS NULL OR x.created_at >= $3)
+		AND ($4::timestamptz IS NULL OR x.created_at < $4)
+		AND ($5 = 0 OR x.transaction_id < $5)
+	ORDER BY x.transaction_id DESC
+	LIMIT $6`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, params.AccountId, params.Direction, params.From, params.To, params.BeforeId, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// UpdateReversal stores the totals reversed from a transaction and its resulting status.
+func (db *TransactionDB) UpdateReversal(ctx context.Context, params transaction.TransactionUpdateReversalParams) error {
 	q := `
-	INSERT INTO transactions (source_account_id, destination_account_id, amount, scale_amount, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, NOW(), NOW())`
-	if _, err := db.db.ExecContext(ctx, q, params.SourceAccountId, params.DestinationAccountId, params.Amount, params.AmountScale); err != nil {
-		return fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	UPDATE transactions
+	SET reversed_amount = $2
+		, reversed_destination_amount = $3
+		, status = $4
+		, updated_at = NOW()
+	WHERE transaction_id = $1`
+	_, err := conn(ctx, db.db).ExecContext(ctx, q, params.TransactionId, params.ReversedAmount, params.ReversedDestinationAmount, params.Status)
+	if err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
 	}
 
 	return nil
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// txKey is the context key under which the active *sqlx.Tx is stored.
+type txKey struct{}
+
+// UnitOfWorkDB runs a group of repository calls inside a single database transaction.
+type UnitOfWorkDB struct {
+	db *sqlx.DB
+}
+
+// NewUnitOfWorkDB creates and returns a new instance of UnitOfWorkDB
+func NewUnitOfWorkDB(db *sqlx.DB) *UnitOfWorkDB {
+	return &UnitOfWorkDB{db}
+}
+
+// Do begins a transaction, binds it to the context passed to fn and commits it when fn
+// returns nil. Any error or panic from fn rolls the transaction back. When ctx already
+// carries a transaction, fn joins it instead of starting a new one.
+func (u *UnitOfWorkDB) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := u.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sql begin: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w [rollback: %s]", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sql commit: %w", err)
+	}
+
+	return nil
+}
+
+// conn returns the transaction bound to ctx, falling back to db when there is none.
+func conn(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
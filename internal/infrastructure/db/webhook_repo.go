@@ -0,0 +1,337 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/webhook"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// subscriptionColumns lists the columns selected into subscriptionRow.
+const subscriptionColumns = `x.subscription_id
+		, x.url
+		, x.event_types
+		, x.secret
+		, x.status
+		, x.created_at
+		, x.updated_at`
+
+// deliveryColumns lists the columns selected into webhook.DeliveryRow; e is the webhook_events
+// row of the delivery.
+const deliveryColumns = `x.delivery_id
+		, x.subscription_id
+		, x.event_id
+		, e.type AS event_type
+		, x.status
+		, x.attempts
+		, x.last_status_code
+		, x.last_error
+		, x.next_attempt_at
+		, x.delivered_at
+		, x.created_at
+		, x.updated_at`
+
+// attemptColumns lists the columns selected into webhook.AttemptRow.
+const attemptColumns = `x.attempt_id
+		, x.delivery_id
+		, x.attempt
+		, x.status_code
+		, x.error
+		, x.response_body
+		, x.duration_ms
+		, x.created_at`
+
+// subscriptionRow scans a webhook_subscriptions row, whose event_types is a Postgres array.
+type subscriptionRow struct {
+	SubscriptionId int            `db:"subscription_id"`
+	URL            string         `db:"url"`
+	EventTypes     pq.StringArray `db:"event_types"`
+	Secret         string         `db:"secret"`
+	Status         string         `db:"status"`
+	CreatedAt      time.Time      `db:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"`
+}
+
+func (row subscriptionRow) toRow() webhook.SubscriptionRow {
+	return webhook.SubscriptionRow{
+		SubscriptionId: row.SubscriptionId,
+		URL:            row.URL,
+		EventTypes:     []string(row.EventTypes),
+		Secret:         row.Secret,
+		Status:         row.Status,
+		CreatedAt:      row.CreatedAt,
+		UpdatedAt:      row.UpdatedAt,
+	}
+}
+
+// WebhookDB provides methods for interacting with the webhook_subscriptions, webhook_events,
+// webhook_deliveries and webhook_delivery_attempts tables in the database.
+type WebhookDB struct {
+	db *sqlx.DB
+}
+
+// NewWebhookDB creates and returns a new instance of WebhookDB
+func NewWebhookDB(db *sqlx.DB) *WebhookDB {
+	return &WebhookDB{db}
+}
+
+// CreateSubscription inserts a new active webhook subscription and returns the stored row
+// including its generated subscription_id.
+func (db *WebhookDB) CreateSubscription(ctx context.Context, params webhook.SubscriptionCreateParams) (webhook.SubscriptionRow, error) {
+	var row subscriptionRow
+
+	q := `
+	INSERT INTO webhook_subscriptions AS x (url, event_types, secret, status, created_at, updated_at)
+	VALUES ($1, $2, $3, 'active', NOW(), NOW())
+	RETURNING ` + subscriptionColumns
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &row, q, params.URL, pq.Array(params.EventTypes), params.Secret)
+	if err != nil {
+		return webhook.SubscriptionRow{}, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return row.toRow(), nil
+}
+
+// SubscriptionById retrieves a webhook subscription from the database by its subscription ID.
+func (db *WebhookDB) SubscriptionById(ctx context.Context, subscriptionId int) (webhook.SubscriptionRow, error) {
+	var rows []subscriptionRow
+
+	q := `
+	SELECT ` + subscriptionColumns + `
+	FROM webhook_subscriptions AS x
+	WHERE x.subscription_id = $1`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, subscriptionId)
+	if err != nil {
+		return webhook.SubscriptionRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return webhook.SubscriptionRow{}, fmt.Errorf("webhook subscription not found [subscription_id: %d]", subscriptionId)
+	}
+
+	return rows[0].toRow(), nil
+}
+
+// Subscriptions returns every webhook subscription ordered by subscription_id.
+func (db *WebhookDB) Subscriptions(ctx context.Context) ([]webhook.SubscriptionRow, error) {
+	var rows []subscriptionRow
+
+	q := `
+	SELECT ` + subscriptionColumns + `
+	FROM webhook_subscriptions AS x
+	ORDER BY x.subscription_id`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	data := make([]webhook.SubscriptionRow, len(rows))
+	for i, row := range rows {
+		data[i] = row.toRow()
+	}
+
+	return data, nil
+}
+
+// DisableSubscription marks a webhook subscription disabled. It reports false when the
+// subscription does not exist.
+func (db *WebhookDB) DisableSubscription(ctx context.Context, subscriptionId int) (bool, error) {
+	q := `
+	UPDATE webhook_subscriptions
+	SET status = 'disabled'
+		, updated_at = NOW()
+	WHERE subscription_id = $1`
+	res, err := conn(ctx, db.db).ExecContext(ctx, q, subscriptionId)
+	if err != nil {
+		return false, fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql rows affected: %w [query: %s]", err, q)
+	}
+
+	return n == 1, nil
+}
+
+// CreateEvent inserts an event and, in the same statement, a pending delivery of it for every
+// active subscription to its type. It returns the generated event_id.
+func (db *WebhookDB) CreateEvent(ctx context.Context, params webhook.EventCreateParams) (int, error) {
+	var eventId int
+
+	q := `
+	WITH event AS (
+		INSERT INTO webhook_events (type, data, created_at)
+		VALUES ($1, $2, NOW())
+		RETURNING event_id
+	), deliveries AS (
+		INSERT INTO webhook_deliveries (subscription_id, event_id, status, attempts, next_attempt_at, created_at
+			, updated_at)
+		SELECT s.subscription_id, event.event_id, 'pending', 0, NOW(), NOW(), NOW()
+		FROM webhook_subscriptions AS s, event
+		WHERE s.status = 'active'
+			AND $1 = ANY(s.event_types)
+	)
+	SELECT event_id FROM event`
+	err := sqlx.GetContext(ctx, conn(ctx, db.db), &eventId, q, params.Type, []byte(params.Data))
+	if err != nil {
+		return 0, fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return eventId, nil
+}
+
+// Deliveries returns the deliveries of a subscription matching params, newest first.
+func (db *WebhookDB) Deliveries(ctx context.Context, params webhook.DeliveryListParams) ([]webhook.DeliveryRow, error) {
+	var rows []webhook.DeliveryRow
+
+	q := `
+	SELECT ` + deliveryColumns + `
+	FROM webhook_deliveries AS x
+	JOIN webhook_events AS e ON e.event_id = x.event_id
+	WHERE x.subscription_id = $1
+		AND ($2 = '' OR x.status = $2)
+	ORDER BY x.delivery_id DESC
+	LIMIT $3`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, params.SubscriptionId, params.Status, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// DeliveryById retrieves a webhook delivery from the database by its delivery ID.
+func (db *WebhookDB) DeliveryById(ctx context.Context, deliveryId int) (webhook.DeliveryRow, error) {
+	var rows []webhook.DeliveryRow
+
+	q := `
+	SELECT ` + deliveryColumns + `
+	FROM webhook_deliveries AS x
+	JOIN webhook_events AS e ON e.event_id = x.event_id
+	WHERE x.delivery_id = $1`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, deliveryId)
+	if err != nil {
+		return webhook.DeliveryRow{}, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	if len(rows) == 0 {
+		return webhook.DeliveryRow{}, fmt.Errorf("webhook delivery not found [delivery_id: %d]", deliveryId)
+	}
+
+	return rows[0], nil
+}
+
+// DueDeliveriesForUpdate locks up to limit pending deliveries of active subscriptions whose next
+// attempt is due at or before now, oldest first. Deliveries locked by another caller are skipped
+// so concurrent dispatchers do not wait on each other nor send the same delivery twice.
+func (db *WebhookDB) DueDeliveriesForUpdate(ctx context.Context, now time.Time, limit int) ([]webhook.DueDeliveryRow, error) {
+	var rows []webhook.DueDeliveryRow
+
+	q := `
+	SELECT x.delivery_id
+		, x.subscription_id
+		, x.attempts
+		, s.url
+		, s.secret
+		, e.event_id
+		, e.type AS event_type
+		, e.data AS event_data
+		, e.created_at AS event_created_at
+	FROM webhook_deliveries AS x
+	JOIN webhook_subscriptions AS s ON s.subscription_id = x.subscription_id
+	JOIN webhook_events AS e ON e.event_id = x.event_id
+	WHERE x.status = 'pending'
+		AND x.next_attempt_at <= $1
+		AND s.status = 'active'
+	ORDER BY x.next_attempt_at, x.delivery_id
+	LIMIT $2
+	FOR UPDATE OF x SKIP LOCKED`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt. delivered_at is set when the delivery
+// succeeded.
+func (db *WebhookDB) UpdateDelivery(ctx context.Context, params webhook.DeliveryUpdateParams) error {
+	q := `
+	UPDATE webhook_deliveries
+	SET status = $2
+		, attempts = $3
+		, last_status_code = $4
+		, last_error = $5
+		, next_attempt_at = $6
+		, delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() END
+		, updated_at = NOW()
+	WHERE delivery_id = $1`
+	_, err := conn(ctx, db.db).ExecContext(ctx, q, params.DeliveryId, params.Status, params.Attempts,
+		params.LastStatusCode, params.LastError, params.NextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// ReplayDelivery makes a delivery pending again, due at now, with its attempts reset. It reports
+// false when the delivery does not exist.
+func (db *WebhookDB) ReplayDelivery(ctx context.Context, deliveryId int, now time.Time) (bool, error) {
+	q := `
+	UPDATE webhook_deliveries
+	SET status = 'pending'
+		, attempts = 0
+		, next_attempt_at = $2
+		, delivered_at = NULL
+		, updated_at = NOW()
+	WHERE delivery_id = $1`
+	res, err := conn(ctx, db.db).ExecContext(ctx, q, deliveryId, now)
+	if err != nil {
+		return false, fmt.Errorf("sql update: %w [query: %s]", err, q)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sql rows affected: %w [query: %s]", err, q)
+	}
+
+	return n == 1, nil
+}
+
+// CreateAttempt appends an attempt to the log of a delivery.
+func (db *WebhookDB) CreateAttempt(ctx context.Context, params webhook.AttemptCreateParams) error {
+	q := `
+	INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, response_body, duration_ms
+		, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, NOW())`
+	_, err := conn(ctx, db.db).ExecContext(ctx, q, params.DeliveryId, params.Attempt, params.StatusCode,
+		params.Error, params.ResponseBody, params.DurationMs)
+	if err != nil {
+		return fmt.Errorf("sql insert: %w [query: %s]", err, q)
+	}
+
+	return nil
+}
+
+// Attempts returns the attempt log of a delivery ordered by attempt_id.
+func (db *WebhookDB) Attempts(ctx context.Context, deliveryId int) ([]webhook.AttemptRow, error) {
+	var rows []webhook.AttemptRow
+
+	q := `
+	SELECT ` + attemptColumns + `
+	FROM webhook_delivery_attempts AS x
+	WHERE x.delivery_id = $1
+	ORDER BY x.attempt_id`
+	err := sqlx.SelectContext(ctx, conn(ctx, db.db), &rows, q, deliveryId)
+	if err != nil {
+		return nil, fmt.Errorf("sql select: %w [query: %s]", err, q)
+	}
+
+	return rows, nil
+}
@@ -8,29 +8,61 @@ import (
 	"strconv"
 
 	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
 )
 
 // AccountHandler is interface that ServiceHandler use to integrate with AccountService
 type AccountHandler interface {
-	Create(ctx context.Context, data account.AccountCreate) error
+	Create(ctx context.Context, data account.AccountCreate) (account.Account, error)
 	ById(ctx context.Context, accountId int) (account.Account, error)
+	ByNumber(ctx context.Context, accountNumber string) (account.Account, error)
+	VerifyBalance(ctx context.Context, accountId int) (account.BalanceVerification, error)
+	ChangeStatus(ctx context.Context, accountId int, data account.AccountStatusChange) (account.Account, error)
+	ChangeOverdraftLimit(ctx context.Context, accountId int, data account.AccountOverdraftLimit) (account.Account, error)
+	OverdraftLimitHistory(ctx context.Context, accountId int) ([]account.OverdraftLimitChange, error)
 }
 
 func (h *ServiceHandler) accountCreate(w http.ResponseWriter, r *http.Request) {
 	var body account.AccountCreate
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
 		writeJSON(w, http.StatusBadRequest, appResponse{
 			Error: "bad request",
 		})
 		return
 	}
 
-	err := h.Account.Create(r.Context(), body)
+	data, err := h.Account.Create(r.Context(), body)
 	if err != nil {
 		if errors.Is(err, account.ErrAccountInitialBalanceNegative) {
 			writeJSON(w, http.StatusBadRequest, appResponse{
 				Error: "account initial balance is negative",
 			})
+		} else if errors.Is(err, account.ErrAccountOverdraftLimitNegative) {
+			writeJSON(w, http.StatusBadRequest, appResponse{
+				Error: "account overdraft limit is negative",
+			})
+		} else if errors.Is(err, account.ErrAccountIdNotAllowed) {
+			writeJSON(w, http.StatusBadRequest, appResponse{
+				Error: "account_id is allocated by the server",
+			})
+		} else if errors.Is(err, account.ErrAccountIdConflict) {
+			writeJSON(w, http.StatusConflict, appResponse{
+				Error: "account_id already exists",
+			})
+		} else if errors.Is(err, account.ErrAccountCurrencyInvalid) {
+			writeJSON(w, http.StatusBadRequest, appResponse{
+				Error: "account currency is not supported",
+			})
+		} else if errors.Is(err, account.ErrAccountTierInvalid) {
+			writeJSON(w, http.StatusBadRequest, appResponse{
+				Error: "account tier must be 1 to 32 lowercase letters, digits, dashes or underscores",
+			})
+		} else if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
 		} else {
 			writeJSON(w, http.StatusBadRequest, appResponse{
 				Error: "bad request",
@@ -39,7 +71,7 @@ func (h *ServiceHandler) accountCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, appResponse{Message: "account created"})
+	writeJSON(w, http.StatusOK, appResponse{Message: "account created", Data: data})
 }
 
 func (h *ServiceHandler) accountById(w http.ResponseWriter, r *http.Request) {
@@ -62,3 +94,210 @@ func (h *ServiceHandler) accountById(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, appResponse{Data: data})
 }
+
+func (h *ServiceHandler) accountByNumber(w http.ResponseWriter, r *http.Request) {
+	data, err := h.Account.ByNumber(r.Context(), r.PathValue("account_number"))
+	if err != nil {
+		if errors.Is(err, account.ErrAccountNumberInvalid) {
+			writeJSON(w, http.StatusBadRequest, appResponse{
+				Error: "invalid account_number",
+			})
+		} else {
+			writeJSON(w, http.StatusNotFound, appResponse{
+				Error: "account not found",
+			})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) accountVerifyBalance(w http.ResponseWriter, r *http.Request) {
+	accountIdStr := r.PathValue("account_id")
+	accountId, err := strconv.Atoi(accountIdStr)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{
+			Error: "invalid account_id",
+		})
+		return
+	}
+
+	data, err := h.Account.VerifyBalance(r.Context(), accountId)
+	if err != nil {
+		if errors.Is(err, account.ErrAccountByIdFailed) {
+			writeJSON(w, http.StatusNotFound, appResponse{
+				Error: "account not found",
+			})
+		} else {
+			writeJSON(w, http.StatusInternalServerError, appResponse{
+				Error: "internal server error",
+			})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) accountChangeStatus(w http.ResponseWriter, r *http.Request) {
+	accountId, err := strconv.Atoi(r.PathValue("account_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid account_id"})
+		return
+	}
+
+	var body account.AccountStatusChange
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Account.ChangeStatus(r.Context(), accountId, body)
+	if err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) {
+			writeJSON(w, http.StatusNotFound, appResponse{Error: "account not found"})
+
+		} else if errors.Is(err, account.ErrAccountForbidden) {
+			writeJSON(w, http.StatusForbidden, appResponse{Error: "account is not one of the accounts of the caller"})
+
+		} else if errors.Is(err, account.ErrAccountStatusInvalid) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "account status must be active, frozen, debit_blocked or credit_blocked; use POST /accounts/{account_id}/close to close it"})
+
+		} else if errors.Is(err, account.ErrAccountStatusReasonRequired) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "account status change reason is required"})
+
+		} else if errors.Is(err, account.ErrAccountClosed) {
+			writeJSON(w, http.StatusGone, appResponse{Error: "account is closed"})
+
+		} else {
+			writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "account status changed", Data: data})
+}
+
+func (h *ServiceHandler) accountChangeOverdraftLimit(w http.ResponseWriter, r *http.Request) {
+	accountId, err := strconv.Atoi(r.PathValue("account_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid account_id"})
+		return
+	}
+
+	var body account.AccountOverdraftLimit
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Account.ChangeOverdraftLimit(r.Context(), accountId, body)
+	if err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) {
+			writeJSON(w, http.StatusNotFound, appResponse{Error: "account not found"})
+
+		} else if errors.Is(err, account.ErrAccountForbidden) {
+			writeJSON(w, http.StatusForbidden, appResponse{Error: "account is not one of the accounts of the caller"})
+
+		} else if errors.Is(err, account.ErrAccountOverdraftLimitNegative) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "account overdraft limit is negative"})
+
+		} else if errors.Is(err, account.ErrAccountOverdraftLimitReasonRequired) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "account overdraft limit change reason is required"})
+
+		} else if errors.Is(err, account.ErrAccountOverdraftNotEnabled) {
+			writeJSON(w, http.StatusConflict, appResponse{Error: "account was not opened with an overdraft limit"})
+
+		} else if errors.Is(err, account.ErrAccountClosed) {
+			writeJSON(w, http.StatusGone, appResponse{Error: "account is closed"})
+
+		} else if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+
+		} else {
+			writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "account overdraft limit changed", Data: data})
+}
+
+func (h *ServiceHandler) accountOverdraftLimitHistory(w http.ResponseWriter, r *http.Request) {
+	accountId, err := strconv.Atoi(r.PathValue("account_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid account_id"})
+		return
+	}
+
+	data, err := h.Account.OverdraftLimitHistory(r.Context(), accountId)
+	if err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) {
+			writeJSON(w, http.StatusNotFound, appResponse{Error: "account not found"})
+		} else {
+			writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) accountClose(w http.ResponseWriter, r *http.Request) {
+	accountId, err := strconv.Atoi(r.PathValue("account_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid account_id"})
+		return
+	}
+
+	var body transaction.AccountClose
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Transaction.CloseAccount(r.Context(), accountId, body)
+	if err != nil {
+		if errors.Is(err, transaction.ErrTransactionAccountNotFound) {
+			writeJSON(w, http.StatusNotFound, appResponse{Error: "account not found"})
+
+		} else if errors.Is(err, transaction.ErrTransactionAccountForbidden) {
+			writeJSON(w, http.StatusForbidden, appResponse{Error: "account is not one of the accounts of the caller"})
+
+		} else if errors.Is(err, account.ErrAccountStatusReasonRequired) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "account close reason is required"})
+
+		} else if errors.Is(err, account.ErrAccountClosed) {
+			writeJSON(w, http.StatusGone, appResponse{Error: "account is already closed"})
+
+		} else if errors.Is(err, transaction.ErrTransactionAccountHasHolds) {
+			writeJSON(w, http.StatusConflict, appResponse{Error: "account has pending holds"})
+
+		} else if errors.Is(err, transaction.ErrTransactionAccountBalanceNotZero) {
+			writeJSON(w, http.StatusConflict, appResponse{Error: "account balance is not zero, sweep_account_id is required and an overdrawn account must be paid back first"})
+
+		} else if errors.Is(err, transaction.ErrTransactionSweepAccountNotFound) {
+			writeJSON(w, http.StatusNotFound, appResponse{Error: "sweep account not found"})
+
+		} else if errors.Is(err, transaction.ErrTransactionSweepAccountSameAsClose) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "sweep account can not be the closed account"})
+
+		} else if errors.Is(err, transaction.ErrTransactionCurrencyMismatch) {
+			writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "sweep account currency differs"})
+
+		} else if statusCode, msg := accountStatusError(err); statusCode != 0 {
+			writeJSON(w, statusCode, appResponse{Error: msg})
+
+		} else {
+			writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "account closed", Data: data})
+}
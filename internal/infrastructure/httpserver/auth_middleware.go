@@ -0,0 +1,87 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/apikey"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/jwtauth"
+)
+
+const (
+	apiKeyHeader        = "X-API-Key"
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+)
+
+var errCredentialsMissing = errors.New("credentials missing")
+
+// AuthHandler authenticates the callers of the API by the credential they present, an API key
+// or a bearer token.
+type AuthHandler interface {
+	Authenticate(ctx context.Context, credential string) (auth.Principal, error)
+}
+
+// authorize serves next to callers presenting an API key or a bearer token granted scope,
+// carrying the caller in the request context for the services to check the accounts it may
+// act on and record who made a transfer. Every request is served when h.Auth and h.Bearer
+// are nil.
+func (h *ServiceHandler) authorize(scope string, next http.HandlerFunc) http.HandlerFunc {
+	if h.Auth == nil && h.Bearer == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := h.authenticate(r)
+		if err != nil {
+			if errors.Is(err, errCredentialsMissing) {
+				writeJSON(w, http.StatusUnauthorized, appResponse{Error: h.credentialsRequired()})
+
+			} else if errors.Is(err, apikey.ErrApiKeyInvalid) {
+				writeJSON(w, http.StatusUnauthorized, appResponse{Error: "api key invalid"})
+
+			} else if errors.Is(err, jwtauth.ErrTokenInvalid) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeJSON(w, http.StatusUnauthorized, appResponse{Error: "bearer token invalid"})
+
+			} else {
+				writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+			}
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			writeJSON(w, http.StatusForbidden, appResponse{Error: "caller lacks the " + scope + " scope"})
+			return
+		}
+
+		next(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	}
+}
+
+// authenticate returns the caller of r, authenticated by the bearer token of its Authorization
+// header when h.Bearer is set, or else by its X-API-Key header when h.Auth is set.
+func (h *ServiceHandler) authenticate(r *http.Request) (auth.Principal, error) {
+	if token, ok := strings.CutPrefix(r.Header.Get(authorizationHeader), bearerPrefix); ok && h.Bearer != nil {
+		return h.Bearer.Authenticate(r.Context(), strings.TrimSpace(token))
+	}
+	if key := r.Header.Get(apiKeyHeader); key != "" && h.Auth != nil {
+		return h.Auth.Authenticate(r.Context(), key)
+	}
+	return auth.Principal{}, errCredentialsMissing
+}
+
+// credentialsRequired returns the message telling callers without credentials which ones are
+// accepted.
+func (h *ServiceHandler) credentialsRequired() string {
+	if h.Auth == nil {
+		return authorizationHeader + " header with a bearer token is required"
+	}
+	if h.Bearer == nil {
+		return apiKeyHeader + " header is required"
+	}
+	return apiKeyHeader + " or " + authorizationHeader + " header is required"
+}
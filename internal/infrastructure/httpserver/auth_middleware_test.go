@@ -0,0 +1,213 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/apikey"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+)
+
+func TestServiceHandler_AccountControls(t *testing.T) {
+	principals := map[string]auth.Principal{
+		"client": {Subject: "api_key:1", Scopes: []string{auth.ScopeAccountsRead, auth.ScopeAccountsWrite, auth.ScopeTransactionsWrite}, AccountIds: []int{1}},
+		"admin":  {Subject: "api_key:2", Scopes: []string{auth.ScopeAdmin}},
+	}
+	h := &ServiceHandler{
+		Account: &fakeAccountHandler{
+			ChangeStatusFunc: func(ctx context.Context, accountId int, data account.AccountStatusChange) (account.Account, error) {
+				return account.Account{AccountId: accountId}, nil
+			},
+			ChangeOverdraftLimitFunc: func(ctx context.Context, accountId int, data account.AccountOverdraftLimit) (account.Account, error) {
+				return account.Account{AccountId: accountId}, nil
+			},
+		},
+		Transaction: &fakeTransactionHandler{
+			CloseAccountFunc: func(ctx context.Context, accountId int, data transaction.AccountClose) (transaction.AccountClosure, error) {
+				return transaction.AccountClosure{}, nil
+			},
+		},
+		Auth: &fakeAuthHandler{
+			AuthenticateFunc: func(ctx context.Context, key string) (auth.Principal, error) {
+				p, ok := principals[key]
+				if !ok {
+					return auth.Principal{}, apikey.ErrApiKeyInvalid
+				}
+				return p, nil
+			},
+		},
+	}
+	mux := NewMux(":0", h).Handler
+
+	tests := []struct {
+		name       string
+		key        string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "error - client unfreezes its account", key: "client", path: "/accounts/1/status", body: `{"status":"active","reason":"unfreeze"}`, wantStatus: http.StatusForbidden},
+		{name: "error - client closes its account", key: "client", path: "/accounts/1/close", body: `{"reason":"close"}`, wantStatus: http.StatusForbidden},
+		{name: "error - client raises its overdraft limit", key: "client", path: "/accounts/1/overdraft-limit", body: `{"overdraft_limit":"1000000","reason":"raise"}`, wantStatus: http.StatusForbidden},
+		{name: "error - unknown key", key: "unknown", path: "/accounts/1/status", body: `{"status":"active","reason":"unfreeze"}`, wantStatus: http.StatusUnauthorized},
+		{name: "success - admin changes status", key: "admin", path: "/accounts/1/status", body: `{"status":"active","reason":"unfreeze"}`, wantStatus: http.StatusOK},
+		{name: "success - admin closes account", key: "admin", path: "/accounts/1/close", body: `{"reason":"close"}`, wantStatus: http.StatusOK},
+		{name: "success - admin changes overdraft limit", key: "admin", path: "/accounts/1/overdraft-limit", body: `{"overdraft_limit":"100","reason":"raise"}`, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(apiKeyHeader, tt.key)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("POST %s status = %d, want %d, body %s", tt.path, rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServiceHandler_DebugVars(t *testing.T) {
+	h := &ServiceHandler{
+		Auth: &fakeAuthHandler{
+			AuthenticateFunc: func(ctx context.Context, key string) (auth.Principal, error) {
+				if key == "admin" {
+					return auth.Principal{Subject: "api_key:2", Scopes: []string{auth.ScopeAdmin}}, nil
+				}
+				return auth.Principal{Subject: "api_key:1", Scopes: []string{auth.ScopeAccountsRead, auth.ScopeTransactionsRead}}, nil
+			},
+		},
+	}
+	mux := NewMux(":0", h).Handler
+
+	tests := []struct {
+		name       string
+		key        string
+		wantStatus int
+	}{
+		{name: "error - no key", wantStatus: http.StatusUnauthorized},
+		{name: "error - client key", key: "client", wantStatus: http.StatusForbidden},
+		{name: "success - admin key", key: "admin", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+			if tt.key != "" {
+				req.Header.Set(apiKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET /debug/vars status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+type fakeAuthHandler struct {
+	AuthenticateFunc func(ctx context.Context, credential string) (auth.Principal, error)
+}
+
+func (f *fakeAuthHandler) Authenticate(ctx context.Context, credential string) (auth.Principal, error) {
+	return f.AuthenticateFunc(ctx, credential)
+}
+
+type fakeAccountHandler struct {
+	CreateFunc                func(ctx context.Context, data account.AccountCreate) (account.Account, error)
+	ByIdFunc                  func(ctx context.Context, accountId int) (account.Account, error)
+	ByNumberFunc              func(ctx context.Context, accountNumber string) (account.Account, error)
+	VerifyBalanceFunc         func(ctx context.Context, accountId int) (account.BalanceVerification, error)
+	ChangeStatusFunc          func(ctx context.Context, accountId int, data account.AccountStatusChange) (account.Account, error)
+	ChangeOverdraftLimitFunc  func(ctx context.Context, accountId int, data account.AccountOverdraftLimit) (account.Account, error)
+	OverdraftLimitHistoryFunc func(ctx context.Context, accountId int) ([]account.OverdraftLimitChange, error)
+}
+
+func (f *fakeAccountHandler) Create(ctx context.Context, data account.AccountCreate) (account.Account, error) {
+	return f.CreateFunc(ctx, data)
+}
+
+func (f *fakeAccountHandler) ById(ctx context.Context, accountId int) (account.Account, error) {
+	return f.ByIdFunc(ctx, accountId)
+}
+
+func (f *fakeAccountHandler) ByNumber(ctx context.Context, accountNumber string) (account.Account, error) {
+	return f.ByNumberFunc(ctx, accountNumber)
+}
+
+func (f *fakeAccountHandler) VerifyBalance(ctx context.Context, accountId int) (account.BalanceVerification, error) {
+	return f.VerifyBalanceFunc(ctx, accountId)
+}
+
+func (f *fakeAccountHandler) ChangeStatus(ctx context.Context, accountId int, data account.AccountStatusChange) (account.Account, error) {
+	return f.ChangeStatusFunc(ctx, accountId, data)
+}
+
+func (f *fakeAccountHandler) ChangeOverdraftLimit(ctx context.Context, accountId int, data account.AccountOverdraftLimit) (account.Account, error) {
+	return f.ChangeOverdraftLimitFunc(ctx, accountId, data)
+}
+
+func (f *fakeAccountHandler) OverdraftLimitHistory(ctx context.Context, accountId int) ([]account.OverdraftLimitChange, error) {
+	return f.OverdraftLimitHistoryFunc(ctx, accountId)
+}
+
+type fakeTransactionHandler struct {
+	CreateFunc       func(ctx context.Context, data transaction.TransactionCreate) (transaction.Transaction, error)
+	BatchFunc        func(ctx context.Context, data transaction.TransactionBatch) (transaction.BatchResult, error)
+	ReverseFunc      func(ctx context.Context, transactionId int, data transaction.TransactionReverse) (transaction.Transaction, error)
+	ByIdFunc         func(ctx context.Context, transactionId int) (transaction.Transaction, error)
+	ByAccountFunc    func(ctx context.Context, filter transaction.TransactionFilter) (transaction.TransactionPage, error)
+	AuthorizeFunc    func(ctx context.Context, data transaction.HoldCreate) (transaction.Hold, error)
+	CaptureFunc      func(ctx context.Context, holdId int, data transaction.HoldCapture) (transaction.Hold, error)
+	VoidFunc         func(ctx context.Context, holdId int) (transaction.Hold, error)
+	HoldByIdFunc     func(ctx context.Context, holdId int) (transaction.Hold, error)
+	CloseAccountFunc func(ctx context.Context, accountId int, data transaction.AccountClose) (transaction.AccountClosure, error)
+}
+
+func (f *fakeTransactionHandler) Create(ctx context.Context, data transaction.TransactionCreate) (transaction.Transaction, error) {
+	return f.CreateFunc(ctx, data)
+}
+
+func (f *fakeTransactionHandler) Batch(ctx context.Context, data transaction.TransactionBatch) (transaction.BatchResult, error) {
+	return f.BatchFunc(ctx, data)
+}
+
+func (f *fakeTransactionHandler) Reverse(ctx context.Context, transactionId int, data transaction.TransactionReverse) (transaction.Transaction, error) {
+	return f.ReverseFunc(ctx, transactionId, data)
+}
+
+func (f *fakeTransactionHandler) ById(ctx context.Context, transactionId int) (transaction.Transaction, error) {
+	return f.ByIdFunc(ctx, transactionId)
+}
+
+func (f *fakeTransactionHandler) ByAccount(ctx context.Context, filter transaction.TransactionFilter) (transaction.TransactionPage, error) {
+	return f.ByAccountFunc(ctx, filter)
+}
+
+func (f *fakeTransactionHandler) Authorize(ctx context.Context, data transaction.HoldCreate) (transaction.Hold, error) {
+	return f.AuthorizeFunc(ctx, data)
+}
+
+func (f *fakeTransactionHandler) Capture(ctx context.Context, holdId int, data transaction.HoldCapture) (transaction.Hold, error) {
+	return f.CaptureFunc(ctx, holdId, data)
+}
+
+func (f *fakeTransactionHandler) Void(ctx context.Context, holdId int) (transaction.Hold, error) {
+	return f.VoidFunc(ctx, holdId)
+}
+
+func (f *fakeTransactionHandler) HoldById(ctx context.Context, holdId int) (transaction.Hold, error) {
+	return f.HoldByIdFunc(ctx, holdId)
+}
+
+func (f *fakeTransactionHandler) CloseAccount(ctx context.Context, accountId int, data transaction.AccountClose) (transaction.AccountClosure, error) {
+	return f.CloseAccountFunc(ctx, accountId, data)
+}
@@ -0,0 +1,78 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/fee"
+)
+
+// FeeHandler is interface that ServiceHandler use to integrate with FeeService
+type FeeHandler interface {
+	Set(ctx context.Context, tier, currency string, data fee.ScheduleSet) (fee.Schedule, error)
+	List(ctx context.Context) ([]fee.Schedule, error)
+	Delete(ctx context.Context, tier, currency string) error
+}
+
+func (h *ServiceHandler) feeScheduleSet(w http.ResponseWriter, r *http.Request) {
+	var body fee.ScheduleSet
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Fee.Set(r.Context(), r.PathValue("tier"), r.PathValue("currency"), body)
+	if err != nil {
+		writeFeeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "fee schedule set", Data: data})
+}
+
+func (h *ServiceHandler) feeScheduleList(w http.ResponseWriter, r *http.Request) {
+	data, err := h.Fee.List(r.Context())
+	if err != nil {
+		writeFeeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) feeScheduleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := h.Fee.Delete(r.Context(), r.PathValue("tier"), r.PathValue("currency")); err != nil {
+		writeFeeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "fee schedule deleted"})
+}
+
+// writeFeeError maps the errors returned by FeeService to responses.
+func writeFeeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, fee.ErrFeeScheduleNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "fee schedule not found"})
+
+	} else if errors.Is(err, fee.ErrFeeKindInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "fee kind must be flat, percentage or tiered"})
+
+	} else if errors.Is(err, fee.ErrFeeTierInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "fee tier is invalid"})
+
+	} else if errors.Is(err, fee.ErrFeeCurrencyInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "fee currency is invalid"})
+
+	} else if errors.Is(err, fee.ErrFeeScheduleInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "fee schedule is invalid, flat needs flat, percentage needs rate_bps between 1 and 10000 with min not above max, and tiered needs bands by ascending up_to with only the last one unbounded"})
+
+	} else {
+		writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+	}
+}
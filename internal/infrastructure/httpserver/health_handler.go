@@ -0,0 +1,48 @@
+package httpserver
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout bounds the time /readyz waits for its checks.
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck is a dependency the server needs to serve requests, such as Postgres. Check
+// returns an error when the dependency is not available.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// healthz reports that the server is alive. It checks no dependency, so a failing database does
+// not get the server restarted.
+func (h *ServiceHandler) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, appResponse{Message: "ok"})
+}
+
+// readyz reports whether the server is ready to serve requests, with the result of each check.
+func (h *ServiceHandler) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	ready := true
+	checks := make(map[string]string, len(h.Readiness))
+	for _, c := range h.Readiness {
+		if err := c.Check(ctx); err != nil {
+			slog.WarnContext(ctx, "readiness check failed", "check", c.Name, "error", err)
+			checks[c.Name] = "unavailable"
+			ready = false
+			continue
+		}
+		checks[c.Name] = "ok"
+	}
+
+	if !ready {
+		writeJSON(w, http.StatusServiceUnavailable, appResponse{Error: "not ready", Data: checks})
+		return
+	}
+	writeJSON(w, http.StatusOK, appResponse{Message: "ready", Data: checks})
+}
@@ -0,0 +1,141 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+)
+
+func (h *ServiceHandler) holdCreate(w http.ResponseWriter, r *http.Request) {
+	var body transaction.HoldCreate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Transaction.Authorize(r.Context(), body)
+	if err != nil {
+		writeHoldError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "hold created", Data: data})
+}
+
+func (h *ServiceHandler) holdCapture(w http.ResponseWriter, r *http.Request) {
+	holdId, err := strconv.Atoi(r.PathValue("hold_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid hold_id"})
+		return
+	}
+
+	var body transaction.HoldCapture
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Transaction.Capture(r.Context(), holdId, body)
+	if err != nil {
+		writeHoldError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "hold captured", Data: data})
+}
+
+func (h *ServiceHandler) holdVoid(w http.ResponseWriter, r *http.Request) {
+	holdId, err := strconv.Atoi(r.PathValue("hold_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid hold_id"})
+		return
+	}
+
+	data, err := h.Transaction.Void(r.Context(), holdId)
+	if err != nil {
+		writeHoldError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "hold voided", Data: data})
+}
+
+func (h *ServiceHandler) holdById(w http.ResponseWriter, r *http.Request) {
+	holdId, err := strconv.Atoi(r.PathValue("hold_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid hold_id"})
+		return
+	}
+
+	data, err := h.Transaction.HoldById(r.Context(), holdId)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "hold not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+// writeHoldError maps the errors returned by the hold operations of TransactionService to responses.
+func writeHoldError(w http.ResponseWriter, err error) {
+	if errors.Is(err, transaction.ErrTransactionHoldNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "hold not found"})
+
+	} else if errors.Is(err, transaction.ErrTransactionAccountForbidden) {
+		writeJSON(w, http.StatusForbidden, appResponse{Error: "hold source account is not one of the accounts of the caller"})
+
+	} else if errors.Is(err, transaction.ErrTransactionHoldNotPending) {
+		writeJSON(w, http.StatusConflict, appResponse{Error: "hold is not pending"})
+
+	} else if errors.Is(err, transaction.ErrTransactionHoldExpired) {
+		writeJSON(w, http.StatusConflict, appResponse{Error: "hold expired"})
+
+	} else if errors.Is(err, transaction.ErrTransactionHoldAmountInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "hold amount is invalid"})
+
+	} else if errors.Is(err, transaction.ErrTransactionHoldTimeoutInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "hold timeout_seconds is invalid"})
+
+	} else if errors.Is(err, transaction.ErrTransactionSourceAccountNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "transaction source account not found"})
+
+	} else if errors.Is(err, transaction.ErrTransactionDestinationAccountNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "transaction destination account not found"})
+
+	} else if errors.Is(err, transaction.ErrTransactionSourceBalanceNotEnough) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction source balance not enough"})
+
+	} else if errors.Is(err, transaction.ErrTransactionSourceDestinationSame) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction source and destination account can not be the same"})
+
+	} else if errors.Is(err, transaction.ErrTransactionSourceBalanceNegative) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction amount is negative"})
+
+	} else if errors.Is(err, transaction.ErrTransactionCurrencyMismatch) {
+		writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "holds can not convert between currencies"})
+
+	} else if violation, ok := limitViolation(err); ok {
+		writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "hold exceeds the " + violation.Kind + " transfer limit", Data: violation})
+
+	} else if statusCode, msg := accountStatusError(err); statusCode != 0 {
+		writeJSON(w, statusCode, appResponse{Error: msg})
+
+	} else if msg := moneyErrorMessage(err); msg != "" {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+
+	} else {
+		writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+	}
+}
@@ -6,31 +6,87 @@ package httpserver
 
 import (
 	"encoding/json"
+	"errors"
+	"expvar"
 	"net/http"
 	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/metrics"
 )
 
 // NewMux creates and configures a new HTTP server with predefined routes
 func NewMux(addr string, h *ServiceHandler) *http.Server {
 	r := http.NewServeMux()
 
-	r.HandleFunc("POST /accounts", h.accountCreate)
-	r.HandleFunc("GET /accounts/{account_id}", h.accountById)
-	r.HandleFunc("POST /transactions", h.transactionCreate)
+	r.HandleFunc("POST /accounts", h.authorize(auth.ScopeAccountsWrite, h.idempotent(h.accountCreate)))
+	r.HandleFunc("GET /accounts/{account_id}", h.authorize(auth.ScopeAccountsRead, h.accountById))
+	r.HandleFunc("GET /account-numbers/{account_number}", h.authorize(auth.ScopeAccountsRead, h.accountByNumber))
+	r.HandleFunc("GET /accounts/{account_id}/balance/verify", h.authorize(auth.ScopeAccountsRead, h.accountVerifyBalance))
+	r.HandleFunc("POST /accounts/{account_id}/status", h.authorize(auth.ScopeAdmin, h.idempotent(h.accountChangeStatus)))
+	r.HandleFunc("POST /accounts/{account_id}/close", h.authorize(auth.ScopeAdmin, h.idempotent(h.accountClose)))
+	r.HandleFunc("POST /accounts/{account_id}/overdraft-limit", h.authorize(auth.ScopeAdmin, h.idempotent(h.accountChangeOverdraftLimit)))
+	r.HandleFunc("GET /accounts/{account_id}/overdraft-limit/history", h.authorize(auth.ScopeAccountsRead, h.accountOverdraftLimitHistory))
+	r.HandleFunc("GET /accounts/{account_id}/transactions", h.authorize(auth.ScopeTransactionsRead, h.transactionByAccount))
+	r.HandleFunc("POST /transactions", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.transactionCreate)))
+	r.HandleFunc("POST /transactions/batch", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.transactionBatch)))
+	r.HandleFunc("GET /transactions/{transaction_id}", h.authorize(auth.ScopeTransactionsRead, h.transactionById))
+	r.HandleFunc("POST /transactions/{transaction_id}/reverse", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.transactionReverse)))
+	r.HandleFunc("POST /holds", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.holdCreate)))
+	r.HandleFunc("GET /holds/{hold_id}", h.authorize(auth.ScopeTransactionsRead, h.holdById))
+	r.HandleFunc("POST /holds/{hold_id}/capture", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.holdCapture)))
+	r.HandleFunc("POST /holds/{hold_id}/void", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.holdVoid)))
+	r.HandleFunc("POST /limits", h.authorize(auth.ScopeAdmin, h.idempotent(h.limitCreate)))
+	r.HandleFunc("GET /limits", h.authorize(auth.ScopeAdmin, h.limitList))
+	r.HandleFunc("GET /limits/{limit_id}", h.authorize(auth.ScopeAdmin, h.limitById))
+	r.HandleFunc("PUT /limits/{limit_id}", h.authorize(auth.ScopeAdmin, h.limitUpdate))
+	r.HandleFunc("DELETE /limits/{limit_id}", h.authorize(auth.ScopeAdmin, h.limitDelete))
+	r.HandleFunc("PUT /fee-schedules/{tier}/{currency}", h.authorize(auth.ScopeAdmin, h.feeScheduleSet))
+	r.HandleFunc("GET /fee-schedules", h.authorize(auth.ScopeAdmin, h.feeScheduleList))
+	r.HandleFunc("DELETE /fee-schedules/{tier}/{currency}", h.authorize(auth.ScopeAdmin, h.feeScheduleDelete))
+	r.HandleFunc("POST /schedules", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.scheduleCreate)))
+	r.HandleFunc("GET /schedules", h.authorize(auth.ScopeTransactionsRead, h.scheduleList))
+	r.HandleFunc("GET /schedules/{schedule_id}", h.authorize(auth.ScopeTransactionsRead, h.scheduleById))
+	r.HandleFunc("GET /schedules/{schedule_id}/runs", h.authorize(auth.ScopeTransactionsRead, h.scheduleRuns))
+	r.HandleFunc("POST /schedules/{schedule_id}/pause", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.schedulePause)))
+	r.HandleFunc("POST /schedules/{schedule_id}/resume", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.scheduleResume)))
+	r.HandleFunc("POST /schedules/{schedule_id}/cancel", h.authorize(auth.ScopeTransactionsWrite, h.idempotent(h.scheduleCancel)))
+	r.HandleFunc("POST /webhooks", h.authorize(auth.ScopeAdmin, h.idempotent(h.webhookCreate)))
+	r.HandleFunc("GET /webhooks", h.authorize(auth.ScopeAdmin, h.webhookList))
+	r.HandleFunc("GET /webhooks/{subscription_id}", h.authorize(auth.ScopeAdmin, h.webhookById))
+	r.HandleFunc("DELETE /webhooks/{subscription_id}", h.authorize(auth.ScopeAdmin, h.webhookDisable))
+	r.HandleFunc("GET /webhooks/{subscription_id}/deliveries", h.authorize(auth.ScopeAdmin, h.webhookDeliveries))
+	r.HandleFunc("GET /webhook-deliveries/{delivery_id}", h.authorize(auth.ScopeAdmin, h.webhookDeliveryById))
+	r.HandleFunc("POST /webhook-deliveries/{delivery_id}/replay", h.authorize(auth.ScopeAdmin, h.idempotent(h.webhookDeliveryReplay)))
+	r.HandleFunc("GET /debug/vars", h.authorize(auth.ScopeAdmin, expvar.Handler().ServeHTTP))
+	r.Handle("GET /metrics", metrics.Handler())
+	r.HandleFunc("GET /healthz", h.healthz)
+	r.HandleFunc("GET /readyz", h.readyz)
 
 	return &http.Server{
 		Addr:              addr,
-		Handler:           r,
+		Handler:           withRequestLog(r),
 		ReadHeaderTimeout: 1 * time.Second,
 	}
 }
 
 // ServiceHandler aggregates handlers for account and transaction services,
 // providing a unified interface for handling HTTP requests related to accounts
-// and transactions within the system.
+// and transactions within the system. Idempotency is optional; when nil the
+// Idempotency-Key header is ignored. Auth authenticates API keys and Bearer JWTs; when
+// both are nil requests are not authenticated. Readiness lists the checks of /readyz.
 type ServiceHandler struct {
 	Account     AccountHandler
 	Transaction TransactionHandler
+	Limit       LimitHandler
+	Fee         FeeHandler
+	Schedule    ScheduleHandler
+	Webhook     WebhookHandler
+	Idempotency IdempotencyHandler
+	Auth        AuthHandler
+	Bearer      AuthHandler
+	Readiness   []ReadinessCheck
 }
 
 // appResponse represents a standard HTTP JSON response structure.
@@ -48,3 +104,16 @@ func writeJSON(w http.ResponseWriter, statusCode int, resp appResponse) {
 		http.Error(w, `{"error":"failed to encode response"}`, http.StatusInternalServerError)
 	}
 }
+
+// moneyErrorMessage returns the client facing message for money parsing errors,
+// or an empty string when err is not one.
+func moneyErrorMessage(err error) string {
+	if errors.Is(err, money.ErrMoneyTooPrecise) {
+		return "amount has too many decimal places"
+	} else if errors.Is(err, money.ErrMoneyOverflow) {
+		return "amount is too large"
+	} else if errors.Is(err, money.ErrMoneyParseFail) {
+		return "invalid amount"
+	}
+	return ""
+}
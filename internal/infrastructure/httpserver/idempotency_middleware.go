@@ -0,0 +1,118 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/idempotency"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyMaxLength   = 255
+	idempotencyBodyMaxBytes   = 1 << 20
+)
+
+// IdempotencyHandler is interface that ServiceHandler use to integrate with IdempotencyService
+type IdempotencyHandler interface {
+	Begin(ctx context.Context, key, scope, fingerprint string) (*idempotency.Response, error)
+	Complete(ctx context.Context, key, scope string, resp idempotency.Response) error
+	Release(ctx context.Context, key, scope string) error
+}
+
+// idempotent wraps next so requests carrying an Idempotency-Key header are executed at
+// most once per key. Replays of a completed key get the stored response, replays with a
+// different body get 422 and replays of a key still being processed get 409.
+// Responses with a 5xx status are not stored so the client can retry them.
+func (h *ServiceHandler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || h.Idempotency == nil {
+			next(w, r)
+			return
+		}
+
+		if len(key) > idempotencyKeyMaxLength {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "idempotency key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, idempotencyBodyMaxBytes))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are per caller so one caller cannot replay the stored response of another.
+		scope := r.Pattern
+		if principal, ok := auth.PrincipalFrom(r.Context()); ok {
+			scope = principal.Subject + " " + scope
+		}
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		stored, err := h.Idempotency.Begin(r.Context(), key, scope, fingerprint)
+		if err != nil {
+			if errors.Is(err, idempotency.ErrIdempotencyKeyMismatch) {
+				writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "idempotency key was used with a different request"})
+			} else if errors.Is(err, idempotency.ErrIdempotencyKeyInProgress) {
+				writeJSON(w, http.StatusConflict, appResponse{Error: "idempotency key request is still in progress"})
+			} else {
+				writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+			}
+			return
+		}
+
+		if stored != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(idempotencyReplayedHeader, "true")
+			w.WriteHeader(stored.StatusCode)
+			_, _ = w.Write(stored.Body)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next(rec, r)
+
+		// The request context may already be cancelled, the outcome must still be recorded.
+		ctx := context.WithoutCancel(r.Context())
+		if rec.statusCode >= http.StatusInternalServerError {
+			err = h.Idempotency.Release(ctx, key, scope)
+		} else {
+			err = h.Idempotency.Complete(ctx, key, scope, idempotency.Response{
+				StatusCode: rec.statusCode,
+				Body:       rec.body.Bytes(),
+			})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "idempotency: failed to record outcome", "key", key, "error", err)
+		}
+	}
+}
+
+// responseRecorder captures the status code and body written through it while still
+// forwarding them to the wrapped http.ResponseWriter.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+	rec.statusCode = statusCode
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
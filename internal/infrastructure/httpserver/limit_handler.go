@@ -0,0 +1,152 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/limit"
+)
+
+// LimitHandler is interface that ServiceHandler use to integrate with LimitService
+type LimitHandler interface {
+	Create(ctx context.Context, data limit.LimitCreate) (limit.Limit, error)
+	ById(ctx context.Context, limitId int) (limit.Limit, error)
+	List(ctx context.Context, filter limit.LimitFilter) ([]limit.Limit, error)
+	Update(ctx context.Context, limitId int, data limit.LimitUpdate) (limit.Limit, error)
+	Delete(ctx context.Context, limitId int) error
+}
+
+func (h *ServiceHandler) limitCreate(w http.ResponseWriter, r *http.Request) {
+	var body limit.LimitCreate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Limit.Create(r.Context(), body)
+	if err != nil {
+		writeLimitError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "limit created", Data: data})
+}
+
+func (h *ServiceHandler) limitList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := limit.LimitFilter{
+		Scope: query.Get("scope"),
+		Tier:  query.Get("tier"),
+	}
+
+	if v := query.Get("account_id"); v != "" {
+		var err error
+		if filter.AccountId, err = strconv.Atoi(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid account_id"})
+			return
+		}
+	}
+
+	data, err := h.Limit.List(r.Context(), filter)
+	if err != nil {
+		writeLimitError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) limitById(w http.ResponseWriter, r *http.Request) {
+	limitId, err := strconv.Atoi(r.PathValue("limit_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid limit_id"})
+		return
+	}
+
+	data, err := h.Limit.ById(r.Context(), limitId)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "limit not found"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) limitUpdate(w http.ResponseWriter, r *http.Request) {
+	limitId, err := strconv.Atoi(r.PathValue("limit_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid limit_id"})
+		return
+	}
+
+	var body limit.LimitUpdate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Limit.Update(r.Context(), limitId, body)
+	if err != nil {
+		writeLimitError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "limit updated", Data: data})
+}
+
+func (h *ServiceHandler) limitDelete(w http.ResponseWriter, r *http.Request) {
+	limitId, err := strconv.Atoi(r.PathValue("limit_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid limit_id"})
+		return
+	}
+
+	if err := h.Limit.Delete(r.Context(), limitId); err != nil {
+		writeLimitError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "limit deleted"})
+}
+
+// writeLimitError maps the errors returned by LimitService to responses.
+func writeLimitError(w http.ResponseWriter, err error) {
+	if errors.Is(err, limit.ErrLimitNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "limit not found"})
+
+	} else if errors.Is(err, limit.ErrLimitAccountNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "limit account not found"})
+
+	} else if errors.Is(err, limit.ErrLimitKindInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "limit kind must be single_amount, daily_amount, monthly_amount or daily_count"})
+
+	} else if errors.Is(err, limit.ErrLimitScopeInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "limit scope must be account with account_id, tier with tier, or global"})
+
+	} else if errors.Is(err, limit.ErrLimitCurrencyInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "limit currency is invalid, amount limits need the currency of the account and count limits none"})
+
+	} else if errors.Is(err, limit.ErrLimitValueInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "limit value is invalid, amount limits need a positive amount and count limits a positive count"})
+
+	} else if errors.Is(err, limit.ErrLimitAlreadyConfigured) {
+		writeJSON(w, http.StatusConflict, appResponse{Error: "limit already configured for this kind, scope and currency"})
+
+	} else if msg := moneyErrorMessage(err); msg != "" {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+
+	} else {
+		writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+	}
+}
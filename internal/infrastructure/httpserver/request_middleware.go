@@ -0,0 +1,87 @@
+package httpserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/logging"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/metrics"
+)
+
+const (
+	requestIdHeader    = "X-Request-ID"
+	requestIdMaxLength = 128
+)
+
+// withRequestLog gives every request a request id, taken from the X-Request-ID header or
+// generated, which is echoed in the response and carried by the request context so the records
+// logged while serving the request include it. Every request is served in a trace span, and is
+// logged and counted in the HTTP metrics once it is served.
+func withRequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestId := r.Header.Get(requestIdHeader)
+		if !isValidRequestId(requestId) {
+			requestId = newRequestId()
+		}
+		w.Header().Set(requestIdHeader, requestId)
+		ctx, span := startServerSpan(r)
+		ctx = logging.WithRequestId(ctx, requestId)
+		r = r.WithContext(ctx)
+
+		rec := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		duration := time.Since(start)
+		endServerSpan(span, r, rec.statusCode)
+		metrics.ObserveHTTP(r.Method, r.Pattern, rec.statusCode, duration)
+
+		level := slog.LevelInfo
+		if rec.statusCode >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "http request",
+			"method", r.Method,
+			"route", r.Pattern,
+			"path", r.URL.Path,
+			"status", rec.statusCode,
+			"duration_ms", duration.Milliseconds(),
+		)
+	})
+}
+
+// isValidRequestId reports whether a client-supplied request id is short printable ASCII, so it
+// can be logged and echoed safely.
+func isValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > requestIdMaxLength {
+		return false
+	}
+	for _, c := range requestId {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestId returns a random request id.
+func newRequestId() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// statusRecorder captures the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (rec *statusRecorder) WriteHeader(statusCode int) {
+	rec.statusCode = statusCode
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
@@ -0,0 +1,159 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/schedule"
+)
+
+// ScheduleHandler is interface that ServiceHandler use to integrate with ScheduleService
+type ScheduleHandler interface {
+	Create(ctx context.Context, data schedule.ScheduleCreate) (schedule.Schedule, error)
+	ById(ctx context.Context, scheduleId int) (schedule.Schedule, error)
+	List(ctx context.Context, filter schedule.ScheduleFilter) ([]schedule.Schedule, error)
+	Runs(ctx context.Context, scheduleId int) ([]schedule.Run, error)
+	Pause(ctx context.Context, scheduleId int) (schedule.Schedule, error)
+	Resume(ctx context.Context, scheduleId int) (schedule.Schedule, error)
+	Cancel(ctx context.Context, scheduleId int) (schedule.Schedule, error)
+}
+
+func (h *ServiceHandler) scheduleCreate(w http.ResponseWriter, r *http.Request) {
+	var body schedule.ScheduleCreate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Schedule.Create(r.Context(), body)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "schedule created", Data: data})
+}
+
+func (h *ServiceHandler) scheduleList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := schedule.ScheduleFilter{Status: query.Get("status")}
+
+	if v := query.Get("account_id"); v != "" {
+		var err error
+		if filter.AccountId, err = strconv.Atoi(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid account_id"})
+			return
+		}
+	}
+
+	data, err := h.Schedule.List(r.Context(), filter)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) scheduleById(w http.ResponseWriter, r *http.Request) {
+	scheduleId, err := strconv.Atoi(r.PathValue("schedule_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid schedule_id"})
+		return
+	}
+
+	data, err := h.Schedule.ById(r.Context(), scheduleId)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) scheduleRuns(w http.ResponseWriter, r *http.Request) {
+	scheduleId, err := strconv.Atoi(r.PathValue("schedule_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid schedule_id"})
+		return
+	}
+
+	data, err := h.Schedule.Runs(r.Context(), scheduleId)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) schedulePause(w http.ResponseWriter, r *http.Request) {
+	h.scheduleTransition(w, r, h.Schedule.Pause, "schedule paused")
+}
+
+func (h *ServiceHandler) scheduleResume(w http.ResponseWriter, r *http.Request) {
+	h.scheduleTransition(w, r, h.Schedule.Resume, "schedule resumed")
+}
+
+func (h *ServiceHandler) scheduleCancel(w http.ResponseWriter, r *http.Request) {
+	h.scheduleTransition(w, r, h.Schedule.Cancel, "schedule cancelled")
+}
+
+// scheduleTransition applies transition to the schedule of the request path and responds with
+// message and the updated schedule.
+func (h *ServiceHandler) scheduleTransition(w http.ResponseWriter, r *http.Request, transition func(ctx context.Context, scheduleId int) (schedule.Schedule, error), message string) {
+	scheduleId, err := strconv.Atoi(r.PathValue("schedule_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid schedule_id"})
+		return
+	}
+
+	data, err := transition(r.Context(), scheduleId)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: message, Data: data})
+}
+
+// writeScheduleError maps the errors returned by ScheduleService to responses.
+func writeScheduleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, schedule.ErrScheduleNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "schedule not found"})
+
+	} else if errors.Is(err, schedule.ErrScheduleAccountNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "schedule account not found"})
+
+	} else if errors.Is(err, schedule.ErrScheduleAccountForbidden) {
+		writeJSON(w, http.StatusForbidden, appResponse{Error: "schedule source account is not one of the accounts of the caller"})
+
+	} else if errors.Is(err, schedule.ErrScheduleInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "schedule is invalid, check frequency, day_of_month, cron, retry_max and retry_interval_seconds"})
+
+	} else if errors.Is(err, schedule.ErrScheduleAmountInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "schedule amount must be positive"})
+
+	} else if errors.Is(err, schedule.ErrScheduleSourceDestinationSame) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "source and destination account can not be the same"})
+
+	} else if errors.Is(err, schedule.ErrScheduleFilterInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "status must be active, paused, cancelled or completed"})
+
+	} else if errors.Is(err, schedule.ErrScheduleStatusInvalid) {
+		writeJSON(w, http.StatusConflict, appResponse{Error: "schedule status does not allow this change"})
+
+	} else if msg := moneyErrorMessage(err); msg != "" {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+
+	} else {
+		writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+	}
+}
@@ -0,0 +1,38 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/gustialfian/transfer-system-golang/internal/infrastructure/httpserver")
+
+// startServerSpan starts the server span of r, continuing the trace of the caller when the
+// request carries a W3C traceparent header.
+func startServerSpan(r *http.Request) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		attribute.String("http.request.method", r.Method),
+		attribute.String("url.path", r.URL.Path),
+	))
+}
+
+// endServerSpan ends span once r is served with statusCode. The span is named after the route
+// r matched and fails on 5xx responses.
+func endServerSpan(span trace.Span, r *http.Request, statusCode int) {
+	if r.Pattern != "" {
+		span.SetName(r.Pattern)
+		span.SetAttributes(attribute.String("http.route", r.Pattern))
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", statusCode))
+	if statusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(statusCode))
+	}
+	span.End()
+}
@@ -4,28 +4,49 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/gustialfian/transfer-system-golang/internal/domains/limit"
 	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
 )
 
 // TransactionHandler is interface that ServiceHandler use to integrate with TransactionService
 type TransactionHandler interface {
-	Create(ctx context.Context, data transaction.TransactionCreate) error
+	Create(ctx context.Context, data transaction.TransactionCreate) (transaction.Transaction, error)
+	Batch(ctx context.Context, data transaction.TransactionBatch) (transaction.BatchResult, error)
+	Reverse(ctx context.Context, transactionId int, data transaction.TransactionReverse) (transaction.Transaction, error)
+	ById(ctx context.Context, transactionId int) (transaction.Transaction, error)
+	ByAccount(ctx context.Context, filter transaction.TransactionFilter) (transaction.TransactionPage, error)
+	Authorize(ctx context.Context, data transaction.HoldCreate) (transaction.Hold, error)
+	Capture(ctx context.Context, holdId int, data transaction.HoldCapture) (transaction.Hold, error)
+	Void(ctx context.Context, holdId int) (transaction.Hold, error)
+	HoldById(ctx context.Context, holdId int) (transaction.Hold, error)
+	CloseAccount(ctx context.Context, accountId int, data transaction.AccountClose) (transaction.AccountClosure, error)
 }
 
 func (h *ServiceHandler) transactionCreate(w http.ResponseWriter, r *http.Request) {
 	var body transaction.TransactionCreate
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
 		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
 		return
 	}
 
-	err := h.Transaction.Create(r.Context(), body)
+	data, err := h.Transaction.Create(r.Context(), body)
 	if err != nil {
 		if errors.Is(err, transaction.ErrTransactionSourceAccountNotFound) {
 			writeJSON(w, http.StatusNotFound, appResponse{Error: "transaction source account not found"})
 
+		} else if errors.Is(err, transaction.ErrTransactionAccountForbidden) {
+			writeJSON(w, http.StatusForbidden, appResponse{Error: "transaction source account is not one of the accounts of the caller"})
+
 		} else if errors.Is(err, transaction.ErrTransactionDestinationAccountNotFound) {
 			writeJSON(w, http.StatusNotFound, appResponse{Error: "transaction destination account not found"})
 
@@ -35,11 +56,229 @@ func (h *ServiceHandler) transactionCreate(w http.ResponseWriter, r *http.Reques
 		} else if errors.Is(err, transaction.ErrTransactionSourceDestinationSame) {
 			writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction source and destination account can not be the same"})
 
+		} else if errors.Is(err, transaction.ErrTransactionSourceBalanceNegative) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction amount is negative"})
+
+		} else if errors.Is(err, transaction.ErrTransactionCurrencyMismatch) {
+			writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "transaction source and destination currency differ, destination_amount is required"})
+
+		} else if errors.Is(err, transaction.ErrTransactionConversionInvalid) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction destination_amount is invalid"})
+
+		} else if errors.Is(err, transaction.ErrTransactionAccountNumberInvalid) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction account number is invalid"})
+
+		} else if violation, ok := limitViolation(err); ok {
+			writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "transaction exceeds the " + violation.Kind + " transfer limit", Data: violation})
+
+		} else if statusCode, msg := accountStatusError(err); statusCode != 0 {
+			writeJSON(w, statusCode, appResponse{Error: msg})
+
+		} else if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+
 		} else {
 			writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
 		}
 		return
 	}
 
-	writeJSON(w, http.StatusOK, appResponse{Message: "transaction created"})
+	writeJSON(w, http.StatusOK, appResponse{Message: "transaction created", Data: data})
+}
+
+func (h *ServiceHandler) transactionBatch(w http.ResponseWriter, r *http.Request) {
+	var body transaction.TransactionBatch
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Transaction.Batch(r.Context(), body)
+	if err != nil {
+		if errors.Is(err, transaction.ErrTransactionBatchInvalid) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: fmt.Sprintf("transaction batch needs mode atomic or independent and 1 to %d transfers", transaction.MaxBatchTransfers)})
+
+		} else if errors.Is(err, transaction.ErrTransactionBatchAborted) {
+			writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "transaction batch aborted, no transfer was created", Data: data})
+
+		} else {
+			writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "transaction batch processed", Data: data})
+}
+
+func (h *ServiceHandler) transactionReverse(w http.ResponseWriter, r *http.Request) {
+	transactionId, err := strconv.Atoi(r.PathValue("transaction_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid transaction_id"})
+		return
+	}
+
+	var body transaction.TransactionReverse
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Transaction.Reverse(r.Context(), transactionId, body)
+	if err != nil {
+		if errors.Is(err, transaction.ErrTransactionNotFound) {
+			writeJSON(w, http.StatusNotFound, appResponse{Error: "transaction not found"})
+
+		} else if errors.Is(err, transaction.ErrTransactionAccountForbidden) {
+			writeJSON(w, http.StatusForbidden, appResponse{Error: "transaction destination account is not one of the accounts of the caller"})
+
+		} else if errors.Is(err, transaction.ErrTransactionNotReversible) {
+			writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "transaction can not be reversed"})
+
+		} else if errors.Is(err, transaction.ErrTransactionAlreadyReversed) {
+			writeJSON(w, http.StatusConflict, appResponse{Error: "transaction already fully reversed"})
+
+		} else if errors.Is(err, transaction.ErrTransactionReversalExceedsOriginal) {
+			writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "transaction reversal exceeds the amount not reversed yet"})
+
+		} else if errors.Is(err, transaction.ErrTransactionSourceBalanceNotEnough) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction destination balance not enough"})
+
+		} else if errors.Is(err, transaction.ErrTransactionSourceBalanceNegative) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction amount is negative"})
+
+		} else if errors.Is(err, transaction.ErrTransactionCurrencyMismatch) {
+			writeJSON(w, http.StatusUnprocessableEntity, appResponse{Error: "transaction is cross-currency, destination_amount is required"})
+
+		} else if errors.Is(err, transaction.ErrTransactionConversionInvalid) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "transaction destination_amount is invalid"})
+
+		} else if statusCode, msg := accountStatusError(err); statusCode != 0 {
+			writeJSON(w, statusCode, appResponse{Error: msg})
+
+		} else if msg := moneyErrorMessage(err); msg != "" {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: msg})
+
+		} else {
+			writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "transaction reversed", Data: data})
+}
+
+func (h *ServiceHandler) transactionById(w http.ResponseWriter, r *http.Request) {
+	transactionId, err := strconv.Atoi(r.PathValue("transaction_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid transaction_id"})
+		return
+	}
+
+	data, err := h.Transaction.ById(r.Context(), transactionId)
+	if err != nil {
+		if errors.Is(err, transaction.ErrTransactionNotFound) {
+			writeJSON(w, http.StatusNotFound, appResponse{Error: "transaction not found"})
+		} else {
+			writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) transactionByAccount(w http.ResponseWriter, r *http.Request) {
+	accountId, err := strconv.Atoi(r.PathValue("account_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid account_id"})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := transaction.TransactionFilter{
+		AccountId: accountId,
+		Direction: query.Get("direction"),
+		Cursor:    query.Get("cursor"),
+	}
+
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid limit"})
+			return
+		}
+	}
+
+	if filter.From, err = parseTimeQuery(query.Get("from")); err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid from, expected RFC 3339 time"})
+		return
+	}
+
+	if filter.To, err = parseTimeQuery(query.Get("to")); err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid to, expected RFC 3339 time"})
+		return
+	}
+
+	data, err := h.Transaction.ByAccount(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, transaction.ErrTransactionAccountNotFound) {
+			writeJSON(w, http.StatusNotFound, appResponse{Error: "account not found"})
+		} else if errors.Is(err, transaction.ErrTransactionFilterInvalid) {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid filter"})
+		} else {
+			writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+// accountStatusError returns the status code and client facing message for the errors raised when
+// the status of an account does not allow a transfer, or 0 when err is not one. Frozen and blocked
+// accounts answer 403 Forbidden and closed accounts 410 Gone.
+func accountStatusError(err error) (int, string) {
+	if errors.Is(err, transaction.ErrTransactionSourceAccountFrozen) {
+		return http.StatusForbidden, "transaction source account is frozen"
+	} else if errors.Is(err, transaction.ErrTransactionSourceAccountDebitBlocked) {
+		return http.StatusForbidden, "transaction source account is blocked for debits"
+	} else if errors.Is(err, transaction.ErrTransactionSourceAccountClosed) {
+		return http.StatusGone, "transaction source account is closed"
+	} else if errors.Is(err, transaction.ErrTransactionDestinationAccountFrozen) {
+		return http.StatusForbidden, "transaction destination account is frozen"
+	} else if errors.Is(err, transaction.ErrTransactionDestinationAccountCreditBlocked) {
+		return http.StatusForbidden, "transaction destination account is blocked for credits"
+	} else if errors.Is(err, transaction.ErrTransactionDestinationAccountClosed) {
+		return http.StatusGone, "transaction destination account is closed"
+	}
+	return 0, ""
+}
+
+// limitViolation returns the transfer limit err reports exceeding, if it is a
+// *transaction.LimitExceededError.
+func limitViolation(err error) (limit.Violation, bool) {
+	var limitErr *transaction.LimitExceededError
+	if errors.As(err, &limitErr) {
+		return limitErr.Violation, true
+	}
+	return limit.Violation{}, false
+}
+
+// parseTimeQuery parses an optional RFC 3339 query parameter, returning nil when it is empty.
+func parseTimeQuery(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
 }
@@ -0,0 +1,41 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+)
+
+func TestServiceHandler_TransactionById(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "success - found", wantStatus: http.StatusOK},
+		{name: "error - not found", err: transaction.ErrTransactionNotFound, wantStatus: http.StatusNotFound},
+		{name: "error - lookup fail", err: transaction.ErrTransactionByIdFailed, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &ServiceHandler{
+				Transaction: &fakeTransactionHandler{
+					ByIdFunc: func(ctx context.Context, transactionId int) (transaction.Transaction, error) {
+						return transaction.Transaction{TransactionId: transactionId}, tt.err
+					},
+				},
+			}
+			req := httptest.NewRequest(http.MethodGet, "/transactions/1", nil)
+			rec := httptest.NewRecorder()
+
+			NewMux(":0", h).Handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET /transactions/1 status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
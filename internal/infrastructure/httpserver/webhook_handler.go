@@ -0,0 +1,162 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/webhook"
+)
+
+// WebhookHandler is interface that ServiceHandler use to integrate with WebhookService
+type WebhookHandler interface {
+	CreateSubscription(ctx context.Context, data webhook.SubscriptionCreate) (webhook.Subscription, error)
+	SubscriptionById(ctx context.Context, subscriptionId int) (webhook.Subscription, error)
+	Subscriptions(ctx context.Context) ([]webhook.Subscription, error)
+	DisableSubscription(ctx context.Context, subscriptionId int) error
+	Deliveries(ctx context.Context, subscriptionId int, filter webhook.DeliveryFilter) ([]webhook.Delivery, error)
+	DeliveryById(ctx context.Context, deliveryId int) (webhook.DeliveryLog, error)
+	ReplayDelivery(ctx context.Context, deliveryId int) (webhook.Delivery, error)
+}
+
+func (h *ServiceHandler) webhookCreate(w http.ResponseWriter, r *http.Request) {
+	var body webhook.SubscriptionCreate
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "bad request"})
+		return
+	}
+
+	data, err := h.Webhook.CreateSubscription(r.Context(), body)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "webhook subscription created", Data: data})
+}
+
+func (h *ServiceHandler) webhookList(w http.ResponseWriter, r *http.Request) {
+	data, err := h.Webhook.Subscriptions(r.Context())
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) webhookById(w http.ResponseWriter, r *http.Request) {
+	subscriptionId, err := strconv.Atoi(r.PathValue("subscription_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid subscription_id"})
+		return
+	}
+
+	data, err := h.Webhook.SubscriptionById(r.Context(), subscriptionId)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) webhookDisable(w http.ResponseWriter, r *http.Request) {
+	subscriptionId, err := strconv.Atoi(r.PathValue("subscription_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid subscription_id"})
+		return
+	}
+
+	if err := h.Webhook.DisableSubscription(r.Context(), subscriptionId); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "webhook subscription disabled"})
+}
+
+func (h *ServiceHandler) webhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	subscriptionId, err := strconv.Atoi(r.PathValue("subscription_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid subscription_id"})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := webhook.DeliveryFilter{Status: query.Get("status")}
+
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid limit"})
+			return
+		}
+	}
+
+	data, err := h.Webhook.Deliveries(r.Context(), subscriptionId, filter)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) webhookDeliveryById(w http.ResponseWriter, r *http.Request) {
+	deliveryId, err := strconv.Atoi(r.PathValue("delivery_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid delivery_id"})
+		return
+	}
+
+	data, err := h.Webhook.DeliveryById(r.Context(), deliveryId)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Data: data})
+}
+
+func (h *ServiceHandler) webhookDeliveryReplay(w http.ResponseWriter, r *http.Request) {
+	deliveryId, err := strconv.Atoi(r.PathValue("delivery_id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "invalid delivery_id"})
+		return
+	}
+
+	data, err := h.Webhook.ReplayDelivery(r.Context(), deliveryId)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, appResponse{Message: "webhook delivery replay scheduled", Data: data})
+}
+
+// writeWebhookError maps the errors returned by WebhookService to responses.
+func writeWebhookError(w http.ResponseWriter, err error) {
+	if errors.Is(err, webhook.ErrWebhookSubscriptionNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "webhook subscription not found"})
+
+	} else if errors.Is(err, webhook.ErrWebhookDeliveryNotFound) {
+		writeJSON(w, http.StatusNotFound, appResponse{Error: "webhook delivery not found"})
+
+	} else if errors.Is(err, webhook.ErrWebhookURLInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "url must be an absolute http or https url"})
+
+	} else if errors.Is(err, webhook.ErrWebhookEventTypeInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "event_types must list account.created, transaction.created or transaction.reversed"})
+
+	} else if errors.Is(err, webhook.ErrWebhookSecretInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "secret must be 16 to 128 characters"})
+
+	} else if errors.Is(err, webhook.ErrWebhookDeliveryFilterInvalid) {
+		writeJSON(w, http.StatusBadRequest, appResponse{Error: "status must be pending, succeeded or failed and limit at most 500"})
+
+	} else {
+		writeJSON(w, http.StatusInternalServerError, appResponse{Error: "internal server error"})
+	}
+}
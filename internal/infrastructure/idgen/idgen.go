@@ -0,0 +1,73 @@
+// Package idgen provides the account id generators that do not need the database: a
+// Snowflake-like generator and one built on TigerBeetle time-based ids. Both produce
+// positive ids that fit in 63 bits and grow over time.
+package idgen
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tbt "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+)
+
+// Snowflake layout: 41 bits of milliseconds since epoch, 10 bits of node and 12 bits of sequence.
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+	MaxNode      = 1<<nodeBits - 1
+	maxSequence  = 1<<sequenceBits - 1
+)
+
+// epoch is the start of the Snowflake clock, which lasts 69 years from it.
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake generates ids from the current millisecond, the node id and a sequence number that
+// restarts every millisecond, so up to 4096 ids per millisecond are unique on a node and nodes
+// with different ids never collide. Ids keep growing when the clock steps back.
+type Snowflake struct {
+	mu       sync.Mutex
+	node     int64
+	last     int64
+	sequence int64
+	now      func() time.Time
+}
+
+// NewSnowflake creates a Snowflake generator for node, which must be between 0 and MaxNode.
+func NewSnowflake(node int) (*Snowflake, error) {
+	if node < 0 || node > MaxNode {
+		return nil, fmt.Errorf("snowflake node %d out of range [0, %d]", node, MaxNode)
+	}
+	return &Snowflake{node: int64(node), now: time.Now}, nil
+}
+
+// NextId returns the next id.
+func (s *Snowflake) NextId(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := max(s.now().Sub(epoch).Milliseconds(), s.last)
+	if ms == s.last {
+		s.sequence = (s.sequence + 1) & maxSequence
+		if s.sequence == 0 {
+			ms++
+		}
+	} else {
+		s.sequence = 0
+	}
+	s.last = ms
+
+	return int(ms<<(nodeBits+sequenceBits) | s.node<<sequenceBits | s.sequence), nil
+}
+
+// TigerBeetle generates ids from tbt.ID, which puts a millisecond timestamp in the upper 48
+// of its 128 bits and random bits below it. Account ids are 63 bits, so only the upper 63 bits
+// are kept: the timestamp and 15 random bits. The rare collisions are retried by AccountService.
+type TigerBeetle struct{}
+
+// NextId returns the next id.
+func (TigerBeetle) NextId(ctx context.Context) (int, error) {
+	id := tbt.ID().BigInt()
+	return int(id.Rsh(&id, 65).Int64()), nil
+}
@@ -0,0 +1,54 @@
+package idgen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnowflake_NextId(t *testing.T) {
+	now := epoch.Add(time.Hour)
+	s, err := NewSnowflake(MaxNode)
+	if err != nil {
+		t.Fatalf("NewSnowflake() error = %v", err)
+	}
+	s.now = func() time.Time { return now }
+
+	last := 0
+	for i := range 2*maxSequence + 10 {
+		if i == maxSequence {
+			now = now.Add(-time.Second) // the clock stepping back must not break ordering
+		}
+		id, err := s.NextId(t.Context())
+		if err != nil {
+			t.Fatalf("Snowflake.NextId() error = %v", err)
+		}
+		if id <= last {
+			t.Fatalf("Snowflake.NextId() = %d after %d, want increasing ids", id, last)
+		}
+		if node := id >> sequenceBits & MaxNode; node != MaxNode {
+			t.Fatalf("Snowflake.NextId() node = %d, want %d", node, MaxNode)
+		}
+		last = id
+	}
+}
+
+func TestNewSnowflake(t *testing.T) {
+	for _, node := range []int{-1, MaxNode + 1} {
+		if _, err := NewSnowflake(node); err == nil {
+			t.Errorf("NewSnowflake(%d) error = nil, want error", node)
+		}
+	}
+}
+
+func TestTigerBeetle_NextId(t *testing.T) {
+	first, err := TigerBeetle{}.NextId(t.Context())
+	if err != nil || first <= 0 {
+		t.Fatalf("TigerBeetle.NextId() = %d, %v, want a positive id", first, err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	second, _ := TigerBeetle{}.NextId(t.Context())
+	if second <= first {
+		t.Errorf("TigerBeetle.NextId() = %d after %d, want increasing ids across milliseconds", second, first)
+	}
+}
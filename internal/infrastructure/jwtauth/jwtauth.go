@@ -0,0 +1,93 @@
+// Package jwtauth authenticates the internal services calling the API with JWTs in the
+// Authorization: Bearer header. Tokens must be signed with RS256 or ES256 by a key of a JWKS
+// and carry a subject; their scopes come from the space separated scope claim or the scp claim.
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// leeway is the clock skew tolerated between the issuer of tokens and the server.
+const leeway = 30 * time.Second
+
+var ErrTokenInvalid = errors.New("bearer token invalid")
+
+// Verifier authenticates callers by their JWT.
+type Verifier struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+// NewVerifier creates a Verifier of tokens signed by keys. When issuer or audience are set,
+// tokens must carry them in their iss and aud claims.
+func NewVerifier(keys *KeySet, issuer, audience string) *Verifier {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(leeway),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	return &Verifier{keys: keys, parser: jwt.NewParser(opts...)}
+}
+
+// claims are the claims of a token the principal is made of.
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string    `json:"scope"`
+	Scp   scopeList `json:"scp"`
+}
+
+// scopeList is a list of scopes given either as a JSON array or as a space separated string.
+type scopeList []string
+
+func (s *scopeList) UnmarshalJSON(b []byte) error {
+	var scopes []string
+	if err := json.Unmarshal(b, &scopes); err == nil {
+		*s = scopes
+		return nil
+	}
+
+	var scope string
+	if err := json.Unmarshal(b, &scope); err != nil {
+		return err
+	}
+	*s = strings.Fields(scope)
+	return nil
+}
+
+// Authenticate verifies token and returns the caller it was issued to, identified by its sub
+// claim and granted the scopes of its scope and scp claims. Callers authenticated by a token
+// may act on every account.
+func (v *Verifier) Authenticate(ctx context.Context, token string) (auth.Principal, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		slog.WarnContext(ctx, ErrTokenInvalid.Error(), "error", err)
+		return auth.Principal{}, ErrTokenInvalid
+	}
+	if c.Subject == "" {
+		slog.WarnContext(ctx, ErrTokenInvalid.Error(), "error", "token has no subject")
+		return auth.Principal{}, ErrTokenInvalid
+	}
+
+	scopes := append(strings.Fields(c.Scope), c.Scp...)
+	return auth.Principal{Subject: c.Subject, Scopes: scopes}, nil
+}
@@ -0,0 +1,255 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signer signs tokens with a locally generated key published in the JWKS under kid.
+type signer struct {
+	kid    string
+	method jwt.SigningMethod
+	key    any
+	jwk    map[string]string
+}
+
+func newRSASigner(t *testing.T, kid string) signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	return signer{kid: kid, method: jwt.SigningMethodRS256, key: key, jwk: map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}
+}
+
+func newECDSASigner(t *testing.T, kid string) signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() error = %v", err)
+	}
+	return signer{kid: kid, method: jwt.SigningMethodES256, key: key, jwk: map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}}
+}
+
+func (s signer) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(s.method, claims)
+	token.Header["kid"] = s.kid
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return signed
+}
+
+func jwks(t *testing.T, signers ...signer) []byte {
+	keys := make([]map[string]string, len(signers))
+	for i, s := range signers {
+		keys[i] = s.jwk
+	}
+	b, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return b
+}
+
+func writeJWKS(t *testing.T, signers ...signer) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks(t, signers...), 0o600); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestVerifier_Authenticate(t *testing.T) {
+	rsaSigner := newRSASigner(t, "rsa-1")
+	ecdsaSigner := newECDSASigner(t, "ec-1")
+	unknownSigner := newRSASigner(t, "rsa-1")
+
+	keys, err := NewKeySet(t.Context(), writeJWKS(t, rsaSigner, ecdsaSigner), http.DefaultClient)
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	verifier := NewVerifier(keys, "https://issuer.test", "transfer-system")
+
+	now := time.Now()
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":   "https://issuer.test",
+			"aud":   "transfer-system",
+			"sub":   "service:payouts",
+			"scope": "accounts:read transactions:write",
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Minute).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+	hs256, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil)).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    auth.Principal
+		wantErr error
+	}{
+		{
+			name:  "success - RS256",
+			token: rsaSigner.sign(t, claims(nil)),
+			want:  auth.Principal{Subject: "service:payouts", Scopes: []string{auth.ScopeAccountsRead, auth.ScopeTransactionsWrite}},
+		},
+		{
+			name:  "success - ES256",
+			token: ecdsaSigner.sign(t, claims(nil)),
+			want:  auth.Principal{Subject: "service:payouts", Scopes: []string{auth.ScopeAccountsRead, auth.ScopeTransactionsWrite}},
+		},
+		{
+			name:  "success - scp array",
+			token: rsaSigner.sign(t, claims(jwt.MapClaims{"scope": nil, "scp": []string{auth.ScopeAdmin}})),
+			want:  auth.Principal{Subject: "service:payouts", Scopes: []string{auth.ScopeAdmin}},
+		},
+		{
+			name:  "success - scp string",
+			token: rsaSigner.sign(t, claims(jwt.MapClaims{"scope": nil, "scp": "accounts:read admin"})),
+			want:  auth.Principal{Subject: "service:payouts", Scopes: []string{auth.ScopeAccountsRead, auth.ScopeAdmin}},
+		},
+		{
+			name:  "success - no scopes",
+			token: rsaSigner.sign(t, claims(jwt.MapClaims{"scope": nil})),
+			want:  auth.Principal{Subject: "service:payouts"},
+		},
+		{name: "error - expired", token: rsaSigner.sign(t, claims(jwt.MapClaims{"exp": now.Add(-time.Hour).Unix()})), wantErr: ErrTokenInvalid},
+		{name: "error - no expiry", token: rsaSigner.sign(t, claims(jwt.MapClaims{"exp": nil})), wantErr: ErrTokenInvalid},
+		{name: "error - other issuer", token: rsaSigner.sign(t, claims(jwt.MapClaims{"iss": "https://other.test"})), wantErr: ErrTokenInvalid},
+		{name: "error - other audience", token: rsaSigner.sign(t, claims(jwt.MapClaims{"aud": "other"})), wantErr: ErrTokenInvalid},
+		{name: "error - no subject", token: rsaSigner.sign(t, claims(jwt.MapClaims{"sub": nil})), wantErr: ErrTokenInvalid},
+		{name: "error - signed by unknown key", token: unknownSigner.sign(t, claims(nil)), wantErr: ErrTokenInvalid},
+		{name: "error - unknown kid", token: newRSASigner(t, "rsa-2").sign(t, claims(nil)), wantErr: ErrTokenInvalid},
+		{name: "error - HS256", token: hs256, wantErr: ErrTokenInvalid},
+		{name: "error - malformed", token: "not-a-token", wantErr: ErrTokenInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Authenticate(t.Context(), tt.token)
+			if !errors.Is(err, tt.wantErr) || (err != nil && tt.wantErr == nil) {
+				t.Fatalf("Verifier.Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if got.Subject != tt.want.Subject || !slices.Equal(got.Scopes, tt.want.Scopes) {
+				t.Errorf("Verifier.Authenticate() = %+v, want %+v", got, tt.want)
+			}
+			if got.AccountIds != nil {
+				t.Errorf("Verifier.Authenticate() AccountIds = %v, want every account", got.AccountIds)
+			}
+		})
+	}
+}
+
+func TestKeySet_Refresh(t *testing.T) {
+	first := newECDSASigner(t, "ec-1")
+	second := newRSASigner(t, "rsa-2")
+
+	var current atomic.Value
+	current.Store(jwks(t, first))
+	var reads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reads.Add(1)
+		_, _ = w.Write(current.Load().([]byte))
+	}))
+	defer server.Close()
+
+	keys, err := NewKeySet(t.Context(), server.URL, server.Client())
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	verifier := NewVerifier(keys, "", "")
+	claims := jwt.MapClaims{"sub": "service:payouts", "exp": now.Add(time.Hour).Unix()}
+
+	if _, err := verifier.Authenticate(t.Context(), first.sign(t, claims)); err != nil {
+		t.Fatalf("Verifier.Authenticate() error = %v", err)
+	}
+
+	// The keys were rotated: an unknown kid reads the JWKS again, at most once a minute.
+	current.Store(jwks(t, second))
+	if _, err := verifier.Authenticate(t.Context(), second.sign(t, claims)); !errors.Is(err, ErrTokenInvalid) {
+		t.Fatalf("Verifier.Authenticate() right after a read error = %v, want %v", err, ErrTokenInvalid)
+	}
+	now = now.Add(keySetMinRefresh)
+	if _, err := verifier.Authenticate(t.Context(), second.sign(t, claims)); err != nil {
+		t.Fatalf("Verifier.Authenticate() with rotated key error = %v", err)
+	}
+	if got := reads.Load(); got != 2 {
+		t.Errorf("JWKS reads = %d, want 2", got)
+	}
+
+	// The removed key stops being accepted once the JWKS is read again.
+	if _, err := verifier.Authenticate(t.Context(), first.sign(t, claims)); !errors.Is(err, ErrTokenInvalid) {
+		t.Errorf("Verifier.Authenticate() with removed key error = %v, want %v", err, ErrTokenInvalid)
+	}
+}
+
+func TestNewKeySet(t *testing.T) {
+	hmac := map[string]string{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}
+	tests := []struct {
+		name    string
+		jwks    string
+		wantErr bool
+	}{
+		{name: "success - EC key", jwks: string(jwks(t, newECDSASigner(t, "ec-1")))},
+		{name: "success - unsupported keys skipped", jwks: string(jwks(t, signer{jwk: hmac}, newECDSASigner(t, "ec-1")))},
+		{name: "error - no usable key", jwks: string(jwks(t, signer{jwk: hmac})), wantErr: true},
+		{name: "error - point not on curve", jwks: `{"keys":[{"kty":"EC","kid":"ec-1","crv":"P-256","x":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA","y":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"}]}`, wantErr: true},
+		{name: "error - not JSON", jwks: `keys`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			if err := os.WriteFile(path, []byte(tt.jwks), 0o600); err != nil {
+				t.Fatalf("os.WriteFile() error = %v", err)
+			}
+			_, err := NewKeySet(t.Context(), path, http.DefaultClient)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeySet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// keySetMaxAge is how long the keys of a JWKS are used before it is read again, so removed
+	// keys stop being accepted.
+	keySetMaxAge = time.Hour
+	// keySetMinRefresh is how long after a read the JWKS is not read again for a token signed by
+	// an unknown key, so such tokens can not flood the JWKS source.
+	keySetMinRefresh = time.Minute
+	keySetMaxBytes   = 1 << 20
+)
+
+var errKeyNotFound = errors.New("key not found")
+
+// KeySet holds the public keys of a JWKS document read from a file or an http(s) URL. The
+// document is read again when it gets older than an hour and, at most once a minute, when a
+// token is signed by a key it does not hold, which picks up rotated keys.
+type KeySet struct {
+	source string
+	client *http.Client
+	now    func() time.Time
+
+	mu     sync.Mutex
+	keys   map[string]crypto.PublicKey
+	readAt time.Time
+}
+
+// NewKeySet reads the JWKS of source, a file path or an http(s) URL fetched with client, and
+// returns its keys. It fails when the document can not be read or holds no RS256 or ES256 key.
+func NewKeySet(ctx context.Context, source string, client *http.Client) (*KeySet, error) {
+	ks := &KeySet{source: source, client: client, now: time.Now}
+	if err := ks.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Key returns the key identified by kid. An empty kid matches the key of a JWKS holding a
+// single key.
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	age := ks.now().Sub(ks.readAt)
+	key, found := ks.lookup(kid)
+	if (!found && age >= keySetMinRefresh) || age >= keySetMaxAge {
+		// A JWKS that can not be read again leaves the keys read last in use.
+		if err := ks.refresh(ctx); err != nil {
+			slog.ErrorContext(ctx, "jwks refresh fail", "source", ks.source, "error", err)
+		}
+		key, found = ks.lookup(kid)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w [kid: %s]", errKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+func (ks *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+// refresh reads the JWKS again. It must be called with ks.mu held, or before ks is shared.
+func (ks *KeySet) refresh(ctx context.Context) error {
+	ks.readAt = ks.now()
+
+	b, err := ks.read(ctx)
+	if err != nil {
+		return fmt.Errorf("jwks read: %w [source: %s]", err, ks.source)
+	}
+	keys, err := parseKeySet(b)
+	if err != nil {
+		return fmt.Errorf("jwks parse: %w [source: %s]", err, ks.source)
+	}
+
+	ks.keys = keys
+	return nil
+}
+
+func (ks *KeySet) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(ks.source, "https://") && !strings.HasPrefix(ks.source, "http://") {
+		return os.ReadFile(ks.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, keySetMaxBytes))
+}
+
+// jwk is a JSON Web Key as defined by RFC 7517, with the members of RSA and elliptic curve keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseKeySet returns the RSA and P-256 signing keys of a JWKS by key id. Keys of other types,
+// curves or uses are skipped.
+func parseKeySet(b []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		var err error
+		switch {
+		case k.Kty == "RSA" && (k.Alg == "" || k.Alg == "RS256"):
+			key, err = rsaKey(k)
+		case k.Kty == "EC" && k.Crv == "P-256" && (k.Alg == "" || k.Alg == "ES256"):
+			key, err = ecdsaKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no RS256 or ES256 signing key")
+	}
+	return keys, nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) < 2048/8 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA key")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func ecdsaKey(k jwk) (*ecdsa.PublicKey, error) {
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+
+	if len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("invalid P-256 key")
+	}
+	// ecdh checks that the uncompressed point is on the curve.
+	if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, fmt.Errorf("invalid P-256 key: %w", err)
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
@@ -0,0 +1,71 @@
+// Package logging configures the structured log/slog logger of the application. Records are
+// written as JSON or text at a configurable level, and records logged with a context carrying a
+// request id, such as slog.ErrorContext(ctx, ...) during an HTTP request, include it so every
+// line of a request can be correlated.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// RequestIdKey is the attribute holding the request id of a record.
+const RequestIdKey = "request_id"
+
+type requestIdKey struct{}
+
+// WithRequestId returns a copy of ctx carrying the request id.
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestId returns the request id carried by ctx, or an empty string.
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// New returns a logger writing records of at least level, one of debug, info, warn or error, to
+// w in format, json or text.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("log level %q: expected debug, info, warn or error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("log format %q: expected json or text", format)
+	}
+
+	return slog.New(contextHandler{h}), nil
+}
+
+// contextHandler adds the request id of the context to the records it handles.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestId := RequestId(ctx); requestId != "" {
+		r.AddAttrs(slog.String(RequestIdKey, requestId))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		level         string
+		format        string
+		wantRequestId string
+		wantLogged    bool
+		wantErr       bool
+	}{
+		{name: "success - json with request id", level: "info", format: "json", wantRequestId: "req-1", wantLogged: true},
+		{name: "success - json without request id", level: "debug", format: "json", wantLogged: true},
+		{name: "success - below level", level: "error", format: "json"},
+		{name: "error - level invalid", level: "verbose", format: "json", wantErr: true},
+		{name: "error - format invalid", level: "info", format: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger, err := New(&buf, tt.level, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			ctx := t.Context()
+			if tt.wantRequestId != "" {
+				ctx = WithRequestId(ctx, tt.wantRequestId)
+			}
+			logger.With("account_id", 7).WarnContext(ctx, "transaction source account not found", slog.Int64("amount", 1_000))
+
+			if buf.Len() == 0 {
+				if tt.wantLogged {
+					t.Fatalf("nothing logged, want a record")
+				}
+				return
+			}
+			if !tt.wantLogged {
+				t.Fatalf("logged %s, want nothing", buf.String())
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("record %s is not JSON: %v", buf.String(), err)
+			}
+			if got["msg"] != "transaction source account not found" || got["level"] != "WARN" || got["account_id"] != 7.0 || got["amount"] != 1_000.0 {
+				t.Errorf("record = %v, want the message, level and attributes", got)
+			}
+			if requestId, _ := got[RequestIdKey].(string); requestId != tt.wantRequestId {
+				t.Errorf("record request id = %q, want %q", requestId, tt.wantRequestId)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Package metrics defines the Prometheus metrics of the API server: HTTP requests per route,
+// transfers and their amounts by outcome, repository query latency, the database connection pool,
+// the outbox backlog and TigerBeetle requests. Metrics are registered with the default Prometheus
+// registry and served by Handler.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "transfer_system"
+
+// OutcomeSucceeded is the outcome of a transfer that was created. Failed transfers are labelled
+// with the error code of transaction.ErrorCode.
+const OutcomeSucceeded = "succeeded"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	transfers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Transfers by outcome, succeeded or the error code of the failure.",
+	}, []string{"outcome"})
+
+	transferAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_amount_total",
+		Help:      "Sum of the requested amounts of transfers by outcome and source currency; the currency is empty for failed transfers.",
+	}, []string{"outcome", "currency"})
+
+	repoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_query_duration_seconds",
+		Help:      "Latency of repository queries by repository, method and whether they failed.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method", "failed"})
+
+	tigerBeetleDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tigerbeetle_request_duration_seconds",
+		Help:      "Latency of TigerBeetle requests by operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+
+	tigerBeetleErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tigerbeetle_request_errors_total",
+		Help:      "TigerBeetle requests that failed by operation.",
+	}, []string{"operation"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDB exposes the connection pool statistics of db, such as open, in use and idle
+// connections and the time spent waiting for one.
+func RegisterDB(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveHTTP records an HTTP request served for route, the pattern it matched.
+func ObserveHTTP(method, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveTransfer records a transfer of amount, in currency, with outcome. Negative amounts,
+// which are rejected, are counted but not summed.
+func ObserveTransfer(outcome, currency string, amount float64) {
+	transfers.WithLabelValues(outcome).Inc()
+	if amount > 0 {
+		transferAmount.WithLabelValues(outcome, currency).Add(amount)
+	}
+}
+
+// observeQuery records a repository query started at start that returned err.
+func observeQuery(repository, method string, start time.Time, err error) {
+	repoDuration.WithLabelValues(repository, method, strconv.FormatBool(err != nil)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveTigerBeetle records a TigerBeetle request of operation started at start, counting it
+// as an error when err is set.
+func ObserveTigerBeetle(operation string, start time.Time, err error) {
+	tigerBeetleDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		tigerBeetleErrors.WithLabelValues(operation).Inc()
+	}
+}
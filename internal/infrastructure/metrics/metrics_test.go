@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveTransfer(t *testing.T) {
+	tests := []struct {
+		name       string
+		outcome    string
+		currency   string
+		amount     float64
+		wantAmount float64
+	}{
+		{name: "success - succeeded", outcome: OutcomeSucceeded, currency: "USD", amount: 12.5, wantAmount: 12.5},
+		{name: "success - failed", outcome: "source_balance_not_enough", amount: 3, wantAmount: 3},
+		{name: "success - negative amount not summed", outcome: "amount_negative", amount: -3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count := testutil.ToFloat64(transfers.WithLabelValues(tt.outcome))
+			amount := testutil.ToFloat64(transferAmount.WithLabelValues(tt.outcome, tt.currency))
+
+			ObserveTransfer(tt.outcome, tt.currency, tt.amount)
+
+			if got := testutil.ToFloat64(transfers.WithLabelValues(tt.outcome)) - count; got != 1 {
+				t.Errorf("transfers counted = %v, want 1", got)
+			}
+			if got := testutil.ToFloat64(transferAmount.WithLabelValues(tt.outcome, tt.currency)) - amount; got != tt.wantAmount {
+				t.Errorf("transfer amount summed = %v, want %v", got, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestObserveTigerBeetle(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantErrors float64
+	}{
+		{name: "success - request succeeded"},
+		{name: "success - request failed", err: errors.New("client closed"), wantErrors: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := testutil.ToFloat64(tigerBeetleErrors.WithLabelValues("lookup_accounts"))
+
+			ObserveTigerBeetle("lookup_accounts", time.Now(), tt.err)
+
+			if got := testutil.ToFloat64(tigerBeetleErrors.WithLabelValues("lookup_accounts")) - errs; got != tt.wantErrors {
+				t.Errorf("errors counted = %v, want %v", got, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestOutboxCollector(t *testing.T) {
+	tests := []struct {
+		name     string
+		stats    outbox.Stats
+		statsErr error
+		want     string
+	}{
+		{
+			name:  "success - stats",
+			stats: outbox.Stats{Pending: 3, Dead: 1, LagSeconds: 2.5},
+			want: `
+# HELP transfer_system_outbox_dead_messages Outbox messages dead-lettered after too many rejected attempts.
+# TYPE transfer_system_outbox_dead_messages gauge
+transfer_system_outbox_dead_messages 1
+# HELP transfer_system_outbox_lag_seconds Age of the oldest pending outbox message, 0 when none is pending.
+# TYPE transfer_system_outbox_lag_seconds gauge
+transfer_system_outbox_lag_seconds 2.5
+# HELP transfer_system_outbox_pending_messages Outbox messages waiting to be published to TigerBeetle.
+# TYPE transfer_system_outbox_pending_messages gauge
+transfer_system_outbox_pending_messages 3
+`,
+		},
+		{name: "success - stats fail", statsErr: outbox.ErrOutboxStatsFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := outboxCollector{stats: func(ctx context.Context) (outbox.Stats, error) {
+				return tt.stats, tt.statsErr
+			}}
+
+			if err := testutil.CollectAndCompare(c, strings.NewReader(tt.want)); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	outboxPendingDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "outbox", "pending_messages"),
+		"Outbox messages waiting to be published to TigerBeetle.", nil, nil)
+	outboxDeadDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "outbox", "dead_messages"),
+		"Outbox messages dead-lettered after too many rejected attempts.", nil, nil)
+	outboxLagDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "outbox", "lag_seconds"),
+		"Age of the oldest pending outbox message, 0 when none is pending.", nil, nil)
+)
+
+// outboxCollector reads the outbox statistics once per scrape.
+type outboxCollector struct {
+	stats func(ctx context.Context) (outbox.Stats, error)
+}
+
+func (c outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- outboxPendingDesc
+	ch <- outboxDeadDesc
+	ch <- outboxLagDesc
+}
+
+// Collect reports nothing when the statistics can not be read, so the gauges go missing
+// instead of dropping to zero.
+func (c outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.stats(context.Background())
+	if err != nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(outboxPendingDesc, prometheus.GaugeValue, float64(stats.Pending))
+	ch <- prometheus.MustNewConstMetric(outboxDeadDesc, prometheus.GaugeValue, float64(stats.Dead))
+	ch <- prometheus.MustNewConstMetric(outboxLagDesc, prometheus.GaugeValue, stats.LagSeconds)
+}
+
+// RegisterOutbox exposes the pending and dead-lettered outbox messages and the outbox lag, read
+// with stats on every scrape.
+func RegisterOutbox(stats func(ctx context.Context) (outbox.Stats, error)) {
+	prometheus.MustRegister(outboxCollector{stats: stats})
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+)
+
+// AccountRepo is an account.AccountRepo that records the latency of the queries of the
+// repository it wraps.
+type AccountRepo struct {
+	next account.AccountRepo
+}
+
+// NewAccountRepo returns an AccountRepo recording the queries of next.
+func NewAccountRepo(next account.AccountRepo) *AccountRepo {
+	return &AccountRepo{next: next}
+}
+
+func (r *AccountRepo) Create(ctx context.Context, data account.AccountCreateParams) (bool, error) {
+	start := time.Now()
+	created, err := r.next.Create(ctx, data)
+	observeQuery("account", "Create", start, err)
+	return created, err
+}
+
+func (r *AccountRepo) ById(ctx context.Context, accountId int) (account.AccountRow, error) {
+	start := time.Now()
+	row, err := r.next.ById(ctx, accountId)
+	observeQuery("account", "ById", start, err)
+	return row, err
+}
+
+func (r *AccountRepo) ByIdsForUpdate(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+	start := time.Now()
+	rows, err := r.next.ByIdsForUpdate(ctx, accountIds)
+	observeQuery("account", "ByIdsForUpdate", start, err)
+	return rows, err
+}
+
+func (r *AccountRepo) UpdateBalance(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+	start := time.Now()
+	err := r.next.UpdateBalance(ctx, params)
+	observeQuery("account", "UpdateBalance", start, err)
+	return err
+}
+
+func (r *AccountRepo) UpdateHeldBalance(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+	start := time.Now()
+	err := r.next.UpdateHeldBalance(ctx, params)
+	observeQuery("account", "UpdateHeldBalance", start, err)
+	return err
+}
+
+func (r *AccountRepo) UpdateStatus(ctx context.Context, params account.AccountUpdateStatusParams) error {
+	start := time.Now()
+	err := r.next.UpdateStatus(ctx, params)
+	observeQuery("account", "UpdateStatus", start, err)
+	return err
+}
+
+func (r *AccountRepo) UpdateOverdraftLimit(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error {
+	start := time.Now()
+	err := r.next.UpdateOverdraftLimit(ctx, params)
+	observeQuery("account", "UpdateOverdraftLimit", start, err)
+	return err
+}
+
+func (r *AccountRepo) OverdraftLimitChanges(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error) {
+	start := time.Now()
+	rows, err := r.next.OverdraftLimitChanges(ctx, accountId)
+	observeQuery("account", "OverdraftLimitChanges", start, err)
+	return rows, err
+}
+
+// TransactionRepo is a transaction.TransactionRepo that records the latency of the queries of
+// the repository it wraps.
+type TransactionRepo struct {
+	next transaction.TransactionRepo
+}
+
+// NewTransactionRepo returns a TransactionRepo recording the queries of next.
+func NewTransactionRepo(next transaction.TransactionRepo) *TransactionRepo {
+	return &TransactionRepo{next: next}
+}
+
+func (r *TransactionRepo) Create(ctx context.Context, data transaction.TransactionCreateParams) (transaction.TransactionRow, error) {
+	start := time.Now()
+	row, err := r.next.Create(ctx, data)
+	observeQuery("transaction", "Create", start, err)
+	return row, err
+}
+
+func (r *TransactionRepo) ById(ctx context.Context, transactionId int) (transaction.TransactionRow, error) {
+	start := time.Now()
+	row, err := r.next.ById(ctx, transactionId)
+	observeQuery("transaction", "ById", start, err)
+	return row, err
+}
+
+func (r *TransactionRepo) ByIdForUpdate(ctx context.Context, transactionId int) (transaction.TransactionRow, error) {
+	start := time.Now()
+	row, err := r.next.ByIdForUpdate(ctx, transactionId)
+	observeQuery("transaction", "ByIdForUpdate", start, err)
+	return row, err
+}
+
+func (r *TransactionRepo) ByAccount(ctx context.Context, params transaction.TransactionListParams) ([]transaction.TransactionRow, error) {
+	start := time.Now()
+	rows, err := r.next.ByAccount(ctx, params)
+	observeQuery("transaction", "ByAccount", start, err)
+	return rows, err
+}
+
+func (r *TransactionRepo) UpdateReversal(ctx context.Context, params transaction.TransactionUpdateReversalParams) error {
+	start := time.Now()
+	err := r.next.UpdateReversal(ctx, params)
+	observeQuery("transaction", "UpdateReversal", start, err)
+	return err
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"math"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/money"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+)
+
+// Transactions is a TransactionService that counts the transfers it creates, single or in
+// batches, by outcome: OutcomeSucceeded or the transaction.ErrorCode of the failure. Transfers
+// aborted with their atomic batch are counted as transaction.BatchItemAborted.
+type Transactions struct {
+	*transaction.TransactionService
+}
+
+// Create creates a transfer as TransactionService.Create does and counts it.
+func (t Transactions) Create(ctx context.Context, data transaction.TransactionCreate) (transaction.Transaction, error) {
+	tx, err := t.TransactionService.Create(ctx, data)
+	if err != nil {
+		ObserveTransfer(transaction.ErrorCode(err), "", amountValue(data.Amount))
+		return tx, err
+	}
+
+	ObserveTransfer(OutcomeSucceeded, tx.Currency, amountValue(tx.Amount))
+	return tx, nil
+}
+
+// Batch creates the transfers of data as TransactionService.Batch does and counts each of them.
+func (t Transactions) Batch(ctx context.Context, data transaction.TransactionBatch) (transaction.BatchResult, error) {
+	result, err := t.TransactionService.Batch(ctx, data)
+	for _, item := range result.Items {
+		switch item.Status {
+		case transaction.BatchItemCompleted:
+			ObserveTransfer(OutcomeSucceeded, item.Transaction.Currency, amountValue(item.Transaction.Amount))
+		case transaction.BatchItemFailed:
+			ObserveTransfer(item.Code, "", amountValue(data.Transfers[item.Index].Amount))
+		case transaction.BatchItemAborted:
+			ObserveTransfer(transaction.BatchItemAborted, "", amountValue(data.Transfers[item.Index].Amount))
+		}
+	}
+	return result, err
+}
+
+// amountValue returns a as a float, precise enough for a metric.
+func amountValue(a money.Amount) float64 {
+	return float64(a.Value()) / math.Pow10(a.Scale())
+}
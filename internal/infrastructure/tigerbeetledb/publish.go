@@ -0,0 +1,453 @@
+package tigerbeetledb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+
+	tbt "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+)
+
+// Publish submits the accounts and transfers of a batch of outbox messages to TigerBeetle in at
+// most two requests: accounts first, so transfers in the same batch can use them. Accounts and
+// transfers that already exist were created by an earlier attempt and count as delivered, as do
+// voids of pending transfers TigerBeetle already expired. A linked chain created by an earlier
+// attempt fails on the transfer that exists, the rest of the chain failing with it, and counts
+// as delivered too. Transfers of a message whose accounts were rejected are not submitted.
+func (tdb *TigerBeetleDB) Publish(ctx context.Context, messages []outbox.Message) ([]error, error) {
+	errs := make([]error, len(messages))
+
+	var accounts []tbt.Account
+	var accountOwners []int
+	var transfers []Transfer
+	var transferOwners []int
+	for i, message := range messages {
+		messageAccounts, messageTransfers, err := decodeMessage(message)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		for _, account := range messageAccounts {
+			accounts = append(accounts, account)
+			accountOwners = append(accountOwners, i)
+		}
+		for _, transfer := range messageTransfers {
+			transfers = append(transfers, transfer)
+			transferOwners = append(transferOwners, i)
+		}
+	}
+
+	if len(accounts) > 0 {
+		res, err := request(ctx, "create_accounts", tdb.client.CreateAccounts, accounts)
+		if err != nil {
+			return nil, fmt.Errorf("error creating accounts: %s", err)
+		}
+		for _, r := range res {
+			if r.Result == tbt.AccountExists {
+				continue
+			}
+			if i := accountOwners[r.Index]; errs[i] == nil {
+				errs[i] = fmt.Errorf("error creating account %d: %s", r.Index, r.Result)
+			}
+		}
+	}
+
+	var submitted []Transfer
+	var submittedOwners []int
+	for j, transfer := range transfers {
+		if errs[transferOwners[j]] == nil {
+			submitted = append(submitted, transfer)
+			submittedOwners = append(submittedOwners, transferOwners[j])
+		}
+	}
+
+	if len(submitted) > 0 {
+		batch := make([]tbt.Transfer, len(submitted))
+		for j, transfer := range submitted {
+			batch[j] = transfer.tb()
+		}
+
+		res, err := request(ctx, "create_transfers", tdb.client.CreateTransfers, batch)
+		if err != nil {
+			return nil, fmt.Errorf("error creating transfer: %s", err)
+		}
+		applied := map[int]bool{}
+		linkedFailed := map[int]uint32{}
+		for _, r := range res {
+			i := submittedOwners[r.Index]
+			if delivered(r.Result, submitted[r.Index]) {
+				applied[i] = true
+				continue
+			}
+			if r.Result == tbt.TransferLinkedEventFailed {
+				linkedFailed[i] = r.Index
+				continue
+			}
+			if errs[i] == nil {
+				errs[i] = fmt.Errorf("error creating transfer %d: %s", r.Index, r.Result)
+			}
+		}
+		for i, index := range linkedFailed {
+			if errs[i] == nil && !applied[i] {
+				errs[i] = fmt.Errorf("error creating transfer %d: %s", index, tbt.TransferLinkedEventFailed)
+			}
+		}
+	}
+
+	return errs, nil
+}
+
+// delivered reports whether TigerBeetle rejected transfer with result because an earlier attempt
+// already applied it. Pending transfers queued before their timeout was fixed in the message
+// were created with the timeout left at the time, so a different timeout still means delivered.
+func delivered(result tbt.CreateTransferResult, transfer Transfer) bool {
+	switch result {
+	case tbt.TransferExists, tbt.TransferExistsWithDifferentTimeout:
+		return true
+	case tbt.TransferPendingTransferExpired:
+		return transfer.Flags.VoidPendingTransfer
+	}
+	return false
+}
+
+// decodeMessage returns the accounts and transfers an outbox message stands for.
+func decodeMessage(message outbox.Message) ([]tbt.Account, []Transfer, error) {
+	switch message.Kind {
+	case outbox.KindAccountCreate:
+		var payload outbox.AccountPayload
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			return nil, nil, fmt.Errorf("error decoding message %d: %s", message.MessageId, err)
+		}
+		return accountCreate(payload)
+
+	case outbox.KindAccountAdjust:
+		var payload outbox.AdjustmentPayload
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			return nil, nil, fmt.Errorf("error decoding message %d: %s", message.MessageId, err)
+		}
+		transfer, err := accountAdjust(message.MessageId, payload)
+		return nil, []Transfer{transfer}, err
+
+	case outbox.KindAccountClose:
+		var payload outbox.AccountPayload
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			return nil, nil, fmt.Errorf("error decoding message %d: %s", message.MessageId, err)
+		}
+		transfer, err := accountClose(payload)
+		return nil, []Transfer{transfer}, err
+
+	case outbox.KindTransferCreate:
+		var payload outbox.TransferPayload
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			return nil, nil, fmt.Errorf("error decoding message %d: %s", message.MessageId, err)
+		}
+		transfers, err := transferCreate(payload)
+		return nil, transfers, err
+
+	case outbox.KindTransferBatch:
+		var payload outbox.TransferBatchPayload
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			return nil, nil, fmt.Errorf("error decoding message %d: %s", message.MessageId, err)
+		}
+		transfers, err := transferBatch(payload)
+		return nil, transfers, err
+
+	case outbox.KindHoldCreate, outbox.KindHoldPost, outbox.KindHoldVoid:
+		var payload outbox.HoldPayload
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			return nil, nil, fmt.Errorf("error decoding message %d: %s", message.MessageId, err)
+		}
+		transfers, err := holdTransfers(message.Kind, payload, time.Now())
+		return nil, transfers, err
+	}
+
+	return nil, nil, fmt.Errorf("error decoding message %d: unknown kind %q", message.MessageId, message.Kind)
+}
+
+// accountCreate returns the account of the payload and the transfer funding its initial balance
+// from the treasury.
+func accountCreate(payload outbox.AccountPayload) ([]tbt.Account, []Transfer, error) {
+	ledger, err := ledgerOf(payload.Currency)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating accounts: %s", err)
+	}
+
+	accounts := []tbt.Account{userAccount(payload.AccountId, ledger, payload.Overdraft)}
+	if payload.InitialBalance == 0 {
+		return accounts, nil, nil
+	}
+
+	return accounts, []Transfer{{
+		ID:     transferId("account", strconv.Itoa(payload.AccountId), "fund"),
+		From:   systemAccountId(systemAccountTreasury, ledger),
+		To:     userAccountId(payload.AccountId),
+		Amount: payload.InitialBalance,
+		Ledger: ledger,
+		Code:   1,
+	}}, nil
+}
+
+// accountAdjust returns the transfer correcting the balance of an account against the suspense
+// account. Adjustments have no record of their own in Postgres, so their id derives from the message.
+func accountAdjust(messageId int, payload outbox.AdjustmentPayload) (Transfer, error) {
+	ledger, err := ledgerOf(payload.Currency)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("error creating transfer: %s", err)
+	}
+
+	transfer := Transfer{
+		ID:     transferId("outbox", strconv.Itoa(messageId), "adjust"),
+		From:   systemAccountId(systemAccountSuspense, ledger),
+		To:     userAccountId(payload.AccountId),
+		Amount: payload.Amount,
+		Ledger: ledger,
+		Code:   adjustmentCode,
+	}
+	if payload.Amount < 0 {
+		transfer.From, transfer.To = transfer.To, transfer.From
+		transfer.Amount = -payload.Amount
+	}
+
+	return transfer, nil
+}
+
+// accountClose returns the closing transfer of an account: a pending transfer of zero from the
+// account to the suspense account that closes the account it debits. TigerBeetle rejects every
+// later transfer touching a closed account. The transfer never times out, so the account stays
+// closed unless the transfer is voided.
+func accountClose(payload outbox.AccountPayload) (Transfer, error) {
+	ledger, err := ledgerOf(payload.Currency)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("error creating transfer: %s", err)
+	}
+
+	return Transfer{
+		ID:     transferId("account", strconv.Itoa(payload.AccountId), "close"),
+		From:   userAccountId(payload.AccountId),
+		To:     systemAccountId(systemAccountSuspense, ledger),
+		Ledger: ledger,
+		Code:   closingCode,
+		Flags:  tbt.TransferFlags{Pending: true, ClosingDebit: true},
+	}, nil
+}
+
+// transferCreate returns the transfers moving money from the source to the destination of the
+// payload. Transfers between ledgers go through the exchange accounts of both ledgers. Reversals
+// carry the transaction they reverse in UserData128 so they can be traced to it. A fee is charged
+// by a transfer from the source to the fees account of its ledger, mirroring the fee posting of
+// the journal and linked to the others so both succeed or fail together. Transfers out of an overdraft account are followed by the control
+// transfers keeping it within its limit.
+func transferCreate(payload outbox.TransferPayload) ([]Transfer, error) {
+	transactionId := strconv.Itoa(payload.TransactionId)
+	leg := "transfer"
+	if payload.ReversalOf != 0 {
+		leg = "reversal"
+	}
+
+	var transfers []Transfer
+	if payload.SourceCurrency == payload.DestinationCurrency {
+		ledger, err := ledgerOf(payload.SourceCurrency)
+		if err != nil {
+			return nil, fmt.Errorf("error creating transfer: %s", err)
+		}
+		transfers = []Transfer{{
+			ID:     transferId("transaction", transactionId, leg),
+			From:   userAccountId(payload.SourceAccountId),
+			To:     userAccountId(payload.DestinationAccountId),
+			Amount: payload.SourceAmount,
+			Ledger: ledger,
+			Code:   1,
+		}}
+	} else {
+		var err error
+		transfers, err = exchangeTransfers(payload, transactionId, leg+"-exchange")
+		if err != nil {
+			return nil, fmt.Errorf("error creating transfer: %s", err)
+		}
+	}
+
+	if payload.ReversalOf != 0 {
+		for i := range transfers {
+			transfers[i].UserData128 = tbt.ToUint128(uint64(payload.ReversalOf))
+		}
+	}
+
+	if payload.Fee > 0 {
+		transfers[len(transfers)-1].Flags.Linked = true
+		transfers = append(transfers, Transfer{
+			ID:     transferId("transaction", transactionId, leg+"-fee"),
+			From:   userAccountId(payload.SourceAccountId),
+			To:     systemAccountId(systemAccountFees, transfers[0].Ledger),
+			Amount: payload.Fee,
+			Ledger: transfers[0].Ledger,
+			Code:   1,
+		})
+	}
+
+	if payload.SourceOverdraftEnabled || payload.SourceOverdraftLimit > 0 {
+		transfers = overdraftControl(transfers, payload.SourceAccountId, payload.SourceOverdraftLimit, transfers[0].Ledger, "transaction", transactionId, leg)
+	}
+	return transfers, nil
+}
+
+// transferBatch returns the transfers of every transfer of an atomic batch as a single linked
+// chain, so TigerBeetle applies all of them or none.
+func transferBatch(payload outbox.TransferBatchPayload) ([]Transfer, error) {
+	var transfers []Transfer
+	for _, transfer := range payload.Transfers {
+		created, err := transferCreate(transfer)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, created...)
+	}
+
+	for i := range len(transfers) - 1 {
+		transfers[i].Flags.Linked = true
+	}
+	return transfers, nil
+}
+
+// exchangeTransfers returns the two linked legs of a cross-ledger movement of a transaction: the
+// source account pays the exchange account of its ledger, and the exchange account of the
+// destination ledger pays the destination account. leg prefixes the names the transfer ids are
+// derived from.
+func exchangeTransfers(payload outbox.TransferPayload, transactionId string, leg string) ([]Transfer, error) {
+	sourceLedger, err := ledgerOf(payload.SourceCurrency)
+	if err != nil {
+		return nil, err
+	}
+	destinationLedger, err := ledgerOf(payload.DestinationCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Transfer{
+		{
+			ID:     transferId("transaction", transactionId, leg+"-source"),
+			From:   userAccountId(payload.SourceAccountId),
+			To:     systemAccountId(systemAccountExchange, sourceLedger),
+			Amount: payload.SourceAmount,
+			Ledger: sourceLedger,
+			Code:   1,
+			Flags:  tbt.TransferFlags{Linked: true},
+		},
+		{
+			ID:     transferId("transaction", transactionId, leg+"-destination"),
+			From:   systemAccountId(systemAccountExchange, destinationLedger),
+			To:     userAccountId(payload.DestinationAccountId),
+			Amount: payload.DestinationAmount,
+			Ledger: destinationLedger,
+			Code:   1,
+		},
+	}, nil
+}
+
+// holdTransfers returns the transfer reserving, posting or voiding the pending transfer of a
+// hold. TigerBeetle counts a pending transfer in the pending balances of both accounts until
+// it is posted, voided or its timeout passes. The timeout is the one of the payload, or for
+// messages queued without one what is left of the hold at now. Holds on an overdraft account are followed by the control transfers keeping it within its
+// limit.
+func holdTransfers(kind string, payload outbox.HoldPayload, now time.Time) ([]Transfer, error) {
+	ledger, err := ledgerOf(payload.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transfer: %s", err)
+	}
+
+	switch kind {
+	case outbox.KindHoldPost:
+		return []Transfer{{
+			ID:         pendingTransferId(payload.HoldId, "post"),
+			PendingID:  pendingTransferId(payload.HoldId, "pending"),
+			Amount:     payload.Amount,
+			UserData64: uint64(payload.HoldId),
+			Ledger:     ledger,
+			Code:       1,
+			Flags:      tbt.TransferFlags{PostPendingTransfer: true},
+		}}, nil
+
+	case outbox.KindHoldVoid:
+		return []Transfer{{
+			ID:         pendingTransferId(payload.HoldId, "void"),
+			PendingID:  pendingTransferId(payload.HoldId, "pending"),
+			UserData64: uint64(payload.HoldId),
+			Ledger:     ledger,
+			Code:       1,
+			Flags:      tbt.TransferFlags{VoidPendingTransfer: true},
+		}}, nil
+	}
+
+	timeout := payload.TimeoutSeconds
+	if timeout == 0 {
+		timeout = outbox.HoldTimeoutSeconds(payload.ExpiresAt, now)
+	}
+	transfers := []Transfer{{
+		ID:         pendingTransferId(payload.HoldId, "pending"),
+		From:       userAccountId(payload.SourceAccountId),
+		To:         userAccountId(payload.DestinationAccountId),
+		Amount:     payload.Amount,
+		UserData64: uint64(payload.HoldId),
+		Timeout:    timeout,
+		Ledger:     ledger,
+		Code:       1,
+		Flags:      tbt.TransferFlags{Pending: true},
+	}}
+
+	if payload.SourceOverdraftEnabled || payload.SourceOverdraftLimit > 0 {
+		transfers = overdraftControl(transfers, payload.SourceAccountId, payload.SourceOverdraftLimit, ledger, "hold", strconv.Itoa(payload.HoldId))
+	}
+	return transfers, nil
+}
+
+// overdraftControl links to transfers, which debit an overdraft account, the control transfers
+// failing the whole chain when they take the account below -limit. TigerBeetle can only bound a
+// balance at zero, so the overdraft account of the ledger lends the account limit plus one unit,
+// a balancing debit takes one unit back, which TigerBeetle refuses when the account has nothing
+// left to debit, and the account pays the rest back. The control transfers cancel out, leaving
+// the balances of both accounts unchanged; their ids derive from parts. Overdraft accounts are
+// created without a balance bound, so the chain is needed with a limit of zero too, when there
+// is nothing to pay back.
+func overdraftControl(transfers []Transfer, accountId int, limit int64, ledger uint32, parts ...string) []Transfer {
+	transfers[len(transfers)-1].Flags.Linked = true
+
+	account := userAccountId(accountId)
+	overdraft := systemAccountId(systemAccountOverdraft, ledger)
+	transfers = append(transfers,
+		Transfer{
+			ID:     transferId(slices.Concat(parts, []string{"overdraft-lend"})...),
+			From:   overdraft,
+			To:     account,
+			Amount: limit + 1,
+			Ledger: ledger,
+			Code:   overdraftControlCode,
+			Flags:  tbt.TransferFlags{Linked: true},
+		},
+		Transfer{
+			ID:     transferId(slices.Concat(parts, []string{"overdraft-check"})...),
+			From:   account,
+			To:     overdraft,
+			Amount: 1,
+			Ledger: ledger,
+			Code:   overdraftControlCode,
+			Flags:  tbt.TransferFlags{BalancingDebit: true},
+		},
+	)
+	if limit == 0 {
+		return transfers
+	}
+
+	transfers[len(transfers)-1].Flags.Linked = true
+	return append(transfers, Transfer{
+		ID:     transferId(slices.Concat(parts, []string{"overdraft-repay"})...),
+		From:   account,
+		To:     overdraft,
+		Amount: limit,
+		Ledger: ledger,
+		Code:   overdraftControlCode,
+	})
+}
@@ -0,0 +1,26 @@
+package tigerbeetledb
+
+import (
+	"context"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/metrics"
+	"github.com/gustialfian/transfer-system-golang/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/gustialfian/transfer-system-golang/internal/infrastructure/tigerbeetledb")
+
+// request sends the TigerBeetle request of operation, calling call with arg, and records its
+// latency and outcome in the metrics and as a span of ctx.
+func request[A, R any](ctx context.Context, operation string, call func(A) (R, error), arg A) (R, error) {
+	_, span := tracer.Start(ctx, "TigerBeetle."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("db.system", "tigerbeetle")))
+	start := time.Now()
+	res, err := call(arg)
+	metrics.ObserveTigerBeetle(operation, start, err)
+	tracing.End(span, err)
+	return res, err
+}
@@ -1,13 +1,144 @@
 package tigerbeetledb
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/reconciliation"
 
 	tb "github.com/tigerbeetle/tigerbeetle-go"
 	tbt "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
 )
 
+// ledgers maps every supported currency to the TigerBeetle ledger holding its accounts.
+// Fiat currencies use their ISO 4217 numeric code; crypto assets use ids above 1_000_000.
+var ledgers = map[string]uint32{
+	"USD": 840,
+	"EUR": 978,
+	"GBP": 826,
+	"SGD": 702,
+	"IDR": 360,
+	"JPY": 392,
+	"KRW": 410,
+	"BTC": 1_000_001,
+	"ETH": 1_000_002,
+}
+
+// System account kinds. Each ledger has one account of every kind, created by Bootstrap.
+const (
+	systemAccountTreasury  uint64 = 1 // Source of initial balance funding; its balance is the money issued.
+	systemAccountExchange  uint64 = 2 // Liquidity account bridging cross-currency transfers.
+	systemAccountFees      uint64 = 3 // Revenue account collecting fees.
+	systemAccountSuspense  uint64 = 4 // Counterparty of corrections made by reconciliation repairs.
+	systemAccountOverdraft uint64 = 5 // Counterparty of the control transfers enforcing overdraft limits.
+)
+
+var systemAccountKinds = []uint64{systemAccountTreasury, systemAccountExchange, systemAccountFees, systemAccountSuspense, systemAccountOverdraft}
+
+// Transfer codes other than 1, which marks the transfers mirroring Postgres records. Adjustments
+// correct a balance after reconciliation, closings close an account and overdraft controls keep
+// an overdraft account within its limit; none of them is counted by AccountTransferCount.
+const (
+	adjustmentCode       uint16 = 2
+	closingCode          uint16 = 3
+	overdraftControlCode uint16 = 4
+)
+
+// maxBatchSize is the largest number of events TigerBeetle accepts or returns in one request.
+const maxBatchSize = 8189
+
+func ledgerOf(currency string) (uint32, error) {
+	ledger, ok := ledgers[currency]
+	if !ok {
+		return 0, fmt.Errorf("no ledger for currency %q", currency)
+	}
+	return ledger, nil
+}
+
+// systemAccountId returns the id of the system account of the given kind on ledger.
+// The kind is stored in the upper 64 bits so system accounts never collide with
+// user accounts, whose ids fit in the lower 64 bits.
+func systemAccountId(kind uint64, ledger uint32) tbt.Uint128 {
+	var b [16]byte
+	binary.LittleEndian.PutUint64(b[0:8], uint64(ledger))
+	binary.LittleEndian.PutUint64(b[8:16], kind)
+	return tbt.BytesToUint128(b)
+}
+
+// userAccountId returns the id of the TigerBeetle account mirroring a Postgres account.
+func userAccountId(accountId int) tbt.Uint128 {
+	return tbt.ToUint128(uint64(accountId))
+}
+
+// transferId derives a deterministic transfer id from the names of the record the transfer
+// belongs to and its leg, e.g. transferId("transaction", "42", "transfer"), so publishing
+// the same outbox message again maps onto the transfer TigerBeetle already holds.
+func transferId(parts ...string) tbt.Uint128 {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "/")))
+	return tbt.BytesToUint128([16]byte(sum[:16]))
+}
+
+// pendingTransferId derives the id of the pending transfer of a hold, and of the transfers
+// posting or voiding it, from the hold id.
+func pendingTransferId(holdId int, leg string) tbt.Uint128 {
+	return transferId("hold", strconv.Itoa(holdId), leg)
+}
+
+// Transfer moves Amount from the From account to the To account. All accounts are
+// credit-normal: a transfer debits From and credits To, so it lowers the balance of From
+// (credits posted minus debits posted) and raises the balance of To. Transfers posting or
+// voiding a pending transfer leave From and To empty; TigerBeetle takes them from PendingID.
+type Transfer struct {
+	ID          tbt.Uint128
+	From        tbt.Uint128
+	To          tbt.Uint128
+	Amount      int64
+	Ledger      uint32
+	Code        uint16
+	PendingID   tbt.Uint128
+	UserData128 tbt.Uint128
+	UserData64  uint64
+	Timeout     uint32
+	Flags       tbt.TransferFlags
+}
+
+// tb returns the TigerBeetle transfer debiting From and crediting To.
+func (t Transfer) tb() tbt.Transfer {
+	return tbt.Transfer{
+		ID:              t.ID,
+		DebitAccountID:  t.From,
+		CreditAccountID: t.To,
+		Amount:          tbt.ToUint128(uint64(t.Amount)),
+		PendingID:       t.PendingID,
+		UserData128:     t.UserData128,
+		UserData64:      t.UserData64,
+		Timeout:         t.Timeout,
+		Ledger:          t.Ledger,
+		Code:            t.Code,
+		Flags:           t.Flags.ToUint16(),
+	}
+}
+
+// userAccount returns the TigerBeetle account mirroring a Postgres account. TigerBeetle rejects
+// any transfer, pending ones included, that would take its balance below zero, except for
+// overdraft accounts, whose limit is enforced by the control transfers of overdraftControl.
+func userAccount(accountId int, ledger uint32, overdraft bool) tbt.Account {
+	return tbt.Account{
+		ID:          userAccountId(accountId),
+		UserData128: userAccountId(accountId),
+		Ledger:      ledger,
+		Code:        1,
+		Flags:       tbt.AccountFlags{DebitsMustNotExceedCredits: !overdraft}.ToUint16(),
+	}
+}
+
+// MustNewTigerbeetle connects to TigerBeetle and bootstraps the system accounts, terminating
+// the application when either fails.
 func MustNewTigerbeetle(address string) *TigerBeetleDB {
 	client, err := tb.NewClient(tbt.ToUint128(0), []string{address})
 	if err != nil {
@@ -15,6 +146,17 @@ func MustNewTigerbeetle(address string) *TigerBeetleDB {
 		return nil
 	}
 
+	tdb := NewTigerBeetleDB(client)
+	if err := tdb.Bootstrap(); err != nil {
+		log.Fatalf("tigerbeetle: %v", err)
+		return nil
+	}
+
+	return tdb
+}
+
+// NewTigerBeetleDB creates a TigerBeetleDB on top of an existing client.
+func NewTigerBeetleDB(client tb.Client) *TigerBeetleDB {
 	return &TigerBeetleDB{client: client}
 }
 
@@ -22,42 +164,110 @@ type TigerBeetleDB struct {
 	client tb.Client
 }
 
-func (tdb *TigerBeetleDB) CreateAccount(accountId int) error {
-	res, err := tdb.client.CreateAccounts([]tbt.Account{{
-		ID:          tbt.ToUint128(uint64(accountId)),
-		UserData128: tbt.ToUint128(uint64(accountId)),
-		Ledger:      1,
-		Code:        1,
-	}})
+// Bootstrap creates the treasury, exchange, fees, suspense and overdraft accounts of every
+// ledger. System accounts carry no balance constraint: the treasury and suspense accounts go
+// below zero by design, the exchange account of a ledger goes below zero whenever more money
+// left the ledger through it than entered, and the overdraft account lends within a chain of
+// transfers what it gets back by the end of it. Accounts created by an earlier start are left as they are.
+func (tdb *TigerBeetleDB) Bootstrap() error {
+	var accounts []tbt.Account
+	for _, ledger := range ledgers {
+		for _, kind := range systemAccountKinds {
+			accounts = append(accounts, tbt.Account{ID: systemAccountId(kind, ledger), Ledger: ledger, Code: 1})
+		}
+	}
+
+	res, err := request(context.Background(), "create_accounts", tdb.client.CreateAccounts, accounts)
 	if err != nil {
-		return fmt.Errorf("error creating accounts: %s", err)
+		return fmt.Errorf("error creating system accounts: %s", err)
 	}
-	for _, err := range res {
-		return fmt.Errorf("error creating account %d: %s", err.Index, err.Result)
+	for _, r := range res {
+		if r.Result == tbt.AccountExists {
+			continue
+		}
+		return fmt.Errorf("error creating system account %s: %s", accounts[r.Index].ID, r.Result)
 	}
 	return nil
 }
 
-func (tdb *TigerBeetleDB) CreateTransaction(debitAccountId int, creditAccountId int, amount int) error {
-	transferRes, err := tdb.client.CreateTransfers([]tbt.Transfer{
-		{
-			ID:              tbt.ID(),
-			DebitAccountID:  tbt.ToUint128(uint64(debitAccountId)),
-			CreditAccountID: tbt.ToUint128(uint64(creditAccountId)),
-			Amount:          tbt.ToUint128(uint64(amount)),
-			Ledger:          1,
-			Code:            1,
-		},
-	})
+// LookupAccounts returns the posted amounts of the given accounts. Accounts TigerBeetle does not
+// know are left out.
+func (tdb *TigerBeetleDB) LookupAccounts(ctx context.Context, accountIds []int) ([]reconciliation.LedgerAccountRow, error) {
+	ids := make([]tbt.Uint128, len(accountIds))
+	for i, accountId := range accountIds {
+		ids[i] = userAccountId(accountId)
+	}
+
+	accounts, err := request(ctx, "lookup_accounts", tdb.client.LookupAccounts, ids)
 	if err != nil {
-		return fmt.Errorf("error creating transfer: %s", err)
+		return nil, fmt.Errorf("error looking up accounts: %s", err)
 	}
-	for _, err := range transferRes {
-		return fmt.Errorf("error creating transfer: %s", err.Result)
+
+	rows := make([]reconciliation.LedgerAccountRow, len(accounts))
+	for i, account := range accounts {
+		accountId, err := toInt64(account.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up accounts: id %s", err)
+		}
+		debits, err := toInt64(account.DebitsPosted)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up account %d: debits posted %s", accountId, err)
+		}
+		credits, err := toInt64(account.CreditsPosted)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up account %d: credits posted %s", accountId, err)
+		}
+		rows[i] = reconciliation.LedgerAccountRow{AccountId: int(accountId), DebitsPosted: debits, CreditsPosted: credits}
 	}
-	return nil
+
+	return rows, nil
 }
 
+// AccountTransferCount counts the transfers an account takes part in, leaving out adjustments.
+func (tdb *TigerBeetleDB) AccountTransferCount(ctx context.Context, accountId int) (int, error) {
+	filter := tbt.AccountFilter{
+		AccountID: userAccountId(accountId),
+		Code:      1,
+		Limit:     maxBatchSize,
+		Flags:     tbt.AccountFilterFlags{Debits: true, Credits: true}.ToUint32(),
+	}
+
+	var count int
+	for {
+		transfers, err := request(ctx, "get_account_transfers", tdb.client.GetAccountTransfers, filter)
+		if err != nil {
+			return 0, fmt.Errorf("error getting transfers of account %d: %s", accountId, err)
+		}
+		count += len(transfers)
+		if len(transfers) < maxBatchSize {
+			return count, nil
+		}
+		filter.TimestampMin = transfers[len(transfers)-1].Timestamp + 1
+	}
+}
+
+// toInt64 converts a TigerBeetle amount or id to an int64.
+func toInt64(value tbt.Uint128) (int64, error) {
+	n := value.BigInt()
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("%s does not fit in 64 bits", value)
+	}
+	return n.Int64(), nil
+}
+
+// Close closes the client. It does nothing without one, as when TigerBeetle is turned off.
 func (tdb *TigerBeetleDB) Close() {
+	if tdb.client == nil {
+		return
+	}
 	tdb.client.Close()
 }
+
+// Ping checks that TigerBeetle answers by looking up the treasury account of the USD ledger.
+func (tdb *TigerBeetleDB) Ping(ctx context.Context) error {
+	_, err := request(ctx, "lookup_accounts", tdb.client.LookupAccounts, []tbt.Uint128{systemAccountId(systemAccountTreasury, ledgers["USD"])})
+	if err != nil {
+		return fmt.Errorf("error pinging: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,438 @@
+package tigerbeetledb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/outbox"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/reconciliation"
+
+	tbt "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+)
+
+func TestTigerBeetleDB_Bootstrap(t *testing.T) {
+	client := newFakeClient()
+	tdb := NewTigerBeetleDB(client)
+
+	for range 2 {
+		if err := tdb.Bootstrap(); err != nil {
+			t.Fatalf("TigerBeetleDB.Bootstrap() error = %v", err)
+		}
+	}
+	if got, want := len(client.accounts), len(ledgers)*len(systemAccountKinds); got != want {
+		t.Errorf("system accounts = %d, want %d", got, want)
+	}
+}
+
+func TestTigerBeetleDB_Ping(t *testing.T) {
+	tdb := NewTigerBeetleDB(newFakeClient())
+	if err := tdb.Ping(t.Context()); err != nil {
+		t.Errorf("TigerBeetleDB.Ping() error = %v", err)
+	}
+}
+
+func TestTigerBeetleDB_Close(t *testing.T) {
+	// TigerBeetle turned off leaves the TigerBeetleDB without a client.
+	tdb := &TigerBeetleDB{}
+	tdb.Close()
+}
+
+func TestTigerBeetleDB_Publish(t *testing.T) {
+	client := newFakeClient()
+	tdb := NewTigerBeetleDB(client)
+	if err := tdb.Bootstrap(); err != nil {
+		t.Fatalf("TigerBeetleDB.Bootstrap() error = %v", err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	steps := []struct {
+		name     string
+		messages []outbox.Message
+		wantErrs []bool
+		want     map[int]int64 // balance per account, credits posted minus debits posted
+	}{
+		{
+			name: "fund accounts from the treasury",
+			messages: []outbox.Message{
+				message(1, outbox.KindAccountCreate, outbox.AccountPayload{AccountId: 1, Currency: "USD", InitialBalance: 10_000}),
+				message(2, outbox.KindAccountCreate, outbox.AccountPayload{AccountId: 2, Currency: "USD"}),
+				message(3, outbox.KindAccountCreate, outbox.AccountPayload{AccountId: 3, Currency: "JPY"}),
+			},
+			wantErrs: []bool{false, false, false},
+			want:     map[int]int64{1: 10_000, 2: 0, 3: 0},
+		},
+		{
+			name: "transfer from source to destination",
+			messages: []outbox.Message{
+				message(4, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 1, SourceAccountId: 1, SourceAmount: 3_000, SourceCurrency: "USD", DestinationAccountId: 2, DestinationAmount: 3_000, DestinationCurrency: "USD"}),
+				message(5, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 2, SourceAccountId: 1, SourceAmount: 1_000, SourceCurrency: "USD", DestinationAccountId: 3, DestinationAmount: 1_500, DestinationCurrency: "JPY"}),
+			},
+			wantErrs: []bool{false, false},
+			want:     map[int]int64{1: 6_000, 2: 3_000, 3: 1_500},
+		},
+		{
+			name: "overdraft rejected",
+			messages: []outbox.Message{
+				message(6, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 3, SourceAccountId: 2, SourceAmount: 3_001, SourceCurrency: "USD", DestinationAccountId: 1, DestinationAmount: 3_001, DestinationCurrency: "USD"}),
+				message(7, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 4, ReversalOf: 1, SourceAccountId: 2, SourceAmount: 1_000, SourceCurrency: "USD", DestinationAccountId: 1, DestinationAmount: 1_000, DestinationCurrency: "USD"}),
+			},
+			wantErrs: []bool{true, false},
+			want:     map[int]int64{1: 7_000, 2: 2_000, 3: 1_500},
+		},
+		{
+			name: "pending hold counts against the balance",
+			messages: []outbox.Message{
+				message(8, outbox.KindHoldCreate, outbox.HoldPayload{HoldId: 1, SourceAccountId: 1, DestinationAccountId: 2, Amount: 6_500, Currency: "USD", ExpiresAt: expiresAt}),
+				message(9, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 5, SourceAccountId: 1, SourceAmount: 501, SourceCurrency: "USD", DestinationAccountId: 2, DestinationAmount: 501, DestinationCurrency: "USD"}),
+			},
+			wantErrs: []bool{false, true},
+			want:     map[int]int64{1: 7_000, 2: 2_000, 3: 1_500},
+		},
+		{
+			name: "capture part of the hold",
+			messages: []outbox.Message{
+				message(10, outbox.KindHoldPost, outbox.HoldPayload{HoldId: 1, SourceAccountId: 1, DestinationAccountId: 2, Amount: 4_000, Currency: "USD", ExpiresAt: expiresAt}),
+				message(11, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 5, SourceAccountId: 1, SourceAmount: 501, SourceCurrency: "USD", DestinationAccountId: 2, DestinationAmount: 501, DestinationCurrency: "USD"}),
+			},
+			wantErrs: []bool{false, false},
+			want:     map[int]int64{1: 2_499, 2: 6_501, 3: 1_500},
+		},
+		{
+			name: "repair from the suspense account",
+			messages: []outbox.Message{
+				message(12, outbox.KindAccountAdjust, outbox.AdjustmentPayload{AccountId: 1, Currency: "USD", Amount: 1}),
+				message(13, outbox.KindAccountAdjust, outbox.AdjustmentPayload{AccountId: 3, Currency: "JPY", Amount: -500}),
+			},
+			wantErrs: []bool{false, false},
+			want:     map[int]int64{1: 2_500, 2: 6_501, 3: 1_000},
+		},
+		{
+			name: "closed account rejects transfers",
+			messages: []outbox.Message{
+				message(14, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 6, SourceAccountId: 3, SourceAmount: 1_000, SourceCurrency: "JPY", DestinationAccountId: 1, DestinationAmount: 1, DestinationCurrency: "USD"}),
+				message(15, outbox.KindAccountClose, outbox.AccountPayload{AccountId: 3, Currency: "JPY"}),
+				message(16, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 7, SourceAccountId: 1, SourceAmount: 1, SourceCurrency: "USD", DestinationAccountId: 3, DestinationAmount: 1, DestinationCurrency: "JPY"}),
+			},
+			wantErrs: []bool{false, false, true},
+			want:     map[int]int64{1: 2_501, 2: 6_501, 3: 0},
+		},
+		{
+			name: "republished messages are applied once",
+			messages: []outbox.Message{
+				message(1, outbox.KindAccountCreate, outbox.AccountPayload{AccountId: 1, Currency: "USD", InitialBalance: 10_000}),
+				message(4, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 1, SourceAccountId: 1, SourceAmount: 3_000, SourceCurrency: "USD", DestinationAccountId: 2, DestinationAmount: 3_000, DestinationCurrency: "USD"}),
+				message(5, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 2, SourceAccountId: 1, SourceAmount: 1_000, SourceCurrency: "USD", DestinationAccountId: 3, DestinationAmount: 1_500, DestinationCurrency: "JPY"}),
+				message(12, outbox.KindAccountAdjust, outbox.AdjustmentPayload{AccountId: 1, Currency: "USD", Amount: 1}),
+			},
+			wantErrs: []bool{false, false, false, false},
+			want:     map[int]int64{1: 2_501, 2: 6_501, 3: 0},
+		},
+		{
+			name: "overdraft account goes below zero within its limit",
+			messages: []outbox.Message{
+				message(17, outbox.KindAccountCreate, outbox.AccountPayload{AccountId: 4, Currency: "USD", Overdraft: true}),
+				message(18, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 8, SourceAccountId: 4, SourceAmount: 1_000, SourceCurrency: "USD", SourceOverdraftLimit: 1_500, DestinationAccountId: 2, DestinationAmount: 1_000, DestinationCurrency: "USD"}),
+				message(19, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 9, SourceAccountId: 4, SourceAmount: 501, SourceCurrency: "USD", SourceOverdraftLimit: 1_500, DestinationAccountId: 2, DestinationAmount: 501, DestinationCurrency: "USD"}),
+			},
+			wantErrs: []bool{false, false, true},
+			want:     map[int]int64{1: 2_501, 2: 7_501, 3: 0, 4: -1_000},
+		},
+		{
+			name: "pending hold counts against the overdraft limit",
+			messages: []outbox.Message{
+				message(20, outbox.KindHoldCreate, outbox.HoldPayload{HoldId: 2, SourceAccountId: 4, SourceOverdraftLimit: 1_500, DestinationAccountId: 2, Amount: 500, Currency: "USD", ExpiresAt: expiresAt}),
+				message(21, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 10, SourceAccountId: 4, SourceAmount: 1, SourceCurrency: "USD", SourceOverdraftLimit: 1_500, DestinationAccountId: 2, DestinationAmount: 1, DestinationCurrency: "USD"}),
+			},
+			wantErrs: []bool{false, true},
+			want:     map[int]int64{1: 2_501, 2: 7_501, 3: 0, 4: -1_000},
+		},
+		{
+			name: "fee charged together with its transfer",
+			messages: []outbox.Message{
+				message(22, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 11, SourceAccountId: 2, SourceAmount: 1_000, SourceCurrency: "USD", Fee: 50, DestinationAccountId: 1, DestinationAmount: 1_000, DestinationCurrency: "USD"}),
+				message(23, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 12, SourceAccountId: 1, SourceAmount: 3_500, SourceCurrency: "USD", Fee: 2, DestinationAccountId: 2, DestinationAmount: 3_500, DestinationCurrency: "USD"}),
+			},
+			wantErrs: []bool{false, true},
+			want:     map[int]int64{1: 3_501, 2: 6_451, 3: 0, 4: -1_000},
+		},
+		{
+			name: "atomic batch applies every transfer or none",
+			messages: []outbox.Message{
+				message(24, outbox.KindTransferBatch, outbox.TransferBatchPayload{Transfers: []outbox.TransferPayload{
+					{TransactionId: 13, SourceAccountId: 1, SourceAmount: 500, SourceCurrency: "USD", DestinationAccountId: 2, DestinationAmount: 500, DestinationCurrency: "USD"},
+					{TransactionId: 14, SourceAccountId: 2, SourceAmount: 7_000, SourceCurrency: "USD", DestinationAccountId: 1, DestinationAmount: 7_000, DestinationCurrency: "USD"},
+				}}),
+				message(25, outbox.KindTransferBatch, outbox.TransferBatchPayload{Transfers: []outbox.TransferPayload{
+					{TransactionId: 15, SourceAccountId: 1, SourceAmount: 500, SourceCurrency: "USD", Fee: 10, DestinationAccountId: 2, DestinationAmount: 500, DestinationCurrency: "USD"},
+					{TransactionId: 16, SourceAccountId: 2, SourceAmount: 200, SourceCurrency: "USD", DestinationAccountId: 1, DestinationAmount: 200, DestinationCurrency: "USD"},
+				}}),
+			},
+			wantErrs: []bool{true, false},
+			want:     map[int]int64{1: 3_191, 2: 6_751, 3: 0, 4: -1_000},
+		},
+		{
+			name: "republished chains and holds are applied once",
+			messages: []outbox.Message{
+				message(22, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 11, SourceAccountId: 2, SourceAmount: 1_000, SourceCurrency: "USD", Fee: 50, DestinationAccountId: 1, DestinationAmount: 1_000, DestinationCurrency: "USD"}),
+				message(18, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 8, SourceAccountId: 4, SourceAmount: 1_000, SourceCurrency: "USD", SourceOverdraftLimit: 1_500, DestinationAccountId: 2, DestinationAmount: 1_000, DestinationCurrency: "USD"}),
+				message(25, outbox.KindTransferBatch, outbox.TransferBatchPayload{Transfers: []outbox.TransferPayload{
+					{TransactionId: 15, SourceAccountId: 1, SourceAmount: 500, SourceCurrency: "USD", Fee: 10, DestinationAccountId: 2, DestinationAmount: 500, DestinationCurrency: "USD"},
+					{TransactionId: 16, SourceAccountId: 2, SourceAmount: 200, SourceCurrency: "USD", DestinationAccountId: 1, DestinationAmount: 200, DestinationCurrency: "USD"},
+				}}),
+				// Queued without a timeout, the first publish used the time left of the hold then.
+				message(20, outbox.KindHoldCreate, outbox.HoldPayload{HoldId: 2, SourceAccountId: 4, SourceOverdraftLimit: 1_500, DestinationAccountId: 2, Amount: 500, Currency: "USD", ExpiresAt: expiresAt, TimeoutSeconds: 7_200}),
+			},
+			wantErrs: []bool{false, false, false, false},
+			want:     map[int]int64{1: 3_191, 2: 6_751, 3: 0, 4: -1_000},
+		},
+		{
+			name: "overdraft account with its limit lowered to zero stays at or above zero",
+			messages: []outbox.Message{
+				message(26, outbox.KindAccountCreate, outbox.AccountPayload{AccountId: 5, Currency: "USD", InitialBalance: 100, Overdraft: true}),
+				message(27, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 17, SourceAccountId: 5, SourceAmount: 100, SourceCurrency: "USD", SourceOverdraftEnabled: true, DestinationAccountId: 2, DestinationAmount: 100, DestinationCurrency: "USD"}),
+				message(28, outbox.KindTransferCreate, outbox.TransferPayload{TransactionId: 18, SourceAccountId: 5, SourceAmount: 1, SourceCurrency: "USD", SourceOverdraftEnabled: true, DestinationAccountId: 2, DestinationAmount: 1, DestinationCurrency: "USD"}),
+				message(29, outbox.KindHoldCreate, outbox.HoldPayload{HoldId: 3, SourceAccountId: 5, SourceOverdraftEnabled: true, DestinationAccountId: 2, Amount: 1, Currency: "USD", ExpiresAt: expiresAt, TimeoutSeconds: 7_200}),
+			},
+			wantErrs: []bool{false, false, true, true},
+			want:     map[int]int64{1: 3_191, 2: 6_851, 3: 0, 4: -1_000, 5: 0},
+		},
+	}
+	for _, step := range steps {
+		errs, err := tdb.Publish(t.Context(), step.messages)
+		if err != nil {
+			t.Fatalf("%s: TigerBeetleDB.Publish() error = %v", step.name, err)
+		}
+		for i, wantErr := range step.wantErrs {
+			if (errs[i] != nil) != wantErr {
+				t.Errorf("%s: message %d error = %v, wantErr %v", step.name, step.messages[i].MessageId, errs[i], wantErr)
+			}
+		}
+
+		rows, err := tdb.LookupAccounts(t.Context(), []int{1, 2, 3, 4, 5})
+		if err != nil {
+			t.Fatalf("%s: TigerBeetleDB.LookupAccounts() error = %v", step.name, err)
+		}
+		got := map[int]int64{}
+		for _, row := range rows {
+			got[row.AccountId] = balance(row)
+		}
+		if !maps.Equal(got, step.want) {
+			t.Errorf("%s: balances = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	overdraft := client.accounts[systemAccountId(systemAccountOverdraft, ledgers["USD"])]
+	if u64(overdraft.CreditsPosted) != u64(overdraft.DebitsPosted) {
+		t.Errorf("USD overdraft account balance = %d, want 0", int64(u64(overdraft.CreditsPosted))-int64(u64(overdraft.DebitsPosted)))
+	}
+
+	fees := client.accounts[systemAccountId(systemAccountFees, ledgers["USD"])]
+	if got := int64(u64(fees.CreditsPosted)) - int64(u64(fees.DebitsPosted)); got != 60 {
+		t.Errorf("USD fees balance = %d, want 60", got)
+	}
+
+	treasury := client.accounts[systemAccountId(systemAccountTreasury, ledgers["USD"])]
+	if got := int64(u64(treasury.CreditsPosted)) - int64(u64(treasury.DebitsPosted)); got != -10_100 {
+		t.Errorf("USD treasury balance = %d, want -10100", got)
+	}
+	if got, err := tdb.AccountTransferCount(t.Context(), 1); err != nil || got != 12 {
+		t.Errorf("TigerBeetleDB.AccountTransferCount() = %d, %v, want 12", got, err)
+	}
+}
+
+func message(messageId int, kind string, payload any) outbox.Message {
+	data, _ := json.Marshal(payload)
+	return outbox.Message{MessageId: messageId, Kind: kind, Payload: data}
+}
+
+func balance(row reconciliation.LedgerAccountRow) int64 {
+	return row.CreditsPosted - row.DebitsPosted
+}
+
+func u64(value tbt.Uint128) uint64 {
+	b := value.Bytes()
+	return binary.LittleEndian.Uint64(b[:8])
+}
+
+// fakeClient is an in-memory TigerBeetle client. It keeps posted and pending amounts, enforces
+// DebitsMustNotExceedCredits, clamps balancing debits and applies linked transfers all or
+// nothing, which is all the adapter relies on.
+type fakeClient struct {
+	accounts  map[tbt.Uint128]tbt.Account
+	transfers map[tbt.Uint128]tbt.Transfer
+	order     []tbt.Uint128
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{accounts: map[tbt.Uint128]tbt.Account{}, transfers: map[tbt.Uint128]tbt.Transfer{}}
+}
+
+func (c *fakeClient) CreateAccounts(accounts []tbt.Account) ([]tbt.AccountEventResult, error) {
+	var res []tbt.AccountEventResult
+	for i, account := range accounts {
+		if existing, ok := c.accounts[account.ID]; ok {
+			result := tbt.AccountExists
+			if existing.Flags != account.Flags {
+				result = tbt.AccountExistsWithDifferentFlags
+			}
+			res = append(res, tbt.AccountEventResult{Index: uint32(i), Result: result})
+			continue
+		}
+		c.accounts[account.ID] = account
+	}
+	return res, nil
+}
+
+func (c *fakeClient) CreateTransfers(transfers []tbt.Transfer) ([]tbt.TransferEventResult, error) {
+	var res []tbt.TransferEventResult
+	for start := 0; start < len(transfers); {
+		end := start
+		for end < len(transfers)-1 && transfers[end].TransferFlags().Linked {
+			end++
+		}
+
+		accounts, created, order := maps.Clone(c.accounts), maps.Clone(c.transfers), c.order
+		for i := start; i <= end; i++ {
+			if result, ok := c.apply(transfers[i]); !ok {
+				c.accounts, c.transfers, c.order = accounts, created, order
+				for j := start; j <= end; j++ {
+					r := tbt.TransferLinkedEventFailed
+					if j == i {
+						r = result
+					}
+					res = append(res, tbt.TransferEventResult{Index: uint32(j), Result: r})
+				}
+				break
+			}
+		}
+		start = end + 1
+	}
+	return res, nil
+}
+
+// apply applies a single transfer and reports whether it succeeded. Like TigerBeetle, an existing
+// transfer fails with TransferExists, or TransferExistsWithDifferentTimeout when only its timeout
+// differs, and fails the rest of its linked chain.
+func (c *fakeClient) apply(t tbt.Transfer) (tbt.CreateTransferResult, bool) {
+	if existing, ok := c.transfers[t.ID]; ok {
+		if existing.Timeout != t.Timeout {
+			return tbt.TransferExistsWithDifferentTimeout, false
+		}
+		return tbt.TransferExists, false
+	}
+
+	flags := t.TransferFlags()
+	amount := u64(t.Amount)
+	if flags.PostPendingTransfer || flags.VoidPendingTransfer {
+		pending, ok := c.transfers[t.PendingID]
+		if !ok {
+			return tbt.TransferPendingTransferNotFound, false
+		}
+		debit, credit := c.accounts[pending.DebitAccountID], c.accounts[pending.CreditAccountID]
+		debit.DebitsPending = tbt.ToUint128(u64(debit.DebitsPending) - u64(pending.Amount))
+		credit.CreditsPending = tbt.ToUint128(u64(credit.CreditsPending) - u64(pending.Amount))
+		if flags.PostPendingTransfer {
+			debit.DebitsPosted = tbt.ToUint128(u64(debit.DebitsPosted) + amount)
+			credit.CreditsPosted = tbt.ToUint128(u64(credit.CreditsPosted) + amount)
+		}
+		c.accounts[debit.ID], c.accounts[credit.ID] = debit, credit
+		c.record(t)
+		return 0, true
+	}
+
+	debit, ok := c.accounts[t.DebitAccountID]
+	if !ok {
+		return tbt.TransferDebitAccountNotFound, false
+	}
+	credit, ok := c.accounts[t.CreditAccountID]
+	if !ok {
+		return tbt.TransferCreditAccountNotFound, false
+	}
+	if debit.AccountFlags().Closed {
+		return tbt.TransferDebitAccountAlreadyClosed, false
+	}
+	if credit.AccountFlags().Closed {
+		return tbt.TransferCreditAccountAlreadyClosed, false
+	}
+	if flags.BalancingDebit {
+		available := int64(u64(debit.CreditsPosted)) - int64(u64(debit.DebitsPosted)) - int64(u64(debit.DebitsPending))
+		if amount = min(amount, uint64(max(available, 0))); amount == 0 {
+			return tbt.TransferExceedsCredits, false
+		}
+		t.Amount = tbt.ToUint128(amount)
+	}
+	if debit.AccountFlags().DebitsMustNotExceedCredits && u64(debit.DebitsPosted)+u64(debit.DebitsPending)+amount > u64(debit.CreditsPosted) {
+		return tbt.TransferExceedsCredits, false
+	}
+
+	if flags.ClosingDebit {
+		accountFlags := debit.AccountFlags()
+		accountFlags.Closed = true
+		debit.Flags = accountFlags.ToUint16()
+	}
+	if flags.Pending {
+		debit.DebitsPending = tbt.ToUint128(u64(debit.DebitsPending) + amount)
+		credit.CreditsPending = tbt.ToUint128(u64(credit.CreditsPending) + amount)
+	} else {
+		debit.DebitsPosted = tbt.ToUint128(u64(debit.DebitsPosted) + amount)
+		credit.CreditsPosted = tbt.ToUint128(u64(credit.CreditsPosted) + amount)
+	}
+	c.accounts[debit.ID], c.accounts[credit.ID] = debit, credit
+	c.record(t)
+	return 0, true
+}
+
+func (c *fakeClient) record(t tbt.Transfer) {
+	c.transfers[t.ID] = t
+	c.order = append(c.order, t.ID)
+}
+
+func (c *fakeClient) LookupAccounts(accountIDs []tbt.Uint128) ([]tbt.Account, error) {
+	var accounts []tbt.Account
+	for _, id := range accountIDs {
+		if account, ok := c.accounts[id]; ok {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts, nil
+}
+
+func (c *fakeClient) LookupTransfers(transferIDs []tbt.Uint128) ([]tbt.Transfer, error) {
+	return nil, nil
+}
+
+// GetAccountTransfers honours AccountID, Code and Limit, which is all the adapter uses.
+func (c *fakeClient) GetAccountTransfers(filter tbt.AccountFilter) ([]tbt.Transfer, error) {
+	var transfers []tbt.Transfer
+	for _, id := range c.order {
+		t := c.transfers[id]
+		pending := c.transfers[t.PendingID]
+		touches := t.DebitAccountID == filter.AccountID || t.CreditAccountID == filter.AccountID ||
+			pending.DebitAccountID == filter.AccountID || pending.CreditAccountID == filter.AccountID
+		if touches && t.Code == filter.Code && len(transfers) < int(filter.Limit) {
+			transfers = append(transfers, t)
+		}
+	}
+	return transfers, nil
+}
+
+func (c *fakeClient) GetAccountBalances(filter tbt.AccountFilter) ([]tbt.AccountBalance, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) QueryAccounts(filter tbt.QueryFilter) ([]tbt.Account, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) QueryTransfers(filter tbt.QueryFilter) ([]tbt.Transfer, error) {
+	return nil, nil
+}
+
+func (c *fakeClient) Nop() error {
+	return nil
+}
+
+func (c *fakeClient) Close() {}
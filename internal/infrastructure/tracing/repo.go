@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/gustialfian/transfer-system-golang/internal/domains/account"
+	"github.com/gustialfian/transfer-system-golang/internal/domains/transaction"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AccountRepo is an account.AccountRepo that traces the queries of the repository it wraps.
+type AccountRepo struct {
+	next account.AccountRepo
+}
+
+// NewAccountRepo returns an AccountRepo tracing the queries of next.
+func NewAccountRepo(next account.AccountRepo) *AccountRepo {
+	return &AccountRepo{next: next}
+}
+
+func (r *AccountRepo) Create(ctx context.Context, data account.AccountCreateParams) (bool, error) {
+	ctx, span := startQuery(ctx, "AccountRepo.Create")
+	created, err := r.next.Create(ctx, data)
+	End(span, err)
+	return created, err
+}
+
+func (r *AccountRepo) ById(ctx context.Context, accountId int) (account.AccountRow, error) {
+	ctx, span := startQuery(ctx, "AccountRepo.ById")
+	row, err := r.next.ById(ctx, accountId)
+	End(span, err)
+	return row, err
+}
+
+func (r *AccountRepo) ByIdsForUpdate(ctx context.Context, accountIds []int) ([]account.AccountRow, error) {
+	ctx, span := startQuery(ctx, "AccountRepo.ByIdsForUpdate")
+	rows, err := r.next.ByIdsForUpdate(ctx, accountIds)
+	End(span, err)
+	return rows, err
+}
+
+func (r *AccountRepo) UpdateBalance(ctx context.Context, params account.AccountUpdateBalanceParams) error {
+	ctx, span := startQuery(ctx, "AccountRepo.UpdateBalance")
+	err := r.next.UpdateBalance(ctx, params)
+	End(span, err)
+	return err
+}
+
+func (r *AccountRepo) UpdateHeldBalance(ctx context.Context, params account.AccountUpdateHeldBalanceParams) error {
+	ctx, span := startQuery(ctx, "AccountRepo.UpdateHeldBalance")
+	err := r.next.UpdateHeldBalance(ctx, params)
+	End(span, err)
+	return err
+}
+
+func (r *AccountRepo) UpdateStatus(ctx context.Context, params account.AccountUpdateStatusParams) error {
+	ctx, span := startQuery(ctx, "AccountRepo.UpdateStatus")
+	err := r.next.UpdateStatus(ctx, params)
+	End(span, err)
+	return err
+}
+
+func (r *AccountRepo) UpdateOverdraftLimit(ctx context.Context, params account.AccountUpdateOverdraftLimitParams) error {
+	ctx, span := startQuery(ctx, "AccountRepo.UpdateOverdraftLimit")
+	err := r.next.UpdateOverdraftLimit(ctx, params)
+	End(span, err)
+	return err
+}
+
+func (r *AccountRepo) OverdraftLimitChanges(ctx context.Context, accountId int) ([]account.OverdraftLimitChangeRow, error) {
+	ctx, span := startQuery(ctx, "AccountRepo.OverdraftLimitChanges")
+	rows, err := r.next.OverdraftLimitChanges(ctx, accountId)
+	End(span, err)
+	return rows, err
+}
+
+// TransactionRepo is a transaction.TransactionRepo that traces the queries of the repository
+// it wraps.
+type TransactionRepo struct {
+	next transaction.TransactionRepo
+}
+
+// NewTransactionRepo returns a TransactionRepo tracing the queries of next.
+func NewTransactionRepo(next transaction.TransactionRepo) *TransactionRepo {
+	return &TransactionRepo{next: next}
+}
+
+func (r *TransactionRepo) Create(ctx context.Context, data transaction.TransactionCreateParams) (transaction.TransactionRow, error) {
+	ctx, span := startQuery(ctx, "TransactionRepo.Create")
+	row, err := r.next.Create(ctx, data)
+	End(span, err)
+	return row, err
+}
+
+func (r *TransactionRepo) ById(ctx context.Context, transactionId int) (transaction.TransactionRow, error) {
+	ctx, span := startQuery(ctx, "TransactionRepo.ById")
+	row, err := r.next.ById(ctx, transactionId)
+	End(span, err)
+	return row, err
+}
+
+func (r *TransactionRepo) ByIdForUpdate(ctx context.Context, transactionId int) (transaction.TransactionRow, error) {
+	ctx, span := startQuery(ctx, "TransactionRepo.ByIdForUpdate")
+	row, err := r.next.ByIdForUpdate(ctx, transactionId)
+	End(span, err)
+	return row, err
+}
+
+func (r *TransactionRepo) ByAccount(ctx context.Context, params transaction.TransactionListParams) ([]transaction.TransactionRow, error) {
+	ctx, span := startQuery(ctx, "TransactionRepo.ByAccount")
+	rows, err := r.next.ByAccount(ctx, params)
+	End(span, err)
+	return rows, err
+}
+
+func (r *TransactionRepo) UpdateReversal(ctx context.Context, params transaction.TransactionUpdateReversalParams) error {
+	ctx, span := startQuery(ctx, "TransactionRepo.UpdateReversal")
+	err := r.next.UpdateReversal(ctx, params)
+	End(span, err)
+	return err
+}
+
+// startQuery starts the span of a repository query called name.
+func startQuery(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attribute.String("db.system", "postgresql")))
+}
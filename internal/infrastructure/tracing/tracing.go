@@ -0,0 +1,73 @@
+// Package tracing sets up OpenTelemetry tracing for the API server: the exporter spans are sent
+// to, W3C trace context propagation, and the spans of the repositories the services use.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is the service.name of the spans, unless OTEL_SERVICE_NAME overrides it.
+const ServiceName = "transfer-system"
+
+var tracer = otel.Tracer("github.com/gustialfian/transfer-system-golang/internal/infrastructure/tracing")
+
+// New installs the global tracer provider and the W3C trace context propagator. Spans are
+// exported with exporter: otlp sends them over OTLP/HTTP to the collector configured by the
+// standard OTEL_EXPORTER_OTLP_* variables, stdout writes them to w as JSON and none drops them.
+// The returned function flushes the spans not exported yet and stops the exporter.
+func New(ctx context.Context, exporter string, w io.Writer) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var spanExporter sdktrace.SpanExporter
+	switch exporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		e, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, fmt.Errorf("stdout exporter: %w", err)
+		}
+		spanExporter = e
+	case "otlp":
+		e, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("otlp exporter: %w", err)
+		}
+		spanExporter = e
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, expected otlp, stdout or none", exporter)
+	}
+
+	res, err := resource.Merge(resource.NewSchemaless(attribute.String("service.name", ServiceName)), resource.Default())
+	if err != nil {
+		return nil, fmt.Errorf("resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// End ends span, recording err and marking the span failed when err is set.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
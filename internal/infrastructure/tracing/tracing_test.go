@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		exporter     string
+		wantExported bool
+		wantErr      bool
+	}{
+		{name: "success - stdout", exporter: "stdout", wantExported: true},
+		{name: "success - none", exporter: "none"},
+		{name: "error - exporter invalid", exporter: "jaeger", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			shutdown, err := New(t.Context(), tt.exporter, &buf)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			_, span := otel.Tracer("test").Start(t.Context(), "AccountRepo.ById")
+			span.End()
+			if err := shutdown(t.Context()); err != nil {
+				t.Fatalf("shutdown() error = %v", err)
+			}
+
+			if exported := strings.Contains(buf.String(), `"Name":"AccountRepo.ById"`); exported != tt.wantExported {
+				t.Errorf("span exported = %v, want %v: %s", exported, tt.wantExported, buf.String())
+			}
+		})
+	}
+}